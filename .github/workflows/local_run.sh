@@ -72,6 +72,7 @@ go test -v ./filewatch
 go test -v ./ingesters/utils
 go test -v ./ingesters/kafka_consumer
 go test -v ./ingesters/SimpleRelay
+go test -v ./ingesters/ingestRelay
 go test -v ./ipexist
 go test -v ./netflow
 go test -v ./client/...
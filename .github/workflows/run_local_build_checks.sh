@@ -24,6 +24,7 @@ echo "Running go vet"
         go vet ./ingesters/utils
         go vet ./ingesters/kafka_consumer
         go vet ./ingesters/SimpleRelay
+        go vet ./ingesters/ingestRelay
         GOOS=linux go vet ./ipexist
         go vet ./netflow
         go vet ./client/...
@@ -44,6 +45,7 @@ echo "Running go test -v"
         go test -v ./ingesters/utils
         go test -v ./ingesters/kafka_consumer
         go test -v ./ingesters/SimpleRelay
+        go test -v ./ingesters/ingestRelay
         if [[ "$(go env GOOS)" == "linux" ]]; then go test -v ./ipexist; fi
         go test -v ./netflow
         go test -v ./client/...
@@ -105,6 +107,7 @@ echo "running staticcheck"
 	staticcheck ./ingesters/session/...
 	GOOS=linux staticcheck ./ingesters/Shodan/...
 	staticcheck ./ingesters/SimpleRelay/...
+	staticcheck ./ingesters/ingestRelay/...
 	staticcheck ./ingesters/singleFile/...
 	staticcheck ./ingesters/snmp/...
 	staticcheck ./ingesters/sqsIngester/...
@@ -0,0 +1,174 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package avro
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+const testSchema = `{
+	"type": "record",
+	"name": "Event",
+	"namespace": "com.example",
+	"fields": [
+		{"name": "id", "type": "long"},
+		{"name": "host", "type": "string"},
+		{"name": "port", "type": "int"},
+		{"name": "score", "type": "double"},
+		{"name": "ratio", "type": "float"},
+		{"name": "ok", "type": "boolean"},
+		{"name": "user", "type": ["null", "string"]},
+		{"name": "level", "type": {"type": "enum", "name": "Level", "symbols": ["LOW", "HIGH"]}},
+		{"name": "tags", "type": {"type": "array", "items": "string"}},
+		{"name": "attrs", "type": {"type": "map", "values": "long"}},
+		{"name": "hash", "type": {"type": "fixed", "name": "MD5", "size": 4}},
+		{"name": "raw", "type": "bytes"},
+		{"name": "ts", "type": {"type": "long", "logicalType": "timestamp-millis"}},
+		{"name": "next", "type": ["null", "Event"]},
+		{"name": "prev_level", "type": "com.example.Level"}
+	]
+}`
+
+func testEvent() map[string]interface{} {
+	return map[string]interface{}{
+		`id`:    int64(-12345678901),
+		`host`:  `web01`,
+		`port`:  int32(443),
+		`score`: 1.5,
+		`ratio`: float32(0.25),
+		`ok`:    true,
+		`user`:  `bob`,
+		`level`: `HIGH`,
+		`tags`:  []interface{}{`a`, `b`},
+		`attrs`: map[string]interface{}{`x`: int64(1), `y`: int64(-2)},
+		`hash`:  []byte{1, 2, 3, 4},
+		`raw`:   []byte(`raw bytes`),
+		`ts`:    int64(1700000000000),
+		`next`: map[string]interface{}{
+			`id`: int64(2), `host`: ``, `port`: 0, `score`: 0.0, `ratio`: float32(0), `ok`: false,
+			`user`: nil, `level`: `LOW`, `tags`: []interface{}{}, `attrs`: map[string]interface{}{},
+			`hash`: []byte{0, 0, 0, 0}, `raw`: []byte{}, `ts`: int64(0), `next`: nil, `prev_level`: `LOW`,
+		},
+		`prev_level`: `LOW`,
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	s, err := ParseSchema([]byte(testSchema))
+	if err != nil {
+		t.Fatal(err)
+	} else if s.Name != `com.example.Event` || len(s.Fields) != 15 {
+		t.Fatalf("bad schema: %s %d", s.Name, len(s.Fields))
+	}
+	b, err := s.encode(testEvent())
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := s.Decode(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, ok := v.(*Record)
+	if !ok {
+		t.Fatalf("decoded to %T", v)
+	}
+	exp := testEvent()
+	for _, k := range []string{`id`, `host`, `port`, `score`, `ratio`, `ok`, `user`, `level`, `ts`, `prev_level`} {
+		if x, ok := r.Get(k); !ok || x != exp[k] {
+			t.Fatalf("bad %s: %v (%T) != %v (%T)", k, x, x, exp[k], exp[k])
+		}
+	}
+	if x, _ := r.Get(`hash`); !bytes.Equal(x.([]byte), []byte{1, 2, 3, 4}) {
+		t.Fatalf("bad fixed %v", x)
+	}
+	next, _ := r.Get(`next`)
+	if nr, ok := next.(*Record); !ok {
+		t.Fatalf("bad recursive record %T", next)
+	} else if u, _ := nr.Get(`user`); u != nil {
+		t.Fatalf("bad null union %v", u)
+	}
+
+	//JSON keeps schema field order
+	js, err := json.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(js, []byte(`{"id":-12345678901,"host":"web01","port":443,"score":1.5,"ratio":0.25,"ok":true,"user":"bob","level":"HIGH","tags":["a","b"],"attrs":{"x":1,"y":-2}`)) {
+		t.Fatalf("bad JSON: %s", js)
+	}
+
+	//trailing and truncated data
+	if _, err = s.Decode(append(b, 0)); !errors.Is(err, ErrTrailingBytes) {
+		t.Fatalf("trailing bytes not caught: %v", err)
+	} else if _, rest, err := s.DecodePrefix(append(b, 7)); err != nil || len(rest) != 1 {
+		t.Fatalf("bad prefix decode: %v %v", err, rest)
+	}
+	for i := 0; i < len(b); i++ {
+		if _, err = s.Decode(b[:i]); err == nil {
+			t.Fatalf("truncated data at %d did not fail", i)
+		}
+	}
+}
+
+func TestBlocks(t *testing.T) {
+	s, err := ParseSchema([]byte(`{"type": "array", "items": "int"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	//two blocks, the second with a negative count and a byte size
+	b := []byte{4, 2, 4, 1, 2, 6, 0}
+	if v, err := s.Decode(b); err != nil {
+		t.Fatal(err)
+	} else if arr := v.([]interface{}); len(arr) != 3 || arr[0] != int32(1) || arr[1] != int32(2) || arr[2] != int32(3) {
+		t.Fatalf("bad array %v", arr)
+	}
+	//a huge count must not be trusted
+	if _, err = s.Decode(appendLong(nil, 1<<40)); err == nil {
+		t.Fatal("huge block count did not fail")
+	}
+	ns, err := ParseSchema([]byte(`{"type": "array", "items": "null"}`))
+	if err != nil {
+		t.Fatal(err)
+	} else if v, err := ns.Decode([]byte{6, 0}); err != nil || len(v.([]interface{})) != 3 {
+		t.Fatalf("bad null array %v %v", v, err)
+	} else if _, err = ns.Decode(append(appendLong(nil, 1<<40), 0)); err == nil {
+		t.Fatal("huge null block count did not fail")
+	}
+}
+
+func TestBadSchemas(t *testing.T) {
+	bad := []string{
+		`not json`,
+		`"nope"`,
+		`[]`,
+		`{"type": "record", "fields": []}`,
+		`{"type": "record", "name": "A", "fields": [{"type": "int"}]}`,
+		`{"type": "record", "name": "A", "fields": [{"name": "a", "type": "B"}]}`,
+		`{"type": "fixed", "name": "F"}`,
+		`{"type": "enum", "name": "E", "symbols": [1]}`,
+		`["int", {"type": "enum", "name": "E", "symbols": []}, {"type": "enum", "name": "E", "symbols": []}]`,
+		`{"type": "array"}`,
+	}
+	for _, v := range bad {
+		if _, err := ParseSchema([]byte(v)); err == nil {
+			t.Fatalf("bad schema did not fail: %s", v)
+		}
+	}
+	s, err := ParseSchema([]byte(`{"type": "enum", "name": "E", "symbols": ["A"]}`))
+	if err != nil {
+		t.Fatal(err)
+	} else if _, err = s.Decode([]byte{2}); !errors.Is(err, ErrInvalidData) {
+		t.Fatalf("bad enum index not caught: %v", err)
+	} else if _, err = s.encode(`B`); err == nil {
+		t.Fatal("bad symbol encoded")
+	}
+}
@@ -0,0 +1,257 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package avro
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+)
+
+const (
+	maxDepth = 256
+	// maxZeroSizedItems caps the item count of a block whose items may occupy no bytes
+	maxZeroSizedItems = 1024 * 1024
+)
+
+var (
+	ErrShortBuffer   = errors.New("Avro data is truncated")
+	ErrInvalidData   = errors.New("Invalid Avro data")
+	ErrTooDeep       = errors.New("Avro data is nested too deeply")
+	ErrTrailingBytes = errors.New("Avro data has trailing bytes")
+)
+
+// Record is a decoded record, field order follows the schema
+type Record struct {
+	Names  []string
+	Values []interface{}
+}
+
+// Get returns the value of the named field
+func (r *Record) Get(name string) (v interface{}, ok bool) {
+	for i, n := range r.Names {
+		if n == name {
+			return r.Values[i], true
+		}
+	}
+	return
+}
+
+// MarshalJSON encodes the record as a JSON object with fields in schema order
+func (r *Record) MarshalJSON() ([]byte, error) {
+	var bb bytes.Buffer
+	bb.WriteByte('{')
+	for i, n := range r.Names {
+		if i > 0 {
+			bb.WriteByte(',')
+		}
+		k, err := json.Marshal(n)
+		if err != nil {
+			return nil, err
+		}
+		bb.Write(k)
+		bb.WriteByte(':')
+		v, err := json.Marshal(r.Values[i])
+		if err != nil {
+			return nil, err
+		}
+		bb.Write(v)
+	}
+	bb.WriteByte('}')
+	return bb.Bytes(), nil
+}
+
+// Decode decodes a single value which must consume all of b
+func (s *Schema) Decode(b []byte) (v interface{}, err error) {
+	var rest []byte
+	if v, rest, err = s.DecodePrefix(b); err == nil && len(rest) > 0 {
+		err = fmt.Errorf("%w: %d", ErrTrailingBytes, len(rest))
+	}
+	return
+}
+
+// DecodePrefix decodes a single value and returns the remaining bytes
+func (s *Schema) DecodePrefix(b []byte) (v interface{}, rest []byte, err error) {
+	d := decoder{b: b}
+	if v, err = d.decode(s, 0); err == nil {
+		rest = d.b
+	}
+	return
+}
+
+type decoder struct {
+	b []byte
+}
+
+func (d *decoder) decode(s *Schema, depth int) (v interface{}, err error) {
+	if depth > maxDepth {
+		return nil, ErrTooDeep
+	}
+	switch s.Type {
+	case TypeNull:
+		return nil, nil
+	case TypeBoolean:
+		if len(d.b) < 1 {
+			return nil, ErrShortBuffer
+		}
+		v, d.b = d.b[0] != 0, d.b[1:]
+	case TypeInt:
+		var x int64
+		if x, err = d.long(); err == nil {
+			if x > math.MaxInt32 || x < math.MinInt32 {
+				err = fmt.Errorf("%w: int out of range", ErrInvalidData)
+			}
+			v = int32(x)
+		}
+	case TypeLong:
+		v, err = d.long()
+	case TypeFloat:
+		if len(d.b) < 4 {
+			return nil, ErrShortBuffer
+		}
+		v, d.b = math.Float32frombits(binary.LittleEndian.Uint32(d.b)), d.b[4:]
+	case TypeDouble:
+		if len(d.b) < 8 {
+			return nil, ErrShortBuffer
+		}
+		v, d.b = math.Float64frombits(binary.LittleEndian.Uint64(d.b)), d.b[8:]
+	case TypeBytes:
+		var b []byte
+		if b, err = d.bytes(); err == nil {
+			v = bytes.Clone(b)
+		}
+	case TypeString:
+		var b []byte
+		if b, err = d.bytes(); err == nil {
+			v = string(b)
+		}
+	case TypeFixed:
+		if len(d.b) < s.Size {
+			return nil, ErrShortBuffer
+		}
+		v, d.b = bytes.Clone(d.b[:s.Size]), d.b[s.Size:]
+	case TypeEnum:
+		var idx int64
+		if idx, err = d.long(); err != nil {
+			return
+		} else if idx < 0 || idx >= int64(len(s.Symbols)) {
+			return nil, fmt.Errorf("%w: enum index %d", ErrInvalidData, idx)
+		}
+		v = s.Symbols[idx]
+	case TypeUnion:
+		var idx int64
+		if idx, err = d.long(); err != nil {
+			return
+		} else if idx < 0 || idx >= int64(len(s.Branches)) {
+			return nil, fmt.Errorf("%w: union index %d", ErrInvalidData, idx)
+		}
+		v, err = d.decode(s.Branches[idx], depth+1)
+	case TypeRecord:
+		r := &Record{
+			Names:  make([]string, len(s.Fields)),
+			Values: make([]interface{}, len(s.Fields)),
+		}
+		for i, f := range s.Fields {
+			r.Names[i] = f.Name
+			if r.Values[i], err = d.decode(f.Schema, depth+1); err != nil {
+				return
+			}
+		}
+		v = r
+	case TypeArray:
+		arr := []interface{}{}
+		err = d.blocks(s.Items.zeroSized, func() (err error) {
+			var x interface{}
+			if x, err = d.decode(s.Items, depth+1); err == nil {
+				arr = append(arr, x)
+			}
+			return
+		})
+		v = arr
+	case TypeMap:
+		m := map[string]interface{}{}
+		err = d.blocks(false, func() (err error) {
+			var k []byte
+			var x interface{}
+			if k, err = d.bytes(); err != nil {
+				return
+			} else if x, err = d.decode(s.Values, depth+1); err == nil {
+				m[string(k)] = x
+			}
+			return
+		})
+		v = m
+	default:
+		err = fmt.Errorf("%w: %s", ErrUnknownType, s.Type)
+	}
+	return
+}
+
+// blocks reads array and map blocks, each block is a count followed by that many items,
+// a negative count is followed by the block size in bytes, and a zero count ends the list
+func (d *decoder) blocks(zeroSized bool, fn func() error) (err error) {
+	var total int64
+	for {
+		var cnt int64
+		if cnt, err = d.long(); err != nil || cnt == 0 {
+			return
+		} else if cnt < 0 {
+			if cnt == math.MinInt64 {
+				return fmt.Errorf("%w: block count", ErrInvalidData)
+			}
+			cnt = -cnt
+			if _, err = d.long(); err != nil {
+				return
+			}
+		}
+		//items which are not zero sized occupy at least one byte each
+		if total += cnt; (zeroSized && total > maxZeroSizedItems) || (!zeroSized && cnt > int64(len(d.b))) {
+			return fmt.Errorf("%w: block count %d", ErrInvalidData, cnt)
+		}
+		for ; cnt > 0; cnt-- {
+			if err = fn(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (d *decoder) long() (v int64, err error) {
+	var ux uint64
+	var n int
+	if ux, n = binary.Uvarint(d.b); n <= 0 {
+		if n == 0 {
+			err = ErrShortBuffer
+		} else {
+			err = fmt.Errorf("%w: varint overflow", ErrInvalidData)
+		}
+		return
+	}
+	d.b = d.b[n:]
+	v = int64(ux>>1) ^ -int64(ux&1)
+	return
+}
+
+func (d *decoder) bytes() (b []byte, err error) {
+	var l int64
+	if l, err = d.long(); err != nil {
+		return
+	} else if l < 0 {
+		err = fmt.Errorf("%w: negative length", ErrInvalidData)
+		return
+	} else if l > int64(len(d.b)) {
+		err = ErrShortBuffer
+		return
+	}
+	b, d.b = d.b[:l], d.b[l:]
+	return
+}
@@ -0,0 +1,181 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package avro
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+var (
+	errUnsupportedValue = errors.New("Value cannot be encoded with the schema")
+)
+
+// encode builds test payloads from the schema.  Records may be given as *Record or as
+// a map[string]interface{}, enums as their symbol, and unions are encoded with the
+// first branch which accepts the value.
+func (s *Schema) encode(v interface{}) ([]byte, error) {
+	return s.appendValue(nil, v, 0)
+}
+
+func (s *Schema) appendValue(b []byte, v interface{}, depth int) ([]byte, error) {
+	if depth > maxDepth {
+		return nil, ErrTooDeep
+	}
+	switch s.Type {
+	case TypeNull:
+		if v == nil {
+			return b, nil
+		}
+	case TypeBoolean:
+		if t, ok := v.(bool); ok {
+			if t {
+				return append(b, 1), nil
+			}
+			return append(b, 0), nil
+		}
+	case TypeInt, TypeLong:
+		if x, ok := toInt64(v); ok {
+			if s.Type == TypeInt && (x > math.MaxInt32 || x < math.MinInt32) {
+				break
+			}
+			return appendLong(b, x), nil
+		}
+	case TypeFloat:
+		if x, ok := toFloat64(v); ok {
+			return binary.LittleEndian.AppendUint32(b, math.Float32bits(float32(x))), nil
+		}
+	case TypeDouble:
+		if x, ok := toFloat64(v); ok {
+			return binary.LittleEndian.AppendUint64(b, math.Float64bits(x)), nil
+		}
+	case TypeBytes, TypeString:
+		switch t := v.(type) {
+		case string:
+			return append(appendLong(b, int64(len(t))), t...), nil
+		case []byte:
+			return append(appendLong(b, int64(len(t))), t...), nil
+		}
+	case TypeFixed:
+		if t, ok := v.([]byte); ok && len(t) == s.Size {
+			return append(b, t...), nil
+		}
+	case TypeEnum:
+		if t, ok := v.(string); ok {
+			for i, sym := range s.Symbols {
+				if sym == t {
+					return appendLong(b, int64(i)), nil
+				}
+			}
+		}
+	case TypeUnion:
+		for i, br := range s.Branches {
+			if r, err := br.appendValue(appendLong(b, int64(i)), v, depth+1); err == nil {
+				return r, nil
+			}
+		}
+	case TypeRecord:
+		return s.appendRecord(b, v, depth)
+	case TypeArray:
+		var items []interface{}
+		switch t := v.(type) {
+		case []interface{}:
+			items = t
+		case []string:
+			for _, x := range t {
+				items = append(items, x)
+			}
+		default:
+			return nil, fmt.Errorf("%w: %T as %s", errUnsupportedValue, v, s.Type)
+		}
+		var err error
+		if len(items) > 0 {
+			b = appendLong(b, int64(len(items)))
+			for _, x := range items {
+				if b, err = s.Items.appendValue(b, x, depth+1); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return appendLong(b, 0), nil
+	case TypeMap:
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var err error
+		if len(keys) > 0 {
+			b = appendLong(b, int64(len(keys)))
+			for _, k := range keys {
+				b = append(appendLong(b, int64(len(k))), k...)
+				if b, err = s.Values.appendValue(b, m[k], depth+1); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return appendLong(b, 0), nil
+	}
+	return nil, fmt.Errorf("%w: %T as %s", errUnsupportedValue, v, s.Type)
+}
+
+func (s *Schema) appendRecord(b []byte, v interface{}, depth int) (_ []byte, err error) {
+	var get func(string) (interface{}, bool)
+	switch t := v.(type) {
+	case *Record:
+		get = t.Get
+	case map[string]interface{}:
+		get = func(k string) (x interface{}, ok bool) {
+			x, ok = t[k]
+			return
+		}
+	default:
+		return nil, fmt.Errorf("%w: %T as %s", errUnsupportedValue, v, s.Type)
+	}
+	for _, f := range s.Fields {
+		x, _ := get(f.Name) //missing fields are encoded as nil, which only null and unions with null accept
+		if b, err = f.Schema.appendValue(b, x, depth+1); err != nil {
+			return nil, fmt.Errorf("field %s: %w", f.Name, err)
+		}
+	}
+	return b, nil
+}
+
+func appendLong(b []byte, v int64) []byte {
+	return binary.AppendUvarint(b, uint64((v<<1)^(v>>63)))
+}
+
+func toInt64(v interface{}) (int64, bool) {
+	switch t := v.(type) {
+	case int:
+		return int64(t), true
+	case int32:
+		return int64(t), true
+	case int64:
+		return t, true
+	}
+	return 0, false
+}
+
+func toFloat64(v interface{}) (float64, bool) {
+	switch t := v.(type) {
+	case float32:
+		return float64(t), true
+	case float64:
+		return t, true
+	}
+	return 0, false
+}
@@ -0,0 +1,257 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+// Package avro decodes and encodes Avro binary data using schemas in the standard JSON
+// schema format.  Values are decoded into generic types: records become *Record, which
+// keeps field order when marshalled to JSON, maps become map[string]interface{}, arrays
+// become []interface{}, and unions decode directly to the value of the selected branch.
+package avro
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Type identifies an Avro primitive or complex type
+type Type string
+
+const (
+	TypeNull    Type = `null`
+	TypeBoolean Type = `boolean`
+	TypeInt     Type = `int`
+	TypeLong    Type = `long`
+	TypeFloat   Type = `float`
+	TypeDouble  Type = `double`
+	TypeBytes   Type = `bytes`
+	TypeString  Type = `string`
+	TypeRecord  Type = `record`
+	TypeEnum    Type = `enum`
+	TypeArray   Type = `array`
+	TypeMap     Type = `map`
+	TypeUnion   Type = `union`
+	TypeFixed   Type = `fixed`
+)
+
+var (
+	ErrInvalidSchema = errors.New("Invalid Avro schema")
+	ErrUnknownType   = errors.New("Unknown Avro type")
+	ErrDuplicateName = errors.New("Duplicate Avro type name")
+)
+
+// Schema is a parsed Avro schema node
+type Schema struct {
+	Type      Type
+	Name      string // full name of named types
+	Logical   string // logicalType annotation, decoding ignores it
+	Fields    []Field
+	Symbols   []string
+	Items     *Schema // array items
+	Values    *Schema // map values
+	Branches  []*Schema
+	Size      int // fixed size
+	zeroSized bool
+}
+
+// Field is a single record field
+type Field struct {
+	Name   string
+	Schema *Schema
+}
+
+// ParseSchemaFile parses the JSON schema in the file at pth
+func ParseSchemaFile(pth string) (s *Schema, err error) {
+	var b []byte
+	if b, err = os.ReadFile(pth); err == nil {
+		s, err = ParseSchema(b)
+	}
+	return
+}
+
+// ParseSchema parses a JSON Avro schema
+func ParseSchema(b []byte) (s *Schema, err error) {
+	var v interface{}
+	if err = json.Unmarshal(b, &v); err != nil {
+		err = fmt.Errorf("%w: %v", ErrInvalidSchema, err)
+		return
+	}
+	p := parser{named: map[string]*Schema{}}
+	if s, err = p.parse(v, ``); err == nil {
+		s.setZeroSized(map[*Schema]bool{})
+	}
+	return
+}
+
+type parser struct {
+	named map[string]*Schema
+}
+
+func (p *parser) parse(v interface{}, ns string) (s *Schema, err error) {
+	switch t := v.(type) {
+	case string:
+		return p.parseName(t, ns)
+	case []interface{}:
+		s = &Schema{Type: TypeUnion}
+		for _, b := range t {
+			var br *Schema
+			if br, err = p.parse(b, ns); err != nil {
+				return
+			}
+			s.Branches = append(s.Branches, br)
+		}
+		if len(s.Branches) == 0 {
+			err = fmt.Errorf("%w: empty union", ErrInvalidSchema)
+		}
+		return
+	case map[string]interface{}:
+		return p.parseComplex(t, ns)
+	}
+	err = fmt.Errorf("%w: unexpected %T", ErrInvalidSchema, v)
+	return
+}
+
+func (p *parser) parseName(name, ns string) (s *Schema, err error) {
+	switch Type(name) {
+	case TypeNull, TypeBoolean, TypeInt, TypeLong, TypeFloat, TypeDouble, TypeBytes, TypeString:
+		s = &Schema{Type: Type(name)}
+		return
+	}
+	var ok bool
+	if s, ok = p.named[fullName(name, ns)]; !ok {
+		if s, ok = p.named[name]; !ok {
+			err = fmt.Errorf("%w: %s", ErrUnknownType, name)
+		}
+	}
+	return
+}
+
+func (p *parser) parseComplex(m map[string]interface{}, ns string) (s *Schema, err error) {
+	typ, ok := m[`type`]
+	if !ok {
+		err = fmt.Errorf("%w: missing type", ErrInvalidSchema)
+		return
+	}
+	ts, ok := typ.(string)
+	if !ok {
+		//the type is itself a schema, such as {"type": {"type": "array", ...}}
+		return p.parse(typ, ns)
+	}
+	s = &Schema{Type: Type(ts)}
+	s.Logical, _ = m[`logicalType`].(string)
+	switch s.Type {
+	case TypeRecord, TypeEnum, TypeFixed:
+		if err = p.register(s, m, &ns); err != nil {
+			return
+		}
+	}
+	switch s.Type {
+	case TypeNull, TypeBoolean, TypeInt, TypeLong, TypeFloat, TypeDouble, TypeBytes, TypeString:
+	case TypeRecord:
+		flds, _ := m[`fields`].([]interface{})
+		for _, f := range flds {
+			fm, ok := f.(map[string]interface{})
+			if !ok {
+				err = fmt.Errorf("%w: invalid field in %s", ErrInvalidSchema, s.Name)
+				return
+			}
+			var fld Field
+			if fld.Name, _ = fm[`name`].(string); fld.Name == `` {
+				err = fmt.Errorf("%w: unnamed field in %s", ErrInvalidSchema, s.Name)
+				return
+			} else if fld.Schema, err = p.parse(fm[`type`], ns); err != nil {
+				return
+			}
+			s.Fields = append(s.Fields, fld)
+		}
+	case TypeEnum:
+		syms, _ := m[`symbols`].([]interface{})
+		for _, v := range syms {
+			sym, ok := v.(string)
+			if !ok {
+				err = fmt.Errorf("%w: invalid symbol in %s", ErrInvalidSchema, s.Name)
+				return
+			}
+			s.Symbols = append(s.Symbols, sym)
+		}
+	case TypeArray:
+		s.Items, err = p.parse(m[`items`], ns)
+	case TypeMap:
+		s.Values, err = p.parse(m[`values`], ns)
+	case TypeFixed:
+		sz, ok := m[`size`].(float64)
+		if !ok || sz < 0 || sz != float64(int(sz)) {
+			err = fmt.Errorf("%w: invalid size for %s", ErrInvalidSchema, s.Name)
+			return
+		}
+		s.Size = int(sz)
+	default:
+		//a named type reference in object form
+		return p.parseName(ts, ns)
+	}
+	return
+}
+
+// register records a named type before its body is parsed so it may refer to itself
+func (p *parser) register(s *Schema, m map[string]interface{}, ns *string) error {
+	name, _ := m[`name`].(string)
+	if name == `` {
+		return fmt.Errorf("%w: %s without a name", ErrInvalidSchema, s.Type)
+	}
+	if v, ok := m[`namespace`].(string); ok && !strings.Contains(name, `.`) {
+		*ns = v
+	}
+	s.Name = fullName(name, *ns)
+	if idx := strings.LastIndex(s.Name, `.`); idx != -1 {
+		*ns = s.Name[:idx]
+	}
+	if _, ok := p.named[s.Name]; ok {
+		return fmt.Errorf("%w: %s", ErrDuplicateName, s.Name)
+	}
+	p.named[s.Name] = s
+	return nil
+}
+
+func fullName(name, ns string) string {
+	if ns == `` || strings.Contains(name, `.`) {
+		return name
+	}
+	return ns + `.` + name
+}
+
+// setZeroSized marks nodes whose encoding may occupy no bytes, these need a cap on the
+// number of array and map items that a block may claim
+func (s *Schema) setZeroSized(seen map[*Schema]bool) bool {
+	if seen[s] {
+		return s.zeroSized
+	}
+	seen[s] = true
+	switch s.Type {
+	case TypeNull:
+		s.zeroSized = true
+	case TypeFixed:
+		s.zeroSized = s.Size == 0
+	case TypeRecord:
+		s.zeroSized = true
+		for _, f := range s.Fields {
+			if !f.Schema.setZeroSized(seen) {
+				s.zeroSized = false
+			}
+		}
+	case TypeArray:
+		s.Items.setZeroSized(seen)
+	case TypeMap:
+		s.Values.setZeroSized(seen)
+	case TypeUnion:
+		for _, b := range s.Branches {
+			b.setZeroSized(seen)
+		}
+	}
+	return s.zeroSized
+}
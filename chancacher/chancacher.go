@@ -20,6 +20,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gofrs/flock"
@@ -46,17 +47,24 @@ type ChanCacher struct {
 
 	cachePath      string
 	cache          bool
-	cacheR         *fileCounter
-	cacheW         *fileCounter
-	cacheEnc       *gob.Encoder
+	sealed         []segment // segments waiting to be read, oldest first
+	segW           *fileCounter
+	segEnc         *segmentWriter
+	segPath        string
+	nextSeq        uint64
+	diskSize       atomic.Int64
 	cacheModified  bool
 	cacheLock      sync.Mutex
 	cacheReading   bool
 	cachePaused    chan bool
 	cacheDone      chan bool
 	cacheAck       chan bool
+	cacheNotify    chan bool
 	cacheIsDone    bool
 	cacheCommitted bool
+	committed      func(interface{}, error) // optional, see SetCommitCallback
+	unsynced       []interface{}            // values in the active segment waiting on a sync
+	peers          []*ChanCacher            // optional, see ShareSizeLimit
 
 	fileLock *flock.Flock
 
@@ -75,18 +83,24 @@ const CacheFlagPermissions = os.O_CREATE | os.O_RDWR
 // quarantineFolder folder where faulty cache files will be stored
 const quarantineFolder = "quarantine"
 
+// legacyCacheFiles are the gob stream cache files written by older versions,
+// they are converted to segments when a ChanCacher is created.
+var legacyCacheFiles = []string{"cache_a", "cache_b"}
+
 // NewChanCacher creates a new ChanCacher with maximum depth, and optional backing file.
 // If maxDepth == 0, the ChanCacher will be unbuffered. If maxDepth == -1, the
 // ChanCacher depth will be set to MaxDepth. To enable a backing store,
-// provide a path to backingPath. chancachers create two files using this
-// prefix named cache_a and cache_b.
+// provide a path to backingPath. chancachers store cached values in a series
+// of bounded segment files in that directory named segment.<sequence>.
 //
 // The maxSize argument sets the maximum amount of disk commit, in bytes.
 //
-// When a new ChanCacher is made, if cachePath points to existing cache files,
+// When a new ChanCacher is made, if cachePath points to existing segments,
 // the ChanCacher will immediately attempt to drain them from disk. In this
 // way, you can recover data sent to disk on a crash or previous use of
-// Commit().
+// Commit(). Damaged records are skipped individually, the rest of a segment
+// is still recovered. Cache files written by older versions are converted
+// to segments.
 func NewChanCacher(maxDepth int, cachePath string, maxSize int, lgr log.IngestLogger) (*ChanCacher, error) {
 	if cachePath != "" {
 		if fi, err := os.Stat(cachePath); err != nil {
@@ -112,6 +126,7 @@ func NewChanCacher(maxDepth int, cachePath string, maxSize int, lgr log.IngestLo
 		cachePaused: make(chan bool),
 		cacheDone:   make(chan bool),
 		cacheAck:    make(chan bool),
+		cacheNotify: make(chan bool, 1),
 		maxSize:     maxSize,
 		lgr:         lgr,
 	}
@@ -129,12 +144,9 @@ func NewChanCacher(maxDepth int, cachePath string, maxSize int, lgr log.IngestLo
 			return nil, err
 		}
 
-		rPath := filepath.Join(c.cachePath, "cache_a")
-		wPath := filepath.Join(c.cachePath, "cache_b")
-
 		// remove old merge_* files if they exist. It's possible to
 		// kill an ingester before we have a chance to remove it after
-		// merging, so we just do a little housekeeping ourselves.
+		// migrating, so we just do a little housekeeping ourselves.
 		detritus, err := filepath.Glob(filepath.Join(c.cachePath, "merge*"))
 		if err != nil {
 			return nil, err
@@ -153,68 +165,20 @@ func NewChanCacher(maxDepth int, cachePath string, maxSize int, lgr log.IngestLo
 			return nil, fmt.Errorf("could not get file lock!")
 		}
 
-		// Validate caches (if they exist) of previous instances.
-		err = validateCache(rPath, quarantineFolder, c.lgr)
-		if err != nil {
+		if c.sealed, c.nextSeq, err = listSegments(c.cachePath); err != nil {
+			c.fileLock.Unlock()
 			return nil, err
 		}
-		err = validateCache(wPath, quarantineFolder, c.lgr)
-		if err != nil {
-			return nil, err
+		for _, seg := range c.sealed {
+			c.diskSize.Add(int64(seg.size))
 		}
 
-		// check if we need to merge
-		var sizeR, sizeW int64
-		fi, err := os.Stat(rPath)
-		if err == nil {
-			sizeR = fi.Size()
-		}
-		fi, err = os.Stat(wPath)
-		if err == nil {
-			sizeW = fi.Size()
-		}
-
-		// if only one file has data in it, just shuffle the files
-		// around. If both have data, merge. If neither have data, no
-		// action is needed.
-		if sizeW != 0 && sizeR == 0 {
-			err := os.Rename(wPath, rPath)
-			if err != nil {
+		// convert caches of previous versions (if they exist).
+		for _, name := range legacyCacheFiles {
+			if err = c.migrateLegacy(filepath.Join(c.cachePath, name)); err != nil {
+				c.fileLock.Unlock()
 				return nil, err
 			}
-		} else if sizeW != 0 && sizeR != 0 {
-			err := merge(rPath, wPath)
-			if err != nil {
-				return nil, err
-			}
-		}
-
-		r, err := os.OpenFile(rPath, CacheFlagPermissions, CacheFilePerm)
-		if err != nil {
-			return nil, err
-		}
-		w, err := os.OpenFile(wPath, CacheFlagPermissions, CacheFilePerm)
-		if err != nil {
-			return nil, err
-		}
-
-		if c.cacheR, err = NewFileCounter(r); err != nil {
-			return nil, err
-		}
-		if c.cacheW, err = NewFileCounter(w); err != nil {
-			return nil, err
-		}
-
-		c.cacheEnc = gob.NewEncoder(c.cacheW)
-
-		// if the write cache data data in it already (recover), then
-		// mark the cache as modified.
-		fi, err = c.cacheW.Stat()
-		if err != nil {
-			return nil, err
-		}
-		if fi.Size() != 0 {
-			c.cacheModified = true
 		}
 
 		go c.cacheHandler()
@@ -223,10 +187,44 @@ func NewChanCacher(maxDepth int, cachePath string, maxSize int, lgr log.IngestLo
 	return c, nil
 }
 
+// migrateLegacy validates a legacy cache file and converts it to a sealed segment.
+func (c *ChanCacher) migrateLegacy(lPath string) (err error) {
+	if _, err = os.Stat(lPath); err != nil {
+		if os.IsNotExist(err) {
+			err = nil
+		}
+		return
+	}
+	if err = validateCache(lPath, quarantineFolder, c.lgr); err != nil {
+		return
+	}
+	fi, err := os.Stat(lPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// quarantined
+			err = nil
+		}
+		return
+	} else if fi.Size() == 0 {
+		return os.Remove(lPath)
+	}
+	sPath := filepath.Join(c.cachePath, segmentName(c.nextSeq))
+	c.nextSeq++
+	var sz int
+	if sz, err = migrateLegacyCache(lPath, sPath); err != nil {
+		c.lgr.Error("Failed to convert legacy cache file", log.KV("cache", lPath), log.KVErr(err))
+		return quarantineCache(lPath, quarantineFolder, c.lgr)
+	} else if sz > 0 {
+		c.sealed = append(c.sealed, segment{path: sPath, size: sz})
+		c.diskSize.Add(int64(sz))
+	}
+	return
+}
+
 // run connects in->out channels, watching the depth on out. When out is full,
 // we block on reads from in. Optionally, we redirect input to a backing store
-// with gob, and continue reading from in indefinitely. When the backing store
-// is enabled, we end up plumbing in->cache->out.
+// of segment files, and continue reading from in indefinitely. When the backing
+// store is enabled, we end up plumbing in->cache->out.
 func (c *ChanCacher) run() {
 	for v := range c.In {
 		select {
@@ -265,6 +263,10 @@ func (c *ChanCacher) run() {
 		// verify the cache reader has stopped trying to write to c.Out
 		<-c.cacheAck
 
+		c.cacheLock.Lock()
+		c.sealSegment()
+		c.cacheLock.Unlock()
+
 		c.fileLock.Unlock()
 	}
 
@@ -274,83 +276,233 @@ func (c *ChanCacher) run() {
 }
 
 func (c *ChanCacher) cacheHandler() {
-	// the main cache loop. We read from R, putting data into out directly
-	// until R is drained. Once R is drained, wait for W to have data and
-	// for run() to signal that we can swap buffers.
-	c.cacheReading = true
+	// the main cache loop. We read sealed segments oldest first, putting
+	// data into out directly. Once there are no sealed segments left, we
+	// seal the active segment if it has data, otherwise we wait for a
+	// write or for the cache to be shut down.
 	for {
-		var err error
+		if seg, ok := c.nextSegment(); ok {
+			c.readSegment(seg)
 
-		dec := gob.NewDecoder(c.cacheR)
-		var v interface{}
-		for {
-			err = dec.Decode(&v)
-			if err != nil {
-				break
-			}
-			if v == nil {
-				continue
+			select {
+			case <-c.cacheDone:
+				close(c.cacheAck)
+				return
+			default:
 			}
-
-			c.Out <- v
-		}
-		if err != io.EOF {
-			c.lgr.Error("Unexpected error while parsing cache", log.KVErr(err))
+			continue
 		}
 
-		c.cacheReading = false
-		c.cacheR.Seek(0, 0)
-		c.cacheR.Truncate(0)
-
 		// This is the only place where CacheHasData() will return false
-
 		select {
 		case <-c.cacheDone:
 			close(c.cacheAck)
 			return
-		default:
+		case <-c.cacheNotify:
 		}
+	}
+}
 
-		// Wait for W to have data.
-		for !c.cacheModified {
-			select {
-			case <-c.cacheDone:
-				close(c.cacheAck)
-				return
-			case <-time.After(time.Second):
+// nextSegment pops the oldest sealed segment, sealing the active segment if
+// there is nothing else to read.
+func (c *ChanCacher) nextSegment() (seg segment, ok bool) {
+	c.cacheLock.Lock()
+	defer c.cacheLock.Unlock()
+	if len(c.sealed) == 0 && c.cacheModified {
+		c.sealSegment()
+	}
+	if len(c.sealed) == 0 {
+		c.cacheReading = false
+		return
+	}
+	c.cacheReading = true
+	seg, c.sealed = c.sealed[0], c.sealed[1:]
+	ok = true
+	return
+}
+
+// readSegment pushes every valid record in a sealed segment to the output
+// channel and then removes the segment. Damaged records are logged and skipped.
+func (c *ChanCacher) readSegment(seg segment) {
+	// the segment leaves the disk size as it is consumed, whatever is left
+	// over (damaged or undecodable records) is released once we are done
+	var consumed int
+	defer func() {
+		c.diskSize.Add(int64(consumed - seg.size))
+	}()
+
+	buff, err := os.ReadFile(seg.path)
+	if err != nil {
+		c.lgr.Error("Failed to read cache segment", log.KV("segment", seg.path), log.KVErr(err))
+		quarantineCache(seg.path, quarantineFolder, c.lgr)
+		return
+	}
+
+	var rd recordDecoder
+	for off := 0; off < len(buff); {
+		flags, payload, n, err := nextRecord(buff[off:])
+		if err != nil {
+			next := len(buff)
+			if err == errRecordChecksum {
+				// the length looks sane, try to pick up right after the record
+				if _, _, _, lerr := nextRecord(buff[off+n:]); lerr == nil || off+n == len(buff) {
+					next = off + n
+				}
+			}
+			if next == len(buff) {
+				next = resync(buff, off+1)
 			}
+			c.lgr.Error("Skipping damaged cache records",
+				log.KV("segment", seg.path), log.KV("offset", off),
+				log.KV("skipped", next-off), log.KVErr(err))
+			c.release(&consumed, next-off)
+			off = next
+			continue
 		}
+		off += n
+		c.release(&consumed, n)
 
-		// swap caches
-		c.cacheLock.Lock()
-		c.cacheR, c.cacheW = c.cacheW, c.cacheR
-		c.cacheR.Seek(0, 0)
-		c.cacheEnc = gob.NewEncoder(c.cacheW)
-		c.cacheModified = false
-		c.cacheReading = true
-		c.cacheLock.Unlock()
+		v, err := rd.decode(flags, payload)
+		if err != nil {
+			c.lgr.Error("Failed to decode cache record", log.KV("segment", seg.path), log.KVErr(err))
+			continue
+		} else if v == nil {
+			continue
+		}
+		c.Out <- v
 	}
+
+	if err = os.Remove(seg.path); err != nil {
+		c.lgr.Error("Failed to remove cache segment", log.KV("segment", seg.path), log.KVErr(err))
+	}
+}
+
+func (c *ChanCacher) release(consumed *int, n int) {
+	*consumed += n
+	c.diskSize.Add(int64(-n))
+}
+
+// sealSegment closes the active segment and queues it for reading.
+// Caller must hold the cache lock.
+func (c *ChanCacher) sealSegment() {
+	if c.segW == nil {
+		return
+	}
+	sz := c.segW.Count()
+	err := c.segW.Sync()
+	if err != nil {
+		c.lgr.Error("failed to sync cache segment", log.KV("segment", c.segPath), log.KVErr(err))
+	}
+	if c.committed != nil {
+		for _, v := range c.unsynced {
+			c.committed(v, err)
+		}
+	}
+	c.unsynced = nil
+	c.segW.Close()
+	if sz == 0 {
+		os.Remove(c.segPath)
+	} else {
+		c.sealed = append(c.sealed, segment{path: c.segPath, size: sz})
+	}
+	c.segW = nil
+	c.segEnc = nil
+	c.segPath = ""
+	c.cacheModified = false
+}
+
+// openSegment starts a new active segment. Caller must hold the cache lock.
+func (c *ChanCacher) openSegment() (err error) {
+	p := filepath.Join(c.cachePath, segmentName(c.nextSeq))
+	c.nextSeq++
+	var f *os.File
+	if f, err = os.OpenFile(p, CacheFlagPermissions|os.O_TRUNC, CacheFilePerm); err != nil {
+		return
+	}
+	if c.segW, err = NewFileCounter(f); err != nil {
+		f.Close()
+		return
+	}
+	c.segEnc = newSegmentWriter(c.segW)
+	c.segPath = p
+	return
 }
 
 func (c *ChanCacher) cacheValue(v interface{}) {
 	if v == nil {
 		return
 	}
-	for c.maxSize != 0 && c.Size() >= c.maxSize {
+	for c.maxSize != 0 && c.sharedSize() >= c.maxSize {
 		time.Sleep(100 * time.Millisecond)
 	}
 
 	c.cacheLock.Lock()
 	defer c.cacheLock.Unlock()
-	if err := c.cacheEnc.Encode(&v); err != nil {
-		c.lgr.Error("failed to encode value into cache", log.KV("value", v), log.KVErr(err))
+	if c.segW != nil && c.segW.Count() >= maxSegmentSize {
+		c.sealSegment()
+	}
+	if c.segW == nil {
+		if err := c.openSegment(); err != nil {
+			c.lgr.Error("failed to open cache segment", log.KV("value", v), log.KVErr(err))
+			if c.committed != nil {
+				c.committed(v, err)
+			}
+			return
+		}
+	}
+	n, err := c.segEnc.write(v)
+	c.diskSize.Add(int64(n))
+	if err != nil {
+		c.lgr.Error("failed to write value into cache", log.KV("value", v), log.KV("segment", c.segPath), log.KVErr(err))
+		if c.committed != nil {
+			c.committed(v, err)
+		}
+		if n == 0 {
+			return
+		}
+	} else if c.committed != nil {
+		c.unsynced = append(c.unsynced, v)
 	}
 	c.cacheModified = true
+	select {
+	case c.cacheNotify <- true:
+	default:
+	}
 }
 
 // CacheHasData returns if the cache has outstanding data not written to the output channel.
 func (c *ChanCacher) CacheHasData() bool {
-	return c.cacheModified || c.cacheReading
+	c.cacheLock.Lock()
+	defer c.cacheLock.Unlock()
+	return c.cacheModified || c.cacheReading || len(c.sealed) > 0
+}
+
+// SetCommitCallback registers fn to be called with every value written to the backing
+// store once the segment holding it has been synced to disk, or with an error if the
+// value could not be stored.  fn is called with the cache locked and must not block
+// or call back into the ChanCacher.  Values which pass through the in-memory buffer
+// never reach fn.
+func (c *ChanCacher) SetCommitCallback(fn func(v interface{}, err error)) {
+	c.cacheLock.Lock()
+	c.committed = fn
+	c.cacheLock.Unlock()
+}
+
+// ShareSizeLimit counts the disk usage of the peer caches against the maximum size of
+// this cache.  Values are held back once this cache and its peers reach the limit
+// together, while the peers are only bound by their own limits.  This lets a cache of
+// less important data back off first when space runs short.  ShareSizeLimit should be
+// called before anything is written to the ChanCacher.
+func (c *ChanCacher) ShareSizeLimit(peers ...*ChanCacher) {
+	c.peers = append(c.peers, peers...)
+}
+
+func (c *ChanCacher) sharedSize() (sz int) {
+	sz = c.Size()
+	for _, p := range c.peers {
+		sz += p.Size()
+	}
+	return
 }
 
 // BufferSize returns the number of elements on the internal buffer.
@@ -415,7 +567,7 @@ func (c *ChanCacher) Drain() {
 //
 // Once Commit() is called, draining the cache cannot be restarted, though
 // writing to the cache will still work. Commit should only be used for teardown
-// scenarios.
+// scenarios. Segments that were not yet read are left on disk untouched.
 func (c *ChanCacher) Commit() {
 	if !c.cache {
 		c.cacheCommitted = true
@@ -437,10 +589,9 @@ func (c *ChanCacher) Commit() {
 		}
 	}
 
-	c.cacheR.Sync()
-	c.cacheW.Sync()
-	c.cacheR.Close()
-	c.cacheW.Close()
+	c.cacheLock.Lock()
+	c.sealSegment()
+	c.cacheLock.Unlock()
 	if c.fileLock != nil {
 		c.fileLock.Unlock()
 	}
@@ -461,79 +612,7 @@ func (c *ChanCacher) finishCache() {
 // Size returns the number of bytes committed to disk. This does not include data in
 // the in-memory buffer.
 func (c *ChanCacher) Size() int {
-	return c.cacheR.Count() + c.cacheW.Count()
-}
-
-// Merge two gob encoded files into a single file. Paths a and b are specified,
-// with the resulting file in a.
-func merge(rPath, wPath string) error {
-	fr, err := os.Open(rPath)
-	if err != nil {
-		return err
-	}
-	defer fr.Close()
-
-	fw, err := os.Open(wPath)
-	if err != nil {
-		return err
-	}
-	defer fw.Close()
-
-	t, err := os.CreateTemp(filepath.Dir(rPath), "merge")
-	if err != nil {
-		return err
-	}
-	defer t.Close()
-	defer os.Remove(t.Name())
-
-	enc := gob.NewEncoder(t)
-
-	rdec := gob.NewDecoder(fr)
-	var v interface{}
-	for {
-		err = rdec.Decode(&v)
-		if err != nil {
-			if err != io.EOF {
-				return err
-			}
-			break
-		}
-		if v == nil {
-			continue
-		}
-		err = enc.Encode(&v)
-		if err != nil {
-			return err
-		}
-	}
-
-	wdec := gob.NewDecoder(fw)
-	for {
-		err = wdec.Decode(&v)
-		if err != nil {
-			if err != io.EOF {
-				return err
-			}
-			break
-		}
-		if v == nil {
-			continue
-		}
-		err = enc.Encode(&v)
-		if err != nil {
-			return err
-		}
-	}
-
-	// remove r, w
-	fr.Close()
-	os.Remove(rPath)
-	fw.Close()
-	os.Remove(wPath)
-
-	// and move our temporary file to r
-	t.Close()
-	return os.Rename(t.Name(), rPath)
+	return int(c.diskSize.Load())
 }
 
 // Attempt to open / create a cache file. Will move cache under quarantineFolder,
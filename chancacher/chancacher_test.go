@@ -9,14 +9,19 @@
 package chancacher
 
 import (
+	"bytes"
+	"encoding/binary"
 	"encoding/gob"
 	"errors"
 	"fmt"
+	"math/rand"
 	"net"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -294,6 +299,45 @@ func TestCommit(t *testing.T) {
 	}
 }
 
+func TestCommitCallback(t *testing.T) {
+	dir := t.TempDir()
+
+	c, _ := NewChanCacher(2, dir, 0, defaultLogger)
+	var mtx sync.Mutex
+	seen := map[int]bool{}
+	c.SetCommitCallback(func(v interface{}, err error) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		if err != nil {
+			t.Errorf("commit failed: %v", err)
+		} else if ct, ok := v.(*ChanCacheTester); !ok {
+			t.Errorf("bad committed value: %T", v)
+		} else {
+			seen[ct.V] = true
+		}
+	})
+
+	for i := 0; i < 100; i++ {
+		select {
+		case c.In <- &ChanCacheTester{V: i}:
+		case <-time.After(DEFAULT_TIMEOUT):
+			t.Fatal("channel should not block!")
+		}
+	}
+
+	close(c.In)
+	c.Commit()
+
+	// everything is on disk after a commit, so every value must have been reported
+	mtx.Lock()
+	defer mtx.Unlock()
+	for i := 0; i < 100; i++ {
+		if !seen[i] {
+			t.Fatalf("value %d was never reported as committed", i)
+		}
+	}
+}
+
 func TestDrain(t *testing.T) {
 	dir := t.TempDir()
 
@@ -483,55 +527,14 @@ func TestDetritus(t *testing.T) {
 	}
 }
 
-func TestMerge(t *testing.T) {
-	staging := t.TempDir()
+func TestSegments(t *testing.T) {
 	dir := t.TempDir()
+	defer func(v int) { maxSegmentSize = v }(maxSegmentSize)
+	maxSegmentSize = 1024
 
 	c, _ := NewChanCacher(2, dir, 0, defaultLogger)
 
-	for i := 0; i < 100; i++ {
-		select {
-		case c.In <- &ChanCacheTester{V: i}:
-		// success
-		case <-time.After(DEFAULT_TIMEOUT):
-			t.Error("channel should not block!")
-			t.FailNow()
-		}
-	}
-
-	close(c.In)
-	c.Commit()
-	<-c.Out
-
-	// move our data to staging
-	file := "cache_a"
-	fi, err := os.Stat(filepath.Join(dir, "cache_a"))
-	if err != nil {
-		t.Error(err)
-		t.FailNow()
-	}
-	if fi.Size() == 0 {
-		file = "cache_b"
-		fi, err = os.Stat(filepath.Join(dir, "cache_b"))
-		if err != nil {
-			t.Error(err)
-			t.FailNow()
-		}
-		if fi.Size() == 0 {
-			t.Error("no data in cache!")
-			t.FailNow()
-		}
-	}
-	err = os.Rename(filepath.Join(dir, file), filepath.Join(staging, file))
-	if err != nil {
-		t.Error(err)
-		t.FailNow()
-	}
-
-	// now do it again
-	c, _ = NewChanCacher(2, dir, 0, defaultLogger)
-
-	for i := 100; i < 200; i++ {
+	for i := 0; i < 200; i++ {
 		select {
 		case c.In <- &ChanCacheTester{V: i}:
 		// success
@@ -545,52 +548,32 @@ func TestMerge(t *testing.T) {
 	c.Commit()
 	<-c.Out
 
-	// now put the staging file back in the zero-length data file
-	filedst := "cache_a"
-	fi, err = os.Stat(filepath.Join(dir, "cache_a"))
+	segs, _, err := listSegments(dir)
 	if err != nil {
-		t.Error(err)
-		t.FailNow()
+		t.Fatal(err)
+	} else if len(segs) < 2 {
+		t.Fatalf("expected multiple segments, got %d", len(segs))
 	}
-	if fi.Size() != 0 {
-		filedst = "cache_b"
-		fi, err = os.Stat(filepath.Join(dir, "cache_b"))
-		if err != nil {
-			t.Error(err)
-			t.FailNow()
-		}
-		if fi.Size() != 0 {
-			t.Error("both files have data in cache!")
-			t.FailNow()
+	for _, seg := range segs {
+		// a segment can only exceed the max by the final record
+		if seg.size > 2*maxSegmentSize {
+			t.Fatalf("segment %s is too large: %d", seg.path, seg.size)
 		}
 	}
 
-	err = os.Rename(filepath.Join(staging, file), filepath.Join(dir, filedst))
-	if err != nil {
-		t.Error(err)
-		t.FailNow()
-	}
-
 	c, _ = NewChanCacher(2, dir, 0, defaultLogger)
 
-	// reads on the cache are not guaranteed to be in-order, so instead we
-	// count the number of times we've seen each value, and expect to see a
-	// count of 1 for 0-199.
 	results := make(map[int]int)
-
-	// now we should read everything back in order
 	for i := 0; i < 200; i++ {
 		select {
 		case v := <-c.Out:
 			if v == nil {
 				t.Error("nil result!")
 			} else {
-				//fmt.Println(v.(*ChanCacheTester).V)
 				results[v.(*ChanCacheTester).V]++
 			}
 		case <-time.After(DEFAULT_TIMEOUT):
-			t.Error("channel should not block!")
-			//t.FailNow()
+			t.Fatal("channel should not block!")
 		}
 	}
 
@@ -603,6 +586,164 @@ func TestMerge(t *testing.T) {
 			t.Errorf("mismatched count: %v: %v", i, count)
 		}
 	}
+
+	close(c.In)
+	<-c.Out
+	if segs, _, err = listSegments(dir); err != nil {
+		t.Fatal(err)
+	} else if len(segs) != 0 {
+		t.Fatalf("segments left after draining: %v", segs)
+	}
+}
+
+// writeTestSegment writes a segment with count records and returns the offset of each record
+func writeTestSegment(t *testing.T, dir string, count int) (offsets []int) {
+	var buff bytes.Buffer
+	sw := newSegmentWriter(&buff)
+	for i := 0; i < count; i++ {
+		offsets = append(offsets, buff.Len())
+		if _, err := sw.write(&ChanCacheTester{V: i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, segmentName(0)), buff.Bytes(), CacheFilePerm); err != nil {
+		t.Fatal(err)
+	}
+	return
+}
+
+func damageSegment(t *testing.T, dir string, fn func([]byte) []byte) {
+	p := filepath.Join(dir, segmentName(0))
+	buff, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(p, fn(buff), CacheFilePerm); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readRecovered reads everything out of a recovered cache
+func readRecovered(t *testing.T, dir string) map[int]int {
+	c, err := NewChanCacher(2, dir, 0, defaultLogger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	close(c.In)
+	results := make(map[int]int)
+	for v := range c.Out {
+		results[v.(*ChanCacheTester).V]++
+	}
+	if c.Size() != 0 {
+		t.Fatalf("Size mismatch %v != 0", c.Size())
+	}
+	return results
+}
+
+func TestDamagedRecords(t *testing.T) {
+	const count = 20
+	defer func(v int) { recordSyncInterval = v }(recordSyncInterval)
+	recordSyncInterval = 8
+	tests := []struct {
+		name    string
+		damage  func(b []byte, offsets []int) []byte
+		missing []int
+		lossy   []int // may or may not be recovered
+	}{
+		{
+			name: "payload",
+			damage: func(b []byte, offsets []int) []byte {
+				b[offsets[5]+recordHeaderSize+2] ^= 0xff
+				return b
+			},
+			missing: []int{5},
+		},
+		{
+			// the first record of a stream carries the type information,
+			// the rest of the stream may be lost with it
+			name: "stream start",
+			damage: func(b []byte, offsets []int) []byte {
+				b[offsets[8]+recordHeaderSize+2] ^= 0xff
+				return b
+			},
+			missing: []int{8},
+			lossy:   []int{9, 10, 11, 12, 13, 14, 15},
+		},
+		{
+			name: "length",
+			damage: func(b []byte, offsets []int) []byte {
+				binary.LittleEndian.PutUint32(b[offsets[7]:], 0xffffff)
+				return b
+			},
+			missing: []int{7},
+		},
+		{
+			name: "zeroed",
+			damage: func(b []byte, offsets []int) []byte {
+				for i := offsets[3]; i < offsets[5]; i++ {
+					b[i] = 0
+				}
+				return b
+			},
+			missing: []int{3, 4},
+		},
+		{
+			name: "truncated",
+			damage: func(b []byte, offsets []int) []byte {
+				return b[:len(b)-3]
+			},
+			missing: []int{count - 1},
+		},
+	}
+	for _, tt := range tests {
+		dir := t.TempDir()
+		offsets := writeTestSegment(t, dir, count)
+		damageSegment(t, dir, func(b []byte) []byte { return tt.damage(b, offsets) })
+		results := readRecovered(t, dir)
+		for i := 0; i < count; i++ {
+			var want int
+			if !slices.Contains(tt.missing, i) {
+				want = 1
+			}
+			if slices.Contains(tt.lossy, i) && results[i] <= 1 {
+				continue
+			} else if results[i] != want {
+				t.Errorf("%s: record %d recovered %d times, expected %d", tt.name, i, results[i], want)
+			}
+		}
+	}
+}
+
+func TestResync(t *testing.T) {
+	var rec bytes.Buffer
+	if _, err := newSegmentWriter(&rec).write(&ChanCacheTester{V: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	// garbage with the odd plausible looking header in front of a good record
+	garbage := make([]byte, 64*1024)
+	rand.New(rand.NewSource(1)).Read(garbage)
+	for i := 0; i+recordHeaderSize < len(garbage); i += 1024 {
+		binary.LittleEndian.PutUint32(garbage[i:], 8)
+		garbage[i+recordHeaderSize] = 0
+	}
+	b := append(garbage, rec.Bytes()...)
+	if off := resync(b, 1); off != len(garbage) {
+		t.Fatalf("resync found offset %d, expected %d", off, len(garbage))
+	}
+
+	// every header claims a body that runs to the end of the segment, checksumming each
+	// one would be quadratic so the scan has to give up once it runs out of budget
+	b = make([]byte, 4*1024*1024)
+	for i := 0; i+recordHeaderSize < len(b); i += recordHeaderSize {
+		binary.LittleEndian.PutUint32(b[i:], uint32(len(b)-i-recordHeaderSize))
+	}
+	start := time.Now()
+	if off := resync(b, 1); off != len(b) {
+		t.Fatalf("resync found offset %d in a segment with no valid records", off)
+	} else if d := time.Since(start); d > 10*time.Second {
+		t.Fatalf("resync took %v", d)
+	}
 }
 
 func TestCacheHasData(t *testing.T) {
@@ -657,6 +798,49 @@ func TestCacheMaxSize(t *testing.T) {
 	}
 }
 
+func TestShareSizeLimit(t *testing.T) {
+	peer, err := NewChanCacher(0, t.TempDir(), 0, defaultLogger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewChanCacher(0, t.TempDir(), 1, defaultLogger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.ShareSizeLimit(peer)
+
+	// nobody is reading the peer, so it holds onto its data
+	for i := 0; i < 3; i++ {
+		peer.In <- &ChanCacheTester{V: i}
+	}
+	for peer.Size() == 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// the first value is picked up, then everything is held back on the peer's usage
+	c.In <- &ChanCacheTester{V: 1}
+	select {
+	case c.In <- &ChanCacheTester{V: 2}:
+		t.Fatal("channel should block on the peer's disk usage")
+	case <-time.After(DEFAULT_TIMEOUT):
+	}
+
+	// draining the peer frees things up
+	for i := 0; i < 3; i++ {
+		<-peer.Out
+	}
+	select {
+	case c.In <- &ChanCacheTester{V: 2}:
+	case <-time.After(5 * time.Second):
+		t.Fatal("channel did not unblock after the peer drained")
+	}
+	for i := 1; i <= 2; i++ {
+		if v := (<-c.Out).(*ChanCacheTester); v.V != i {
+			t.Fatalf("bad value %d != %d", v.V, i)
+		}
+	}
+}
+
 // TestCacheEntries verifies that we can write entries, with EVs
 // attached, and read them back out.
 func TestCacheEntries(t *testing.T) {
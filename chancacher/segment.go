@@ -0,0 +1,279 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package chancacher
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The on-disk cache is an append-only log broken into segment files named
+// segment.<sequence>.  Every value is stored as a framed record:
+//
+//	uint32 body length | uint32 CRC32-C of the body | body
+//
+// The body is a flags byte followed by the gob encoding of the value.  Records
+// in a segment share a gob stream so that type information is not repeated for
+// every value, but the stream is restarted at the start of every segment and
+// every recordSyncInterval records.  A record that restarts the stream is flagged
+// so a reader can always pick the stream back up after a damaged record.
+// A damaged record only costs that record, unless it was the one carrying the
+// type information for its stream; then the rest of that stream may be lost.
+
+const (
+	segmentPrefix    = "segment."
+	recordHeaderSize = 8
+
+	recordStreamStart byte = 0x1
+)
+
+var (
+	// maxSegmentSize is the size at which the active segment is sealed and a new
+	// one is started.  A single record larger than this still lands in one segment.
+	maxSegmentSize = 4 * 1024 * 1024
+
+	// recordSyncInterval is the number of records written before the gob stream is restarted.
+	recordSyncInterval = 64
+
+	// resyncChecksumFactor bounds the bytes resync will checksum relative to the segment size.
+	resyncChecksumFactor = 16
+)
+
+var (
+	errShortRecord    = errors.New("truncated cache record")
+	errInvalidRecord  = errors.New("invalid cache record length")
+	errRecordChecksum = errors.New("cache record checksum mismatch")
+
+	crcTable = crc32.MakeTable(crc32.Castagnoli)
+)
+
+type segment struct {
+	path string
+	size int
+}
+
+func segmentName(seq uint64) string {
+	return fmt.Sprintf("%s%016d", segmentPrefix, seq)
+}
+
+// listSegments returns the segments in a cache directory in sequence order along
+// with the next sequence number that should be used.  Empty segments are removed.
+func listSegments(dir string) (segs []segment, next uint64, err error) {
+	var matches []string
+	if matches, err = filepath.Glob(filepath.Join(dir, segmentPrefix+"*")); err != nil {
+		return
+	}
+	type seqPath struct {
+		seq  uint64
+		path string
+	}
+	var found []seqPath
+	for _, m := range matches {
+		seq, lerr := strconv.ParseUint(strings.TrimPrefix(filepath.Base(m), segmentPrefix), 10, 64)
+		if lerr != nil {
+			continue
+		}
+		found = append(found, seqPath{seq: seq, path: m})
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].seq < found[j].seq })
+	for _, f := range found {
+		if f.seq >= next {
+			next = f.seq + 1
+		}
+		var fi os.FileInfo
+		if fi, err = os.Stat(f.path); err != nil {
+			return
+		} else if fi.IsDir() {
+			continue
+		} else if fi.Size() == 0 {
+			os.Remove(f.path)
+			continue
+		}
+		segs = append(segs, segment{path: f.path, size: int(fi.Size())})
+	}
+	return
+}
+
+// segmentWriter frames values into records and writes them to a segment.
+type segmentWriter struct {
+	w     io.Writer
+	buff  bytes.Buffer
+	enc   *gob.Encoder
+	count int
+}
+
+func newSegmentWriter(w io.Writer) *segmentWriter {
+	return &segmentWriter{w: w}
+}
+
+// write encodes and writes a single record, returning the number of bytes written.
+func (sw *segmentWriter) write(v interface{}) (n int, err error) {
+	var flags byte
+	if sw.enc == nil || sw.count >= recordSyncInterval {
+		sw.enc = gob.NewEncoder(&sw.buff)
+		sw.count = 0
+		flags = recordStreamStart
+	}
+	sw.buff.Reset()
+	sw.buff.Write(make([]byte, recordHeaderSize))
+	sw.buff.WriteByte(flags)
+	if err = sw.enc.Encode(&v); err != nil {
+		//the encoder may believe it sent type information that we are throwing away
+		sw.enc = nil
+		return
+	}
+	b := sw.buff.Bytes()
+	body := b[recordHeaderSize:]
+	binary.LittleEndian.PutUint32(b, uint32(len(body)))
+	binary.LittleEndian.PutUint32(b[4:], crc32.Checksum(body, crcTable))
+	if n, err = sw.w.Write(b); err != nil {
+		//partial record, make sure the next one starts a fresh stream
+		sw.enc = nil
+		return
+	}
+	sw.count++
+	return
+}
+
+// nextRecord validates the record at the start of b, returning the flags, the gob
+// payload, and the total number of bytes the record occupies.
+func nextRecord(b []byte) (flags byte, payload []byte, n int, err error) {
+	if len(b) < recordHeaderSize {
+		err = errShortRecord
+		return
+	}
+	l := int(binary.LittleEndian.Uint32(b))
+	if l <= 1 {
+		//a body always has flags and a value, this is usually a zeroed tail after a crash
+		err = errInvalidRecord
+		return
+	} else if l > len(b)-recordHeaderSize {
+		err = errShortRecord
+		return
+	}
+	n = recordHeaderSize + l
+	body := b[recordHeaderSize:n]
+	if crc32.Checksum(body, crcTable) != binary.LittleEndian.Uint32(b[4:]) {
+		err = errRecordChecksum
+		return
+	}
+	flags, payload = body[0], body[1:]
+	return
+}
+
+// resync finds the offset of the next valid record in b at or after off, if there
+// are no more valid records len(b) is returned.
+// Only offsets with a plausible header are checksummed and the total number of bytes
+// checksummed is capped at resyncChecksumFactor times the length of b, so a damaged
+// segment full of plausible looking lengths cannot make the scan quadratic.
+func resync(b []byte, off int) int {
+	budget := resyncChecksumFactor * len(b)
+	for ; off < len(b); off++ {
+		l, ok := plausibleRecord(b[off:])
+		if !ok {
+			continue
+		} else if budget -= l; budget < 0 {
+			break
+		}
+		if _, _, _, err := nextRecord(b[off:]); err == nil {
+			return off
+		}
+	}
+	return len(b)
+}
+
+// plausibleRecord checks the length and flags of the record at the start of b without
+// checksumming the body, returning the body length.
+func plausibleRecord(b []byte) (l int, ok bool) {
+	if len(b) < recordHeaderSize+2 {
+		return
+	}
+	l = int(binary.LittleEndian.Uint32(b))
+	if l <= 1 || l > len(b)-recordHeaderSize {
+		return
+	}
+	ok = b[recordHeaderSize]&^recordStreamStart == 0
+	return
+}
+
+// recordDecoder decodes the records of a single segment in order.
+type recordDecoder struct {
+	rdr bytes.Reader
+	dec *gob.Decoder
+}
+
+func (rd *recordDecoder) decode(flags byte, payload []byte) (v interface{}, err error) {
+	// bytes.Reader is an io.ByteReader so the gob decoder will not buffer past the record
+	rd.rdr.Reset(payload)
+	if rd.dec == nil || flags&recordStreamStart != 0 {
+		rd.dec = gob.NewDecoder(&rd.rdr)
+	}
+	err = rd.dec.Decode(&v)
+	return
+}
+
+// migrateLegacyCache converts a gob stream cache file written by older versions into
+// a segment at path.  The segment is built in a temporary merge file and renamed into
+// place so that an interrupted migration never leaves a partial segment behind.
+func migrateLegacyCache(legacyPath, path string) (size int, err error) {
+	var fin *os.File
+	if fin, err = os.Open(legacyPath); err != nil {
+		return
+	}
+	defer fin.Close()
+
+	var t *os.File
+	if t, err = os.CreateTemp(filepath.Dir(path), "merge"); err != nil {
+		return
+	}
+	defer os.Remove(t.Name())
+	defer t.Close()
+
+	sw := newSegmentWriter(t)
+	dec := gob.NewDecoder(fin)
+	for {
+		var v interface{}
+		if err = dec.Decode(&v); err != nil {
+			if err != io.EOF {
+				return
+			}
+			break
+		} else if v == nil {
+			continue
+		}
+		var n int
+		n, err = sw.write(v)
+		if size += n; err != nil {
+			return
+		}
+	}
+	if err = t.Sync(); err != nil {
+		return
+	} else if err = t.Close(); err != nil {
+		return
+	}
+	if size > 0 {
+		if err = os.Rename(t.Name(), path); err != nil {
+			return
+		}
+	}
+	fin.Close()
+	err = os.Remove(legacyPath)
+	return
+}
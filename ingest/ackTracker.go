@@ -0,0 +1,143 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package ingest
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gravwell/gravwell/v4/ingest/entry"
+)
+
+var (
+	ErrEntryAlreadyTracked = errors.New("Entry is already waiting on an acknowledgement")
+)
+
+// ackGroup is a batch written with WriteBatchAcked
+type ackGroup struct {
+	pending int
+	err     error
+	cb      func(error)
+}
+
+// ackTracker maps entries written with WriteBatchAcked back to their batch.  Entries
+// keep their identity all the way through the muxer, so they are tracked by pointer
+// until an indexer confirms them or the cache commits them to disk.
+type ackTracker struct {
+	active atomic.Int64 //tracked entry count, lets the confirmation path skip the lock
+	mtx    sync.Mutex
+	ents   map[*entry.Entry]*ackGroup
+}
+
+func newAckTracker() *ackTracker {
+	return &ackTracker{
+		ents: map[*entry.Entry]*ackGroup{},
+	}
+}
+
+// track registers a batch, cb fires once every entry in the batch is done
+func (at *ackTracker) track(ents []*entry.Entry, cb func(error)) error {
+	g := &ackGroup{cb: cb}
+	at.mtx.Lock()
+	defer at.mtx.Unlock()
+	for i, ent := range ents {
+		if ent == nil {
+			at.remove(ents[:i], g)
+			return ErrInvalidEntry
+		} else if og, ok := at.ents[ent]; ok {
+			if og == g {
+				continue //the same entry twice in one batch
+			}
+			at.remove(ents[:i], g)
+			return ErrEntryAlreadyTracked
+		}
+		at.ents[ent] = g
+		g.pending++
+	}
+	at.active.Add(int64(g.pending))
+	return nil
+}
+
+// untrack drops a batch without firing its callback
+func (at *ackTracker) untrack(ents []*entry.Entry) {
+	at.mtx.Lock()
+	defer at.mtx.Unlock()
+	for _, ent := range ents {
+		if _, ok := at.ents[ent]; ok {
+			delete(at.ents, ent)
+			at.active.Add(-1)
+		}
+	}
+}
+
+// remove unwinds a partially registered group, caller must hold the lock
+func (at *ackTracker) remove(ents []*entry.Entry, g *ackGroup) {
+	for _, ent := range ents {
+		if at.ents[ent] == g {
+			delete(at.ents, ent)
+		}
+	}
+}
+
+// confirm is handed to entry writers and fires for every entry an indexer confirms
+func (at *ackTracker) confirm(ent *entry.Entry) {
+	at.done(ent, nil)
+}
+
+// committed is handed to the chancachers and fires as cached values hit the disk
+func (at *ackTracker) committed(v interface{}, err error) {
+	switch t := v.(type) {
+	case *entry.Entry:
+		at.done(t, err)
+	case []*entry.Entry:
+		for _, ent := range t {
+			at.done(ent, err)
+		}
+	}
+}
+
+// done marks a single entry as finished, the first error sticks to the batch
+func (at *ackTracker) done(ent *entry.Entry, err error) {
+	if ent == nil || at.active.Load() == 0 {
+		return
+	}
+	at.mtx.Lock()
+	g, ok := at.ents[ent]
+	if !ok {
+		at.mtx.Unlock()
+		return
+	}
+	delete(at.ents, ent)
+	at.active.Add(-1)
+	if err != nil && g.err == nil {
+		g.err = err
+	}
+	g.pending--
+	fire := g.pending == 0
+	at.mtx.Unlock()
+	if fire {
+		g.cb(g.err)
+	}
+}
+
+// fail fires every outstanding callback with the given error
+func (at *ackTracker) fail(err error) {
+	at.mtx.Lock()
+	groups := map[*ackGroup]struct{}{}
+	for ent, g := range at.ents {
+		groups[g] = struct{}{}
+		delete(at.ents, ent)
+	}
+	at.active.Store(0)
+	at.mtx.Unlock()
+	for g := range groups {
+		g.cb(err)
+	}
+}
@@ -0,0 +1,111 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package ingest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gravwell/gravwell/v4/ingest/entry"
+)
+
+func newAckTestBatch(n int) (ents []*entry.Entry) {
+	for i := 0; i < n; i++ {
+		ents = append(ents, &entry.Entry{TS: entry.Now(), Data: []byte(`test`)})
+	}
+	return
+}
+
+type ackTestCallback struct {
+	calls int
+	err   error
+}
+
+func (c *ackTestCallback) cb(err error) {
+	c.calls++
+	c.err = err
+}
+
+func TestAckTrackerConfirm(t *testing.T) {
+	at := newAckTracker()
+	var c ackTestCallback
+	ents := newAckTestBatch(4)
+	if err := at.track(ents, c.cb); err != nil {
+		t.Fatal(err)
+	}
+	at.confirm(ents[0])
+	at.confirm(ents[0]) //duplicate confirmations are ignored
+	at.confirm(&entry.Entry{})
+	at.committed([]*entry.Entry{ents[1], ents[2]}, nil)
+	if c.calls != 0 {
+		t.Fatal("callback fired early")
+	}
+	at.committed(ents[3], nil)
+	if c.calls != 1 || c.err != nil {
+		t.Fatalf("bad callback state: %+v", c)
+	} else if at.active.Load() != 0 || len(at.ents) != 0 {
+		t.Fatalf("tracker not empty: %d %d", at.active.Load(), len(at.ents))
+	}
+}
+
+func TestAckTrackerErrors(t *testing.T) {
+	at := newAckTracker()
+	var c ackTestCallback
+	ents := newAckTestBatch(3)
+	if err := at.track(append(ents, ents[0]), c.cb); err != nil {
+		t.Fatal(err)
+	}
+	//entries may not be in two batches at once
+	if err := at.track(ents[1:], c.cb); err != ErrEntryAlreadyTracked {
+		t.Fatalf("reused entry was not rejected: %v", err)
+	} else if err = at.track([]*entry.Entry{{}, nil}, c.cb); err != ErrInvalidEntry {
+		t.Fatalf("nil entry was not rejected: %v", err)
+	} else if len(at.ents) != 3 {
+		t.Fatalf("rejected batches left entries behind: %d", len(at.ents))
+	}
+
+	//the first error sticks to the batch
+	cacheErr := errors.New("cache failure")
+	at.confirm(ents[0])
+	at.committed(ents[1], cacheErr)
+	at.done(ents[2], ErrUnknownTag)
+	if c.calls != 1 || c.err != cacheErr {
+		t.Fatalf("bad callback state: %+v", c)
+	}
+
+	//untracked batches never fire
+	if err := at.track(ents, c.cb); err != nil {
+		t.Fatal(err)
+	}
+	at.untrack(ents)
+	at.confirm(ents[0])
+	if c.calls != 1 || at.active.Load() != 0 {
+		t.Fatalf("untracked batch fired: %+v", c)
+	}
+}
+
+func TestAckTrackerFail(t *testing.T) {
+	at := newAckTracker()
+	var a, b ackTestCallback
+	ba, bb := newAckTestBatch(2), newAckTestBatch(2)
+	if err := at.track(ba, a.cb); err != nil {
+		t.Fatal(err)
+	} else if err = at.track(bb, b.cb); err != nil {
+		t.Fatal(err)
+	}
+	at.confirm(ba[0])
+	at.fail(ErrNotRunning)
+	if a.calls != 1 || a.err != ErrNotRunning || b.calls != 1 || b.err != ErrNotRunning {
+		t.Fatalf("bad callback state: %+v %+v", a, b)
+	}
+	at.confirm(ba[1])
+	if a.calls != 1 {
+		t.Fatal("callback fired twice")
+	}
+}
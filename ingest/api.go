@@ -31,6 +31,8 @@ const (
 	maxIngestStateSize              uint32          = 1024 * 1024
 	CompressNone                    CompressionType = 0
 	CompressSnappy                  CompressionType = 0x10
+	CompressZstd                    CompressionType = 0x20
+	CompressLZ4                     CompressionType = 0x30
 )
 
 var (
@@ -125,18 +127,49 @@ func (ct CompressionType) validate() (err error) {
 	switch ct {
 	case CompressNone:
 	case CompressSnappy:
+	case CompressZstd:
+	case CompressLZ4:
 	default:
 		err = fmt.Errorf("Unknown compression id %x", ct)
 	}
 	return
 }
 
+// minimumVersion returns the minimum API version a remote side must speak in order to
+// handle the compression type.  Snappy has been around since dynamic stream configuration
+// was introduced, zstd and lz4 require a newer peer.
+func (ct CompressionType) minimumVersion() uint16 {
+	switch ct {
+	case CompressZstd, CompressLZ4:
+		return MINIMUM_EXT_COMPRESSION_VERSION
+	}
+	return MINIMUM_DYN_CONFIG_VERSION
+}
+
+func (ct CompressionType) String() string {
+	switch ct {
+	case CompressNone:
+		return `none`
+	case CompressSnappy:
+		return `snappy`
+	case CompressZstd:
+		return `zstd`
+	case CompressLZ4:
+		return `lz4`
+	}
+	return fmt.Sprintf("unknown(%x)", uint8(ct))
+}
+
 func ParseCompression(v string) (ct CompressionType, err error) {
 	switch strings.ToLower(strings.TrimSpace(v)) {
 	case ``:
 	case `none`:
 	case `snappy`:
 		ct = CompressSnappy
+	case `zstd`:
+		ct = CompressZstd
+	case `lz4`:
+		ct = CompressLZ4
 	default:
 		err = fmt.Errorf("Unknown compression type %q", v)
 	}
@@ -160,6 +193,7 @@ type IngesterState struct {
 	Children      map[string]IngesterState
 	Configuration json.RawMessage `json:",omitempty"`
 	Metadata      json.RawMessage `json:",omitempty"`
+	ActiveGroup   string          `json:",omitempty"` // destination group receiving data, empty without standby targets
 }
 
 type writeCounter struct {
@@ -319,6 +353,7 @@ func (s IngesterState) MarshalJSON() ([]byte, error) {
 		Children      mis
 		Configuration json.RawMessage `json:",omitempty"`
 		Metadata      json.RawMessage `json:",omitempty"`
+		ActiveGroup   string          `json:",omitempty"`
 	}{
 		UUID:          s.UUID,
 		Name:          s.Name,
@@ -336,6 +371,7 @@ func (s IngesterState) MarshalJSON() ([]byte, error) {
 		Children:      mis{mp: s.Children},
 		Configuration: s.Configuration,
 		Metadata:      s.Metadata,
+		ActiveGroup:   s.ActiveGroup,
 	}
 	return json.Marshal(x)
 }
@@ -100,3 +100,23 @@ func TestIngestState(t *testing.T) {
 		t.Fatalf("ReadWrite failure: %+v != %+v\n", x, y)
 	}
 }
+
+func TestParseCompression(t *testing.T) {
+	for _, ct := range []CompressionType{CompressNone, CompressSnappy, CompressZstd, CompressLZ4} {
+		if v, err := ParseCompression(ct.String()); err != nil {
+			t.Fatal(err)
+		} else if v != ct {
+			t.Fatalf("bad compression parse: %v != %v", v, ct)
+		} else if err = v.validate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := ParseCompression(`brotli`); err == nil {
+		t.Fatal("Failed to catch bad compression type")
+	}
+	if CompressSnappy.minimumVersion() > MINIMUM_DYN_CONFIG_VERSION {
+		t.Fatal("snappy should not require a newer server")
+	} else if CompressZstd.minimumVersion() <= MINIMUM_DITTO_VERSION {
+		t.Fatal("zstd should require a newer server")
+	}
+}
@@ -10,7 +10,9 @@ package ingest
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"net"
 	"testing"
 
 	"github.com/gravwell/gravwell/v4/ingest/entry"
@@ -289,3 +291,56 @@ func FuzzAuthChallengeResponse(f *testing.F) {
 		}
 	})
 }
+
+type testTagManager map[string]entry.EntryTag
+
+func (tm testTagManager) GetAndPopulate(name string) (tg entry.EntryTag, err error) {
+	var ok bool
+	if tg, ok = tm[name]; !ok {
+		err = errors.New("tag not allowed")
+	}
+	return
+}
+
+func TestAuthenticateIngester(t *testing.T) {
+	hsh, err := GenAuthHash(pwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tm := testTagManager{`foo`: 1, `bar`: 7}
+	handshake := func(secret, tenant string, tags []string) (srvTenant string, srvErr, cliErr error, tagIDs map[string]entry.EntryTag) {
+		cliAuth, err := GenAuthHash(secret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cli, srv := net.Pipe()
+		defer cli.Close()
+		errch := make(chan error, 1)
+		go func() {
+			var err error
+			srvTenant, err = AuthenticateIngester(srv, hsh, tm)
+			srv.Close()
+			errch <- err
+		}()
+		tagIDs, _, cliErr = authenticate(cli, tenant, cliAuth, tags)
+		cli.Close()
+		srvErr = <-errch
+		return
+	}
+
+	tenant, srvErr, cliErr, tagIDs := handshake(pwd, `tenant1`, []string{`foo`, `bar`})
+	if srvErr != nil || cliErr != nil {
+		t.Fatal(srvErr, cliErr)
+	} else if tenant != `tenant1` {
+		t.Fatalf("bad tenant: %q", tenant)
+	} else if len(tagIDs) != 2 || tagIDs[`foo`] != 1 || tagIDs[`bar`] != 7 {
+		t.Fatalf("bad tags: %v", tagIDs)
+	}
+
+	if _, srvErr, cliErr, _ = handshake(`bad`, ``, []string{`foo`}); srvErr != ErrFailedAuth || cliErr != ErrFailedAuth {
+		t.Fatalf("bad secret not rejected: %v %v", srvErr, cliErr)
+	}
+	if _, srvErr, cliErr, _ = handshake(pwd, ``, []string{`foo`, `baz`}); srvErr == nil || cliErr != ErrFailedTagNegotiation {
+		t.Fatalf("bad tag not rejected: %v %v", srvErr, cliErr)
+	}
+}
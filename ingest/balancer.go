@@ -0,0 +1,312 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package ingest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gravwell/gravwell/v4/ingest/entry"
+)
+
+const (
+	defaultTargetWeight = 1
+	maxTargetWeight     = 1000
+	forwardChanDepth    = 64
+)
+
+var (
+	ErrInvalidTargetWeight   = errors.New("Invalid target weight")
+	ErrUnknownAffinityTarget = errors.New("Tag affinity references an unknown target")
+)
+
+// loadBalancer steers entries between the relay routines of a muxer when targets
+// carry different weights or when tags are pinned to a subset of targets.
+// If every target has the same weight and there are no affinity rules the muxer
+// does not build a balancer at all and relay routines simply compete for entries.
+//
+// Entries are steered by handing them to the relay routine of the chosen target
+// via a small forwarding channel, the relay routine that pulled the entry off of
+// the feeder channels does not write it.
+type loadBalancer struct {
+	mtx      sync.Mutex
+	weights  []int
+	current  []int //smooth weighted round robin state
+	hot      []bool
+	weighted bool
+	rules    map[string][]bool         //tag name to allowed targets
+	affinity map[entry.EntryTag][]bool //resolved rules for negotiated tags
+	fwd      []chan interface{}
+}
+
+// newLoadBalancer validates weights and affinity rules and returns a balancer.
+// A nil balancer with no error means the targets are uniform and unconstrained.
+func newLoadBalancer(dests []Target, affinity map[string][]string, tagMap map[string]entry.EntryTag) (*loadBalancer, error) {
+	weights := make([]int, len(dests))
+	var weighted bool
+	for i := range dests {
+		if w := dests[i].Weight; w < 0 || w > maxTargetWeight {
+			return nil, fmt.Errorf("%w %d for %s", ErrInvalidTargetWeight, w, dests[i].Address)
+		} else if w == 0 {
+			weights[i] = defaultTargetWeight
+		} else {
+			weights[i] = w
+		}
+		if weights[i] != weights[0] {
+			weighted = true
+		}
+	}
+
+	rules := make(map[string][]bool, len(affinity))
+	for tag, refs := range affinity {
+		if err := CheckTag(tag); err != nil {
+			return nil, fmt.Errorf("Invalid tag affinity tag %q %w", tag, err)
+		} else if len(refs) == 0 {
+			return nil, fmt.Errorf("Tag affinity for %q does not specify any targets", tag)
+		}
+		allowed := make([]bool, len(dests))
+		for _, ref := range refs {
+			var found bool
+			for i := range dests {
+				if targetMatches(dests[i].Address, ref) {
+					allowed[i] = true
+					found = true
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("%w %q for tag %q", ErrUnknownAffinityTarget, ref, tag)
+			}
+		}
+		rules[tag] = allowed
+	}
+
+	if len(dests) < 2 || (!weighted && len(rules) == 0) {
+		return nil, nil
+	}
+
+	lb := &loadBalancer{
+		weights:  weights,
+		current:  make([]int, len(dests)),
+		hot:      make([]bool, len(dests)),
+		weighted: weighted,
+		rules:    rules,
+		affinity: make(map[entry.EntryTag][]bool, len(rules)),
+		fwd:      make([]chan interface{}, len(dests)),
+	}
+	for i := range lb.fwd {
+		lb.fwd[i] = make(chan interface{}, forwardChanDepth)
+	}
+	for name, tg := range tagMap {
+		lb.registerTag(name, tg)
+	}
+	return lb, nil
+}
+
+// targetMatches checks if a reference from a configuration refers to a target address.
+// References may be the full address (tcp://10.0.0.1:4023), the address without the
+// connection type (10.0.0.1:4023), or just the host (10.0.0.1).
+func targetMatches(addr, ref string) bool {
+	if ref = strings.TrimSpace(ref); ref == `` {
+		return false
+	} else if ref == addr {
+		return true
+	}
+	_, dest, err := ConnectionType(addr)
+	if err != nil {
+		return false
+	} else if ref == dest {
+		return true
+	}
+	if host, _, err := net.SplitHostPort(dest); err == nil && ref == host {
+		return true
+	}
+	return false
+}
+
+// registerTag resolves any affinity rule for a tag name to the muxer local tag value
+func (lb *loadBalancer) registerTag(name string, tg entry.EntryTag) {
+	lb.mtx.Lock()
+	if allowed, ok := lb.rules[name]; ok {
+		lb.affinity[tg] = allowed
+	}
+	lb.mtx.Unlock()
+}
+
+func (lb *loadBalancer) setHot(idx int, hot bool) {
+	lb.mtx.Lock()
+	if idx >= 0 && idx < len(lb.hot) {
+		lb.hot[idx] = hot
+		lb.current[idx] = 0
+	}
+	lb.mtx.Unlock()
+}
+
+// pick returns the target index that should receive an entry with the given tag which was
+// pulled off the feeder channels by the relay routine at index self.
+// pinned is true when the entry may not be written by self due to an affinity rule.
+func (lb *loadBalancer) pick(tag entry.EntryTag, self int) (idx int, pinned bool) {
+	lb.mtx.Lock()
+	defer lb.mtx.Unlock()
+	return lb.pickLocked(tag, self)
+}
+
+func (lb *loadBalancer) pickLocked(tag entry.EntryTag, self int) (idx int, pinned bool) {
+	allowed := lb.affinity[tag]
+	pinned = allowed != nil && !allowed[self]
+	if !lb.weighted && !pinned {
+		//competing relay routines already spread the load evenly
+		return self, false
+	}
+	if idx = lb.next(allowed); idx >= 0 {
+		return
+	}
+	//every preferred target is dead, fall back to any hot connection
+	pinned = false
+	if idx = lb.next(nil); idx < 0 {
+		idx = self
+	}
+	return
+}
+
+// next performs a smooth weighted round robin selection across the hot targets, if
+// allowed is not nil only targets set in allowed are considered.  Returns -1 if
+// no targets are available.  Caller must hold the lock.
+func (lb *loadBalancer) next(allowed []bool) (idx int) {
+	var total int
+	idx = -1
+	for i := range lb.weights {
+		if !lb.hot[i] || (allowed != nil && !allowed[i]) {
+			continue
+		}
+		lb.current[i] += lb.weights[i]
+		total += lb.weights[i]
+		if idx == -1 || lb.current[i] > lb.current[idx] {
+			idx = i
+		}
+	}
+	if idx >= 0 {
+		lb.current[idx] -= total
+	}
+	return
+}
+
+// splitBatch breaks a block of entries up by destination.  Weighting is applied to the block
+// as a whole, affinity rules are applied to each entry.  Entries that should be written by
+// the relay routine at index self are returned in local.
+func (lb *loadBalancer) splitBatch(b []*entry.Entry, self int) (local []*entry.Entry, remote map[int][]*entry.Entry) {
+	lb.mtx.Lock()
+	defer lb.mtx.Unlock()
+	blockTarget := self
+	if lb.weighted {
+		if blockTarget = lb.next(nil); blockTarget < 0 {
+			blockTarget = self
+		}
+	}
+	if len(lb.affinity) == 0 {
+		if blockTarget == self {
+			return b, nil
+		}
+		return nil, map[int][]*entry.Entry{blockTarget: b}
+	}
+
+	picks := map[entry.EntryTag]int{}
+	for _, ent := range b {
+		if ent == nil {
+			continue
+		}
+		idx := blockTarget
+		if allowed, ok := lb.affinity[ent.Tag]; ok && !allowed[blockTarget] {
+			var cached bool
+			if idx, cached = picks[ent.Tag]; !cached {
+				if allowed[self] {
+					idx = self
+				} else {
+					idx, _ = lb.pickLocked(ent.Tag, self)
+				}
+				picks[ent.Tag] = idx
+			}
+		}
+		if idx == self {
+			local = append(local, ent)
+		} else {
+			if remote == nil {
+				remote = map[int][]*entry.Entry{}
+			}
+			remote[idx] = append(remote[idx], ent)
+		}
+	}
+	return
+}
+
+// pinnedBlock indicates that a block holds entries with an affinity rule that does not allow
+// the relay routine at index self, so it may not simply be written by self.
+// Blocks that were only steered for weighting are not pinned.
+func (lb *loadBalancer) pinnedBlock(b []*entry.Entry, self int) bool {
+	lb.mtx.Lock()
+	defer lb.mtx.Unlock()
+	if len(lb.affinity) == 0 {
+		return false
+	}
+	for _, ent := range b {
+		if ent == nil {
+			continue
+		} else if allowed, ok := lb.affinity[ent.Tag]; ok && !allowed[self] {
+			return true
+		}
+	}
+	return false
+}
+
+// forward hands an entry or block of entries to the relay routine at idx.
+// Entries that are only being steered for weighting are never worth stalling over, so if
+// the destination is backed up we return false and the caller writes them itself.
+// Pinned entries will wait up to the recycle timeout before giving up, we would rather
+// violate an affinity rule than deadlock two relay routines that are feeding each other.
+func (lb *loadBalancer) forward(ctx context.Context, idx int, v interface{}, pinned bool) bool {
+	select {
+	case lb.fwd[idx] <- v:
+		return true
+	default:
+	}
+	if !pinned {
+		return false
+	}
+	tmr := time.NewTimer(recycleTimeout)
+	defer tmr.Stop()
+	select {
+	case lb.fwd[idx] <- v:
+		return true
+	case <-tmr.C:
+	case <-ctx.Done():
+	}
+	return false
+}
+
+// drain pulls everything out of a forwarding channel, this is used when the connection
+// behind a relay routine goes dead so that forwarded entries are not stuck waiting on a reconnect.
+func (lb *loadBalancer) drain(idx int) (ents []*entry.Entry, blks [][]*entry.Entry) {
+	for {
+		select {
+		case v := <-lb.fwd[idx]:
+			switch t := v.(type) {
+			case *entry.Entry:
+				ents = append(ents, t)
+			case []*entry.Entry:
+				blks = append(blks, t)
+			}
+		default:
+			return
+		}
+	}
+}
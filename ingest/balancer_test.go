@@ -0,0 +1,150 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package ingest
+
+import (
+	"testing"
+
+	"github.com/gravwell/gravwell/v4/ingest/entry"
+)
+
+var testBalancerTargets = []Target{
+	{Address: `tcp://10.0.0.1:4023`, Weight: 3},
+	{Address: `tcp://10.0.0.2:4023`, Weight: 1},
+	{Address: `tls://10.0.0.3:4024`},
+}
+
+func TestLoadBalancerUniform(t *testing.T) {
+	dests := []Target{{Address: `tcp://10.0.0.1:4023`}, {Address: `tcp://10.0.0.2:4023`, Weight: 1}}
+	if lb, err := newLoadBalancer(dests, nil, nil); err != nil {
+		t.Fatal(err)
+	} else if lb != nil {
+		t.Fatal("uniform targets should not produce a balancer")
+	}
+	if _, err := newLoadBalancer([]Target{{Address: `tcp://10.0.0.1`, Weight: -1}}, nil, nil); err == nil {
+		t.Fatal("failed to catch bad weight")
+	}
+	if _, err := newLoadBalancer(dests, map[string][]string{`pcap`: {`10.0.0.9`}}, nil); err == nil {
+		t.Fatal("failed to catch unknown affinity target")
+	}
+}
+
+func TestLoadBalancerWeights(t *testing.T) {
+	lb, err := newLoadBalancer(testBalancerTargets, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	} else if lb == nil {
+		t.Fatal("nil balancer")
+	}
+	for i := range testBalancerTargets {
+		lb.setHot(i, true)
+	}
+	counts := make([]int, len(testBalancerTargets))
+	for i := 0; i < 500; i++ {
+		idx, pinned := lb.pick(0, 2)
+		if pinned {
+			t.Fatal("unexpected pinned result")
+		}
+		counts[idx]++
+	}
+	if counts[0] != 300 || counts[1] != 100 || counts[2] != 100 {
+		t.Fatalf("bad weighted distribution: %v", counts)
+	}
+	//blocks steered by weight alone never wait on a destination
+	if lb.pinnedBlock([]*entry.Entry{{Tag: 0}, nil}, 2) {
+		t.Fatal("weighted block is pinned")
+	}
+
+	//kill the heavy target and make sure it is never picked
+	lb.setHot(0, false)
+	for i := 0; i < 100; i++ {
+		if idx, _ := lb.pick(0, 1); idx == 0 {
+			t.Fatal("picked a dead target")
+		}
+	}
+}
+
+func TestLoadBalancerAffinity(t *testing.T) {
+	dests := []Target{{Address: `tcp://10.0.0.1:4023`}, {Address: `tcp://10.0.0.2:4023`}, {Address: `tcp://10.0.0.3:4023`}}
+	tagMap := map[string]entry.EntryTag{`default`: 0, `pcap`: 1}
+	lb, err := newLoadBalancer(dests, map[string][]string{`pcap`: {`10.0.0.1`, `10.0.0.2:4023`}}, tagMap)
+	if err != nil {
+		t.Fatal(err)
+	} else if lb == nil {
+		t.Fatal("nil balancer")
+	}
+	for i := range dests {
+		lb.setHot(i, true)
+	}
+	//unweighted and unpinned entries stay with whoever pulled them
+	if idx, pinned := lb.pick(0, 2); idx != 2 || pinned {
+		t.Fatalf("bad unpinned pick %d %v", idx, pinned)
+	}
+	//allowed targets keep their own entries
+	if idx, pinned := lb.pick(1, 1); idx != 1 || pinned {
+		t.Fatalf("bad allowed pick %d %v", idx, pinned)
+	}
+	for i := 0; i < 10; i++ {
+		if idx, pinned := lb.pick(1, 2); idx == 2 || !pinned {
+			t.Fatalf("pinned tag sent to disallowed target %d %v", idx, pinned)
+		}
+	}
+
+	//batches get split
+	ents := []*entry.Entry{{Tag: 0}, {Tag: 1}, {Tag: 0}, {Tag: 1}}
+	local, remote := lb.splitBatch(ents, 2)
+	if len(local) != 2 || len(remote) != 1 {
+		t.Fatalf("bad batch split %d %d", len(local), len(remote))
+	}
+	for idx, blk := range remote {
+		if idx == 2 || len(blk) != 2 {
+			t.Fatalf("bad remote block %d %d", idx, len(blk))
+		} else if !lb.pinnedBlock(blk, 2) {
+			t.Fatal("block of pinned entries is not pinned")
+		}
+	}
+	//only blocks carrying entries which may not be written locally are pinned
+	if lb.pinnedBlock(local, 2) || lb.pinnedBlock(ents, 0) {
+		t.Fatal("block without disallowed entries is pinned")
+	}
+
+	//all preferred targets dead, fall back to any hot connection
+	lb.setHot(0, false)
+	lb.setHot(1, false)
+	if idx, pinned := lb.pick(1, 2); idx != 2 || pinned {
+		t.Fatalf("failed to fall back %d %v", idx, pinned)
+	}
+
+	//tags negotiated later pick up their rules
+	lb.registerTag(`pcap`, 5)
+	if _, pinned := lb.pick(5, 1); pinned {
+		t.Fatal("allowed target pinned")
+	}
+}
+
+func TestLoadBalancerForward(t *testing.T) {
+	lb, err := newLoadBalancer(testBalancerTargets, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < forwardChanDepth; i++ {
+		if !lb.forward(t.Context(), 1, &entry.Entry{}, false) {
+			t.Fatal("failed to forward")
+		}
+	}
+	if lb.forward(t.Context(), 1, &entry.Entry{}, false) {
+		t.Fatal("forwarded into a full channel")
+	}
+	lb.forward(t.Context(), 0, []*entry.Entry{{}, {}}, false)
+	if ents, blks := lb.drain(1); len(ents) != forwardChanDepth || len(blks) != 0 {
+		t.Fatalf("bad drain %d %d", len(ents), len(blks))
+	} else if ents, blks = lb.drain(0); len(ents) != 0 || len(blks) != 1 {
+		t.Fatalf("bad drain %d %d", len(ents), len(blks))
+	}
+}
@@ -68,10 +68,12 @@ const (
 	envEncTarget         string = `GRAVWELL_ENCRYPTED_TARGETS`
 	envPipeTarget        string = `GRAVWELL_PIPE_TARGETS`
 	envCompressionTarget string = `GRAVWELL_ENABLE_COMPRESSION`
+	envCompressionType   string = `GRAVWELL_COMPRESSION_TYPE`
 	envCacheMode         string = `GRAVWELL_CACHE_MODE`
 	envCachePath         string = `GRAVWELL_CACHE_PATH`
 	envMaxCache          string = `GRAVWELL_CACHE_SIZE`
 	envDisableSelfIngest string = `GRAVWELL_DISABLE_SELF_INGEST`
+	envMetricsBind       string = `GRAVWELL_METRICS_BIND`
 
 	DefaultCleartextPort uint16 = 4023
 	DefaultTLSPort       uint16 = 4024
@@ -126,10 +128,39 @@ type IngestConfig struct {
 	Timestamp_Max_Past_Delta   string   // if set to > 0 (e.g. "1h"), set TS of entries further than this in the past to now
 	Timestamp_Max_Future_Delta string   // if set to > 0, set TS of entries further that this in the future to now.
 	Max_Entry_Size             int      `json:",omitempty"`
+	Target_Weight              []string `json:",omitempty"` // target=weight, skews load balancing towards larger indexers
+	Tag_Affinity               []string `json:",omitempty"` // tag:target,target pins a tag to a subset of targets
+	Priority_Tag               []string `json:",omitempty"` // tags which skip ahead of bulk data
+	Metrics_Bind               string   `json:",omitempty"` // if set, serve OpenMetrics on this address
+
+	// standby targets only receive data once the primary targets above have failed
+	Standby_Cleartext_Backend_Target []string `json:",omitempty"`
+	Standby_Encrypted_Backend_Target []string `json:",omitempty"`
+	Standby_Pipe_Backend_Target      []string `json:",omitempty"`
+	Standby_Min_Primary              int      `json:",omitempty"` // fail over when fewer primary targets are up, defaults to 1
+	Standby_Failover_Delay           string   `json:",omitempty"` // how long the primaries must be down before failing over
+	Standby_Failback_Delay           string   `json:",omitempty"` // how long the primaries must be back before failing back
 }
 
 type IngestStreamConfig struct {
-	Enable_Compression bool `json:",omitempty"`
+	Enable_Compression bool   `json:",omitempty"`
+	Compression_Type   string `json:",omitempty"` // snappy, zstd, or lz4; setting a type implies Enable-Compression
+}
+
+// verify normalizes the compression type and makes sure it is something the ingest API understands.
+// Older indexers that do not support the requested type are handled at connection time by falling back to snappy.
+func (isc *IngestStreamConfig) verify() error {
+	isc.Compression_Type = strings.ToLower(strings.TrimSpace(isc.Compression_Type))
+	switch isc.Compression_Type {
+	case ``:
+	case `none`:
+		isc.Enable_Compression = false
+	case `snappy`, `zstd`, `lz4`:
+		isc.Enable_Compression = true
+	default:
+		return fmt.Errorf("Invalid Compression-Type %q, must be [none,snappy,zstd,lz4]", isc.Compression_Type)
+	}
+	return nil
 }
 
 type TimeFormat struct {
@@ -169,6 +200,9 @@ func (ic *IngestConfig) loadDefaults() error {
 	if err := LoadEnvVar(&ic.Enable_Compression, envCompressionTarget, false); err != nil {
 		return err
 	}
+	if err := LoadEnvVar(&ic.Compression_Type, envCompressionType, nil); err != nil {
+		return err
+	}
 	// Cache
 	if err := LoadEnvVar(&ic.Cache_Mode, envCacheMode, nil); err != nil {
 		return err
@@ -182,6 +216,9 @@ func (ic *IngestConfig) loadDefaults() error {
 	if err := LoadEnvVar(&ic.Disable_Self_Ingest, envDisableSelfIngest, false); err != nil {
 		return err
 	}
+	if err := LoadEnvVar(&ic.Metrics_Bind, envMetricsBind, nil); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -200,6 +237,9 @@ func (ic *IngestConfig) Verify() error {
 	}
 
 	ic.Log_Level = strings.ToUpper(strings.TrimSpace(ic.Log_Level))
+	if err := ic.IngestStreamConfig.verify(); err != nil {
+		return err
+	}
 	if ic.Max_Ingest_Cache == 0 && len(ic.Ingest_Cache_Path) != 0 {
 		ic.Max_Ingest_Cache = CACHE_SIZE_DEFAULT
 	}
@@ -308,6 +348,41 @@ func (ic *IngestConfig) Verify() error {
 		}
 	}
 
+	if _, err := ic.TargetWeights(); err != nil {
+		return err
+	}
+	if _, err := ic.TagAffinity(); err != nil {
+		return err
+	}
+	if _, err := ic.PriorityTags(); err != nil {
+		return err
+	}
+	if _, _, _, err := ic.StandbyThresholds(); err != nil {
+		return err
+	}
+	if standby := ic.StandbyTargets(); len(standby) > 0 {
+		primary, _ := ic.Targets()
+		seen := make(map[string]struct{}, len(primary))
+		for _, v := range primary {
+			seen[v] = struct{}{}
+		}
+		for _, v := range standby {
+			if _, ok := seen[v]; ok {
+				return fmt.Errorf("Standby target %s is also a primary target", v)
+			}
+		}
+	}
+
+	if ic.Metrics_Bind != `` {
+		if _, port, err := net.SplitHostPort(ic.Metrics_Bind); err != nil {
+			return fmt.Errorf("Invalid Metrics-Bind %q %w", ic.Metrics_Bind, err)
+		} else if p, err := strconv.ParseUint(port, 10, 16); err != nil {
+			return fmt.Errorf("Invalid Metrics-Bind %q port %s is invalid %w", ic.Metrics_Bind, port, err)
+		} else if p == 0 {
+			return fmt.Errorf("Invalid Metrics-Bind %q port %s is invalid", ic.Metrics_Bind, port)
+		}
+	}
+
 	if ic.Max_Entry_Size == 0 {
 		ic.Max_Entry_Size = MAX_ENTRY_SIZE_DEFAULT
 	} else if ic.Max_Entry_Size < 0 || ic.Max_Entry_Size > MAX_ENTRY_SIZE_DEFAULT {
@@ -322,20 +397,137 @@ func (ic *IngestConfig) Verify() error {
 //
 //	tcp://10.0.0.1:4023
 func (ic *IngestConfig) Targets() ([]string, error) {
-	var conns []string
-	for _, v := range ic.Cleartext_Backend_Target {
+	conns := backendTargets(ic.Cleartext_Backend_Target, ic.Encrypted_Backend_Target, ic.Pipe_Backend_Target)
+	if len(conns) == 0 {
+		return nil, ErrNoConnections
+	}
+	return conns, nil
+}
+
+// StandbyTargets returns the list of standby indexer targets in the same form as Targets.
+// Standby targets are optional, an empty list means there is no standby group.
+func (ic *IngestConfig) StandbyTargets() []string {
+	return backendTargets(ic.Standby_Cleartext_Backend_Target, ic.Standby_Encrypted_Backend_Target, ic.Standby_Pipe_Backend_Target)
+}
+
+func backendTargets(cleartext, encrypted, pipe []string) (conns []string) {
+	for _, v := range cleartext {
 		conns = append(conns, "tcp://"+AppendDefaultPort(v, DefaultCleartextPort))
 	}
-	for _, v := range ic.Encrypted_Backend_Target {
+	for _, v := range encrypted {
 		conns = append(conns, "tls://"+AppendDefaultPort(v, DefaultTLSPort))
 	}
-	for _, v := range ic.Pipe_Backend_Target {
+	for _, v := range pipe {
 		conns = append(conns, "pipe://"+v)
 	}
-	if len(conns) == 0 {
-		return nil, ErrNoConnections
+	return
+}
+
+// StandbyThresholds returns the parameters controlling failover to the standby targets.
+// minPrimary is the number of primary targets that must be up to stay on the primaries,
+// a zero value means the default of failing over only once every primary target is down.
+func (ic *IngestConfig) StandbyThresholds() (minPrimary int, failover, failback time.Duration, err error) {
+	if minPrimary = ic.Standby_Min_Primary; minPrimary < 0 {
+		err = fmt.Errorf("Invalid Standby-Min-Primary %d", minPrimary)
+		return
+	} else if minPrimary > len(ic.Cleartext_Backend_Target)+len(ic.Encrypted_Backend_Target)+len(ic.Pipe_Backend_Target) {
+		err = fmt.Errorf("Invalid Standby-Min-Primary %d, there are not that many primary targets", minPrimary)
+		return
 	}
-	return conns, nil
+	if ic.Standby_Failover_Delay != `` {
+		if failover, err = time.ParseDuration(ic.Standby_Failover_Delay); err != nil || failover < 0 {
+			err = fmt.Errorf("Invalid Standby-Failover-Delay %q", ic.Standby_Failover_Delay)
+			return
+		}
+	}
+	if ic.Standby_Failback_Delay != `` {
+		if failback, err = time.ParseDuration(ic.Standby_Failback_Delay); err != nil || failback < 0 {
+			err = fmt.Errorf("Invalid Standby-Failback-Delay %q", ic.Standby_Failback_Delay)
+			return
+		}
+	}
+	return
+}
+
+// TargetWeights returns the Target-Weight parameters as a map of target to weight.
+// Targets are referenced as they are written in the Backend-Target parameters, e.g.:
+//
+//	Target-Weight="10.0.0.1:4023=4"
+func (ic *IngestConfig) TargetWeights() (map[string]int, error) {
+	if len(ic.Target_Weight) == 0 {
+		return nil, nil
+	}
+	r := make(map[string]int, len(ic.Target_Weight))
+	for _, v := range ic.Target_Weight {
+		idx := strings.LastIndex(v, `=`)
+		if idx <= 0 {
+			return nil, fmt.Errorf("Invalid Target-Weight %q, must be target=weight", v)
+		}
+		tgt := strings.TrimSpace(v[:idx])
+		w, err := strconv.Atoi(strings.TrimSpace(v[idx+1:]))
+		if err != nil || w <= 0 {
+			return nil, fmt.Errorf("Invalid Target-Weight %q, weight must be a positive integer", v)
+		} else if _, ok := r[tgt]; ok {
+			return nil, fmt.Errorf("Duplicate Target-Weight for %q", tgt)
+		}
+		r[tgt] = w
+	}
+	return r, nil
+}
+
+// TagAffinity returns the Tag-Affinity parameters as a map of tag to the targets that may receive it.
+// Multiple Tag-Affinity parameters for the same tag are merged, e.g.:
+//
+//	Tag-Affinity="pcap:10.0.0.1,10.0.0.2"
+func (ic *IngestConfig) TagAffinity() (map[string][]string, error) {
+	if len(ic.Tag_Affinity) == 0 {
+		return nil, nil
+	}
+	r := make(map[string][]string, len(ic.Tag_Affinity))
+	for _, v := range ic.Tag_Affinity {
+		bits := strings.SplitN(v, `:`, 2)
+		if len(bits) != 2 {
+			return nil, fmt.Errorf("Invalid Tag-Affinity %q, must be tag:target,target", v)
+		}
+		tag := strings.TrimSpace(bits[0])
+		if tag == `` {
+			return nil, fmt.Errorf("Invalid Tag-Affinity %q, missing tag", v)
+		}
+		for _, tgt := range strings.Split(bits[1], `,`) {
+			if tgt = strings.TrimSpace(tgt); tgt != `` {
+				r[tag] = append(r[tag], tgt)
+			}
+		}
+		if len(r[tag]) == 0 {
+			return nil, fmt.Errorf("Invalid Tag-Affinity %q, missing targets", v)
+		}
+	}
+	return r, nil
+}
+
+// PriorityTags returns the tags named by the Priority-Tag parameters, entries with these tags
+// are queued, cached, and relayed ahead of everything else.  Each parameter may name several tags, e.g.:
+//
+//	Priority-Tag="alerts,ids"
+func (ic *IngestConfig) PriorityTags() (r []string, err error) {
+	seen := make(map[string]bool, len(ic.Priority_Tag))
+	for _, v := range ic.Priority_Tag {
+		var found bool
+		for _, tag := range strings.Split(v, `,`) {
+			if tag = strings.TrimSpace(tag); tag == `` {
+				continue
+			}
+			found = true
+			if !seen[tag] {
+				seen[tag] = true
+				r = append(r, tag)
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("Invalid Priority-Tag %q, missing tag", v)
+		}
+	}
+	return
 }
 
 // InsecureSkipTLSVerification returns true if the Insecure-Skip-TLS-Verify
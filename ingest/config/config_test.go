@@ -11,6 +11,7 @@ package config
 import (
 	"net"
 	"testing"
+	"time"
 )
 
 func TestParseSourceIP(t *testing.T) {
@@ -53,3 +54,104 @@ func TestParseSourceIntegter(t *testing.T) {
 		}
 	}
 }
+
+func TestIngestStreamConfigVerify(t *testing.T) {
+	isc := IngestStreamConfig{Compression_Type: ` ZSTD `}
+	if err := isc.verify(); err != nil {
+		t.Fatal(err)
+	} else if !isc.Enable_Compression || isc.Compression_Type != `zstd` {
+		t.Fatalf("bad normalized stream config: %+v", isc)
+	}
+
+	isc = IngestStreamConfig{Enable_Compression: true, Compression_Type: `none`}
+	if err := isc.verify(); err != nil {
+		t.Fatal(err)
+	} else if isc.Enable_Compression {
+		t.Fatal("Compression-Type none did not disable compression")
+	}
+
+	isc = IngestStreamConfig{Compression_Type: `brotli`}
+	if err := isc.verify(); err == nil {
+		t.Fatal("failed to catch bad compression type")
+	}
+}
+
+func TestTargetWeightsAndAffinity(t *testing.T) {
+	ic := IngestConfig{
+		Target_Weight: []string{`10.0.0.1:4023=4`, `[dead::beef]:4023 = 2`},
+		Tag_Affinity:  []string{`pcap:10.0.0.1, 10.0.0.2`, `pcap:10.0.0.3`, `syslog:10.0.0.1`},
+	}
+	if w, err := ic.TargetWeights(); err != nil {
+		t.Fatal(err)
+	} else if len(w) != 2 || w[`10.0.0.1:4023`] != 4 || w[`[dead::beef]:4023`] != 2 {
+		t.Fatalf("bad weights: %v", w)
+	}
+	if a, err := ic.TagAffinity(); err != nil {
+		t.Fatal(err)
+	} else if len(a) != 2 || len(a[`pcap`]) != 3 || len(a[`syslog`]) != 1 {
+		t.Fatalf("bad affinity: %v", a)
+	}
+
+	for _, v := range []string{`10.0.0.1`, `10.0.0.1=0`, `=4`, `10.0.0.1=foo`} {
+		ic = IngestConfig{Target_Weight: []string{v}}
+		if _, err := ic.TargetWeights(); err == nil {
+			t.Fatalf("failed to catch bad weight %q", v)
+		}
+	}
+	for _, v := range []string{`pcap`, `:10.0.0.1`, `pcap:`, `pcap: , `} {
+		ic = IngestConfig{Tag_Affinity: []string{v}}
+		if _, err := ic.TagAffinity(); err == nil {
+			t.Fatalf("failed to catch bad affinity %q", v)
+		}
+	}
+}
+
+func TestPriorityTags(t *testing.T) {
+	ic := IngestConfig{
+		Priority_Tag: []string{`alerts, ids`, `alerts`, `audit`},
+	}
+	if tags, err := ic.PriorityTags(); err != nil {
+		t.Fatal(err)
+	} else if len(tags) != 3 || tags[0] != `alerts` || tags[1] != `ids` || tags[2] != `audit` {
+		t.Fatalf("bad priority tags: %v", tags)
+	}
+	for _, v := range []string{``, ` , `} {
+		ic = IngestConfig{Priority_Tag: []string{v}}
+		if _, err := ic.PriorityTags(); err == nil {
+			t.Fatalf("failed to catch bad priority tag %q", v)
+		}
+	}
+}
+
+func TestStandbyTargets(t *testing.T) {
+	ic := IngestConfig{
+		Cleartext_Backend_Target:         []string{`10.0.0.1`, `10.0.0.2`},
+		Standby_Cleartext_Backend_Target: []string{`10.1.0.1`},
+		Standby_Encrypted_Backend_Target: []string{`10.1.0.2:4000`},
+		Standby_Pipe_Backend_Target:      []string{`/opt/gravwell/comms/pipe`},
+		Standby_Min_Primary:              2,
+		Standby_Failover_Delay:           `30s`,
+	}
+	if tgts := ic.StandbyTargets(); len(tgts) != 3 || tgts[0] != `tcp://10.1.0.1:4023` || tgts[1] != `tls://10.1.0.2:4000` || tgts[2] != `pipe:///opt/gravwell/comms/pipe` {
+		t.Fatalf("bad standby targets: %v", tgts)
+	}
+	if minPrimary, failover, failback, err := ic.StandbyThresholds(); err != nil {
+		t.Fatal(err)
+	} else if minPrimary != 2 || failover != 30*time.Second || failback != 0 {
+		t.Fatalf("bad standby thresholds: %d %v %v", minPrimary, failover, failback)
+	}
+	if tgts := (&IngestConfig{}).StandbyTargets(); len(tgts) != 0 {
+		t.Fatalf("unexpected standby targets: %v", tgts)
+	}
+
+	for _, bad := range []IngestConfig{
+		{Cleartext_Backend_Target: []string{`10.0.0.1`}, Standby_Min_Primary: 2},
+		{Cleartext_Backend_Target: []string{`10.0.0.1`}, Standby_Min_Primary: -1},
+		{Cleartext_Backend_Target: []string{`10.0.0.1`}, Standby_Failover_Delay: `soon`},
+		{Cleartext_Backend_Target: []string{`10.0.0.1`}, Standby_Failback_Delay: `-1m`},
+	} {
+		if _, _, _, err := bad.StandbyThresholds(); err == nil {
+			t.Fatalf("failed to catch bad standby thresholds: %+v", bad)
+		}
+	}
+}
@@ -25,10 +25,11 @@ const (
 )
 
 var (
-	errNoEnvArg     = errors.New("no env arg")
-	ErrInvalidArg   = errors.New("Invalid arguments")
-	ErrEmptyEnvFile = errors.New("Environment secret file is empty")
-	ErrBadValue     = errors.New("Environment value is invalid")
+	errNoEnvArg      = errors.New("no env arg")
+	ErrInvalidArg    = errors.New("Invalid arguments")
+	ErrEmptyEnvFile  = errors.New("Environment secret file is empty")
+	ErrBadValue      = errors.New("Environment value is invalid")
+	ErrMissingSecret = errors.New("Secret is not set")
 )
 
 func loadEnvFile(nm string) (r string, err error) {
@@ -508,6 +509,28 @@ func loadEnvVarList(lst *[]string, envName string) error {
 	return nil
 }
 
+// LoadSecret populates val with a secret that may be specified directly, read from the
+// file at pth, or loaded from the environment variable envName (or the file named by
+// envName_FILE).  A value that is already populated is always used over the file, and
+// the file over the environment.  ErrMissingSecret is returned if none are available.
+func LoadSecret(val *string, pth, envName string) (err error) {
+	if val == nil {
+		return ErrInvalidArg
+	} else if len(*val) > 0 {
+		return
+	} else if len(pth) > 0 {
+		if err = loadStringFromFile(pth, val); err != nil {
+			return fmt.Errorf("Failed to load secret from %q %w", pth, err)
+		}
+	} else if err = LoadEnvVar(val, envName, ``); err != nil {
+		return
+	}
+	if len(*val) == 0 {
+		err = ErrMissingSecret
+	}
+	return
+}
+
 func loadStringFromFile(pth string, val *string) (err error) {
 	if pth == `` {
 		return errors.New("invalid path")
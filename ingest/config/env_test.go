@@ -367,3 +367,37 @@ func TestEnvFileLoadInt64(t *testing.T) {
 		t.Fatalf("Did not pull value from environment: %v != %v", v, tval)
 	}
 }
+
+func TestLoadSecret(t *testing.T) {
+	envId := `GRAVWELL_SECRET_TEST`
+	tfile := filepath.Join(t.TempDir(), `secret`)
+	if err := os.WriteFile(tfile, []byte("from file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	var v string
+	if err := LoadSecret(&v, ``, envId); err != ErrMissingSecret {
+		t.Fatalf("missing secret did not fail: %v", err)
+	}
+	t.Setenv(envId, `from env`)
+	if err := LoadSecret(&v, ``, envId); err != nil {
+		t.Fatal(err)
+	} else if v != `from env` {
+		t.Fatalf("Did not pull value from environment: %q", v)
+	}
+	v = ``
+	if err := LoadSecret(&v, tfile, envId); err != nil {
+		t.Fatal(err)
+	} else if v != `from file` {
+		t.Fatalf("Did not pull value from file: %q", v)
+	}
+	v = `direct`
+	if err := LoadSecret(&v, tfile, envId); err != nil {
+		t.Fatal(err)
+	} else if v != `direct` {
+		t.Fatalf("Did not leave existing value: %q", v)
+	}
+	v = ``
+	if err := LoadSecret(&v, filepath.Join(t.TempDir(), `missing`), envId); err == nil {
+		t.Fatal("missing file did not fail")
+	}
+}
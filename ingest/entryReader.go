@@ -22,6 +22,7 @@ import (
 
 	"github.com/gravwell/gravwell/v4/ingest/entry"
 	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/zstd"
 )
 
 const (
@@ -247,9 +248,26 @@ func (er *EntryReader) startCompression(ct CompressionType) (err error) {
 		//get a writer rolling
 		wtr := snappy.NewBufferedWriter(er.conn)
 		er.flshr = wtr
-		er.bAckWriter.Reset(newSnappyFlushWriter(wtr))
+		er.bAckWriter.Reset(newAutoFlushWriter(wtr))
 		//get a reader rolling
 		er.bIO.Reset(snappy.NewReader(er.conn))
+	case CompressZstd:
+		var rdr *zstd.Decoder
+		var wtr *zstd.Encoder
+		if rdr, err = newZstdReader(er.conn); err != nil {
+			return
+		} else if wtr, err = newZstdWriter(er.conn); err != nil {
+			rdr.Close()
+			return
+		}
+		er.flshr = wtr
+		er.bAckWriter.Reset(newAutoFlushWriter(wtr))
+		er.bIO.Reset(rdr)
+	case CompressLZ4:
+		wtr := newLZ4BlockWriter(er.conn)
+		er.flshr = wtr
+		er.bAckWriter.Reset(wtr)
+		er.bIO.Reset(newLZ4BlockReader(er.conn))
 	default:
 		err = fmt.Errorf("Unknown compression id %x", ct)
 	}
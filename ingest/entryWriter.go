@@ -20,6 +20,7 @@ import (
 
 	"github.com/gravwell/gravwell/v4/ingest/entry"
 	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/zstd"
 )
 
 const (
@@ -43,6 +44,7 @@ const (
 	MINIMUM_INGEST_STATE_VERSION    uint16 = 0x6 // minimum server version to send detailed ingester state messages
 	MINIMUM_INGEST_EV_VERSION       uint16 = 0x8 // minimum server version to send enumerated values attached to entries
 	MINIMUM_DITTO_VERSION           uint16 = 0x9 // minimum server version to send ditto blocks
+	MINIMUM_EXT_COMPRESSION_VERSION uint16 = 0xA // minimum server version to use zstd and lz4 stream compression
 
 	maxThrottleDur time.Duration = 5 * time.Second
 
@@ -96,6 +98,7 @@ type EntryWriter struct {
 	ackTimeout    time.Duration
 	serverVersion uint16
 	ctx           context.Context
+	confirmed     func(*entry.Entry) // optional, called for every entry the remote side confirms
 }
 
 func NewEntryWriter(conn net.Conn) (*EntryWriter, error) {
@@ -164,6 +167,23 @@ func (ew *EntryWriter) OverrideAckTimeout(t time.Duration) error {
 	return nil
 }
 
+// setConfirmCallback registers a function which is called with every entry as the remote
+// side confirms it.  The callback is called with the writer locked and must not block.
+func (ew *EntryWriter) setConfirmCallback(fn func(*entry.Entry)) {
+	ew.mtx.Lock()
+	ew.confirmed = fn
+	ew.mtx.Unlock()
+}
+
+// confirm removes a confirmed entry from the confirmation buffer, caller must hold the mutex
+func (ew *EntryWriter) confirm(id entrySendID) error {
+	ent, err := ew.ecb.confirm(id)
+	if err == nil && ew.confirmed != nil {
+		ew.confirmed(ent)
+	}
+	return err
+}
+
 type connWrapper func(conn) conn
 
 // wrapConn passes in a function that can wrap a reader/writer
@@ -566,6 +586,10 @@ func (ew *EntryWriter) ConfigureStream(c StreamConfiguration) (err error) {
 		//just return quietly, its ok
 		return
 	}
+	//if the server is too old to handle the requested compression, fall back to snappy
+	if c.Compression.minimumVersion() > ew.serverVersion {
+		c.Compression = CompressSnappy
+	}
 	//set our timeouts and perform the exchange
 	if err = c.Write(ew.bIO); err != nil {
 		err = fmt.Errorf("failed to write StreamConfiguration %w", err)
@@ -648,7 +672,24 @@ func (ew *EntryWriter) startCompression(ct CompressionType) (err error) {
 		//get a writer rolling
 		wtr := snappy.NewBufferedWriter(ew.conn)
 		ew.flshr = wtr
-		ew.bIO.Reset(newSnappyFlushWriter(wtr))
+		ew.bIO.Reset(newAutoFlushWriter(wtr))
+	case CompressZstd:
+		var rdr *zstd.Decoder
+		var wtr *zstd.Encoder
+		if rdr, err = newZstdReader(ew.conn); err != nil {
+			return
+		} else if wtr, err = newZstdWriter(ew.conn); err != nil {
+			rdr.Close()
+			return
+		}
+		ew.bAckReader.Reset(rdr)
+		ew.flshr = wtr
+		ew.bIO.Reset(newAutoFlushWriter(wtr))
+	case CompressLZ4:
+		ew.bAckReader.Reset(newLZ4BlockReader(ew.conn))
+		wtr := newLZ4BlockWriter(ew.conn)
+		ew.flshr = wtr
+		ew.bIO.Reset(wtr)
 	default:
 		err = fmt.Errorf("Unknown compression id %x", ct)
 	}
@@ -873,7 +914,7 @@ tagCmdLoop:
 			//check if the ID is the head, if not pop the head and resend
 			//TODO: if we get an ID we don't know about we just ignore it
 			//      is this the best course of action?
-			if err = ew.ecb.Confirm(entrySendID(ac.val)); err != nil {
+			if err = ew.confirm(entrySendID(ac.val)); err != nil {
 				if err != errEntryNotFound {
 					return
 				}
@@ -974,7 +1015,7 @@ loop:
 			//check if the ID is the head, if not pop the head and resend
 			//TODO: if we get an ID we don't know about we just ignore it
 			//      is this the best course of action?
-			if err = ew.ecb.Confirm(entrySendID(ac.val)); err != nil {
+			if err = ew.confirm(entrySendID(ac.val)); err != nil {
 				if err != errEntryNotFound {
 					break loop
 				}
@@ -1032,7 +1073,7 @@ func (ew *EntryWriter) readCommandsUntil(cmd IngestCommand) (ac ackCommand, err
 			//check if the ID is the head, if not pop the head and resend
 			//TODO: if we get an ID we don't know about we just ignore it
 			//      is this the best course of action?
-			if err = ew.ecb.Confirm(entrySendID(ac.val)); err != nil {
+			if err = ew.confirm(entrySendID(ac.val)); err != nil {
 				if err != errEntryNotFound {
 					return
 				}
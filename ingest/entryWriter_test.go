@@ -303,6 +303,15 @@ func TestThrottled(t *testing.T) {
 	performThrottleCycles(t, THROTTLE_WRITES)
 }
 
+func TestCompressedRead(t *testing.T) {
+	for _, ct := range []CompressionType{CompressSnappy, CompressZstd, CompressLZ4} {
+		if err := cleanup(); err != nil {
+			t.Fatal(err)
+		}
+		performCompressedCycles(t, SMALL_WRITES, ct)
+	}
+}
+
 func TestWriterOutstandingMismatch(t *testing.T) {
 	wtrCfg := EntryReaderWriterConfig{
 		OutstandingEntryCount: 2,
@@ -479,6 +488,57 @@ func performReaderCycles(t *testing.T, count, segments int) (time.Duration, uint
 	return dur, totalBytes
 }
 
+func performCompressedCycles(t *testing.T, count int, ct CompressionType) {
+	errChan := make(chan error)
+	lst, cli, srv, err := getConnections()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	etSrv, err := NewEntryReader(srv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = etSrv.startCompression(ct); err != nil {
+		t.Fatal(err)
+	}
+	etSrv.Start()
+
+	etCli, err := NewEntryWriter(cli)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = etCli.startCompression(ct); err != nil {
+		t.Fatal(err)
+	}
+	go reader(etSrv, count, 0xffffffff, errChan)
+	for i := 0; i < count; i++ {
+		ent := makeEntry()
+		if err = etCli.Write(ent); err != nil {
+			t.Fatal(ct, err)
+		}
+	}
+	if err = etCli.ForceAck(); err != nil {
+		t.Fatal(ct, err)
+	}
+	if err = etCli.Ping(); err != nil {
+		t.Fatal(ct, err)
+	}
+	if err = etCli.Close(); err != nil {
+		t.Fatal(ct, err)
+	}
+	if err = <-errChan; err != nil {
+		t.Fatal(ct, err)
+	}
+	if err = etSrv.Close(); err != nil {
+		t.Fatal(ct, err)
+	}
+	if err = closeConnections(cli, srv); err != nil {
+		t.Fatal(err)
+	}
+	lst.Close()
+}
+
 func performBatchCycles(t *testing.T, count int) (time.Duration, uint64) {
 	var dur time.Duration
 	var totalBytes uint64
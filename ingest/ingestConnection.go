@@ -11,6 +11,7 @@ package ingest
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"net"
 	"sync"
@@ -30,6 +31,7 @@ var (
 	ErrOversizedTag   = errors.New("Tag name is too long")
 	ErrForbiddenTag   = errors.New("Forbidden character in tag")
 	ErrInvalidTimeout = errors.New("invalid timeout value")
+	ErrNotHot         = errors.New("Ingester did not send a hot state")
 )
 
 // IngestConnection is a lower-level interface for connecting to and
@@ -345,6 +347,83 @@ func authenticate(conn io.ReadWriter, tenant string, hash AuthHash, tags []strin
 	return tagResp.Tags, chal.Version, nil
 }
 
+// AuthenticateIngester performs the indexer side of the handshake on a newly accepted
+// connection.  The ingester must answer a challenge built from the auth hash, then every
+// tag it requests is resolved through the tag manager; if any tag fails the negotiation
+// is refused.  The tenant requested by the ingester is returned.  Callers should set a
+// deadline on the connection, hand it to an EntryReader, and call SetupConnection once
+// this returns.
+func AuthenticateIngester(conn io.ReadWriter, auth AuthHash, tm TagManager) (tenant string, err error) {
+	var resp ChallengeResponse
+	var state StateResponse
+	var tagReq TagRequest
+	var chal Challenge
+	if tm == nil {
+		err = ErrFailedTagNegotiation
+		return
+	}
+
+	//throw the challenge and check the response
+	if chal, err = NewChallenge(auth); err != nil {
+		return
+	} else if err = chal.Write(conn); err != nil {
+		return
+	} else if err = resp.Read(conn); err != nil {
+		return
+	}
+	if VerifyResponse(auth, chal, resp) != nil {
+		state = StateResponse{ID: STATE_NOT_AUTHENTICATED, Info: ErrFailedAuth.Error()}
+		state.Write(conn)
+		err = ErrFailedAuth
+		return
+	}
+	state = StateResponse{ID: STATE_AUTHENTICATED}
+	if err = state.Write(conn); err != nil {
+		return
+	}
+
+	//resolve the requested tags, a zero count tells the ingester negotiation failed
+	if err = tagReq.Read(conn); err != nil {
+		return
+	}
+	tagResp := TagResponse{Tags: make(map[string]entry.EntryTag, len(tagReq.Tags))}
+	if int(tagReq.Count) != len(tagReq.Tags) {
+		err = ErrInvalidTagRequestLen
+	} else {
+		for _, name := range tagReq.Tags {
+			var tg entry.EntryTag
+			if tg, err = tm.GetAndPopulate(name); err != nil {
+				err = fmt.Errorf("%q: %w", name, err)
+				break
+			}
+			tagResp.Tags[name] = tg
+		}
+	}
+	if err != nil || len(tagResp.Tags) == 0 {
+		tagResp.Tags = map[string]entry.EntryTag{}
+	}
+	tagResp.Count = uint32(len(tagResp.Tags))
+	if lerr := tagResp.Write(conn); lerr != nil && err == nil {
+		err = lerr
+	}
+	if err != nil {
+		return
+	} else if tagResp.Count == 0 {
+		err = ErrFailedTagNegotiation
+		return
+	}
+
+	//wait for the "we're hot" message
+	if err = state.Read(conn); err != nil {
+		return
+	} else if state.ID != STATE_HOT {
+		err = ErrNotHot
+		return
+	}
+	tenant = resp.Tenant
+	return
+}
+
 func checkTags(tags []string) error {
 	for i := range tags {
 		if err := CheckTag(tags[i]); err != nil {
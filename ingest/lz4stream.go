@@ -0,0 +1,158 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package ingest
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/pierrec/lz4/v4"
+)
+
+const (
+	lz4MaxBlockSize    int = 256 * 1024
+	lz4BlockHeaderSize int = 8 // uint32 raw size + uint32 compressed size
+)
+
+var (
+	errInvalidLZ4Block = errors.New("invalid lz4 block header")
+)
+
+// The lz4 frame format readers will not return until the entire read buffer is filled,
+// which does not work on an interactive stream where we need acks to flow as soon as they are written.
+// So we use a very simple block framing: each write is chunked into blocks that are compressed
+// and pushed to the wire immediately with a small header.  A compressed size of zero
+// indicates that the block did not compress and is stored raw.
+type lz4BlockWriter struct {
+	w    io.Writer
+	c    lz4.Compressor
+	buff []byte
+}
+
+func newLZ4BlockWriter(w io.Writer) *lz4BlockWriter {
+	return &lz4BlockWriter{
+		w:    w,
+		buff: make([]byte, lz4BlockHeaderSize+lz4.CompressBlockBound(lz4MaxBlockSize)),
+	}
+}
+
+func (lw *lz4BlockWriter) Write(b []byte) (n int, err error) {
+	for len(b) > 0 {
+		chunk := b
+		if len(chunk) > lz4MaxBlockSize {
+			chunk = chunk[:lz4MaxBlockSize]
+		}
+		if err = lw.writeBlock(chunk); err != nil {
+			return
+		}
+		n += len(chunk)
+		b = b[len(chunk):]
+	}
+	return
+}
+
+func (lw *lz4BlockWriter) writeBlock(chunk []byte) (err error) {
+	var cl int
+	if cl, err = lw.c.CompressBlock(chunk, lw.buff[lz4BlockHeaderSize:]); err != nil {
+		return
+	}
+	binary.LittleEndian.PutUint32(lw.buff, uint32(len(chunk)))
+	if cl == 0 || cl >= len(chunk) {
+		//incompressible, ship it raw
+		binary.LittleEndian.PutUint32(lw.buff[4:], 0)
+		if err = writeFull(lw.w, lw.buff[:lz4BlockHeaderSize]); err == nil {
+			err = writeFull(lw.w, chunk)
+		}
+		return
+	}
+	binary.LittleEndian.PutUint32(lw.buff[4:], uint32(cl))
+	err = writeFull(lw.w, lw.buff[:lz4BlockHeaderSize+cl])
+	return
+}
+
+// Flush is a no-op, every write is pushed to the wire as it happens
+func (lw *lz4BlockWriter) Flush() error {
+	return nil
+}
+
+// Close is a no-op, the underlying connection is owned by the caller
+func (lw *lz4BlockWriter) Close() error {
+	return nil
+}
+
+type lz4BlockReader struct {
+	r       io.Reader
+	hdr     []byte
+	cbuff   []byte
+	dbuff   []byte
+	pending []byte
+}
+
+func newLZ4BlockReader(r io.Reader) *lz4BlockReader {
+	return &lz4BlockReader{
+		r:     r,
+		hdr:   make([]byte, lz4BlockHeaderSize),
+		cbuff: make([]byte, lz4.CompressBlockBound(lz4MaxBlockSize)),
+		dbuff: make([]byte, lz4MaxBlockSize),
+	}
+}
+
+// Read hands back whatever is left in the current block, reading at most one new block
+// so that the caller is never blocked waiting on data that has not been sent.
+func (lr *lz4BlockReader) Read(b []byte) (n int, err error) {
+	if len(lr.pending) == 0 {
+		if err = lr.readBlock(); err != nil {
+			return
+		}
+	}
+	n = copy(b, lr.pending)
+	lr.pending = lr.pending[n:]
+	return
+}
+
+func (lr *lz4BlockReader) readBlock() (err error) {
+	if _, err = io.ReadFull(lr.r, lr.hdr); err != nil {
+		return
+	}
+	rawLen := int(binary.LittleEndian.Uint32(lr.hdr))
+	compLen := int(binary.LittleEndian.Uint32(lr.hdr[4:]))
+	if rawLen == 0 || rawLen > lz4MaxBlockSize || compLen > len(lr.cbuff) {
+		return errInvalidLZ4Block
+	}
+	if compLen == 0 {
+		//raw block
+		if _, err = io.ReadFull(lr.r, lr.dbuff[:rawLen]); err == nil {
+			lr.pending = lr.dbuff[:rawLen]
+		}
+		return
+	}
+	if _, err = io.ReadFull(lr.r, lr.cbuff[:compLen]); err != nil {
+		return
+	}
+	var n int
+	if n, err = lz4.UncompressBlock(lr.cbuff[:compLen], lr.dbuff[:rawLen]); err != nil {
+		return
+	} else if n != rawLen {
+		return errInvalidLZ4Block
+	}
+	lr.pending = lr.dbuff[:rawLen]
+	return
+}
+
+func writeFull(w io.Writer, b []byte) (err error) {
+	var n int
+	for len(b) > 0 {
+		if n, err = w.Write(b); err != nil {
+			return
+		}
+		b = b[n:]
+	}
+	return
+}
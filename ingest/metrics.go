@@ -0,0 +1,174 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package ingest
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gravwell/gravwell/v4/ingest/entry"
+)
+
+// MuxerMetrics is a point in time snapshot of the muxer state intended for metrics exporters.
+type MuxerMetrics struct {
+	Destinations int
+	Hot          int
+	Dead         int
+	Entries      uint64 // entries handed to the muxer
+	Bytes        uint64 // bytes of entry data handed to the muxer
+	Uptime       time.Duration
+	CacheEnabled bool
+	CacheState   string
+	CacheSize    uint64 // bytes committed to the on-disk cache
+	CacheDepth   int    // entries and blocks sitting in the in-memory cache buffers
+	Children     int
+	ActiveGroup  string // destination group receiving data, empty without standby targets
+	Tags         []TagMetrics
+}
+
+// TagMetrics holds the entry and byte counts handed to the muxer for a single tag.
+type TagMetrics struct {
+	Name    string
+	Entries uint64
+	Bytes   uint64
+}
+
+type tagCounter struct {
+	entries atomic.Uint64
+	bytes   atomic.Uint64
+}
+
+// tagCounters tracks per tag entry and byte counts, counters are never removed
+type tagCounters struct {
+	mtx sync.RWMutex
+	m   map[entry.EntryTag]*tagCounter
+}
+
+func (tc *tagCounters) get(tag entry.EntryTag) (c *tagCounter) {
+	tc.mtx.RLock()
+	c = tc.m[tag]
+	tc.mtx.RUnlock()
+	if c != nil {
+		return
+	}
+	tc.mtx.Lock()
+	if tc.m == nil {
+		tc.m = map[entry.EntryTag]*tagCounter{}
+	}
+	if c = tc.m[tag]; c == nil {
+		c = &tagCounter{}
+		tc.m[tag] = c
+	}
+	tc.mtx.Unlock()
+	return
+}
+
+func (tc *tagCounters) add(tag entry.EntryTag, sz int) {
+	c := tc.get(tag)
+	c.entries.Add(1)
+	c.bytes.Add(uint64(sz))
+}
+
+func (tc *tagCounters) addTally(t tagTally) {
+	for _, d := range t {
+		c := tc.get(d.tag)
+		c.entries.Add(d.entries)
+		c.bytes.Add(d.bytes)
+	}
+}
+
+type tagDelta struct {
+	tag     entry.EntryTag
+	entries uint64
+	bytes   uint64
+}
+
+// tagTally holds the per tag counts for a block of entries.  Relay routines translate
+// tags in place, so the tally is taken before a block is handed off and only added to
+// the counters once the write succeeds.  Blocks rarely carry more than a few tags.
+type tagTally []tagDelta
+
+func (t tagTally) add(tag entry.EntryTag, sz int) tagTally {
+	for i := range t {
+		if t[i].tag == tag {
+			t[i].entries++
+			t[i].bytes += uint64(sz)
+			return t
+		}
+	}
+	return append(t, tagDelta{tag: tag, entries: 1, bytes: uint64(sz)})
+}
+
+func tallyBatch(b []*entry.Entry) (t tagTally) {
+	for _, e := range b {
+		if e != nil {
+			t = t.add(e.Tag, len(e.Data))
+		}
+	}
+	return
+}
+
+func tallyDitto(b []entry.Entry) (t tagTally) {
+	for i := range b {
+		t = t.add(b[i].Tag, len(b[i].Data))
+	}
+	return
+}
+
+func (tc *tagCounters) snapshot() (r map[entry.EntryTag]TagMetrics) {
+	tc.mtx.RLock()
+	r = make(map[entry.EntryTag]TagMetrics, len(tc.m))
+	for tag, c := range tc.m {
+		r[tag] = TagMetrics{
+			Entries: c.entries.Load(),
+			Bytes:   c.bytes.Load(),
+		}
+	}
+	tc.mtx.RUnlock()
+	return
+}
+
+// Metrics returns a snapshot of the muxer state, connection counts, cache usage, and
+// per tag counters.  Tags are sorted by name.
+func (im *IngestMuxer) Metrics() (m MuxerMetrics) {
+	im.mtx.RLock()
+	m = MuxerMetrics{
+		Destinations: len(im.dests),
+		Hot:          int(atomic.LoadInt32(&im.connHot)),
+		Dead:         int(atomic.LoadInt32(&im.connDead)),
+		Entries:      im.ingesterState.Entries,
+		Bytes:        im.ingesterState.Size,
+		Uptime:       time.Since(im.start),
+		CacheEnabled: im.cacheEnabled,
+		CacheState:   im.ingesterState.CacheState,
+		Children:     len(im.ingesterState.Children),
+		ActiveGroup:  im.sb.group(),
+	}
+	if im.cacheEnabled {
+		m.CacheSize = uint64(im.cache.Size()) + uint64(im.bcache.Size()) + uint64(im.pcache.Size())
+		m.CacheDepth = im.cache.BufferSize() + im.bcache.BufferSize() + im.pcache.BufferSize()
+	}
+	names := make(map[entry.EntryTag]string, len(im.tagMap)+1)
+	for name, tag := range im.tagMap {
+		names[tag] = name
+	}
+	im.mtx.RUnlock()
+	names[entry.GravwellTagId] = entry.GravwellTagName
+
+	for tag, tm := range im.tagCounts.snapshot() {
+		var ok bool
+		if tm.Name, ok = names[tag]; ok {
+			m.Tags = append(m.Tags, tm)
+		}
+	}
+	sort.Slice(m.Tags, func(i, j int) bool { return m.Tags[i].Name < m.Tags[j].Name })
+	return
+}
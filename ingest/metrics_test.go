@@ -0,0 +1,37 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package ingest
+
+import (
+	"testing"
+
+	"github.com/gravwell/gravwell/v4/ingest/entry"
+)
+
+func TestTagCounters(t *testing.T) {
+	var tc tagCounters
+	tc.add(1, len("hello"))
+	b := []*entry.Entry{{Tag: 1, Data: []byte("a")}, {Tag: 2, Data: []byte("bb")}, nil}
+	tally := tallyBatch(b)
+	//the tally is a copy, translating tags after the fact does not change it
+	b[0].Tag = 7
+	tc.addTally(tally)
+	tc.addTally(tallyDitto([]entry.Entry{{Tag: 2, Data: []byte("ccc")}}))
+
+	snap := tc.snapshot()
+	if len(snap) != 2 {
+		t.Fatalf("bad tag count %d", len(snap))
+	}
+	if tm := snap[1]; tm.Entries != 2 || tm.Bytes != 6 {
+		t.Fatalf("bad counters for tag 1: %+v", tm)
+	}
+	if tm := snap[2]; tm.Entries != 2 || tm.Bytes != 5 {
+		t.Fatalf("bad counters for tag 2: %+v", tm)
+	}
+}
@@ -96,6 +96,8 @@ type Target struct {
 	Address string
 	Tenant  string
 	Secret  string
+	Weight  int  // relative share of entries, zero is treated as the default weight of 1
+	Standby bool // only receives data once the primary targets fail
 }
 
 type TargetError struct {
@@ -133,6 +135,8 @@ type IngestMuxer struct {
 	eChanOut             chan interface{}
 	bChan                chan interface{}
 	bChanOut             chan interface{}
+	pChan                chan interface{} // priority lane, carries both entries and blocks
+	pChanOut             chan interface{}
 	dittoChan            chan dittoBlock
 	eq                   *emergencyQueue
 	writeBarrier         chan bool
@@ -148,6 +152,7 @@ type IngestMuxer struct {
 	cacheSize            int
 	cache                *chancacher.ChanCacher
 	bcache               *chancacher.ChanCacher
+	pcache               *chancacher.ChanCacher
 	cacheAlways          bool
 	name                 string
 	version              string
@@ -162,6 +167,11 @@ type IngestMuxer struct {
 	attachActive         bool
 	minVersion           uint16
 	maxEntrySize         int
+	lb                   *loadBalancer // nil unless targets are weighted or tags have affinity
+	tagCounts            tagCounters
+	acks                 *ackTracker    // batches written with WriteBatchAcked
+	prio                 *tagPriority   // nil unless tags have priority
+	sb                   *standbyGroups // nil unless there are standby targets
 }
 
 type UniformMuxerConfig struct {
@@ -188,6 +198,11 @@ type UniformMuxerConfig struct {
 	Attach            attach.AttachConfig `gcfg:",section=raw,ident=regex"`
 	MinVersion        uint16              // minimum API version of indexers
 	MaxEntrySize      int
+	Weights           map[string]int      // optional weights keyed by destination
+	TagAffinity       map[string][]string // optional tag to destination pinning
+	PriorityTags      []string            // optional tags which skip ahead of bulk data
+	StandbyTargets    []string            // optional destinations which only receive data once the primaries fail
+	Standby           StandbyConfig       // failover thresholds for the standby destinations
 }
 
 type MuxerConfig struct {
@@ -212,6 +227,9 @@ type MuxerConfig struct {
 	Attach            attach.AttachConfig `gcfg:",section=raw,ident=regex"`
 	MinVersion        uint16              // minimum API version of indexers
 	MaxEntrySize      int
+	TagAffinity       map[string][]string // optional tag to destination pinning, tags without a rule go anywhere
+	PriorityTags      []string            // optional tags which use the priority lane
+	Standby           StandbyConfig       // failover thresholds, used if any destination is a standby
 }
 
 func NewUniformMuxer(c UniformMuxerConfig) (*IngestMuxer, error) {
@@ -243,7 +261,7 @@ func newUniformIngestMuxerEx(c UniformMuxerConfig) (*IngestMuxer, error) {
 	if len(c.Auth) == 0 {
 		return nil, ErrEmptyAuth
 	}
-	destinations := make([]Target, len(c.Destinations))
+	destinations := make([]Target, len(c.Destinations), len(c.Destinations)+len(c.StandbyTargets))
 	for i := range c.Destinations {
 		destinations[i].Address = c.Destinations[i]
 		destinations[i].Secret = c.Auth
@@ -252,6 +270,26 @@ func newUniformIngestMuxerEx(c UniformMuxerConfig) (*IngestMuxer, error) {
 	if len(destinations) == 0 {
 		return nil, ErrNoTargets
 	}
+	for _, dst := range c.StandbyTargets {
+		destinations = append(destinations, Target{
+			Address: dst,
+			Secret:  c.Auth,
+			Tenant:  c.Tenant,
+			Standby: true,
+		})
+	}
+	for ref, w := range c.Weights {
+		var found bool
+		for i := range destinations {
+			if targetMatches(destinations[i].Address, ref) {
+				destinations[i].Weight = w
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("Weight specified for unknown target %q", ref)
+		}
+	}
 	if len(c.Tags) > int(entry.MaxTagId) {
 		return nil, ErrTooManyTags
 	}
@@ -278,6 +316,9 @@ func newUniformIngestMuxerEx(c UniformMuxerConfig) (*IngestMuxer, error) {
 		Attach:             c.Attach,
 		MinVersion:         c.MinVersion,
 		MaxEntrySize:       c.MaxEntrySize,
+		TagAffinity:        c.TagAffinity,
+		PriorityTags:       c.PriorityTags,
+		Standby:            c.Standby,
 	}
 	return newIngestMuxer(cfg)
 }
@@ -320,9 +361,11 @@ func newIngestMuxer(c MuxerConfig) (*IngestMuxer, error) {
 	}
 
 	// connect up the chancacher
+	acks := newAckTracker()
 	var cache *chancacher.ChanCacher
 	var bcache *chancacher.ChanCacher
-	var eIn, eOut, bIn, bOut chan interface{}
+	var pcache *chancacher.ChanCacher
+	var eIn, eOut, bIn, bOut, pIn, pOut chan interface{}
 
 	var err error
 	if c.CachePath != "" {
@@ -336,12 +379,26 @@ func newIngestMuxer(c MuxerConfig) (*IngestMuxer, error) {
 			c.Logger.Error("Error initializing write cache", log.KVErr(err))
 			return nil, err
 		}
+		// the priority cache always exists so that anything left behind by a previous run is recovered
+		pcache, err = chancacher.NewChanCacher(c.CacheDepth, filepath.Join(c.CachePath, "p"), mb*c.CacheSize, c.Logger)
+		if err != nil {
+			c.Logger.Error("Error initializing priority cache", log.KVErr(err))
+			return nil, err
+		}
+		// bulk data backs off first when the cache fills up
+		cache.ShareSizeLimit(pcache)
+		bcache.ShareSizeLimit(pcache)
 		if c.CacheMode == CacheModeFail {
 			cache.CacheStop()
 			bcache.CacheStop()
+			pcache.CacheStop()
 		}
+		cache.SetCommitCallback(acks.committed)
+		bcache.SetCommitCallback(acks.committed)
+		pcache.SetCommitCallback(acks.committed)
 		eIn, eOut = cache.In, cache.Out
 		bIn, bOut = bcache.In, bcache.Out
+		pIn, pOut = pcache.In, pcache.Out
 	} else {
 		// no cache active, just plumb a channel all the way through
 		depth := c.CacheDepth
@@ -352,10 +409,13 @@ func newIngestMuxer(c MuxerConfig) (*IngestMuxer, error) {
 		}
 		eChan := make(chan interface{}, depth)
 		bChan := make(chan interface{}, depth)
+		pChan := make(chan interface{}, depth)
 		eIn = eChan
 		eOut = eChan
 		bIn = bChan
 		bOut = bChan
+		pIn = pChan
+		pOut = pChan
 	}
 
 	id := uuid.Nil
@@ -450,6 +510,22 @@ func newIngestMuxer(c MuxerConfig) (*IngestMuxer, error) {
 		tc.add(v)
 	}
 
+	lb, err := newLoadBalancer(c.Destinations, c.TagAffinity, tagMap)
+	if err != nil {
+		return nil, err
+	}
+
+	prio, err := newTagPriority(c.PriorityTags, tagMap)
+	if err != nil {
+		return nil, err
+	}
+
+	sb, err := newStandbyGroups(c.Destinations, c.Standby)
+	if err != nil {
+		return nil, err
+	}
+	state.ActiveGroup = sb.group()
+
 	ctx, cf := context.WithCancel(context.Background())
 
 	return &IngestMuxer{
@@ -473,13 +549,16 @@ func newIngestMuxer(c MuxerConfig) (*IngestMuxer, error) {
 		eChanOut:          eOut,
 		bChan:             bIn,
 		bChanOut:          bOut,
+		pChan:             pIn,
+		pChanOut:          pOut,
 		dittoChan:         make(chan dittoBlock), // synchronous as hell
-		eq:                newEmergencyQueue(),
+		eq:                newEmergencyQueue(prio),
 		writeBarrier:      make(chan bool),
 		upChan:            make(chan bool, 1),
 		errChan:           make(chan error, len(c.Destinations)),
 		cache:             cache,
 		bcache:            bcache,
+		pcache:            pcache,
 		cacheEnabled:      c.CachePath != "",
 		cacheSize:         mb * c.CacheSize,
 		cachePath:         c.CachePath,
@@ -495,6 +574,10 @@ func newIngestMuxer(c MuxerConfig) (*IngestMuxer, error) {
 		attachActive:      atch.Active(),
 		minVersion:        c.MinVersion,
 		maxEntrySize:      c.MaxEntrySize,
+		lb:                lb,
+		acks:              acks,
+		prio:              prio,
+		sb:                sb,
 	}, nil
 }
 
@@ -546,6 +629,7 @@ func (im *IngestMuxer) Start() error {
 	if im.cacheEnabled && im.cacheAlways {
 		im.cache.CacheStart()
 		im.bcache.CacheStart()
+		im.pcache.CacheStart()
 	}
 
 	//fire up the ingest routines
@@ -553,6 +637,12 @@ func (im *IngestMuxer) Start() error {
 	im.tagTranslators = make([]*tagTrans, len(im.dests))
 	im.wg.Add(len(im.dests))
 	im.connDead = int32(len(im.dests))
+	if im.sb != nil {
+		//only the active group counts, and the primaries always start out active
+		im.connDead = int32(im.sb.primaries)
+		im.wg.Add(1)
+		go im.standbyRoutine()
+	}
 	for i := 0; i < len(im.dests); i++ {
 		go im.connRoutine(i)
 	}
@@ -588,21 +678,35 @@ func (im *IngestMuxer) Close() error {
 	im.mtx.Lock()
 	defer im.mtx.Unlock()
 
+	//anything still sitting in a forwarding channel goes into the emergency queue
+	if im.lb != nil {
+		for i := range im.dests {
+			ents, blks := im.lb.drain(i)
+			for _, ent := range ents {
+				im.eq.push(ent, nil)
+			}
+			for _, blk := range blks {
+				im.eq.push(nil, blk)
+			}
+		}
+	}
+
 	//drain the emergency queue into the channels IF the cache is enabled
 	if im.cacheEnabled {
 		//tell the cache that it needs to start pushing to disk
 		//this is safe to call multiple times (in case ingestConnections already died)
 		im.cache.CacheStart()
 		im.bcache.CacheStart()
+		im.pcache.CacheStart()
 
 		//drain the emergency queue into the cache
 		for im.eq.len() > 0 {
 			if ent, block, ok := im.eq.pop(); ok {
 				if ent != nil {
-					im.eChan <- ent
+					im.entryLane(ent) <- ent
 				}
 				if len(block) > 0 {
-					im.bChan <- block
+					im.blockLane(block) <- block
 				}
 			}
 		}
@@ -611,18 +715,23 @@ func (im *IngestMuxer) Close() error {
 	//close inputs, signalling that we want everything to really really shutdown
 	close(im.eChan)
 	close(im.bChan)
+	close(im.pChan)
 
 	// commit any outstanding data to disk, if the backing path is enabled.
 	if im.cacheEnabled {
 		im.cache.Commit()
 		im.bcache.Commit()
-		// If BOTH caches are empty, we can delete the stored tag map
-		if im.cache.Size() == 0 && im.bcache.Size() == 0 {
+		im.pcache.Commit()
+		// If ALL caches are empty, we can delete the stored tag map
+		if im.cache.Size() == 0 && im.bcache.Size() == 0 && im.pcache.Size() == 0 {
 			path := filepath.Join(im.cachePath, "tagcache")
 			os.Remove(path)
 		}
 	}
 
+	//anything still waiting on an acknowledgement is never going to get one
+	im.acks.fail(ErrNotRunning)
+
 	//everyone is dead, clean up
 	close(im.upChan)
 	return nil
@@ -635,7 +744,7 @@ func (im *IngestMuxer) ingesterStateDirty() (dirty bool) {
 	} else if im.ingesterStateUpdated {
 		dirty = true
 	} else if im.cacheEnabled {
-		sz := uint64(im.cache.Size()) + uint64(im.bcache.Size())
+		sz := uint64(im.cache.Size()) + uint64(im.bcache.Size()) + uint64(im.pcache.Size())
 		if im.ingesterState.CacheSize != sz {
 			dirty = true
 		}
@@ -658,6 +767,7 @@ func (im *IngestMuxer) getIngesterState(lastPush time.Time, lastEntryCount uint6
 	if im.cacheEnabled {
 		im.ingesterState.CacheSize = uint64(im.cache.Size())
 		im.ingesterState.CacheSize += uint64(im.bcache.Size())
+		im.ingesterState.CacheSize += uint64(im.pcache.Size())
 	}
 	im.ingesterState.Uptime = time.Since(im.start)
 	im.ingesterState.Tags = im.tags
@@ -738,10 +848,12 @@ func (im *IngestMuxer) WillBlock() bool {
 		return true // no writers alive and cache is not enabled
 	}
 
-	// cache is enabled here
-	if im.cache.Size() >= im.cacheSize {
+	// cache is enabled here, the bulk caches share their limit with the priority cache
+	if psz := im.pcache.Size(); psz >= im.cacheSize {
+		return true
+	} else if im.cache.Size()+psz >= im.cacheSize {
 		return true
-	} else if im.bcache.Size() >= im.cacheSize {
+	} else if im.bcache.Size()+psz >= im.cacheSize {
 		return true
 	}
 
@@ -854,6 +966,10 @@ func (im *IngestMuxer) NegotiateTag(name string) (tg entry.EntryTag, err error)
 	tg = entry.EntryTag(tagNext + 1)
 	im.tagMap[name] = tg
 	im.tc.add(tg)
+	if im.lb != nil {
+		im.lb.registerTag(name, tg)
+	}
+	im.prio.registerTag(name, tg)
 
 	// update the tag cache
 	if im.cachePath != "" {
@@ -900,7 +1016,8 @@ func (im *IngestMuxer) SyncContext(ctx context.Context, to time.Duration) error
 			return err
 		}
 		time.Sleep(10 * time.Millisecond)
-		if len(im.eChanOut) == 0 && len(im.bChanOut) == 0 && len(im.eChan) == 0 && len(im.bChan) == 0 {
+		if len(im.eChanOut) == 0 && len(im.bChanOut) == 0 && len(im.pChanOut) == 0 &&
+			len(im.eChan) == 0 && len(im.bChan) == 0 && len(im.pChan) == 0 {
 			// all pipelines are empty
 			break
 		}
@@ -1065,7 +1182,8 @@ mainLoop:
 	return nil //someone came up
 }
 
-// Hot returns how many connections are functioning
+// Hot returns how many connections are functioning, if there are standby targets only
+// connections in the active group are counted
 func (im *IngestMuxer) Hot() (int, error) {
 	im.mtx.RLock()
 	defer im.mtx.RUnlock()
@@ -1078,6 +1196,17 @@ func (im *IngestMuxer) Hot() (int, error) {
 // goHot is a convenience function used by routines when they become active
 func (im *IngestMuxer) goHot() {
 	atomic.AddInt32(&im.connDead, -1)
+	im.hotUp()
+}
+
+// goDead is a convenience function used by routines when they become dead
+func (im *IngestMuxer) goDead() {
+	im.hotDown()
+	atomic.AddInt32(&im.connDead, 1)
+}
+
+// hotUp counts a hot connection without touching the dead count
+func (im *IngestMuxer) hotUp() {
 	//attempt a single on going hot, but don't block
 	//increment the hot counter
 	if atomic.AddInt32(&im.connHot, 1) == 1 {
@@ -1085,6 +1214,7 @@ func (im *IngestMuxer) goHot() {
 		if im.cacheEnabled && !im.cacheAlways {
 			im.cache.CacheStop()
 			im.bcache.CacheStop()
+			im.pcache.CacheStop()
 		}
 	}
 	select {
@@ -1093,27 +1223,42 @@ func (im *IngestMuxer) goHot() {
 	}
 }
 
-// goDead is a convenience function used by routines when they become dead
-func (im *IngestMuxer) goDead() {
+// hotDown removes a hot connection without touching the dead count
+func (im *IngestMuxer) hotDown() {
 	//decrement the hot counter
 	if atomic.AddInt32(&im.connHot, -1) == 0 {
 		// if the cache is enabled AND we are not in always cache mode start things
 		if im.cacheEnabled && !im.cacheAlways {
 			im.cache.CacheStart()
 			im.bcache.CacheStart()
+			im.pcache.CacheStart()
 		}
 	}
-	atomic.AddInt32(&im.connDead, 1)
 }
 
-// Dead returns how many connections are currently dead
+// Dead returns how many connections are currently dead, if there are standby targets only
+// connections in the active group are counted
 func (im *IngestMuxer) Dead() (int, error) {
 	im.mtx.RLock()
 	defer im.mtx.RUnlock()
 	if im.state != running {
 		return -1, ErrNotRunning
 	}
-	return int(im.connDead), nil
+	return int(atomic.LoadInt32(&im.connDead)), nil
+}
+
+// ActiveGroup returns the destination group currently receiving data, either
+// DestinationGroupPrimary or DestinationGroupStandby.  A muxer without standby
+// targets always reports the primary group.
+func (im *IngestMuxer) ActiveGroup() (string, error) {
+	im.mtx.RLock()
+	defer im.mtx.RUnlock()
+	if im.state != running {
+		return ``, ErrNotRunning
+	} else if im.sb == nil {
+		return DestinationGroupPrimary, nil
+	}
+	return im.sb.group(), nil
 }
 
 // Size returns the total number of specified connections, hot or dead
@@ -1156,13 +1301,16 @@ func (im *IngestMuxer) WriteEntry(e *entry.Entry) error {
 	if im.attachActive {
 		im.attacher.Attach(e)
 	}
+	//grab the tag before handing off, the relay routines translate it in place
+	tag := e.Tag
 	select {
-	case im.eChan <- e:
+	case im.entryLane(e) <- e:
 	case <-im.writeBarrier:
 		return ErrNotRunning
 	}
 	im.ingesterState.Entries++
 	im.ingesterState.Size += uint64(len(e.Data))
+	im.tagCounts.add(tag, len(e.Data))
 	return nil
 }
 
@@ -1184,10 +1332,12 @@ func (im *IngestMuxer) WriteEntryContext(ctx context.Context, e *entry.Entry) er
 	if im.attachActive {
 		im.attacher.Attach(e)
 	}
+	tag := e.Tag
 	select {
-	case im.eChan <- e:
+	case im.entryLane(e) <- e:
 		im.ingesterState.Entries++
 		im.ingesterState.Size += uint64(len(e.Data))
+		im.tagCounts.add(tag, len(e.Data))
 	case <-ctx.Done():
 		return ctx.Err()
 	case <-im.writeBarrier:
@@ -1215,10 +1365,12 @@ func (im *IngestMuxer) WriteEntryTimeout(e *entry.Entry, d time.Duration) (err e
 		im.attacher.Attach(e)
 	}
 	tmr := time.NewTimer(d)
+	tag := e.Tag
 	select {
-	case im.eChan <- e:
+	case im.entryLane(e) <- e:
 		im.ingesterState.Entries++
 		im.ingesterState.Size += uint64(len(e.Data))
+		im.tagCounts.add(tag, len(e.Data))
 	case <-tmr.C:
 		err = ErrWriteTimeout
 	case <-im.writeBarrier:
@@ -1255,15 +1407,15 @@ func (im *IngestMuxer) WriteBatch(b []*entry.Entry) error {
 			im.attacher.Attach(e)
 		}
 	}
-	select {
-	case im.bChan <- b:
-	case <-im.writeBarrier:
-		return ErrNotRunning
+	tally := tallyBatch(b)
+	if err := im.queueBatch(context.Background(), b); err != nil {
+		return err
 	}
 	im.ingesterState.Entries += uint64(len(b))
 	for i := range b {
 		im.ingesterState.Size += uint64(len(b[i].Data))
 	}
+	im.tagCounts.addTally(tally)
 	return nil
 }
 
@@ -1298,20 +1450,82 @@ func (im *IngestMuxer) WriteBatchContext(ctx context.Context, b []*entry.Entry)
 			im.attacher.Attach(e)
 		}
 	}
-	select {
-	case im.bChan <- b:
-		im.ingesterState.Entries += uint64(len(b))
-		for i := range b {
-			im.ingesterState.Size += uint64(len(b[i].Data))
+	tally := tallyBatch(b)
+	if err := im.queueBatch(ctx, b); err != nil {
+		return err
+	}
+	im.ingesterState.Entries += uint64(len(b))
+	for i := range b {
+		im.ingesterState.Size += uint64(len(b[i].Data))
+	}
+	im.tagCounts.addTally(tally)
+	return nil
+}
+
+// queueBatch puts a block on the bulk lane, any priority entries are split out
+// and queued on the priority lane ahead of the rest of the block.
+// A split block is not queued atomically, if the bulk half cannot be queued the error is
+// returned but the priority half has already been queued and will still be delivered.
+func (im *IngestMuxer) queueBatch(ctx context.Context, b []*entry.Entry) error {
+	hot, bulk := im.prio.split(b)
+	for _, blk := range [][]*entry.Entry{hot, bulk} {
+		if len(blk) == 0 {
+			continue
+		}
+		select {
+		case im.blockLane(blk) <- blk:
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-im.writeBarrier:
+			return ErrNotRunning
 		}
-	case <-ctx.Done():
-		return ctx.Err()
-	case <-im.writeBarrier:
-		return ErrNotRunning
 	}
 	return nil
 }
 
+// entryLane returns the channel an entry is queued on
+func (im *IngestMuxer) entryLane(e *entry.Entry) chan interface{} {
+	if im.prio.has(e.Tag) {
+		return im.pChan
+	}
+	return im.eChan
+}
+
+// blockLane returns the channel a block is queued on, blocks holding
+// any priority entries go on the priority lane as a whole
+func (im *IngestMuxer) blockLane(b []*entry.Entry) chan interface{} {
+	if im.prio.hasAny(b) {
+		return im.pChan
+	}
+	return im.bChan
+}
+
+// WriteBatchAcked queues a slice of entries just like WriteBatchContext, but cb is called
+// once every entry in the batch has been acknowledged by an indexer or synced into the
+// muxer cache.  Ingesters pulling from queues can hold off committing offsets until cb
+// fires to get at-least-once delivery.  cb gets nil on success or the first error hit by
+// the batch, and is only called if WriteBatchAcked returns nil.  Entries may be resent
+// after a connection failure, so indexers can see duplicates.  The entries must not be
+// modified or written again until cb fires.  cb is called from within the muxer and
+// must not block or write to the muxer.
+func (im *IngestMuxer) WriteBatchAcked(ctx context.Context, b []*entry.Entry, cb func(error)) (err error) {
+	if cb == nil {
+		return im.WriteBatchContext(ctx, b)
+	} else if len(b) == 0 {
+		cb(nil)
+		return
+	}
+	if err = im.acks.track(b, cb); err != nil {
+		return
+	}
+	if err = im.WriteBatchContext(ctx, b); err != nil {
+		//the priority half of a split batch may already be queued, it is delivered without
+		//firing cb, so a caller that retries the batch may deliver those entries twice
+		im.acks.untrack(b)
+	}
+	return
+}
+
 // Write puts together the arguments to create an entry and writes it
 // to the queue to be sent out by the first available
 // entry writer routine, if all routines are dead, THIS WILL BLOCK once the
@@ -1370,6 +1584,7 @@ func (im *IngestMuxer) DittoWriteContext(ctx context.Context, b []entry.Entry) e
 		ents: b,
 		cb:   cb,
 	}
+	tally := tallyDitto(b)
 	select {
 	case im.dittoChan <- db:
 		// Now wait for the callback to be called
@@ -1379,6 +1594,7 @@ func (im *IngestMuxer) DittoWriteContext(ctx context.Context, b []entry.Entry) e
 		for i := range b {
 			im.ingesterState.Size += uint64(len(b[i].Data))
 		}
+		im.tagCounts.addTally(tally)
 
 	case <-ctx.Done():
 		return ctx.Err()
@@ -1414,7 +1630,7 @@ func (im *IngestMuxer) getNewConnSet(csc chan connSet, connFailure chan bool, or
 			return
 		}
 		//attempt to clear the emergency queue and throw at our new connection
-		if !im.eq.clear(nc.ig, nc.tt) || nc.ig.Sync() != nil {
+		if (im.sb.active(nc.idx) && !im.eq.clear(nc.ig, nc.tt)) || nc.ig.Sync() != nil {
 			//try to send, if we can't just roll on
 			select {
 			case connFailure <- shouldSleep:
@@ -1448,15 +1664,15 @@ func (im *IngestMuxer) shouldSched() (ok bool) {
 	//there is more than one connection
 	if im.cacheEnabled {
 		//check what the cache says
-		ok = im.cache.BufferSize() == 0 && im.bcache.BufferSize() == 0
+		ok = im.cache.BufferSize() == 0 && im.bcache.BufferSize() == 0 && im.pcache.BufferSize() == 0
 	} else {
 		//no cache, so just check the channels
-		ok = len(im.eChanOut) == 0 && len(im.bChanOut) == 0
+		ok = len(im.eChanOut) == 0 && len(im.bChanOut) == 0 && len(im.pChanOut) == 0
 	}
 	return
 }
 
-func (im *IngestMuxer) writeRelayRoutine(csc chan connSet, connFailure chan bool) {
+func (im *IngestMuxer) writeRelayRoutine(self int, csc chan connSet, connFailure chan bool) {
 	tmr := time.NewTimer(tickerInterval())
 	defer tmr.Stop()
 	defer close(connFailure)
@@ -1473,13 +1689,42 @@ func (im *IngestMuxer) writeRelayRoutine(csc chan connSet, connFailure chan bool
 
 	eC := im.eChanOut
 	bC := im.bChanOut
+	pC := im.pChanOut
 	dC := im.dittoChan // not cached
+	var fC chan interface{}
+	if im.lb != nil {
+		fC = im.lb.fwd[self]
+	}
 
 	var lastStatePushEntryCount uint64
 	var lastStatePush time.Time
 
 inputLoop:
 	for {
+		//routines in the inactive destination group leave the feeder lanes alone until the group switches
+		wake := im.sb.wake()
+		eIn, bIn, pIn, dIn := eC, bC, pC, dC
+		if !im.sb.active(self) {
+			eIn, bIn, pIn, dIn = nil, nil, nil, nil
+		}
+
+		//the priority lane always goes first, only fall through to the rest once it is empty
+		select {
+		case pv, ok := <-pIn:
+			if !ok {
+				pC = nil
+				if eC == nil && bC == nil && dC == nil {
+					return
+				}
+				continue
+			}
+			if nc, ok = im.relayQueued(pv, self, nc, csc, connFailure); !ok {
+				break inputLoop
+			}
+			continue
+		default:
+		}
+
 		select {
 		case <-im.ctx.Done():
 			//the caller will detect that we exited and will take care of getting outstanding entries
@@ -1491,10 +1736,13 @@ inputLoop:
 			*/
 			im.syncAndCloseConnection(nc)
 			return
-		case db, ok := <-dC:
+		case <-wake:
+			//the active group changed, go figure out which lanes we should be reading
+			continue
+		case db, ok := <-dIn:
 			if !ok {
 				dC = nil
-				if eC == nil && bC == nil {
+				if eC == nil && bC == nil && pC == nil {
 					return
 				}
 				continue
@@ -1533,144 +1781,58 @@ inputLoop:
 
 			// let somebody else have a turn
 			runtime.Gosched()
-		case ee, ok := <-eC:
+		case pv, ok := <-pIn:
 			if !ok {
-				eC = nil
-				if bC == nil && dC == nil {
+				pC = nil
+				if eC == nil && bC == nil && dC == nil {
 					return
 				}
 				continue
 			}
-			if ee == nil {
-				continue
+			if nc, ok = im.relayQueued(pv, self, nc, csc, connFailure); !ok {
+				break inputLoop
 			}
-
-			e := ee.(*entry.Entry)
-
-			if ttag, err = nc.translateTag(e.Tag); err != nil {
-				// If the ingest muxer has no idea what this tag is, drop it and notify
-				if name, ok := im.LookupTag(e.Tag); !ok {
-					//we have controls in the muxer to prevent this, this shouldn't actually be possible
-					im.Error("Got entry tagged with completely unknown intermediate tag, dropping it",
-						log.KV("tagvalue", e.Tag),
-						log.KV("ingester", im.name),
-						log.KV("ingesteruuid", im.uuid),
-						log.KVErr(err),
-					)
-				} else {
-					im.Info("Got entry with new tag, need to renegotiate connection",
-						log.KV("tag", name),
-						log.KV("tagvalue", e.Tag),
-						log.KV("ingester", im.name),
-						log.KV("ingesteruuid", im.uuid),
-						log.KVErr(err),
-					)
-					// Could not translate, but it's a valid tag the muxer has seen before.
-					// We need to push this to the equeue and reconnect
-					// so we get the correct tag set.
-					// DO NOT reverse translate, muxer knows about the tag
-					im.recycleEntry(e)
-				}
-				im.syncAndCloseConnection(nc)
-				if nc, ok = im.getNewConnSet(csc, connFailure, false, false); !ok {
-					break inputLoop
+		case ee, ok := <-eIn:
+			if !ok {
+				eC = nil
+				if bC == nil && dC == nil && pC == nil {
+					return
 				}
-				continue inputLoop
-			}
-			e.Tag = ttag
-
-			if len(e.SRC) == 0 {
-				e.SRC = nc.src
+				continue
 			}
-			if err = nc.ig.WriteEntry(e); err != nil {
-				e.Tag = nc.tt.reverse(e.Tag)
-				im.recycleEntry(e)
-				im.syncAndCloseConnection(nc)
-				if nc, ok = im.getNewConnSet(csc, connFailure, false, false); !ok {
-					break inputLoop
-				}
-				continue inputLoop
+			if nc, ok = im.relayQueued(ee, self, nc, csc, connFailure); !ok {
+				break inputLoop
 			}
 			//hack to get better distribution across connections in an muxer
 			if im.shouldSched() {
 				runtime.Gosched()
 			}
-		case bb, ok := <-bC:
+		case bb, ok := <-bIn:
 			if !ok {
 				bC = nil
-				if eC == nil && dC == nil {
+				if eC == nil && dC == nil && pC == nil {
 					return
 				}
 				continue
 			}
-			if bb == nil {
-				continue
-			}
-
-			b := bb.([]*entry.Entry)
-			for i := range b {
-				if b[i] != nil {
-					if ttag, err = nc.translateTag(b[i].Tag); err != nil {
-						if name, ok := im.LookupTag(b[i].Tag); !ok {
-							//we have controls in the muxer to prevent this, this shouldn't actually be possible
-							im.Error("Got entry tagged with completely unknown intermediate tag, dropping it",
-								log.KV("tagvalue", b[i].Tag),
-								log.KV("ingester", im.name),
-								log.KV("ingesteruuid", im.uuid),
-								log.KVErr(err),
-							)
-							//discard this entry, this isn't real and there is no way to get here
-							b[i] = nil //this is safe, we check for this everywhere
-							// first, reverse anything we've translated already
-							for j := 0; j < i; j++ {
-								b[j].Tag = nc.tt.reverse(b[j].Tag)
-							}
-							im.recycleEntryBatch(b) //recycle and save what we can
-						} else {
-							im.Info("Got entry with new tag, need to renegotiate connection",
-								log.KV("tag", name),
-								log.KV("tagvalue", b[i].Tag),
-								log.KV("ingester", im.name),
-								log.KV("ingesteruuid", im.uuid),
-								log.KVErr(err),
-							)
-							// Could not translate! We need to push this to the equeue and reconnect
-							// so we get the correct tag set.
-
-							// first, reverse anything we've translated already
-							for j := 0; j < i; j++ {
-								b[j].Tag = nc.tt.reverse(b[j].Tag)
-							}
-							im.recycleEntryBatch(b)
-						}
-						im.syncAndCloseConnection(nc)
-						if nc, ok = im.getNewConnSet(csc, connFailure, false, false); !ok {
-							break inputLoop
-						}
-						continue inputLoop
-					}
-					b[i].Tag = ttag
-
-					if len(b[i].SRC) == 0 {
-						b[i].SRC = nc.src
-					}
-				}
-			}
-			var n int
-			if n, err = nc.ig.writeBatchEntry(b); err != nil {
-				for i := n; i < len(b); i++ {
-					b[i].Tag = nc.tt.reverse(b[i].Tag)
-				}
-				im.recycleEntryBatch(b[n:])
-				im.syncAndCloseConnection(nc)
-				if nc, ok = im.getNewConnSet(csc, connFailure, false, false); !ok {
-					break inputLoop
-				}
+			if nc, ok = im.relayQueued(bb, self, nc, csc, connFailure); !ok {
+				break inputLoop
 			}
 			//hack to get better distribution across connections in an muxer
 			if im.shouldSched() {
 				runtime.Gosched()
 			}
+		case fv := <-fC:
+			//entries handed to us by another relay routine
+			switch v := fv.(type) {
+			case *entry.Entry:
+				nc, ok = im.relayEntry(v, nc, csc, connFailure)
+			case []*entry.Entry:
+				nc, ok = im.relayBatch(v, nc, csc, connFailure)
+			}
+			if !ok {
+				break inputLoop
+			}
 		case tnc, ok = <-csc: //in case we get an unexpected new connection
 			//because this is unexpected
 			//we need to take care of the outstanding entry extraction and cycling back into
@@ -1711,8 +1873,8 @@ inputLoop:
 				}
 			}
 
-			//then we try to clear the emergency queue
-			if !im.eq.clear(nc.ig, nc.tt) {
+			//then we try to clear the emergency queue, inactive standby routines leave it alone
+			if im.sb.active(nc.idx) && !im.eq.clear(nc.ig, nc.tt) {
 				//treat this as failure, sync and close the connection
 				im.syncAndCloseConnection(nc)
 				if nc, ok = im.getNewConnSet(csc, connFailure, false, false); !ok {
@@ -1725,6 +1887,151 @@ inputLoop:
 	}
 }
 
+// relayQueued relays an entry or block pulled off of one of the feeder lanes, anything
+// the load balancer steers to another relay routine is forwarded instead of written.
+// ok is false when the relay routine should exit.
+func (im *IngestMuxer) relayQueued(v interface{}, self int, nc connSet, csc chan connSet, connFailure chan bool) (connSet, bool) {
+	switch t := v.(type) {
+	case *entry.Entry:
+		if t == nil {
+			break
+		}
+		if im.lb != nil {
+			if idx, pinned := im.lb.pick(t.Tag, self); idx != self && im.lb.forward(im.ctx, idx, t, pinned) {
+				break
+			}
+		}
+		return im.relayEntry(t, nc, csc, connFailure)
+	case []*entry.Entry:
+		b := t
+		if im.lb != nil {
+			local, remote := im.lb.splitBatch(b, self)
+			for idx, blk := range remote {
+				if !im.lb.forward(im.ctx, idx, blk, im.lb.pinnedBlock(blk, self)) {
+					local = append(local, blk...)
+				}
+			}
+			b = local
+		}
+		if len(b) > 0 {
+			return im.relayBatch(b, nc, csc, connFailure)
+		}
+	}
+	return nc, true
+}
+
+// relayEntry translates and writes a single entry to the current connection.
+// If the write fails the entry is recycled and we attempt to get a new connection set,
+// ok is false when the relay routine should exit.
+func (im *IngestMuxer) relayEntry(e *entry.Entry, nc connSet, csc chan connSet, connFailure chan bool) (connSet, bool) {
+	var ttag entry.EntryTag
+	var err error
+	if ttag, err = nc.translateTag(e.Tag); err != nil {
+		// If the ingest muxer has no idea what this tag is, drop it and notify
+		if name, ok := im.LookupTag(e.Tag); !ok {
+			//we have controls in the muxer to prevent this, this shouldn't actually be possible
+			im.Error("Got entry tagged with completely unknown intermediate tag, dropping it",
+				log.KV("tagvalue", e.Tag),
+				log.KV("ingester", im.name),
+				log.KV("ingesteruuid", im.uuid),
+				log.KVErr(err),
+			)
+			im.acks.done(e, ErrUnknownTag)
+		} else {
+			im.Info("Got entry with new tag, need to renegotiate connection",
+				log.KV("tag", name),
+				log.KV("tagvalue", e.Tag),
+				log.KV("ingester", im.name),
+				log.KV("ingesteruuid", im.uuid),
+				log.KVErr(err),
+			)
+			// Could not translate, but it's a valid tag the muxer has seen before.
+			// We need to push this to the equeue and reconnect
+			// so we get the correct tag set.
+			// DO NOT reverse translate, muxer knows about the tag
+			im.recycleEntry(e)
+		}
+		im.syncAndCloseConnection(nc)
+		return im.getNewConnSet(csc, connFailure, false, false)
+	}
+	e.Tag = ttag
+
+	if len(e.SRC) == 0 {
+		e.SRC = nc.src
+	}
+	if err = nc.ig.WriteEntry(e); err != nil {
+		e.Tag = nc.tt.reverse(e.Tag)
+		im.recycleEntry(e)
+		im.syncAndCloseConnection(nc)
+		return im.getNewConnSet(csc, connFailure, false, false)
+	}
+	return nc, true
+}
+
+// relayBatch translates and writes a block of entries to the current connection.
+// If the write fails the entries are recycled and we attempt to get a new connection set,
+// ok is false when the relay routine should exit.
+func (im *IngestMuxer) relayBatch(b []*entry.Entry, nc connSet, csc chan connSet, connFailure chan bool) (connSet, bool) {
+	var ttag entry.EntryTag
+	var err error
+	for i := range b {
+		if b[i] != nil {
+			if ttag, err = nc.translateTag(b[i].Tag); err != nil {
+				if name, ok := im.LookupTag(b[i].Tag); !ok {
+					//we have controls in the muxer to prevent this, this shouldn't actually be possible
+					im.Error("Got entry tagged with completely unknown intermediate tag, dropping it",
+						log.KV("tagvalue", b[i].Tag),
+						log.KV("ingester", im.name),
+						log.KV("ingesteruuid", im.uuid),
+						log.KVErr(err),
+					)
+					//discard this entry, this isn't real and there is no way to get here
+					im.acks.done(b[i], ErrUnknownTag)
+					b[i] = nil //this is safe, we check for this everywhere
+					// first, reverse anything we've translated already
+					for j := 0; j < i; j++ {
+						b[j].Tag = nc.tt.reverse(b[j].Tag)
+					}
+					im.recycleEntryBatch(b) //recycle and save what we can
+				} else {
+					im.Info("Got entry with new tag, need to renegotiate connection",
+						log.KV("tag", name),
+						log.KV("tagvalue", b[i].Tag),
+						log.KV("ingester", im.name),
+						log.KV("ingesteruuid", im.uuid),
+						log.KVErr(err),
+					)
+					// Could not translate! We need to push this to the equeue and reconnect
+					// so we get the correct tag set.
+
+					// first, reverse anything we've translated already
+					for j := 0; j < i; j++ {
+						b[j].Tag = nc.tt.reverse(b[j].Tag)
+					}
+					im.recycleEntryBatch(b)
+				}
+				im.syncAndCloseConnection(nc)
+				return im.getNewConnSet(csc, connFailure, false, false)
+			}
+			b[i].Tag = ttag
+
+			if len(b[i].SRC) == 0 {
+				b[i].SRC = nc.src
+			}
+		}
+	}
+	var n int
+	if n, err = nc.ig.writeBatchEntry(b); err != nil {
+		for i := n; i < len(b); i++ {
+			b[i].Tag = nc.tt.reverse(b[i].Tag)
+		}
+		im.recycleEntryBatch(b[n:])
+		im.syncAndCloseConnection(nc)
+		return im.getNewConnSet(csc, connFailure, false, false)
+	}
+	return nc, true
+}
+
 func (im *IngestMuxer) syncAndCloseConnection(nc connSet) {
 	nc.ig.syncTimeout(connectionShutdownSyncTimeout)
 	nc.ig.Close()
@@ -1768,7 +2075,7 @@ func (im *IngestMuxer) connRoutine(igIdx int) {
 	ncc := make(chan connSet, 1)
 	defer close(ncc)
 
-	go im.writeRelayRoutine(ncc, connErrNotif)
+	go im.writeRelayRoutine(igIdx, ncc, connErrNotif)
 
 	connErrNotif <- false // no sleep, get on it
 
@@ -1780,7 +2087,17 @@ func (im *IngestMuxer) connRoutine(igIdx int) {
 		//if there is a cache enabled we will drop it into there when the muxer shuts down
 		if igst != nil {
 			igst.Close()
-			im.goDead() //let the world know of our failures
+			im.connDown(igIdx) //let the world know of our failures
+			if im.lb != nil {
+				//hand back anything that was already forwarded to us
+				ents, blks := im.lb.drain(igIdx)
+				for _, ent := range ents {
+					im.recycleEntry(ent)
+				}
+				for _, blk := range blks {
+					im.recycleEntryBatch(blk)
+				}
+			}
 
 			//pull any entries out of the ingest connection and put them into the emergency queue
 			ents := igst.ejectOutstandingEntries()
@@ -1839,8 +2156,9 @@ func (im *IngestMuxer) connRoutine(igIdx int) {
 		im.tagTranslators[igIdx] = tt
 		im.mtx.Unlock()
 
-		im.goHot()
+		im.connUp(igIdx)
 		ncc <- connSet{
+			idx: igIdx,
 			dst: dst.Address,
 			src: src,
 			ig:  igst,
@@ -1863,7 +2181,7 @@ func (im *IngestMuxer) recycleEntryBatch(ents []*entry.Entry) {
 	select {
 	case <-tmr.C:
 		im.eq.push(nil, ents)
-	case im.bChan <- ents:
+	case im.blockLane(ents) <- ents:
 	}
 }
 
@@ -1885,7 +2203,7 @@ func (im *IngestMuxer) recycleEntry(ent *entry.Entry) {
 	select {
 	case <-tmr.C:
 		im.eq.push(ent, nil)
-	case im.eChan <- ent:
+	case im.entryLane(ent) <- ent:
 	}
 }
 
@@ -1996,6 +2314,7 @@ loop:
 		if im.rateParent != nil {
 			ig.ew.setConn(im.rateParent.newThrottleConn(ig.ew.conn))
 		}
+		ig.ew.setConfirmCallback(im.acks.confirm)
 
 		//no error, attempt to do a tag translation
 		//we have a good connection, build our tag map
@@ -2161,14 +2480,18 @@ type emStruct struct {
 }
 
 type emergencyQueue struct {
-	mtx *sync.Mutex
-	lst *list.List
+	mtx  *sync.Mutex
+	lst  *list.List
+	plst *list.List //anything holding priority entries, always popped first
+	prio *tagPriority
 }
 
-func newEmergencyQueue() *emergencyQueue {
+func newEmergencyQueue(prio *tagPriority) *emergencyQueue {
 	return &emergencyQueue{
-		mtx: &sync.Mutex{},
-		lst: list.New(),
+		mtx:  &sync.Mutex{},
+		lst:  list.New(),
+		plst: list.New(),
+		prio: prio,
 	}
 }
 
@@ -2187,14 +2510,18 @@ func (eq *emergencyQueue) push(e *entry.Entry, ents []*entry.Entry) {
 		e:    e,
 		ents: ents,
 	}
+	lst := eq.lst
+	if (e != nil && eq.prio.has(e.Tag)) || eq.prio.hasAny(ents) {
+		lst = eq.plst
+	}
 	eq.mtx.Lock()
-	eq.lst.PushBack(ems)
+	lst.PushBack(ems)
 	eq.mtx.Unlock()
 }
 
 func (eq *emergencyQueue) len() (r int) {
 	eq.mtx.Lock()
-	r = eq.lst.Len()
+	r = eq.lst.Len() + eq.plst.Len()
 	eq.mtx.Unlock()
 	return
 }
@@ -2205,15 +2532,19 @@ func (eq *emergencyQueue) pop() (e *entry.Entry, ents []*entry.Entry, ok bool) {
 	var elm emStruct
 	eq.mtx.Lock()
 	defer eq.mtx.Unlock()
-	if eq.lst.Len() == 0 {
+	lst := eq.plst
+	if lst.Len() == 0 {
+		lst = eq.lst
+	}
+	if lst.Len() == 0 {
 		//nothing here, bail
 		return
 	}
-	el := eq.lst.Front()
+	el := lst.Front()
 	if el == nil {
 		return
 	}
-	eq.lst.Remove(el) //its valid, remove it
+	lst.Remove(el) //its valid, remove it
 	elm, ok = el.Value.(emStruct)
 	if !ok {
 		//THROW A FIT!  This should not be possible
@@ -2292,6 +2623,7 @@ func (eq *emergencyQueue) clear(igst *IngestConnection, tt *tagTrans) (ok bool)
 type connSet struct {
 	ig  *IngestConnection
 	tt  *tagTrans
+	idx int // destination index
 	dst string
 	src net.IP
 }
@@ -2424,6 +2756,12 @@ func (tt *tagTrans) reverse(t entry.EntryTag) entry.EntryTag {
 }
 
 func getStreamConfig(cfg config.IngestStreamConfig) (sc StreamConfiguration) {
+	if cfg.Compression_Type != `` {
+		if ct, err := ParseCompression(cfg.Compression_Type); err == nil {
+			sc.Compression = ct
+			return
+		}
+	}
 	if cfg.Enable_Compression {
 		sc.Compression = CompressSnappy
 	}
@@ -0,0 +1,110 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package ingest
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/gravwell/gravwell/v4/ingest/entry"
+)
+
+// tagPriority tracks the tags which ride the priority lane of a muxer.  Entries with a
+// priority tag are queued, cached, and recycled separately from bulk data and relay
+// routines always drain the priority lane first, so they do not wait behind a backlog.
+// A nil tagPriority means there are no priority tags and everything is bulk data.
+type tagPriority struct {
+	names map[string]struct{}
+	mtx   sync.RWMutex //tags are registered as they are negotiated, while entries are flowing
+	mask  tagMaskTracker
+}
+
+// newTagPriority validates the priority tags and resolves the ones already in the tag map,
+// the rest are resolved as they are negotiated.
+func newTagPriority(tags []string, tagMap map[string]entry.EntryTag) (*tagPriority, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	tp := &tagPriority{
+		names: make(map[string]struct{}, len(tags)),
+	}
+	for _, name := range tags {
+		if err := CheckTag(name); err != nil {
+			return nil, fmt.Errorf("Invalid priority tag %q %w", name, err)
+		}
+		tp.names[name] = struct{}{}
+		if tg, ok := tagMap[name]; ok {
+			tp.mask.add(tg)
+		}
+	}
+	return tp, nil
+}
+
+// registerTag resolves a priority tag once it has been negotiated
+func (tp *tagPriority) registerTag(name string, tg entry.EntryTag) {
+	if tp == nil {
+		return
+	} else if _, ok := tp.names[name]; ok {
+		tp.mtx.Lock()
+		tp.mask.add(tg)
+		tp.mtx.Unlock()
+	}
+}
+
+func (tp *tagPriority) has(tg entry.EntryTag) (r bool) {
+	if tp != nil {
+		tp.mtx.RLock()
+		r = tp.mask.has(tg)
+		tp.mtx.RUnlock()
+	}
+	return
+}
+
+// hasAny returns true if any entry in the block carries a priority tag
+func (tp *tagPriority) hasAny(ents []*entry.Entry) (r bool) {
+	if tp != nil {
+		tp.mtx.RLock()
+		r = tp.anyLocked(ents)
+		tp.mtx.RUnlock()
+	}
+	return
+}
+
+// anyLocked is hasAny for callers already holding the lock
+func (tp *tagPriority) anyLocked(ents []*entry.Entry) bool {
+	for _, ent := range ents {
+		if ent != nil && tp.mask.has(ent.Tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// split separates the priority entries out of a block, a block without any priority
+// entries is handed back untouched as bulk.
+func (tp *tagPriority) split(ents []*entry.Entry) (hot, bulk []*entry.Entry) {
+	if tp == nil {
+		bulk = ents
+		return
+	}
+	tp.mtx.RLock()
+	defer tp.mtx.RUnlock()
+	if !tp.anyLocked(ents) {
+		bulk = ents
+		return
+	}
+	for _, ent := range ents {
+		if ent != nil && tp.mask.has(ent.Tag) {
+			hot = append(hot, ent)
+		} else {
+			bulk = append(bulk, ent)
+		}
+	}
+	return
+}
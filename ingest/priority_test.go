@@ -0,0 +1,104 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package ingest
+
+import (
+	"testing"
+
+	"github.com/gravwell/gravwell/v4/ingest/entry"
+)
+
+func TestTagPriority(t *testing.T) {
+	if tp, err := newTagPriority(nil, nil); err != nil || tp != nil {
+		t.Fatalf("no priority tags should not produce a tracker: %v", err)
+	} else if tp.has(0) || tp.hasAny([]*entry.Entry{{}}) {
+		t.Fatal("nil tracker claims priority")
+	}
+	if _, err := newTagPriority([]string{`bad tag`}, nil); err == nil {
+		t.Fatal("failed to catch bad priority tag")
+	}
+
+	tp, err := newTagPriority([]string{`alerts`, `ids`}, map[string]entry.EntryTag{`alerts`: 1, `bulk`: 2})
+	if err != nil {
+		t.Fatal(err)
+	} else if !tp.has(1) || tp.has(2) || tp.has(3) {
+		t.Fatal("bad initial priority tags")
+	}
+	//priority tags negotiated later are picked up
+	tp.registerTag(`bulk2`, 4)
+	tp.registerTag(`ids`, 3)
+	if !tp.has(3) || tp.has(4) {
+		t.Fatal("negotiated tags were not resolved")
+	}
+
+	bulk := []*entry.Entry{{Tag: 2}, {Tag: 4}}
+	if hot, rest := tp.split(bulk); hot != nil || len(rest) != 2 || &rest[0] != &bulk[0] {
+		t.Fatal("bulk only block was not passed through")
+	}
+	mixed := []*entry.Entry{{Tag: 2}, {Tag: 1}, nil, {Tag: 3}}
+	if hot, rest := tp.split(mixed); len(hot) != 2 || len(rest) != 2 || hot[0] != mixed[1] || hot[1] != mixed[3] {
+		t.Fatalf("bad split: %v %v", hot, rest)
+	}
+}
+
+// priority tags are registered while writers are checking entries, run with -race
+func TestTagPriorityConcurrent(t *testing.T) {
+	tp, err := newTagPriority([]string{`alerts`}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			tp.registerTag(`alerts`, entry.EntryTag(i%64))
+		}
+	}()
+	ents := []*entry.Entry{{Tag: 1}, {Tag: 2}}
+	for i := 0; i < 1000; i++ {
+		tp.has(entry.EntryTag(i % 64))
+		tp.split(ents)
+	}
+	<-done
+	if hot, bulk := tp.split(ents); len(hot) != 2 || len(bulk) != 0 {
+		t.Fatalf("bad split %d %d", len(hot), len(bulk))
+	}
+}
+
+func TestEmergencyQueuePriority(t *testing.T) {
+	tp, err := newTagPriority([]string{`alerts`}, map[string]entry.EntryTag{`alerts`: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	eq := newEmergencyQueue(tp)
+	bulkEnt := &entry.Entry{Tag: 2}
+	hotEnt := &entry.Entry{Tag: 1}
+	bulkBlk := []*entry.Entry{{Tag: 2}}
+	hotBlk := []*entry.Entry{{Tag: 2}, {Tag: 1}}
+	eq.push(bulkEnt, nil)
+	eq.push(nil, bulkBlk)
+	eq.push(hotEnt, nil)
+	eq.push(nil, hotBlk)
+	if eq.len() != 4 {
+		t.Fatalf("bad queue length: %d", eq.len())
+	}
+
+	//priority items come out first, in order, then the bulk items
+	if e, _, ok := eq.pop(); !ok || e != hotEnt {
+		t.Fatal("priority entry was not popped first")
+	} else if _, blk, ok := eq.pop(); !ok || len(blk) != 2 || blk[1] != hotBlk[1] {
+		t.Fatal("priority block was not popped second")
+	} else if e, _, ok := eq.pop(); !ok || e != bulkEnt {
+		t.Fatal("bulk entry out of order")
+	} else if _, blk, ok := eq.pop(); !ok || len(blk) != 1 || blk[0] != bulkBlk[0] {
+		t.Fatal("bulk block out of order")
+	} else if _, _, ok := eq.pop(); ok {
+		t.Fatal("queue should be empty")
+	}
+}
@@ -0,0 +1,177 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package processors
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gravwell/gravwell/v4/avro"
+	"github.com/gravwell/gravwell/v4/ingest/config"
+	"github.com/gravwell/gravwell/v4/ingest/entry"
+)
+
+const (
+	AvroDecodeProcessor string = `avrodecode`
+
+	avroSchemaExt = `.avsc`
+)
+
+var (
+	ErrAvroNoSchema = errors.New("Schema-File or Schema-Directory is required")
+)
+
+// AvroDecodeConfig decodes Avro binary payloads into JSON or enumerated values.  Payloads
+// are decoded with Schema-File, or when Schema-Directory is set, payloads in the Confluent
+// wire format are decoded with the schema named by their ID, such as 42.avsc.  Entries that
+// cannot be decoded are passed through untouched unless Drop-Misses is set.
+type AvroDecodeConfig struct {
+	Schema_File      string
+	Schema_Directory string
+	Output_Format    string // json or ev, default is json
+	EV_Prefix        string
+	Drop_Misses      bool
+}
+
+func AvroDecodeLoadConfig(vc *config.VariableConfig) (c AvroDecodeConfig, err error) {
+	if err = vc.MapTo(&c); err == nil {
+		_, err = c.validate()
+	}
+	return
+}
+
+func (c *AvroDecodeConfig) validate() (s *avro.Schema, err error) {
+	c.Schema_File, c.Schema_Directory = strings.TrimSpace(c.Schema_File), strings.TrimSpace(c.Schema_Directory)
+	if c.Schema_File == `` && c.Schema_Directory == `` {
+		err = ErrAvroNoSchema
+		return
+	} else if c.Output_Format, err = checkSchemaOutput(c.Output_Format, c.EV_Prefix); err != nil {
+		return
+	}
+	if c.Schema_Directory != `` {
+		if err = checkSchemaDirectory(c.Schema_Directory); err != nil {
+			return
+		}
+	}
+	if c.Schema_File != `` {
+		if s, err = avro.ParseSchemaFile(c.Schema_File); err != nil {
+			err = fmt.Errorf("failed to load Schema-File %s: %w", c.Schema_File, err)
+		}
+	}
+	return
+}
+
+type AvroDecoder struct {
+	nocloser
+	AvroDecodeConfig
+	schema *avro.Schema
+	dir    *schemaDirectory[avro.Schema]
+	now    func() time.Time
+}
+
+func NewAvroDecoder(cfg AvroDecodeConfig) (ad *AvroDecoder, err error) {
+	ad = &AvroDecoder{
+		now: time.Now,
+	}
+	if err = ad.init(cfg); err != nil {
+		ad = nil
+	}
+	return
+}
+
+func (ad *AvroDecoder) Config(v interface{}) (err error) {
+	if v == nil {
+		err = ErrNilConfig
+	} else if cfg, ok := v.(AvroDecodeConfig); ok {
+		err = ad.init(cfg)
+	} else {
+		err = fmt.Errorf("Invalid configuration, unknown type type %T", v)
+	}
+	return
+}
+
+func (ad *AvroDecoder) init(cfg AvroDecodeConfig) (err error) {
+	var s *avro.Schema
+	if s, err = cfg.validate(); err != nil {
+		return
+	}
+	ad.AvroDecodeConfig = cfg
+	ad.schema = s
+	ad.dir = nil
+	if cfg.Schema_Directory != `` {
+		ad.dir = newSchemaDirectory(cfg.Schema_Directory, avroSchemaExt, avro.ParseSchemaFile)
+	}
+	return
+}
+
+func (ad *AvroDecoder) Process(ents []*entry.Entry) (rset []*entry.Entry, err error) {
+	if len(ents) == 0 {
+		return
+	}
+	now := ad.now()
+	rset = ents[:0]
+	for _, ent := range ents {
+		if ent == nil {
+			continue
+		} else if derr := ad.decode(ent, now); derr != nil && ad.Drop_Misses {
+			continue
+		}
+		rset = append(rset, ent)
+	}
+	return
+}
+
+func (ad *AvroDecoder) decode(ent *entry.Entry, now time.Time) (err error) {
+	s, payload := ad.schema, ent.Data
+	if ad.dir != nil {
+		if id, p, ok := confluentFrame(ent.Data); ok {
+			if s, err = ad.dir.get(id, now); err != nil {
+				return
+			}
+			payload = p
+		}
+	}
+	if s == nil {
+		return ErrSchemaNotFound
+	}
+	var v interface{}
+	if v, err = s.Decode(payload); err != nil {
+		return
+	}
+	if ad.Output_Format == schemaOutputEV {
+		attachSchemaEVs(ent, ad.EV_Prefix, avroFields(v))
+		return
+	}
+	var b []byte
+	if b, err = json.Marshal(v); err == nil {
+		ent.Data = b
+	}
+	return
+}
+
+// avroFields returns the top level fields of records and maps, anything else is a single
+// field named value
+func avroFields(v interface{}) (flds []schemaField) {
+	switch t := v.(type) {
+	case *avro.Record:
+		for i, n := range t.Names {
+			flds = append(flds, schemaField{name: n, val: t.Values[i]})
+		}
+	case map[string]interface{}:
+		for k, x := range t {
+			flds = append(flds, schemaField{name: k, val: x})
+		}
+	default:
+		flds = append(flds, schemaField{name: `value`, val: v})
+	}
+	return
+}
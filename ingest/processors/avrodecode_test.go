@@ -0,0 +1,185 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package processors
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gravwell/gravwell/v4/ingest/entry"
+)
+
+const testAvroSchema = `{
+	"type": "record", "name": "Event", "namespace": "test",
+	"fields": [
+		{"name": "host", "type": "string"},
+		{"name": "count", "type": "long"},
+		{"name": "tags", "type": {"type": "array", "items": "string"}},
+		{"name": "note", "type": ["null", "string"]}
+	]
+}`
+
+func writeTestFile(t *testing.T, pth string, b []byte) {
+	t.Helper()
+	if err := os.WriteFile(pth, b, 0640); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// testAvroEvent is testAvroSchema encoded with host foo, count 99, tags a and b, and a null note
+func testAvroEvent() []byte {
+	return []byte{0x06, 'f', 'o', 'o', 0xc6, 0x01, 0x04, 0x02, 'a', 0x02, 'b', 0x00, 0x00}
+}
+
+func confluentTestFrame(id uint32, payload []byte) []byte {
+	b := make([]byte, confluentHeaderSize, confluentHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(b[1:], id)
+	return append(b, payload...)
+}
+
+func TestAvroDecodeConfig(t *testing.T) {
+	dir := t.TempDir()
+	pth := filepath.Join(dir, `event.avsc`)
+	writeTestFile(t, pth, []byte(testAvroSchema))
+	b := fmt.Sprintf(`
+	[preprocessor "avro"]
+		type = avrodecode
+		Schema-File=%q
+		Output-Format=EV
+		EV-Prefix=avro_
+	`, pth)
+	p, err := testLoadPreprocessor(b, `avro`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ad, ok := p.(*AvroDecoder)
+	if !ok {
+		t.Fatalf("preprocessor is the wrong type: %T != *AvroDecoder", p)
+	} else if ad.Output_Format != schemaOutputEV || ad.schema == nil || ad.dir != nil {
+		t.Fatalf("bad config: %+v", ad.AvroDecodeConfig)
+	}
+
+	bad := []AvroDecodeConfig{
+		{},
+		{Schema_File: pth, Output_Format: `xml`},
+		{Schema_File: filepath.Join(dir, `missing.avsc`)},
+		{Schema_Directory: pth},
+	}
+	for _, c := range bad {
+		if _, err = NewAvroDecoder(c); err == nil {
+			t.Fatalf("bad config %+v did not fail", c)
+		}
+	}
+}
+
+func TestAvroDecodeJSON(t *testing.T) {
+	dir := t.TempDir()
+	pth := filepath.Join(dir, `event.avsc`)
+	writeTestFile(t, pth, []byte(testAvroSchema))
+	ad, err := NewAvroDecoder(AvroDecodeConfig{Schema_File: pth})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ents := []*entry.Entry{
+		{Data: testAvroEvent()},
+		{Data: []byte(`not avro`)},
+	}
+	out, err := ad.Process(ents)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(out) != 2 {
+		t.Fatalf("invalid output count: %d", len(out))
+	} else if s := string(out[0].Data); s != `{"host":"foo","count":99,"tags":["a","b"],"note":null}` {
+		t.Fatalf("bad JSON: %s", s)
+	} else if string(out[1].Data) != `not avro` {
+		t.Fatalf("miss was modified: %s", out[1].Data)
+	}
+
+	//drop misses
+	ad.Drop_Misses = true
+	if out, err = ad.Process([]*entry.Entry{{Data: []byte(`not avro`)}}); err != nil {
+		t.Fatal(err)
+	} else if len(out) != 0 {
+		t.Fatalf("miss was not dropped")
+	}
+}
+
+func TestAvroDecodeEV(t *testing.T) {
+	dir := t.TempDir()
+	pth := filepath.Join(dir, `event.avsc`)
+	writeTestFile(t, pth, []byte(testAvroSchema))
+	ad, err := NewAvroDecoder(AvroDecodeConfig{Schema_File: pth, Output_Format: `ev`, EV_Prefix: `a.`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := testAvroEvent()
+	out, err := ad.Process([]*entry.Entry{{Data: data}})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(out) != 1 {
+		t.Fatalf("invalid output count: %d", len(out))
+	}
+	ent := out[0]
+	if string(ent.Data) != string(data) {
+		t.Fatalf("data was modified")
+	}
+	if ev, ok := ent.GetEnumeratedValue(`a.host`); !ok || ev != `foo` {
+		t.Fatalf("bad host: %v", ev)
+	} else if ev, ok = ent.GetEnumeratedValue(`a.count`); !ok || ev != int64(99) {
+		t.Fatalf("bad count: %v %T", ev, ev)
+	} else if ev, ok = ent.GetEnumeratedValue(`a.tags`); !ok || ev != `["a","b"]` {
+		t.Fatalf("bad tags: %v", ev)
+	} else if _, ok = ent.GetEnumeratedValue(`a.note`); ok {
+		t.Fatalf("null value was attached")
+	}
+}
+
+func TestAvroDecodeDirectory(t *testing.T) {
+	dir := t.TempDir()
+	ad, err := NewAvroDecoder(AvroDecodeConfig{Schema_Directory: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	ad.now = func() time.Time { return now }
+	frame := confluentTestFrame(42, testAvroEvent())
+
+	//schema is not there yet, so the entry passes through
+	out, err := ad.Process([]*entry.Entry{{Data: frame}})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(out) != 1 || string(out[0].Data) != string(frame) {
+		t.Fatalf("miss was modified")
+	}
+
+	//drop the schema in, it is not retried until the retry interval passes
+	writeTestFile(t, filepath.Join(dir, `42.avsc`), []byte(testAvroSchema))
+	if out, err = ad.Process([]*entry.Entry{{Data: frame}}); err != nil {
+		t.Fatal(err)
+	} else if string(out[0].Data) != string(frame) {
+		t.Fatalf("missed schema was retried early")
+	}
+	now = now.Add(schemaRetryInterval)
+	if out, err = ad.Process([]*entry.Entry{{Data: frame}}); err != nil {
+		t.Fatal(err)
+	} else if s := string(out[0].Data); s != `{"host":"foo","count":99,"tags":["a","b"],"note":null}` {
+		t.Fatalf("bad JSON: %s", s)
+	}
+
+	//unframed data has no schema to fall back on
+	if _, err = ad.Process([]*entry.Entry{{Data: testAvroEvent()}}); err != nil {
+		t.Fatal(err)
+	} else if err = ad.decode(&entry.Entry{Data: testAvroEvent()}, now); err != ErrSchemaNotFound {
+		t.Fatalf("unframed entry did not fail: %v", err)
+	}
+}
@@ -0,0 +1,422 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package processors
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gravwell/gravwell/v4/ingest/config"
+	"github.com/gravwell/gravwell/v4/ingest/entry"
+	"github.com/gravwell/gravwell/v4/timegrinder"
+)
+
+const (
+	CEFProcessor  string = `cef`
+	LEEFProcessor string = `leef`
+
+	cefPrefix  = `CEF:`
+	leefPrefix = `LEEF:`
+
+	defaultCEFTimestampKey  = `rt`
+	defaultLEEFTimestampKey = `devTime`
+
+	cefExtensionsName = `Extensions`
+	leefAttrsName     = `Attributes`
+)
+
+var (
+	cefHeaderNames  = []string{`CEFVersion`, `DeviceVendor`, `DeviceProduct`, `DeviceVersion`, `SignatureID`, `Name`, `Severity`}
+	leefHeaderNames = []string{`LEEFVersion`, `Vendor`, `Product`, `Version`, `EventID`}
+)
+
+// CEFConfig is shared by the cef and leef preprocessors.  Entries are rewritten as a JSON
+// object holding the header fields and an object of extensions (CEF) or attributes (LEEF).
+type CEFConfig struct {
+	Enumerated_Values     bool   // attach header fields and extensions as enumerated values instead of rewriting the entry
+	Drop_Misses           bool   // drop entries that are not CEF or LEEF
+	Timestamp_Key         string // key holding the event time, default is rt for CEF and devTime for LEEF
+	Ignore_Timestamps     bool   // do not set the entry timestamp from the event
+	Timestamp_Override    string
+	Assume_Local_Timezone bool
+}
+
+func CEFLoadConfig(vc *config.VariableConfig) (c CEFConfig, err error) {
+	if err = vc.MapTo(&c); err == nil {
+		err = c.validate()
+	}
+	return
+}
+
+// LEEFLoadConfig loads the configuration for a leef preprocessor, the config is the same as cef.
+func LEEFLoadConfig(vc *config.VariableConfig) (c CEFConfig, err error) {
+	return CEFLoadConfig(vc)
+}
+
+func (c CEFConfig) validate() (err error) {
+	if ov := strings.TrimSpace(c.Timestamp_Override); ov != `` {
+		err = timegrinder.ValidateFormatOverride(ov)
+	}
+	return
+}
+
+type cefPair struct {
+	key string
+	val string
+}
+
+// CEF parses ArcSight CEF or IBM QRadar LEEF events, the event may be preceded by a syslog header.
+type CEF struct {
+	nocloser
+	CEFConfig
+	leef bool
+	tg   *timegrinder.TimeGrinder
+	bb   bytes.Buffer
+	hdr  []string
+	ext  []cefPair
+}
+
+func NewCEF(cfg CEFConfig) (*CEF, error) {
+	return newCEF(cfg, false)
+}
+
+func NewLEEF(cfg CEFConfig) (*CEF, error) {
+	return newCEF(cfg, true)
+}
+
+func newCEF(cfg CEFConfig, leef bool) (c *CEF, err error) {
+	if err = cfg.validate(); err != nil {
+		return
+	}
+	c = &CEF{
+		CEFConfig: cfg,
+		leef:      leef,
+	}
+	if err = c.setup(); err != nil {
+		c = nil
+	}
+	return
+}
+
+func (c *CEF) setup() (err error) {
+	if c.Timestamp_Key == `` {
+		if c.leef {
+			c.Timestamp_Key = defaultLEEFTimestampKey
+		} else {
+			c.Timestamp_Key = defaultCEFTimestampKey
+		}
+	}
+	if c.Ignore_Timestamps {
+		c.tg = nil
+		return
+	}
+	if c.tg, err = timegrinder.New(timegrinder.Config{FormatOverride: c.Timestamp_Override}); err != nil {
+		return
+	}
+	if c.Assume_Local_Timezone {
+		c.tg.SetLocalTime()
+	}
+	return
+}
+
+func (c *CEF) Config(v interface{}) (err error) {
+	if v == nil {
+		err = ErrNilConfig
+	} else if cfg, ok := v.(CEFConfig); ok {
+		if err = cfg.validate(); err == nil {
+			c.CEFConfig = cfg
+			err = c.setup()
+		}
+	} else {
+		err = fmt.Errorf("Invalid configuration, unknown type type %T", v)
+	}
+	return
+}
+
+func (c *CEF) Process(ents []*entry.Entry) (rset []*entry.Entry, err error) {
+	if len(ents) == 0 {
+		return
+	}
+	rset = ents[:0]
+	for _, ent := range ents {
+		if ent == nil {
+			continue
+		} else if ent = c.processItem(ent); ent != nil {
+			rset = append(rset, ent)
+		}
+	}
+	return
+}
+
+func (c *CEF) processItem(ent *entry.Entry) *entry.Entry {
+	var ok bool
+	if c.leef {
+		c.hdr, c.ext, ok = parseLEEF(string(ent.Data), c.hdr[:0], c.ext[:0])
+	} else {
+		c.hdr, c.ext, ok = parseCEF(string(ent.Data), c.hdr[:0], c.ext[:0])
+	}
+	if !ok {
+		if c.Drop_Misses {
+			return nil
+		}
+		return ent
+	}
+	if c.tg != nil {
+		if v, ok := c.lookup(c.Timestamp_Key); ok {
+			if ts, ok := c.extractTimestamp(v); ok {
+				ent.TS = ts
+			}
+		}
+	}
+
+	names, extName := cefHeaderNames, cefExtensionsName
+	if c.leef {
+		names, extName = leefHeaderNames, leefAttrsName
+	}
+	if c.Enumerated_Values {
+		for i, v := range c.hdr {
+			ent.AddEnumeratedValueEx(names[i], v)
+		}
+		for _, p := range c.ext {
+			ent.AddEnumeratedValueEx(p.key, p.val)
+		}
+		return ent
+	}
+
+	c.bb.Reset()
+	c.bb.WriteByte('{')
+	for i, v := range c.hdr {
+		writeJSONString(&c.bb, names[i])
+		c.bb.WriteByte(':')
+		writeJSONString(&c.bb, v)
+		c.bb.WriteByte(',')
+	}
+	writeJSONString(&c.bb, extName)
+	c.bb.WriteString(`:{`)
+	for i, p := range c.ext {
+		if c.firstIndex(p.key) != i {
+			continue //first instance of a key wins
+		}
+		if i > 0 {
+			c.bb.WriteByte(',')
+		}
+		writeJSONString(&c.bb, p.key)
+		c.bb.WriteByte(':')
+		writeJSONString(&c.bb, p.val)
+	}
+	c.bb.WriteString(`}}`)
+	ent.Data = append([]byte{}, c.bb.Bytes()...) //force allocation
+	return ent
+}
+
+func (c *CEF) firstIndex(key string) int {
+	for i, p := range c.ext {
+		if p.key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+func (c *CEF) lookup(key string) (v string, ok bool) {
+	if idx := c.firstIndex(key); idx != -1 {
+		v, ok = c.ext[idx].val, true
+	}
+	return
+}
+
+// extractTimestamp handles the epoch millisecond form allowed by CEF and LEEF
+// before handing the value to timegrinder.
+func (c *CEF) extractTimestamp(v string) (ts entry.Timestamp, ok bool) {
+	if v = strings.TrimSpace(v); v == `` {
+		return
+	}
+	if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+		if n > 100000000000 {
+			ts = entry.UnixTime(n/1000, (n%1000)*1000000)
+		} else {
+			ts = entry.UnixTime(n, 0)
+		}
+		ok = true
+		return
+	}
+	if t, tok, err := c.tg.Extract([]byte(v)); err == nil && tok {
+		ts, ok = entry.FromStandard(t), true
+	}
+	return
+}
+
+// parseCEF parses a CEF event of the form
+//
+//	CEF:Version|Device Vendor|Device Product|Device Version|Signature ID|Name|Severity|Extension
+//
+// anything ahead of the CEF: prefix, such as a syslog header, is ignored.
+func parseCEF(s string, hdr []string, ext []cefPair) ([]string, []cefPair, bool) {
+	idx := strings.Index(s, cefPrefix)
+	if idx == -1 {
+		return hdr, ext, false
+	}
+	s = s[idx+len(cefPrefix):]
+	for len(hdr) < len(cefHeaderNames) {
+		end := indexUnescaped(s, '|')
+		if end == -1 {
+			return hdr, ext, false
+		}
+		hdr = append(hdr, unescapeCEF(s[:end], false))
+		s = s[end+1:]
+	}
+	return hdr, parseCEFExtension(s, ext), true
+}
+
+// parseCEFExtension parses space separated key=value pairs where values may contain spaces.
+// A value ends at the last space before the next key.  Equal signs are supposed to be escaped
+// in values, but plenty of producers do not, so an equal sign is only treated as a pair
+// separator if the text ahead of it looks like a key.
+func parseCEFExtension(s string, ext []cefPair) []cefPair {
+	var key string
+	var valStart int
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+			continue
+		case '=':
+		default:
+			continue
+		}
+		ks := strings.LastIndexByte(s[:i], ' ') + 1
+		if ks < valStart || !isCEFKey(s[ks:i]) {
+			continue
+		}
+		if key != `` {
+			ext = append(ext, cefPair{key: key, val: unescapeCEF(strings.TrimRight(s[valStart:ks], " "), true)})
+		}
+		key, valStart = s[ks:i], i+1
+	}
+	if key != `` {
+		ext = append(ext, cefPair{key: key, val: unescapeCEF(strings.TrimRight(s[valStart:], " \r\n"), true)})
+	}
+	return ext
+}
+
+func isCEFKey(k string) bool {
+	if k == `` {
+		return false
+	}
+	for _, r := range k {
+		switch {
+		case r >= 'a' && r <= 'z':
+		case r >= 'A' && r <= 'Z':
+		case r >= '0' && r <= '9':
+		case r == '_' || r == '.' || r == '-' || r == '[' || r == ']':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// parseLEEF parses a LEEF 1.0 or 2.0 event of the form
+//
+//	LEEF:Version|Vendor|Product|Version|EventID|[Delimiter|]Attributes
+//
+// the delimiter field is only present in LEEF 2.0, attributes default to being tab delimited.
+func parseLEEF(s string, hdr []string, ext []cefPair) ([]string, []cefPair, bool) {
+	idx := strings.Index(s, leefPrefix)
+	if idx == -1 {
+		return hdr, ext, false
+	}
+	s = s[idx+len(leefPrefix):]
+	for len(hdr) < len(leefHeaderNames) {
+		end := indexUnescaped(s, '|')
+		if end == -1 {
+			return hdr, ext, false
+		}
+		hdr = append(hdr, unescapeCEF(s[:end], false))
+		s = s[end+1:]
+	}
+	delim := "\t"
+	if strings.HasPrefix(hdr[0], `2`) {
+		if end := strings.IndexByte(s, '|'); end != -1 {
+			if d, ok := leefDelimiter(s[:end]); ok {
+				delim, s = d, s[end+1:]
+			}
+		}
+	}
+	for _, attr := range strings.Split(strings.TrimRight(s, "\r\n"), delim) {
+		if k, v, ok := strings.Cut(attr, `=`); ok && k != `` {
+			ext = append(ext, cefPair{key: strings.TrimSpace(k), val: v})
+		}
+	}
+	return hdr, ext, true
+}
+
+// leefDelimiter decodes a LEEF 2.0 delimiter, which is either a single character
+// or a hex value such as x09 or 0x09.  An empty delimiter means the default tab.
+func leefDelimiter(s string) (string, bool) {
+	if s == `` {
+		return "\t", true
+	} else if len(s) == 1 {
+		return s, true
+	}
+	h := strings.TrimPrefix(strings.TrimPrefix(strings.ToLower(s), `0`), `x`)
+	if len(h) == len(s) || len(h) == 0 || len(h) > 4 {
+		return ``, false
+	}
+	v, err := strconv.ParseUint(h, 16, 16)
+	if err != nil || v == 0 {
+		return ``, false
+	}
+	return string(rune(v)), true
+}
+
+func indexUnescaped(s string, c byte) int {
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' {
+			i++
+		} else if s[i] == c {
+			return i
+		}
+	}
+	return -1
+}
+
+// unescapeCEF removes CEF escaping, headers escape pipes and backslashes while
+// extensions escape equal signs, backslashes, and newlines.
+func unescapeCEF(s string, extension bool) string {
+	if strings.IndexByte(s, '\\') == -1 {
+		return s
+	}
+	var sb strings.Builder
+	sb.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			sb.WriteByte(s[i])
+			continue
+		}
+		switch n := s[i+1]; {
+		case n == '\\' || n == '|':
+			sb.WriteByte(n)
+		case extension && n == '=':
+			sb.WriteByte(n)
+		case extension && n == 'n':
+			sb.WriteByte('\n')
+		case extension && n == 'r':
+			sb.WriteByte('\r')
+		default:
+			//not an escape we know about, keep it as is
+			sb.WriteByte('\\')
+			sb.WriteByte(n)
+		}
+		i++
+	}
+	return sb.String()
+}
@@ -0,0 +1,189 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package processors
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gravwell/gravwell/v4/ingest/entry"
+)
+
+const (
+	testCEF = `<134>Feb 14 19:04:54 fw01 CEF:0|Security|threat\|manager|1.0|100|worm successfully stopped|10|` +
+		`src=10.0.0.1 dst=2.1.2.2 spt=1232 msg=Detected a threat. No action needed\=true request=http://x.com/?a=b rt=1700000000123 cs1Label=path cs1=c:\\temp`
+	testLEEF1 = "LEEF:1.0|Microsoft|MSExchange|4.0 SP1|15345|src=192.0.2.0\tdst=172.50.123.1\tsev=5\tcat=anomaly\tdevTime=Nov 14 2023 22:13:20"
+	testLEEF2 = "<13>Jan 1 host LEEF:2.0|Lancope|StealthWatch|1.0|41|^|src=10.0.1.8^dst=10.0.0.5^sev=5^msg=a|b c"
+)
+
+func TestCEFConfig(t *testing.T) {
+	b := `
+	[preprocessor "cef"]
+		type = cef
+		Enumerated-Values=true
+		Drop-Misses=true
+	`
+	p, err := testLoadPreprocessor(b, `cef`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, ok := p.(*CEF)
+	if !ok {
+		t.Fatalf("preprocessor is the wrong type: %T != *CEF", p)
+	} else if c.leef || !c.Enumerated_Values || !c.Drop_Misses || c.Timestamp_Key != `rt` {
+		t.Fatalf("bad config: %+v", c.CEFConfig)
+	}
+	b = `
+	[preprocessor "leef"]
+		type = leef
+	`
+	if p, err = testLoadPreprocessor(b, `leef`); err != nil {
+		t.Fatal(err)
+	} else if c, ok = p.(*CEF); !ok || !c.leef || c.Timestamp_Key != `devTime` {
+		t.Fatalf("bad leef preprocessor: %T %+v", p, p)
+	}
+	b = `
+	[preprocessor "cef"]
+		type = cef
+		Timestamp-Override="foobar"
+	`
+	if _, err = testLoadPreprocessor(b, `cef`); err == nil {
+		t.Fatal("failed to catch bad timestamp override")
+	}
+}
+
+func TestCEFParse(t *testing.T) {
+	hdr, ext, ok := parseCEF(testCEF, nil, nil)
+	if !ok {
+		t.Fatal("failed to parse")
+	}
+	if want := []string{`0`, `Security`, `threat|manager`, `1.0`, `100`, `worm successfully stopped`, `10`}; !reflect.DeepEqual(hdr, want) {
+		t.Fatalf("bad header:\n%q\n%q", hdr, want)
+	}
+	want := []cefPair{
+		{`src`, `10.0.0.1`},
+		{`dst`, `2.1.2.2`},
+		{`spt`, `1232`},
+		{`msg`, `Detected a threat. No action needed=true`},
+		{`request`, `http://x.com/?a=b`},
+		{`rt`, `1700000000123`},
+		{`cs1Label`, `path`},
+		{`cs1`, `c:\temp`},
+	}
+	if !reflect.DeepEqual(ext, want) {
+		t.Fatalf("bad extensions:\n%q\n%q", ext, want)
+	}
+
+	if _, _, ok = parseCEF(`CEF:0|a|b|c|d|e`, nil, nil); ok {
+		t.Fatal("parsed a truncated header")
+	} else if _, _, ok = parseCEF(`just a log`, nil, nil); ok {
+		t.Fatal("parsed a non CEF entry")
+	}
+	//an empty extension is fine
+	if hdr, ext, ok = parseCEF(`CEF:0|a|b|c|d|e|1|`, nil, nil); !ok || len(hdr) != 7 || len(ext) != 0 {
+		t.Fatalf("bad empty extension parse: %v %q %q", ok, hdr, ext)
+	}
+}
+
+func TestLEEFParse(t *testing.T) {
+	hdr, ext, ok := parseLEEF(testLEEF1, nil, nil)
+	if !ok {
+		t.Fatal("failed to parse")
+	} else if want := []string{`1.0`, `Microsoft`, `MSExchange`, `4.0 SP1`, `15345`}; !reflect.DeepEqual(hdr, want) {
+		t.Fatalf("bad header:\n%q\n%q", hdr, want)
+	} else if len(ext) != 5 || ext[0] != (cefPair{`src`, `192.0.2.0`}) || ext[4] != (cefPair{`devTime`, `Nov 14 2023 22:13:20`}) {
+		t.Fatalf("bad attributes: %q", ext)
+	}
+
+	if hdr, ext, ok = parseLEEF(testLEEF2, nil, nil); !ok {
+		t.Fatal("failed to parse")
+	} else if hdr[0] != `2.0` || hdr[4] != `41` {
+		t.Fatalf("bad header: %q", hdr)
+	} else if want := []cefPair{{`src`, `10.0.1.8`}, {`dst`, `10.0.0.5`}, {`sev`, `5`}, {`msg`, `a|b c`}}; !reflect.DeepEqual(ext, want) {
+		t.Fatalf("bad attributes:\n%q\n%q", ext, want)
+	}
+
+	//hex delimiter
+	if _, ext, ok = parseLEEF(`LEEF:2.0|V|P|1|E|0x7c|a=1|b=2`, nil, nil); !ok || len(ext) != 2 || ext[1] != (cefPair{`b`, `2`}) {
+		t.Fatalf("bad hex delimiter parse: %v %q", ok, ext)
+	}
+	for _, v := range []struct {
+		in  string
+		out string
+		ok  bool
+	}{{``, "\t", true}, {`^`, `^`, true}, {`x09`, "\t", true}, {`0x5E`, `^`, true}, {`xyz`, ``, false}, {`ab`, ``, false}} {
+		if d, ok := leefDelimiter(v.in); ok != v.ok || d != v.out {
+			t.Fatalf("bad delimiter %q: %q %v", v.in, d, ok)
+		}
+	}
+}
+
+func TestCEFProcessJSON(t *testing.T) {
+	c, err := NewCEF(CEFConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ents, err := c.Process([]*entry.Entry{{Data: []byte(testCEF)}, {Data: []byte(`not cef`)}})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(ents) != 2 {
+		t.Fatalf("bad entry count %d", len(ents))
+	} else if string(ents[1].Data) != `not cef` {
+		t.Fatalf("miss was modified: %s", ents[1].Data)
+	}
+	var obj struct {
+		DeviceProduct string
+		Severity      string
+		Extensions    map[string]string
+	}
+	if err = json.Unmarshal(ents[0].Data, &obj); err != nil {
+		t.Fatalf("bad JSON %v: %s", err, ents[0].Data)
+	} else if obj.DeviceProduct != `threat|manager` || obj.Severity != `10` || obj.Extensions[`cs1`] != `c:\temp` || len(obj.Extensions) != 8 {
+		t.Fatalf("bad output: %s", ents[0].Data)
+	}
+	if want := entry.UnixTime(1700000000, 123000000); ents[0].TS != want {
+		t.Fatalf("bad timestamp: %v != %v", ents[0].TS, want)
+	}
+
+	if c, err = NewCEF(CEFConfig{Drop_Misses: true, Ignore_Timestamps: true}); err != nil {
+		t.Fatal(err)
+	}
+	ts := entry.Now()
+	if ents, err = c.Process([]*entry.Entry{{TS: ts, Data: []byte(testCEF)}, {Data: []byte(`not cef`)}}); err != nil {
+		t.Fatal(err)
+	} else if len(ents) != 1 {
+		t.Fatalf("miss not dropped: %d", len(ents))
+	} else if ents[0].TS != ts {
+		t.Fatal("timestamp updated when ignoring timestamps")
+	}
+}
+
+func TestLEEFProcessEVs(t *testing.T) {
+	c, err := NewLEEF(CEFConfig{Enumerated_Values: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ents, err := c.Process([]*entry.Entry{{Data: []byte(testLEEF1)}})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(ents) != 1 || string(ents[0].Data) != testLEEF1 {
+		t.Fatalf("bad entries: %v", ents)
+	}
+	for k, v := range map[string]string{`Vendor`: `Microsoft`, `EventID`: `15345`, `dst`: `172.50.123.1`, `cat`: `anomaly`} {
+		if val, ok := ents[0].GetEnumeratedValue(k); !ok || val != v {
+			t.Fatalf("bad EV %s: %v", k, val)
+		}
+	}
+	want := time.Date(2023, time.November, 14, 22, 13, 20, 0, time.UTC)
+	if got := ents[0].TS.StandardTime().UTC(); !got.Equal(want) {
+		t.Fatalf("bad timestamp: %v != %v", got, want)
+	}
+}
@@ -0,0 +1,414 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package processors
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gravwell/gravwell/v4/ingest/config"
+	"github.com/gravwell/gravwell/v4/ingest/entry"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zlib"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+	"github.com/ulikunitz/xz"
+)
+
+const (
+	DecompressProcessor string = `decompress`
+
+	formatAuto  = `auto`
+	formatGzip  = `gzip`
+	formatZlib  = `zlib`
+	formatZstd  = `zstd`
+	formatXZ    = `xz`
+	formatBzip2 = `bzip2`
+	formatLZ4   = `lz4`
+
+	// zstd windows and xz dictionaries are allowed to be at least this large even if the buffer is smaller
+	minDecompressWindow = 8 * mb
+
+	xzHeaderSize  = 12 // stream header and stream footer
+	xzLZMA2Filter = 0x21
+)
+
+var (
+	ErrUnknownCompression   = errors.New("Input is not in a known compression format")
+	ErrInvalidCompression   = errors.New("Format must be auto, gzip, zlib, zstd, xz, bzip2, or lz4")
+	ErrDecompressedTooLarge = errors.New("Decompressed data exceeds Max-Buff-MB")
+	ErrInvalidXZ            = errors.New("Malformed xz stream")
+	ErrXZDictTooLarge       = errors.New("xz dictionary exceeds Max-Buff-MB")
+
+	zstdMagic  = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	xzMagic    = []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}
+	lz4Magic   = []byte{0x04, 0x22, 0x4d, 0x18}
+	bzip2Magic = []byte(`BZh`)
+)
+
+// DecompressConfig decompresses entries in a fixed format, or detects the format from
+// the leading magic bytes when Format is auto.  Buffers are sized as with the gzip
+// preprocessor, and Max-Buff-MB also caps the size of a decompressed entry so a small
+// compressed bomb cannot exhaust memory.  Entries which fail to decompress are dropped.
+type DecompressConfig struct {
+	Format              string // auto, gzip, zlib, zstd, xz, bzip2, or lz4, default is auto
+	Passthrough_Unknown bool   // pass entries with no recognized format through untouched
+	Min_Buff_MB         uint
+	Max_Buff_MB         uint
+}
+
+func DecompressLoadConfig(vc *config.VariableConfig) (c DecompressConfig, err error) {
+	if err = vc.MapTo(&c); err == nil {
+		err = c.validate()
+	}
+	return
+}
+
+func (c *DecompressConfig) validate() (err error) {
+	switch c.Format = strings.ToLower(strings.TrimSpace(c.Format)); c.Format {
+	case ``:
+		c.Format = formatAuto
+	case formatAuto, formatGzip, formatZlib, formatZstd, formatXZ, formatBzip2, formatLZ4:
+	default:
+		err = fmt.Errorf("%q: %w", c.Format, ErrInvalidCompression)
+	}
+	return
+}
+
+func (c DecompressConfig) BufferSizes() (base, max int) {
+	return GzipDecompressorConfig{Min_Buff_MB: c.Min_Buff_MB, Max_Buff_MB: c.Max_Buff_MB}.BufferSizes()
+}
+
+// Decompressor keeps a reader for each format so they can be reset rather than
+// allocated for every entry
+type Decompressor struct {
+	DecompressConfig
+	rdr      *bytes.Reader
+	gzrdr    *gzip.Reader
+	zstdrdr  *zstd.Decoder
+	lz4rdr   *lz4.Reader
+	bb       *bytes.Buffer
+	baseBuff int
+	maxBuff  int
+}
+
+func NewDecompressor(cfg DecompressConfig) (d *Decompressor, err error) {
+	d = &Decompressor{
+		rdr:    bytes.NewReader(nil),
+		gzrdr:  new(gzip.Reader),
+		lz4rdr: lz4.NewReader(nil),
+	}
+	if err = d.init(cfg); err != nil {
+		d = nil
+	}
+	return
+}
+
+func (d *Decompressor) Config(v interface{}) (err error) {
+	if v == nil {
+		err = ErrNilConfig
+	} else if cfg, ok := v.(DecompressConfig); ok {
+		err = d.init(cfg)
+	} else {
+		err = fmt.Errorf("Invalid configuration, unknown type type %T", v)
+	}
+	return
+}
+
+func (d *Decompressor) init(cfg DecompressConfig) (err error) {
+	if err = cfg.validate(); err != nil {
+		return
+	}
+	base, maxBuff := cfg.BufferSizes()
+	var zr *zstd.Decoder
+	//decoders are expected to support 8MB windows, so do not go below that even if the buffer is smaller
+	window := uint64(max(maxBuff, minDecompressWindow))
+	if zr, err = zstd.NewReader(nil, zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxWindow(window)); err != nil {
+		return
+	}
+	if d.zstdrdr != nil {
+		d.zstdrdr.Close()
+	}
+	d.DecompressConfig = cfg
+	d.zstdrdr = zr
+	d.bb = bytes.NewBuffer(make([]byte, 0, base))
+	d.baseBuff, d.maxBuff = base, maxBuff
+	return
+}
+
+func (d *Decompressor) Process(ents []*entry.Entry) ([]*entry.Entry, error) {
+	if len(ents) == 0 {
+		return nil, nil
+	}
+	rset := ents[:0]
+	for _, ent := range ents {
+		if ent == nil {
+			continue
+		} else if err := d.procEnt(ent); err == nil {
+			rset = append(rset, ent)
+		}
+	}
+	return rset, nil
+}
+
+func (d *Decompressor) Flush() []*entry.Entry {
+	return nil
+}
+
+func (d *Decompressor) Close() error {
+	if d.zstdrdr != nil {
+		d.zstdrdr.Close()
+		d.zstdrdr = nil
+	}
+	return nil
+}
+
+func (d *Decompressor) procEnt(ent *entry.Entry) (err error) {
+	format := d.Format
+	if format == formatAuto {
+		if format = detectCompression(ent.Data); format == `` {
+			if !d.Passthrough_Unknown {
+				err = ErrUnknownCompression
+			}
+			return
+		}
+	}
+	if format == formatXZ {
+		//the xz reader allocates whatever dictionary a block header asks for, check them first
+		var dict int64
+		if dict, err = xzMaxDict(ent.Data); err != nil {
+			return
+		} else if dict > int64(max(d.maxBuff, minDecompressWindow)) {
+			err = ErrXZDictTooLarge
+			return
+		}
+	}
+	d.rdr.Reset(ent.Data)
+	var zr io.Reader
+	if zr, err = d.reader(format); err != nil {
+		return
+	}
+	d.bb.Reset()
+	//read one byte past the limit so we can tell a full buffer from an oversized one
+	if _, err = io.Copy(d.bb, io.LimitReader(zr, int64(d.maxBuff)+1)); err == nil {
+		if d.bb.Len() > d.maxBuff {
+			err = ErrDecompressedTooLarge
+		} else {
+			ent.Data = append(nb, d.bb.Bytes()...)
+		}
+	}
+	if d.bb.Cap() > d.maxBuff {
+		d.bb = bytes.NewBuffer(make([]byte, 0, d.baseBuff))
+	}
+	return
+}
+
+func (d *Decompressor) reader(format string) (zr io.Reader, err error) {
+	switch format {
+	case formatGzip:
+		if err = d.gzrdr.Reset(d.rdr); err == nil {
+			zr = d.gzrdr
+		}
+	case formatZlib:
+		zr, err = zlib.NewReader(d.rdr)
+	case formatZstd:
+		if err = d.zstdrdr.Reset(d.rdr); err == nil {
+			zr = d.zstdrdr
+		}
+	case formatXZ:
+		zr, err = xz.NewReader(d.rdr)
+	case formatBzip2:
+		zr = bzip2.NewReader(d.rdr)
+	case formatLZ4:
+		d.lz4rdr.Reset(d.rdr)
+		zr = d.lz4rdr
+	default:
+		err = ErrUnknownCompression
+	}
+	return
+}
+
+// detectCompression identifies the format from the magic bytes, zlib has no magic so
+// its header checksum is used and preset dictionaries are rejected
+func detectCompression(b []byte) string {
+	switch {
+	case len(b) > 2 && b[0] == 0x1f && b[1] == 0x8b:
+		return formatGzip
+	case bytes.HasPrefix(b, zstdMagic):
+		return formatZstd
+	case bytes.HasPrefix(b, xzMagic):
+		return formatXZ
+	case bytes.HasPrefix(b, lz4Magic):
+		return formatLZ4
+	case len(b) > 3 && bytes.HasPrefix(b, bzip2Magic) && b[3] >= '1' && b[3] <= '9':
+		return formatBzip2
+	case len(b) > 2 && b[0]&0x0f == 8 && b[0]>>4 <= 7 && b[1]&0x20 == 0 && (uint16(b[0])<<8|uint16(b[1]))%31 == 0:
+		return formatZlib
+	}
+	return ``
+}
+
+// xzMaxDict walks the streams and blocks of an xz file and returns the largest LZMA2
+// dictionary requested by any block.  Blocks are skipped using the LZMA2 chunk headers,
+// which is how the xz reader finds the next block header.
+func xzMaxDict(b []byte) (dict int64, err error) {
+	for len(b) > 0 {
+		if len(b) < xzHeaderSize || !bytes.HasPrefix(b, xzMagic) {
+			return 0, ErrInvalidXZ
+		}
+		check := xzCheckSize(b[7] & 0x0f)
+		b = b[xzHeaderSize:]
+		//blocks run until the index indicator
+		for len(b) > 0 && b[0] != 0 {
+			hlen := (int(b[0]) + 1) * 4
+			if hlen > len(b) {
+				return 0, ErrInvalidXZ
+			}
+			var dc int64
+			var n int
+			if dc, err = xzBlockDict(b[:hlen]); err != nil {
+				return
+			} else if n, err = lzma2Size(b[hlen:]); err != nil {
+				return
+			}
+			dict = max(dict, dc)
+			n += hlen
+			n += (4-n%4)%4 + check
+			if n > len(b) {
+				return 0, ErrInvalidXZ
+			}
+			b = b[n:]
+		}
+		var n int
+		if n, err = xzIndexSize(b); err != nil {
+			return
+		} else if n += xzHeaderSize; n > len(b) {
+			return 0, ErrInvalidXZ
+		}
+		b = b[n:]
+		//streams can be padded with null words
+		for len(b) >= 4 && binary.LittleEndian.Uint32(b) == 0 {
+			b = b[4:]
+		}
+	}
+	return
+}
+
+// xzCheckSize returns the size of the check field following each block
+func xzCheckSize(id byte) int {
+	if id == 0 {
+		return 0
+	}
+	return 4 << ((id - 1) / 3)
+}
+
+// xzBlockDict returns the LZMA2 dictionary size in a block header
+func xzBlockDict(hdr []byte) (dict int64, err error) {
+	if len(hdr) < 8 {
+		return 0, ErrInvalidXZ
+	}
+	flags := hdr[1]
+	b := hdr[2 : len(hdr)-4] //drop the CRC
+	//skip the optional compressed and uncompressed sizes
+	for _, bit := range []byte{0x40, 0x80} {
+		if flags&bit == 0 {
+			continue
+		} else if b, err = xzSkipVarint(b); err != nil {
+			return
+		}
+	}
+	for i := 0; i < int(flags&0x3)+1; i++ {
+		id, n := binary.Uvarint(b)
+		if n <= 0 {
+			return 0, ErrInvalidXZ
+		}
+		b = b[n:]
+		sz, n := binary.Uvarint(b)
+		if n <= 0 || sz > uint64(len(b)-n) {
+			return 0, ErrInvalidXZ
+		}
+		props := b[n : n+int(sz)]
+		b = b[n+int(sz):]
+		if id != xzLZMA2Filter {
+			continue
+		} else if len(props) != 1 || props[0] > 40 {
+			return 0, ErrInvalidXZ
+		} else if props[0] == 40 {
+			dict = 1<<32 - 1
+		} else {
+			dict = int64(2|props[0]&1) << (props[0]/2 + 11)
+		}
+	}
+	return
+}
+
+func xzSkipVarint(b []byte) ([]byte, error) {
+	if _, n := binary.Uvarint(b); n > 0 {
+		return b[n:], nil
+	}
+	return nil, ErrInvalidXZ
+}
+
+// lzma2Size returns the length of the LZMA2 chunks at the start of b, including the end marker
+func lzma2Size(b []byte) (n int, err error) {
+	for n < len(b) {
+		c := b[n]
+		switch {
+		case c == 0:
+			return n + 1, nil
+		case c == 1 || c == 2:
+			//uncompressed chunk
+			if n+3 > len(b) {
+				return 0, ErrInvalidXZ
+			}
+			n += 3 + int(binary.BigEndian.Uint16(b[n+1:])) + 1
+		case c >= 0x80:
+			hlen := 5
+			if c >= 0xc0 {
+				hlen++ //new properties
+			}
+			if n+hlen > len(b) {
+				return 0, ErrInvalidXZ
+			}
+			n += hlen + int(binary.BigEndian.Uint16(b[n+3:])) + 1
+		default:
+			return 0, ErrInvalidXZ
+		}
+	}
+	return 0, ErrInvalidXZ
+}
+
+// xzIndexSize returns the length of the stream index at the start of b
+func xzIndexSize(b []byte) (n int, err error) {
+	if len(b) == 0 || b[0] != 0 {
+		return 0, ErrInvalidXZ
+	}
+	rem := b[1:]
+	count, vn := binary.Uvarint(rem)
+	if vn <= 0 {
+		return 0, ErrInvalidXZ
+	}
+	rem = rem[vn:]
+	//every record is an unpadded and an uncompressed size
+	for i := uint64(0); i < 2*count; i++ {
+		if rem, err = xzSkipVarint(rem); err != nil {
+			return
+		}
+	}
+	n = len(b) - len(rem)
+	if n += (4-n%4)%4 + 4; n > len(b) {
+		return 0, ErrInvalidXZ
+	}
+	return
+}
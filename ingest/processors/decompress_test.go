@@ -0,0 +1,237 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package processors
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"testing"
+
+	"github.com/gravwell/gravwell/v4/ingest/entry"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zlib"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+	"github.com/ulikunitz/xz"
+)
+
+const (
+	testBzip2Val = `hello bzip2 world`
+	// the standard library has no bzip2 writer, this is testBzip2Val compressed with bzip2 -9
+	testBzip2B64 = `QlpoOTFBWSZTWR9OcLoAAAMZgEAAEAAWZNCQIAAxANABTANGlqGF0dyPE6Dwu5IpwoSA+nOF0A==`
+)
+
+func compressTestVal(t *testing.T, format string, val []byte) []byte {
+	var bb bytes.Buffer
+	var w io.WriteCloser
+	var err error
+	switch format {
+	case formatGzip:
+		w = gzip.NewWriter(&bb)
+	case formatZlib:
+		w = zlib.NewWriter(&bb)
+	case formatZstd:
+		w, err = zstd.NewWriter(&bb)
+	case formatXZ:
+		w, err = xz.NewWriter(&bb)
+	case formatLZ4:
+		w = lz4.NewWriter(&bb)
+	case formatBzip2:
+		b, err := base64.StdEncoding.DecodeString(testBzip2B64)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	if err != nil {
+		t.Fatal(err)
+	} else if _, err = w.Write(val); err != nil {
+		t.Fatal(err)
+	} else if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return bb.Bytes()
+}
+
+func TestDecompressConfig(t *testing.T) {
+	b := `
+	[preprocessor "dc"]
+		type = decompress
+		Format=ZSTD
+		Max-Buff-MB=8
+	`
+	p, err := testLoadPreprocessor(b, `dc`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, ok := p.(*Decompressor)
+	if !ok {
+		t.Fatalf("preprocessor is the wrong type: %T != *Decompressor", p)
+	} else if d.Format != formatZstd || d.maxBuff != 8*mb || d.baseBuff != defaultBaseBuff {
+		t.Fatalf("bad config: %+v %d %d", d.DecompressConfig, d.baseBuff, d.maxBuff)
+	}
+	if err = p.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = NewDecompressor(DecompressConfig{Format: `rar`}); err == nil {
+		t.Fatal("bad format did not fail")
+	}
+}
+
+func TestDecompressFormats(t *testing.T) {
+	val := bytes.Repeat([]byte(`testing this test `), 100)
+	formats := []string{formatGzip, formatZlib, formatZstd, formatXZ, formatBzip2, formatLZ4}
+	auto, err := NewDecompressor(DecompressConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer auto.Close()
+	for _, format := range formats {
+		exp := val
+		if format == formatBzip2 {
+			exp = []byte(testBzip2Val)
+		}
+		data := compressTestVal(t, format, exp)
+		if f := detectCompression(data); f != format {
+			t.Fatalf("detected %q as %q", format, f)
+		}
+		explicit, err := NewDecompressor(DecompressConfig{Format: format})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, d := range []*Decompressor{auto, explicit} {
+			//run twice so the reused readers are reset
+			for i := 0; i < 2; i++ {
+				ents, err := d.Process([]*entry.Entry{{Tag: 5, Data: bytes.Clone(data)}})
+				if err != nil {
+					t.Fatal(err)
+				} else if len(ents) != 1 || !bytes.Equal(ents[0].Data, exp) || ents[0].Tag != 5 {
+					t.Fatalf("%s: bad decompression with format %s", format, d.Format)
+				}
+			}
+		}
+		explicit.Close()
+	}
+}
+
+func TestDecompressUnknown(t *testing.T) {
+	plain := []string{`hello`, `x^ not zlib`, `BZh nope`, ``}
+	d, err := NewDecompressor(DecompressConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range plain {
+		if f := detectCompression([]byte(v)); f != `` && f != formatZlib {
+			t.Fatalf("%q detected as %s", v, f)
+		}
+		if ents, err := d.Process([]*entry.Entry{{Data: []byte(v)}}); err != nil {
+			t.Fatal(err)
+		} else if len(ents) != 0 {
+			t.Fatalf("%q was not dropped", v)
+		}
+	}
+	d.Close()
+
+	if d, err = NewDecompressor(DecompressConfig{Passthrough_Unknown: true}); err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	if ents, err := d.Process([]*entry.Entry{{Data: []byte(`hello`)}}); err != nil {
+		t.Fatal(err)
+	} else if len(ents) != 1 || string(ents[0].Data) != `hello` {
+		t.Fatal("unknown data was not passed through")
+	}
+	//a recognized but corrupt entry is dropped even with passthrough enabled
+	bad := compressTestVal(t, formatZstd, []byte(`hello`))
+	bad = bad[:len(bad)-2]
+	if ents, err := d.Process([]*entry.Entry{{Data: bad}}); err != nil {
+		t.Fatal(err)
+	} else if len(ents) != 0 {
+		t.Fatal("corrupt entry was not dropped")
+	}
+}
+
+func TestDecompressBomb(t *testing.T) {
+	d, err := NewDecompressor(DecompressConfig{Min_Buff_MB: 1, Max_Buff_MB: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	for _, format := range []string{formatGzip, formatZstd, formatXZ, formatLZ4} {
+		bomb := compressTestVal(t, format, make([]byte, 4*mb))
+		if len(bomb) > mb {
+			t.Fatalf("%s: bomb did not compress %d", format, len(bomb))
+		}
+		ent := &entry.Entry{Data: bomb}
+		if err := d.procEnt(ent); err != ErrDecompressedTooLarge {
+			t.Fatalf("%s: bomb not caught: %v", format, err)
+		}
+		exact := compressTestVal(t, format, make([]byte, mb))
+		ent = &entry.Entry{Data: exact}
+		if err := d.procEnt(ent); err != nil || len(ent.Data) != mb {
+			t.Fatalf("%s: entry at the limit failed: %v", format, err)
+		}
+	}
+}
+
+// setXZDict rewrites the LZMA2 dictionary size code in the first block header of an xz stream
+func setXZDict(t *testing.T, b []byte, code byte) []byte {
+	b = bytes.Clone(b)
+	hdr := b[xzHeaderSize : xzHeaderSize+(int(b[xzHeaderSize])+1)*4]
+	body := hdr[:len(hdr)-4]
+	i := bytes.Index(body, []byte{xzLZMA2Filter, 1})
+	if i < 0 {
+		t.Fatal("no LZMA2 filter in block header")
+	}
+	body[i+2] = code
+	binary.LittleEndian.PutUint32(hdr[len(body):], crc32.ChecksumIEEE(body))
+	return b
+}
+
+func TestDecompressXZDictionary(t *testing.T) {
+	val := []byte(`testing the xz dictionary`)
+	good := compressTestVal(t, formatXZ, val)
+	if dict, err := xzMaxDict(good); err != nil {
+		t.Fatal(err)
+	} else if dict != minDecompressWindow {
+		t.Fatalf("bad dictionary size %d", dict)
+	}
+	huge := setXZDict(t, good, 40)
+	if dict, err := xzMaxDict(huge); err != nil {
+		t.Fatal(err)
+	} else if dict != 1<<32-1 {
+		t.Fatalf("bad dictionary size %d", dict)
+	}
+
+	d, err := NewDecompressor(DecompressConfig{Max_Buff_MB: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	//a legitimate stream followed by one asking for a 4GB dictionary
+	multi := append(bytes.Clone(good), good...)
+	if err = d.procEnt(&entry.Entry{Data: multi}); err != nil {
+		t.Fatal(err)
+	}
+	multi = append(bytes.Clone(good), huge...)
+	if err = d.procEnt(&entry.Entry{Data: multi}); err != ErrXZDictTooLarge {
+		t.Fatalf("oversized dictionary not caught: %v", err)
+	}
+	if ents, err := d.Process([]*entry.Entry{{Data: huge}}); err != nil {
+		t.Fatal(err)
+	} else if len(ents) != 0 {
+		t.Fatal("oversized dictionary entry was not dropped")
+	}
+	if _, err = xzMaxDict(good[:len(good)-6]); err != ErrInvalidXZ {
+		t.Fatalf("truncated stream not caught: %v", err)
+	}
+}
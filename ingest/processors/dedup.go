@@ -0,0 +1,301 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package processors
+
+import (
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gravwell/gravwell/v4/ingest/config"
+	"github.com/gravwell/gravwell/v4/ingest/entry"
+)
+
+const (
+	DedupProcessor string = `dedup`
+
+	dedupDataField = `_DATA_`
+	dedupTagField  = `_TAG_`
+	dedupSrcField  = `_SRC_`
+
+	defaultDedupWindow     = 5 * time.Minute
+	defaultDedupMaxEntries = 1000000
+	dedupMinAlloc          = 1024
+)
+
+var (
+	ErrInvalidDedupWindow = errors.New("Window must be a positive duration")
+	ErrInvalidMaxEntries  = errors.New("Max-Entries must be positive")
+	ErrDuplicateDedupKey  = errors.New("Duplicate dedup field")
+
+	defaultDedupFields = []string{dedupDataField, dedupTagField}
+)
+
+// DedupConfig controls which parts of an entry are hashed to detect repeats.  Fields may
+// be _DATA_, _TAG_, _SRC_, or the name of an enumerated value; the default is _DATA_ and _TAG_.
+type DedupConfig struct {
+	Fields      []string
+	Window      string // repeats seen within this long of the first sighting are dropped
+	Max_Entries int    // maximum number of hashes held, the oldest are forgotten first
+	State_File  string // optional file used to persist the window across restarts
+}
+
+func DedupLoadConfig(vc *config.VariableConfig) (c DedupConfig, err error) {
+	if err = vc.MapTo(&c); err == nil {
+		_, err = c.validate()
+	}
+	return
+}
+
+func (c *DedupConfig) validate() (window time.Duration, err error) {
+	window = defaultDedupWindow
+	if c.Window != `` {
+		if window, err = time.ParseDuration(c.Window); err != nil {
+			return
+		} else if window <= 0 {
+			err = ErrInvalidDedupWindow
+			return
+		}
+	}
+	if c.Max_Entries < 0 {
+		err = ErrInvalidMaxEntries
+		return
+	} else if c.Max_Entries == 0 {
+		c.Max_Entries = defaultDedupMaxEntries
+	}
+	var fields []string
+	for _, f := range c.Fields {
+		if f = strings.TrimSpace(f); f == `` {
+			continue
+		} else if inStringSet(fields, f) {
+			err = fmt.Errorf("%s: %w", f, ErrDuplicateDedupKey)
+			return
+		}
+		fields = append(fields, f)
+	}
+	if len(fields) == 0 {
+		fields = defaultDedupFields
+	}
+	c.Fields = fields
+	return
+}
+
+type dedupHash [16]byte
+
+type dedupRecord struct {
+	Hash dedupHash
+	Seen int64 // unix nanoseconds of the first sighting
+}
+
+// Dedup drops entries whose hashed fields were already seen within the window.
+// Hashes are held in first-seen order so the oldest can be expired or evicted cheaply.
+type Dedup struct {
+	DedupConfig
+	window time.Duration
+	h      hash.Hash
+	seen   map[dedupHash]int64
+	order  []dedupRecord // ring of hashes in first-seen order
+	head   int
+	count  int
+	now    func() time.Time
+}
+
+func NewDedup(cfg DedupConfig) (d *Dedup, err error) {
+	var window time.Duration
+	if window, err = cfg.validate(); err != nil {
+		return
+	}
+	d = &Dedup{
+		DedupConfig: cfg,
+		window:      window,
+		h:           fnv.New128a(),
+		seen:        map[dedupHash]int64{},
+		now:         time.Now,
+	}
+	if err = d.load(); err != nil {
+		d = nil
+	}
+	return
+}
+
+func (d *Dedup) Config(v interface{}) (err error) {
+	if v == nil {
+		err = ErrNilConfig
+	} else if cfg, ok := v.(DedupConfig); ok {
+		var window time.Duration
+		if window, err = cfg.validate(); err != nil {
+			return
+		} else if cfg.Max_Entries != d.Max_Entries {
+			err = errors.New("Max-Entries cannot be changed")
+			return
+		}
+		d.DedupConfig = cfg
+		d.window = window
+	} else {
+		err = fmt.Errorf("Invalid configuration, unknown type type %T", v)
+	}
+	return
+}
+
+func (d *Dedup) Process(ents []*entry.Entry) (rset []*entry.Entry, err error) {
+	if len(ents) == 0 {
+		return
+	}
+	now := d.now().UnixNano()
+	d.expire(now)
+	rset = ents[:0]
+	for _, ent := range ents {
+		if ent == nil {
+			continue
+		}
+		k := d.hash(ent)
+		if _, ok := d.seen[k]; ok {
+			continue //repeat, drop it
+		}
+		d.add(k, now)
+		rset = append(rset, ent)
+	}
+	return
+}
+
+func (d *Dedup) Flush() []*entry.Entry {
+	return nil
+}
+
+// Close persists the window if a state file is configured
+func (d *Dedup) Close() error {
+	return d.save()
+}
+
+func (d *Dedup) hash(ent *entry.Entry) (k dedupHash) {
+	var lb [8]byte
+	d.h.Reset()
+	//length prefix every field so that adjacent fields cannot alias
+	write := func(b []byte) {
+		binary.LittleEndian.PutUint64(lb[:], uint64(len(b)))
+		d.h.Write(lb[:])
+		d.h.Write(b)
+	}
+	for _, f := range d.Fields {
+		switch f {
+		case dedupDataField:
+			write(ent.Data)
+		case dedupTagField:
+			binary.LittleEndian.PutUint16(lb[:], uint16(ent.Tag))
+			d.h.Write(lb[:2])
+		case dedupSrcField:
+			write(ent.SRC)
+		default:
+			if ev, ok := ent.EVB.Get(f); ok {
+				d.h.Write([]byte{ev.TypeID()})
+				write(ev.ValueBuff())
+			} else {
+				d.h.Write([]byte{0xff}) //missing EVs still hash differently than empty ones
+			}
+		}
+	}
+	d.h.Sum(k[:0])
+	return
+}
+
+func (d *Dedup) add(k dedupHash, now int64) {
+	if d.count == len(d.order) {
+		if len(d.order) < d.Max_Entries {
+			d.grow()
+		} else {
+			d.pop() //full, forget the oldest
+		}
+	}
+	d.order[(d.head+d.count)%len(d.order)] = dedupRecord{Hash: k, Seen: now}
+	d.count++
+	d.seen[k] = now
+}
+
+// grow expands the ring up to Max-Entries so memory is only used as the window fills
+func (d *Dedup) grow() {
+	sz := min(max(2*len(d.order), dedupMinAlloc), d.Max_Entries)
+	order := make([]dedupRecord, sz)
+	for i := 0; i < d.count; i++ {
+		order[i] = d.order[(d.head+i)%len(d.order)]
+	}
+	d.order, d.head = order, 0
+}
+
+func (d *Dedup) pop() {
+	r := d.order[d.head]
+	if v, ok := d.seen[r.Hash]; ok && v == r.Seen {
+		delete(d.seen, r.Hash)
+	}
+	d.head = (d.head + 1) % len(d.order)
+	d.count--
+}
+
+// expire forgets every hash first seen before the window
+func (d *Dedup) expire(now int64) {
+	cutoff := now - int64(d.window)
+	for d.count > 0 && d.order[d.head].Seen < cutoff {
+		d.pop()
+	}
+}
+
+func (d *Dedup) load() (err error) {
+	if d.State_File == `` {
+		return
+	}
+	var fin *os.File
+	if fin, err = os.Open(d.State_File); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			err = nil
+		}
+		return
+	}
+	defer fin.Close()
+	var recs []dedupRecord
+	if err = gob.NewDecoder(fin).Decode(&recs); err != nil {
+		return fmt.Errorf("failed to load dedup state %s: %w", d.State_File, err)
+	}
+	for _, r := range recs {
+		if _, ok := d.seen[r.Hash]; !ok {
+			d.add(r.Hash, r.Seen)
+		}
+	}
+	d.expire(d.now().UnixNano())
+	return
+}
+
+func (d *Dedup) save() (err error) {
+	if d.State_File == `` {
+		return
+	}
+	d.expire(d.now().UnixNano())
+	recs := make([]dedupRecord, 0, d.count)
+	for i := 0; i < d.count; i++ {
+		recs = append(recs, d.order[(d.head+i)%len(d.order)])
+	}
+	var fout *os.File
+	if fout, err = os.CreateTemp(filepath.Dir(d.State_File), filepath.Base(d.State_File)+".tmp"); err != nil {
+		return
+	}
+	defer os.Remove(fout.Name())
+	if err = gob.NewEncoder(fout).Encode(recs); err != nil {
+		fout.Close()
+		return
+	} else if err = fout.Close(); err != nil {
+		return
+	}
+	err = os.Rename(fout.Name(), d.State_File)
+	return
+}
@@ -0,0 +1,167 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package processors
+
+import (
+	"fmt"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gravwell/gravwell/v4/ingest/entry"
+)
+
+type testClock struct {
+	t time.Time
+}
+
+func (tc *testClock) now() time.Time {
+	return tc.t
+}
+
+func newTestDedup(t *testing.T, cfg DedupConfig) (*Dedup, *testClock) {
+	d, err := NewDedup(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clk := &testClock{t: time.Now()}
+	d.now = clk.now
+	return d, clk
+}
+
+func dedupCount(t *testing.T, d *Dedup, ents ...*entry.Entry) int {
+	r, err := d.Process(ents)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(r)
+}
+
+func TestDedupConfig(t *testing.T) {
+	b := `
+	[preprocessor "dd"]
+		type = dedup
+		Fields=_DATA_
+		Fields=_SRC_
+		Fields=user
+		Window=1m
+		Max-Entries=100
+	`
+	p, err := testLoadPreprocessor(b, `dd`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, ok := p.(*Dedup)
+	if !ok {
+		t.Fatalf("preprocessor is the wrong type: %T != *Dedup", p)
+	} else if d.window != time.Minute || d.Max_Entries != 100 || len(d.Fields) != 3 {
+		t.Fatalf("bad config: %+v", d.DedupConfig)
+	}
+
+	bad := []DedupConfig{
+		{Window: `foo`},
+		{Window: `-1s`},
+		{Max_Entries: -1},
+		{Fields: []string{`_DATA_`, `_DATA_`}},
+	}
+	for _, cfg := range bad {
+		if _, err := NewDedup(cfg); err == nil {
+			t.Fatalf("bad config did not fail: %+v", cfg)
+		}
+	}
+}
+
+func TestDedupWindow(t *testing.T) {
+	d, clk := newTestDedup(t, DedupConfig{Window: `1m`})
+	if n := dedupCount(t, d, &entry.Entry{Tag: 1, Data: []byte(`a`)}, &entry.Entry{Tag: 1, Data: []byte(`a`)}, &entry.Entry{Tag: 1, Data: []byte(`b`)}); n != 2 {
+		t.Fatalf("repeat in a batch not dropped: %d", n)
+	}
+	//same data with a different tag is not a repeat by default
+	if n := dedupCount(t, d, &entry.Entry{Tag: 2, Data: []byte(`a`)}); n != 1 {
+		t.Fatal("entry with a different tag was dropped")
+	}
+	clk.t = clk.t.Add(30 * time.Second)
+	if n := dedupCount(t, d, &entry.Entry{Tag: 1, Data: []byte(`a`)}); n != 0 {
+		t.Fatal("repeat within the window was not dropped")
+	}
+	//the window runs from the first sighting
+	clk.t = clk.t.Add(31 * time.Second)
+	if n := dedupCount(t, d, &entry.Entry{Tag: 1, Data: []byte(`a`)}); n != 1 {
+		t.Fatal("entry outside the window was dropped")
+	}
+}
+
+func TestDedupFields(t *testing.T) {
+	d, _ := newTestDedup(t, DedupConfig{Fields: []string{`_SRC_`, `user`}})
+	mk := func(src, user string, data string) *entry.Entry {
+		ent := &entry.Entry{SRC: net.ParseIP(src), Data: []byte(data)}
+		if user != `` {
+			ent.AddEnumeratedValueEx(`user`, user)
+		}
+		return ent
+	}
+	if n := dedupCount(t, d,
+		mk(`10.0.0.1`, `bob`, `a`),
+		mk(`10.0.0.1`, `bob`, `b`), //data is ignored
+		mk(`10.0.0.2`, `bob`, `a`),
+		mk(`10.0.0.1`, `alice`, `a`),
+		mk(`10.0.0.1`, ``, `a`),
+		mk(`10.0.0.1`, ``, `c`),
+	); n != 4 {
+		t.Fatalf("bad dedup count %d", n)
+	}
+}
+
+func TestDedupMaxEntries(t *testing.T) {
+	d, _ := newTestDedup(t, DedupConfig{Max_Entries: 2000})
+	for i := 0; i < 3000; i++ {
+		if n := dedupCount(t, d, &entry.Entry{Data: []byte(fmt.Sprintf("%d", i))}); n != 1 {
+			t.Fatalf("unique entry %d dropped", i)
+		}
+	}
+	if len(d.seen) != 2000 || d.count != 2000 || len(d.order) != 2000 {
+		t.Fatalf("memory not bounded: %d %d %d", len(d.seen), d.count, len(d.order))
+	}
+	//the oldest were forgotten, the newest were not
+	if n := dedupCount(t, d, &entry.Entry{Data: []byte(`0`)}); n != 1 {
+		t.Fatal("evicted entry was dropped")
+	} else if n = dedupCount(t, d, &entry.Entry{Data: []byte(`2999`)}); n != 0 {
+		t.Fatal("retained entry was not dropped")
+	}
+}
+
+func TestDedupStateFile(t *testing.T) {
+	pth := filepath.Join(t.TempDir(), `dedup.state`)
+	d, clk := newTestDedup(t, DedupConfig{Window: `1m`, State_File: pth})
+	if n := dedupCount(t, d, &entry.Entry{Data: []byte(`a`)}); n != 1 {
+		t.Fatal("unique entry dropped")
+	}
+	clk.t = clk.t.Add(59 * time.Second)
+	if n := dedupCount(t, d, &entry.Entry{Data: []byte(`b`)}); n != 1 {
+		t.Fatal("unique entry dropped")
+	}
+	if err := d.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	//reload and make sure the window survived
+	d2, err := NewDedup(DedupConfig{Window: `1m`, State_File: pth})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d2.now = clk.now
+	if n := dedupCount(t, d2, &entry.Entry{Data: []byte(`a`)}, &entry.Entry{Data: []byte(`b`)}); n != 0 {
+		t.Fatalf("persisted entries were not dropped: %d", n)
+	}
+	clk.t = clk.t.Add(2 * time.Second)
+	if n := dedupCount(t, d2, &entry.Entry{Data: []byte(`a`)}, &entry.Entry{Data: []byte(`b`)}); n != 1 {
+		t.Fatalf("persisted window did not expire: %d", n)
+	}
+}
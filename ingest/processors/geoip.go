@@ -0,0 +1,269 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package processors
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gravwell/gravwell/v4/ingest/config"
+	"github.com/gravwell/gravwell/v4/ingest/entry"
+	"github.com/gravwell/gravwell/v4/ingest/log"
+	"github.com/gravwell/gravwell/v4/mmdb"
+)
+
+const (
+	GeoIPProcessor string = `geoip`
+
+	defaultGeoIPLanguage = `en`
+	geoipLangKey         = `$lang`
+)
+
+var (
+	ErrGeoIPNoDatabase   = errors.New("at least one Database is required")
+	ErrGeoIPUnknownField = errors.New("Unknown geoip field")
+
+	defaultGeoIPFields = []string{`country`, `city`, `asn`, `org`}
+
+	// geoipFields maps field names to the record paths that may hold them, checked in order
+	geoipFields = map[string][][]interface{}{
+		`country`:      {{`country`, `iso_code`}, {`registered_country`, `iso_code`}},
+		`country_name`: {{`country`, `names`, geoipLangKey}, {`registered_country`, `names`, geoipLangKey}},
+		`continent`:    {{`continent`, `code`}},
+		`city`:         {{`city`, `names`, geoipLangKey}},
+		`subdivision`:  {{`subdivisions`, 0, `iso_code`}},
+		`postal`:       {{`postal`, `code`}},
+		`lat`:          {{`location`, `latitude`}},
+		`long`:         {{`location`, `longitude`}},
+		`timezone`:     {{`location`, `time_zone`}},
+		`asn`:          {{`autonomous_system_number`}},
+		`org`:          {{`autonomous_system_organization`}, {`organization`}},
+		`isp`:          {{`isp`}},
+	}
+)
+
+// GeoIPConfig looks up an IP taken from an enumerated value, a JSON path, or a regular
+// expression capture in local MaxMind format databases and attaches the results as
+// enumerated values.  Fields may be renamed by specifying them as field:name.  Databases
+// are checked in order, so city and ASN databases may be combined, and are reloaded when
+// the file on disk changes.
+type GeoIPConfig struct {
+	Database        []string
+	Source_EV       string
+	JSON_Path       string
+	Regex           string // uses the capture group named ip, or the first capture group
+	Fields          []string
+	EV_Prefix       string
+	Language        string // language used for country_name and city, default is en
+	Reload_Interval string // how often database files are checked for changes
+}
+
+type geoipField struct {
+	name  string
+	paths [][]interface{}
+}
+
+func GeoIPLoadConfig(vc *config.VariableConfig) (c GeoIPConfig, err error) {
+	if err = vc.MapTo(&c); err == nil {
+		_, err = c.validate()
+	}
+	return
+}
+
+func (c *GeoIPConfig) validate() (fields []geoipField, err error) {
+	var dbs []string
+	for _, v := range c.Database {
+		if v = strings.TrimSpace(v); v != `` {
+			dbs = append(dbs, filepath.Clean(v))
+		}
+	}
+	if c.Database = dbs; len(dbs) == 0 {
+		err = ErrGeoIPNoDatabase
+		return
+	}
+	c.Source_EV, c.JSON_Path = strings.TrimSpace(c.Source_EV), strings.TrimSpace(c.JSON_Path)
+	if _, err = newIPSource(c.Source_EV, c.JSON_Path, c.Regex); err != nil {
+		return
+	}
+	if c.Language = strings.TrimSpace(c.Language); c.Language == `` {
+		c.Language = defaultGeoIPLanguage
+	}
+	if _, err = parseReloadInterval(c.Reload_Interval); err != nil {
+		return
+	}
+	flds := c.Fields
+	if len(flds) == 0 {
+		flds = defaultGeoIPFields
+	}
+	var names []string
+	for _, f := range flds {
+		if f = strings.TrimSpace(f); f == `` {
+			continue
+		}
+		name := f
+		if idx := strings.Index(f, `:`); idx != -1 {
+			f, name = strings.TrimSpace(f[:idx]), strings.TrimSpace(f[idx+1:])
+		}
+		paths, ok := geoipFields[strings.ToLower(f)]
+		if !ok {
+			err = fmt.Errorf("%s: %w", f, ErrGeoIPUnknownField)
+			return
+		} else if name = c.EV_Prefix + name; len(name) == 0 || len(name) > entry.MaxEvNameLength {
+			err = fmt.Errorf("%q: %w", name, ErrInvalidKeyname)
+			return
+		} else if inStringSet(names, name) {
+			err = fmt.Errorf("%s: %w", name, ErrDuplicateKeyname)
+			return
+		}
+		names = append(names, name)
+		fields = append(fields, geoipField{name: name, paths: c.localize(paths)})
+	}
+	if len(fields) == 0 {
+		err = ErrInvalidExtractions
+	}
+	return
+}
+
+// localize swaps the language placeholder in name paths for the configured language
+func (c *GeoIPConfig) localize(paths [][]interface{}) (r [][]interface{}) {
+	r = make([][]interface{}, 0, len(paths))
+	for _, p := range paths {
+		lp := make([]interface{}, len(p))
+		for i, k := range p {
+			if k == geoipLangKey {
+				k = c.Language
+			}
+			lp[i] = k
+		}
+		r = append(r, lp)
+	}
+	return
+}
+
+// GeoIP attaches location and network ownership information for an IP found in each entry
+type GeoIP struct {
+	GeoIPConfig
+	fields   []geoipField
+	src      ipSource
+	interval time.Duration
+	dbs      []*sharedFile[mmdb.Reader]
+	found    []bool
+	lg       log.IngestLogger
+}
+
+func NewGeoIP(cfg GeoIPConfig, tagger Tagger) (g *GeoIP, err error) {
+	g = &GeoIP{}
+	if lg, ok := tagger.(log.IngestLogger); ok {
+		g.lg = lg
+	}
+	if err = g.init(cfg); err != nil {
+		g = nil
+	}
+	return
+}
+
+func (g *GeoIP) Config(v interface{}) (err error) {
+	if v == nil {
+		err = ErrNilConfig
+	} else if cfg, ok := v.(GeoIPConfig); ok {
+		err = g.init(cfg)
+	} else {
+		err = fmt.Errorf("Invalid configuration, unknown type type %T", v)
+	}
+	return
+}
+
+func (g *GeoIP) init(cfg GeoIPConfig) (err error) {
+	var fields []geoipField
+	if fields, err = cfg.validate(); err != nil {
+		return
+	}
+	var dbs []*sharedFile[mmdb.Reader]
+	for _, pth := range cfg.Database {
+		var db *sharedFile[mmdb.Reader]
+		if db, err = geoipDBs.acquire(pth); err != nil {
+			geoipDBs.release(dbs)
+			return
+		}
+		dbs = append(dbs, db)
+	}
+	geoipDBs.release(g.dbs)
+	g.GeoIPConfig = cfg
+	g.fields = fields
+	g.found = make([]bool, len(fields))
+	g.dbs = dbs
+	g.interval, _ = parseReloadInterval(cfg.Reload_Interval)
+	g.src, _ = newIPSource(cfg.Source_EV, cfg.JSON_Path, cfg.Regex)
+	return
+}
+
+func (g *GeoIP) Process(ents []*entry.Entry) (rset []*entry.Entry, err error) {
+	if len(ents) == 0 {
+		return
+	}
+	now := time.Now()
+	for _, db := range g.dbs {
+		if lerr := db.checkReload(now, g.interval); lerr != nil && g.lg != nil {
+			g.lg.Warn("failed to reload geoip database", log.KV("database", db.path), log.KVErr(lerr))
+		}
+	}
+	for _, ent := range ents {
+		if ent == nil {
+			continue
+		} else if ip := g.src.extract(ent); ip != nil {
+			g.enrich(ent, ip)
+		}
+	}
+	rset = ents
+	return
+}
+
+func (g *GeoIP) Flush() []*entry.Entry {
+	return nil
+}
+
+// Close releases the databases, they are unloaded once no preprocessor holds them
+func (g *GeoIP) Close() error {
+	geoipDBs.release(g.dbs)
+	g.dbs = nil
+	return nil
+}
+
+func (g *GeoIP) enrich(ent *entry.Entry, ip net.IP) {
+	clear(g.found) //earlier databases win
+	for _, db := range g.dbs {
+		r := db.get()
+		if r == nil {
+			continue
+		}
+		rec, ok, err := r.Lookup(ip)
+		if err != nil || !ok {
+			continue
+		}
+		for i, f := range g.fields {
+			if g.found[i] {
+				continue
+			}
+			for _, p := range f.paths {
+				if v, ok := rec.Path(p...); ok {
+					if ent.AddEnumeratedValueEx(f.name, v) == nil {
+						g.found[i] = true
+					}
+					break
+				}
+			}
+		}
+	}
+}
+
+var geoipDBs = newSharedFileSet(mmdb.Open)
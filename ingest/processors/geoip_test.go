@@ -0,0 +1,194 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package processors
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gravwell/gravwell/v4/ingest/entry"
+)
+
+// copyTestMMDB places one of the databases under test_data/geoip at pth.  city.mmdb holds
+// 1.2.3.0/24 (AU, Sydney) and 2001:db8::/32 (JP), city_nz.mmdb holds 1.2.3.0/24 (NZ), and
+// asn.mmdb holds 1.2.0.0/16 (AS13335).  They are built with the writer in the mmdb tests.
+func copyTestMMDB(t *testing.T, pth, name string) {
+	b, err := os.ReadFile(filepath.Join(`test_data`, `geoip`, name))
+	if err != nil {
+		t.Fatal(err)
+	} else if err = os.WriteFile(pth, b, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testGeoIPDBs(t *testing.T) (city, asn string) {
+	dir := t.TempDir()
+	city, asn = filepath.Join(dir, `city.mmdb`), filepath.Join(dir, `asn.mmdb`)
+	copyTestMMDB(t, city, `city.mmdb`)
+	copyTestMMDB(t, asn, `asn.mmdb`)
+	return
+}
+
+func TestGeoIPConfig(t *testing.T) {
+	city, asn := testGeoIPDBs(t)
+	b := `
+	[preprocessor "geo"]
+		type = geoip
+		Database="` + city + `"
+		Database="` + asn + `"
+		Regex="src=(?P<ip>\\S+)"
+		Fields=country
+		Fields="asn:src_asn"
+		EV-Prefix=geo_
+	`
+	p, err := testLoadPreprocessor(b, `geo`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, ok := p.(*GeoIP)
+	if !ok {
+		t.Fatalf("preprocessor is the wrong type: %T != *GeoIP", p)
+	} else if len(g.dbs) != 2 || len(g.fields) != 2 || g.fields[1].name != `geo_src_asn` || g.src.rx == nil {
+		t.Fatalf("bad config: %+v", g.GeoIPConfig)
+	}
+	g.Close()
+
+	bad := []GeoIPConfig{
+		{Source_EV: `ip`},
+		{Database: []string{city}},
+		{Database: []string{city}, Source_EV: `ip`, Regex: `(.*)`},
+		{Database: []string{city}, Regex: `.*`},
+		{Database: []string{city}, Source_EV: `ip`, Fields: []string{`nope`}},
+		{Database: []string{city}, Source_EV: `ip`, Fields: []string{`city`, `country:city`}},
+		{Database: []string{city}, Source_EV: `ip`, Reload_Interval: `-1s`},
+		{Database: []string{filepath.Join(t.TempDir(), `missing.mmdb`)}, Source_EV: `ip`},
+	}
+	var tg testTagger
+	for _, cfg := range bad {
+		if _, err := NewGeoIP(cfg, &tg); err == nil {
+			t.Fatalf("bad config did not fail: %+v", cfg)
+		}
+	}
+}
+
+func TestGeoIPSources(t *testing.T) {
+	city, asn := testGeoIPDBs(t)
+	var tg testTagger
+	cfgs := []struct {
+		cfg GeoIPConfig
+		ent func(ip string) *entry.Entry
+	}{
+		{
+			cfg: GeoIPConfig{Source_EV: `src`},
+			ent: func(ip string) *entry.Entry {
+				ent := &entry.Entry{}
+				ent.AddEnumeratedValueEx(`src`, net.ParseIP(ip))
+				return ent
+			},
+		},
+		{
+			cfg: GeoIPConfig{JSON_Path: `flow.src`},
+			ent: func(ip string) *entry.Entry {
+				return &entry.Entry{Data: []byte(`{"flow":{"src":"` + ip + `"}}`)}
+			},
+		},
+		{
+			cfg: GeoIPConfig{Regex: `from (\S+) port`},
+			ent: func(ip string) *entry.Entry {
+				return &entry.Entry{Data: []byte(`accepted from ` + ip + ` port 22`)}
+			},
+		},
+	}
+	for i, c := range cfgs {
+		c.cfg.Database = []string{city, asn}
+		c.cfg.Fields = []string{`country`, `country_name`, `city`, `lat`, `asn`, `org`}
+		g, err := NewGeoIP(c.cfg, &tg)
+		if err != nil {
+			t.Fatalf("%d: %v", i, err)
+		}
+		ents, err := g.Process([]*entry.Entry{c.ent(`1.2.3.4`), c.ent(`1.2.200.1`), c.ent(`2001:db8::1`), c.ent(`9.9.9.9`)})
+		if err != nil {
+			t.Fatal(err)
+		} else if len(ents) != 4 {
+			t.Fatalf("%d: bad entry count %d", i, len(ents))
+		}
+		checkGeoIPEVs(t, ents[0], map[string]interface{}{`country`: `AU`, `country_name`: `Australia`, `city`: `Sydney`, `lat`: -33.5, `asn`: uint64(13335), `org`: `Example Networks`})
+		checkGeoIPEVs(t, ents[1], map[string]interface{}{`asn`: uint64(13335), `org`: `Example Networks`})
+		checkGeoIPEVs(t, ents[2], map[string]interface{}{`country`: `JP`})
+		checkGeoIPEVs(t, ents[3], nil)
+		g.Close()
+	}
+}
+
+func checkGeoIPEVs(t *testing.T, ent *entry.Entry, want map[string]interface{}) {
+	t.Helper()
+	for _, k := range []string{`country`, `country_name`, `city`, `lat`, `asn`, `org`} {
+		v, ok := ent.GetEnumeratedValue(k)
+		if w, exp := want[k]; exp != ok {
+			t.Fatalf("bad EV %s presence: %v", k, ok)
+		} else if ok && v != w {
+			t.Fatalf("bad EV %s: %v(%T) != %v(%T)", k, v, v, w, w)
+		}
+	}
+}
+
+func TestGeoIPReload(t *testing.T) {
+	city, _ := testGeoIPDBs(t)
+	var tg testTagger
+	g, err := NewGeoIP(GeoIPConfig{Database: []string{city}, Source_EV: `ip`, Fields: []string{`country`}, Language: `de`}, &tg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g.Close()
+	//a second preprocessor shares the loaded database
+	g2, err := NewGeoIP(GeoIPConfig{Database: []string{city}, Source_EV: `ip`}, &tg)
+	if err != nil {
+		t.Fatal(err)
+	} else if g2.dbs[0] != g.dbs[0] || g.dbs[0].refs != 2 {
+		t.Fatal("database not shared")
+	}
+	g2.Close()
+
+	lookup := func() (v interface{}) {
+		ent := &entry.Entry{}
+		ent.AddEnumeratedValueEx(`ip`, `1.2.3.4`)
+		if _, err := g.Process([]*entry.Entry{ent}); err != nil {
+			t.Fatal(err)
+		}
+		v, _ = ent.GetEnumeratedValue(`country`)
+		return
+	}
+	if v := lookup(); v != `AU` {
+		t.Fatalf("bad country %v", v)
+	}
+	copyTestMMDB(t, city, `city_nz.mmdb`)
+	future := time.Now().Add(time.Minute)
+	if err = os.Chtimes(city, future, future); err != nil {
+		t.Fatal(err)
+	}
+	//not due for a check yet
+	if v := lookup(); v != `AU` {
+		t.Fatalf("database reloaded early: %v", v)
+	}
+	g.dbs[0].lastCheck = time.Time{}
+	if v := lookup(); v != `NZ` {
+		t.Fatalf("database not reloaded: %v", v)
+	}
+	//a bad file leaves the current database in place
+	if err = os.WriteFile(city, []byte(`garbage`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	g.dbs[0].lastCheck = time.Time{}
+	if v := lookup(); v != `NZ` {
+		t.Fatalf("bad database replaced a good one: %v", v)
+	}
+}
@@ -0,0 +1,124 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package processors
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/gravwell/gravwell/v4/ingest"
+	"github.com/gravwell/gravwell/v4/ingest/config"
+	"github.com/gravwell/gravwell/v4/ingest/entry"
+)
+
+const (
+	IPExistProcessor string = `ipexist`
+
+	ipexistActionAttach = `attach`
+	ipexistActionTag    = `tag`
+	ipexistActionDrop   = `drop`
+
+	defaultIPExistEVName = `ipexist`
+)
+
+var (
+	ErrIPExistNoBitmap      = errors.New("at least one Bitmap is required")
+	ErrIPExistDuplicate     = errors.New("Duplicate bitmap label")
+	ErrIPExistInvalidAction = errors.New("Action must be attach, tag, or drop")
+	ErrIPExistMissingTag    = errors.New("Hit-Tag is required when Action is tag")
+	ErrIPExistUnsupported   = errors.New("ipexist preprocessor is only supported on Linux")
+)
+
+// IPExistConfig checks an IP taken from an enumerated value, a JSON path, or a regular
+// expression capture against one or more encoded ipexist bitmaps.  Bitmaps may be labeled
+// as label=path, otherwise the label is the file name without its extension.  Hits either
+// get an enumerated value holding the labels of every bitmap that matched, are retagged,
+// or are dropped.  Bitmaps are reloaded when the file on disk is replaced.
+type IPExistConfig struct {
+	Bitmap          []string
+	Source_EV       string
+	JSON_Path       string
+	Regex           string // uses the capture group named ip, or the first capture group
+	Action          string // attach (default), tag, or drop
+	EV_Name         string // enumerated value attached on a hit, default is ipexist
+	Boolean_EV      bool   // attach true on a hit and false on a miss rather than the hit labels
+	Hit_Tag         string
+	Reload_Interval string // how often bitmap files are checked for changes
+}
+
+type ipexistBitmap struct {
+	label string
+	path  string
+}
+
+func IPExistLoadConfig(vc *config.VariableConfig) (c IPExistConfig, err error) {
+	if err = vc.MapTo(&c); err == nil {
+		_, err = c.validate()
+	}
+	return
+}
+
+func (c *IPExistConfig) validate() (bms []ipexistBitmap, err error) {
+	var labels []string
+	for _, v := range c.Bitmap {
+		if v = strings.TrimSpace(v); v == `` {
+			continue
+		}
+		var bm ipexistBitmap
+		if label, pth, ok := strings.Cut(v, `=`); ok {
+			bm.label, bm.path = strings.TrimSpace(label), filepath.Clean(strings.TrimSpace(pth))
+		} else {
+			bm.path = filepath.Clean(v)
+			bm.label = strings.TrimSuffix(filepath.Base(bm.path), filepath.Ext(bm.path))
+		}
+		if bm.label == `` || strings.ContainsRune(bm.label, ',') {
+			err = fmt.Errorf("Invalid bitmap label %q: %w", bm.label, ErrInvalidKeyname)
+			return
+		} else if inStringSet(labels, bm.label) {
+			err = fmt.Errorf("%s: %w", bm.label, ErrIPExistDuplicate)
+			return
+		}
+		labels = append(labels, bm.label)
+		bms = append(bms, bm)
+	}
+	if len(bms) == 0 {
+		err = ErrIPExistNoBitmap
+		return
+	}
+	c.Source_EV, c.JSON_Path = strings.TrimSpace(c.Source_EV), strings.TrimSpace(c.JSON_Path)
+	if _, err = newIPSource(c.Source_EV, c.JSON_Path, c.Regex); err != nil {
+		return
+	}
+	switch c.Action = strings.ToLower(strings.TrimSpace(c.Action)); c.Action {
+	case ``:
+		c.Action = ipexistActionAttach
+	case ipexistActionAttach, ipexistActionDrop:
+	case ipexistActionTag:
+		if c.Hit_Tag = strings.TrimSpace(c.Hit_Tag); c.Hit_Tag == `` {
+			err = ErrIPExistMissingTag
+			return
+		} else if err = ingest.CheckTag(c.Hit_Tag); err != nil {
+			err = fmt.Errorf("invalid Hit-Tag %q: %w", c.Hit_Tag, err)
+			return
+		}
+	default:
+		err = ErrIPExistInvalidAction
+		return
+	}
+	if c.EV_Name = strings.TrimSpace(c.EV_Name); c.EV_Name == `` {
+		c.EV_Name = defaultIPExistEVName
+	} else if len(c.EV_Name) > entry.MaxEvNameLength {
+		err = fmt.Errorf("%q: %w", c.EV_Name, ErrInvalidKeyname)
+		return
+	}
+	_, err = parseReloadInterval(c.Reload_Interval)
+	return
+}
@@ -0,0 +1,171 @@
+//go:build linux
+// +build linux
+
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package processors
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gravwell/gravwell/v4/ingest/entry"
+	"github.com/gravwell/gravwell/v4/ingest/log"
+	"github.com/gravwell/gravwell/v4/ipexist"
+)
+
+var ipexistBitmaps = newSharedFileSet(loadIPBitMap)
+
+func loadIPBitMap(pth string) (*ipexist.IpBitMap, error) {
+	fin, err := os.Open(pth)
+	if err != nil {
+		return nil, err
+	}
+	defer fin.Close()
+	return ipexist.LoadIPBitMap(bufio.NewReader(fin))
+}
+
+// IPExist checks IPv4 addresses for membership in one or more bitmaps
+type IPExist struct {
+	IPExistConfig
+	src      ipSource
+	labels   []string
+	bms      []*sharedFile[ipexist.IpBitMap]
+	interval time.Duration
+	tagger   Tagger
+	tag      entry.EntryTag
+	hits     []string
+	lg       log.IngestLogger
+}
+
+func NewIPExist(cfg IPExistConfig, tagger Tagger) (ie *IPExist, err error) {
+	ie = &IPExist{tagger: tagger}
+	if lg, ok := tagger.(log.IngestLogger); ok {
+		ie.lg = lg
+	}
+	if err = ie.init(cfg); err != nil {
+		ie = nil
+	}
+	return
+}
+
+func (ie *IPExist) Config(v interface{}) (err error) {
+	if v == nil {
+		err = ErrNilConfig
+	} else if cfg, ok := v.(IPExistConfig); ok {
+		err = ie.init(cfg)
+	} else {
+		err = fmt.Errorf("Invalid configuration, unknown type type %T", v)
+	}
+	return
+}
+
+func (ie *IPExist) init(cfg IPExistConfig) (err error) {
+	var bmcfg []ipexistBitmap
+	if bmcfg, err = cfg.validate(); err != nil {
+		return
+	}
+	var tag entry.EntryTag
+	if cfg.Action == ipexistActionTag {
+		if ie.tagger == nil {
+			err = fmt.Errorf("Hit-Tag requires a tagger")
+			return
+		} else if tag, err = ie.tagger.NegotiateTag(cfg.Hit_Tag); err != nil {
+			err = fmt.Errorf("Failed to get tag %s: %v", cfg.Hit_Tag, err)
+			return
+		}
+	}
+	var bms []*sharedFile[ipexist.IpBitMap]
+	labels := make([]string, 0, len(bmcfg))
+	for _, b := range bmcfg {
+		var bm *sharedFile[ipexist.IpBitMap]
+		if bm, err = ipexistBitmaps.acquire(b.path); err != nil {
+			ipexistBitmaps.release(bms)
+			return
+		}
+		bms = append(bms, bm)
+		labels = append(labels, b.label)
+	}
+	ipexistBitmaps.release(ie.bms)
+	ie.IPExistConfig = cfg
+	ie.bms, ie.labels = bms, labels
+	ie.tag = tag
+	ie.interval, _ = parseReloadInterval(cfg.Reload_Interval)
+	ie.src, _ = newIPSource(cfg.Source_EV, cfg.JSON_Path, cfg.Regex)
+	return
+}
+
+func (ie *IPExist) Process(ents []*entry.Entry) (rset []*entry.Entry, err error) {
+	if len(ents) == 0 {
+		return
+	}
+	now := time.Now()
+	for _, bm := range ie.bms {
+		if lerr := bm.checkReload(now, ie.interval); lerr != nil && ie.lg != nil {
+			ie.lg.Warn("failed to reload ipexist bitmap", log.KV("bitmap", bm.path), log.KVErr(lerr))
+		}
+	}
+	rset = ents[:0]
+	for _, ent := range ents {
+		if ent == nil {
+			continue
+		}
+		hit := ie.check(ie.src.extract(ent))
+		switch {
+		case ie.Action == ipexistActionDrop:
+			if hit {
+				continue
+			}
+		case ie.Action == ipexistActionTag:
+			if hit {
+				ent.Tag = ie.tag
+			}
+		case ie.Boolean_EV:
+			ent.AddEnumeratedValueEx(ie.EV_Name, hit)
+		case hit:
+			ent.AddEnumeratedValueEx(ie.EV_Name, strings.Join(ie.hits, `,`))
+		}
+		rset = append(rset, ent)
+	}
+	return
+}
+
+// check returns true if any bitmap holds the IP, the labels of the matching bitmaps are left in hits
+func (ie *IPExist) check(ip net.IP) bool {
+	ie.hits = ie.hits[:0]
+	if ip == nil {
+		return false
+	}
+	for i, bm := range ie.bms {
+		if m := bm.get(); m != nil {
+			if ok, err := m.IPExists(ip); err == nil && ok {
+				ie.hits = append(ie.hits, ie.labels[i])
+				if ie.Action != ipexistActionAttach || ie.Boolean_EV {
+					break //only need one hit
+				}
+			}
+		}
+	}
+	return len(ie.hits) > 0
+}
+
+func (ie *IPExist) Flush() []*entry.Entry {
+	return nil
+}
+
+// Close releases the bitmaps, they are unloaded once no preprocessor holds them
+func (ie *IPExist) Close() error {
+	ipexistBitmaps.release(ie.bms)
+	ie.bms = nil
+	return nil
+}
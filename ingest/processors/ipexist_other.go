@@ -0,0 +1,33 @@
+//go:build !linux
+// +build !linux
+
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package processors
+
+import (
+	"github.com/gravwell/gravwell/v4/ingest/entry"
+)
+
+// IPExist is unavailable because the ipexist bitmaps are memory map aware and only build on Linux
+type IPExist struct {
+	nocloser
+	IPExistConfig
+}
+
+func NewIPExist(cfg IPExistConfig, tagger Tagger) (*IPExist, error) {
+	if _, err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return nil, ErrIPExistUnsupported
+}
+
+func (ie *IPExist) Process(ents []*entry.Entry) ([]*entry.Entry, error) {
+	return ents, nil
+}
@@ -0,0 +1,197 @@
+//go:build linux
+// +build linux
+
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package processors
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gravwell/gravwell/v4/ingest/entry"
+	"github.com/gravwell/gravwell/v4/ipexist"
+)
+
+func writeTestBitmap(t *testing.T, pth string, ips ...string) {
+	bm := ipexist.NewIPBitMap()
+	for _, ip := range ips {
+		if err := bm.AddIP(net.ParseIP(ip)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fout, err := os.Create(pth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = bm.Encode(fout); err != nil {
+		t.Fatal(err)
+	} else if err = fout.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testBitmaps(t *testing.T) (threats, assets string) {
+	dir := t.TempDir()
+	threats, assets = filepath.Join(dir, `threats.bin`), filepath.Join(dir, `assets.bin`)
+	writeTestBitmap(t, threats, `1.2.3.4`, `5.6.7.8`)
+	writeTestBitmap(t, assets, `10.0.0.1`, `5.6.7.8`)
+	return
+}
+
+func TestIPExistConfig(t *testing.T) {
+	threats, assets := testBitmaps(t)
+	b := `
+	[preprocessor "ipe"]
+		type = ipexist
+		Bitmap="` + threats + `"
+		Bitmap="internal=` + assets + `"
+		Source-EV=src
+		Action=tag
+		Hit-Tag=threats
+	`
+	p, err := testLoadPreprocessor(b, `ipe`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ie, ok := p.(*IPExist)
+	if !ok {
+		t.Fatalf("preprocessor is the wrong type: %T != *IPExist", p)
+	} else if len(ie.bms) != 2 || ie.labels[0] != `threats` || ie.labels[1] != `internal` || ie.EV_Name != defaultIPExistEVName {
+		t.Fatalf("bad config: %+v %v", ie.IPExistConfig, ie.labels)
+	}
+	ie.Close()
+
+	bad := []IPExistConfig{
+		{Source_EV: `src`},
+		{Bitmap: []string{threats}},
+		{Bitmap: []string{threats, `threats=` + assets}, Source_EV: `src`},
+		{Bitmap: []string{threats}, Source_EV: `src`, Action: `explode`},
+		{Bitmap: []string{threats}, Source_EV: `src`, Action: `tag`},
+		{Bitmap: []string{filepath.Join(t.TempDir(), `missing.bin`)}, Source_EV: `src`},
+	}
+	var tg testTagger
+	for _, cfg := range bad {
+		if _, err := NewIPExist(cfg, &tg); err == nil {
+			t.Fatalf("bad config did not fail: %+v", cfg)
+		}
+	}
+}
+
+func TestIPExistActions(t *testing.T) {
+	threats, assets := testBitmaps(t)
+	var tg testTagger
+	mk := func() []*entry.Entry {
+		return []*entry.Entry{
+			{Tag: 100, Data: []byte(`{"ip":"1.2.3.4"}`)},
+			{Tag: 100, Data: []byte(`{"ip":"5.6.7.8"}`)},
+			{Tag: 100, Data: []byte(`{"ip":"10.0.0.1"}`)},
+			{Tag: 100, Data: []byte(`{"ip":"9.9.9.9"}`)},
+			{Tag: 100, Data: []byte(`{"ip":"2001:db8::1"}`)},
+			{Tag: 100, Data: []byte(`{}`)},
+		}
+	}
+	base := IPExistConfig{Bitmap: []string{threats, assets}, JSON_Path: `ip`}
+
+	//label EVs list every bitmap that matched
+	ie, err := NewIPExist(base, &tg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ents, err := ie.Process(mk())
+	if err != nil {
+		t.Fatal(err)
+	} else if len(ents) != 6 {
+		t.Fatalf("bad entry count %d", len(ents))
+	}
+	for i, want := range []interface{}{`threats`, `threats,assets`, `assets`, nil, nil, nil} {
+		if v, _ := ents[i].GetEnumeratedValue(`ipexist`); v != want {
+			t.Fatalf("%d: bad EV %v != %v", i, v, want)
+		}
+	}
+	ie.Close()
+
+	cfg := base
+	cfg.Boolean_EV, cfg.EV_Name = true, `known`
+	if ie, err = NewIPExist(cfg, &tg); err != nil {
+		t.Fatal(err)
+	} else if ents, err = ie.Process(mk()); err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []bool{true, true, true, false, false, false} {
+		if v, ok := ents[i].GetEnumeratedValue(`known`); !ok || v != want {
+			t.Fatalf("%d: bad EV %v != %v", i, v, want)
+		}
+	}
+	ie.Close()
+
+	cfg = base
+	cfg.Action, cfg.Hit_Tag = `tag`, `threats`
+	if ie, err = NewIPExist(cfg, &tg); err != nil {
+		t.Fatal(err)
+	} else if ents, err = ie.Process(mk()); err != nil {
+		t.Fatal(err)
+	}
+	hitTag, _ := tg.NegotiateTag(`threats`)
+	for i, want := range []entry.EntryTag{hitTag, hitTag, hitTag, 100, 100, 100} {
+		if ents[i].Tag != want {
+			t.Fatalf("%d: bad tag %v != %v", i, ents[i].Tag, want)
+		}
+	}
+	ie.Close()
+
+	cfg = base
+	cfg.Bitmap, cfg.Action = []string{threats}, `drop`
+	if ie, err = NewIPExist(cfg, &tg); err != nil {
+		t.Fatal(err)
+	} else if ents, err = ie.Process(mk()); err != nil {
+		t.Fatal(err)
+	} else if len(ents) != 4 || string(ents[0].Data) != `{"ip":"10.0.0.1"}` {
+		t.Fatalf("hits not dropped: %d", len(ents))
+	}
+	ie.Close()
+}
+
+func TestIPExistReload(t *testing.T) {
+	threats, _ := testBitmaps(t)
+	var tg testTagger
+	ie, err := NewIPExist(IPExistConfig{Bitmap: []string{threats}, Source_EV: `ip`, Boolean_EV: true}, &tg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ie.Close()
+	check := func(ip string) bool {
+		ent := &entry.Entry{}
+		ent.AddEnumeratedValueEx(`ip`, net.ParseIP(ip))
+		if _, err := ie.Process([]*entry.Entry{ent}); err != nil {
+			t.Fatal(err)
+		}
+		v, _ := ent.GetEnumeratedValue(`ipexist`)
+		return v == true
+	}
+	if !check(`1.2.3.4`) || check(`192.168.0.1`) {
+		t.Fatal("bad initial bitmap")
+	}
+	//replace the file the way a feed updater would
+	tmp := threats + `.new`
+	writeTestBitmap(t, tmp, `192.168.0.1`)
+	future := time.Now().Add(time.Minute)
+	if err = os.Chtimes(tmp, future, future); err != nil {
+		t.Fatal(err)
+	} else if err = os.Rename(tmp, threats); err != nil {
+		t.Fatal(err)
+	}
+	ie.bms[0].lastCheck = time.Time{}
+	if check(`1.2.3.4`) || !check(`192.168.0.1`) {
+		t.Fatal("bitmap not reloaded")
+	}
+}
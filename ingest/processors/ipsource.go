@@ -0,0 +1,97 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package processors
+
+import (
+	"errors"
+	"net"
+	"regexp"
+	"strings"
+
+	"github.com/gravwell/gravwell/v4/ingest/entry"
+	"github.com/gravwell/jsonparser"
+)
+
+var (
+	ErrIPSourceConflict     = errors.New("exactly one of Source-EV, JSON-Path, or Regex is required")
+	ErrIPSourceRegexCapture = errors.New("Regex must contain a capture group")
+)
+
+// ipSource pulls an IP out of an entry using an enumerated value, a JSON path,
+// or a regular expression capture group
+type ipSource struct {
+	ev    string
+	keys  []string
+	rx    *regexp.Regexp
+	rxIdx int
+}
+
+// newIPSource builds an ipSource from exactly one of its arguments.  Regular expressions
+// use the capture group named ip if there is one, otherwise the first capture group.
+func newIPSource(ev, jsonPath, rx string) (src ipSource, err error) {
+	var cnt int
+	for _, v := range []string{ev, jsonPath, rx} {
+		if v != `` {
+			cnt++
+		}
+	}
+	if cnt != 1 {
+		err = ErrIPSourceConflict
+		return
+	}
+	if jsonPath != `` {
+		src.keys = unquoteFields(splitRespectQuotes(jsonPath, dotSplitter))
+	} else if rx != `` {
+		if src.rx, err = regexp.Compile(rx); err != nil {
+			return
+		} else if src.rx.NumSubexp() == 0 {
+			err = ErrIPSourceRegexCapture
+			return
+		}
+		if src.rxIdx = src.rx.SubexpIndex(`ip`); src.rxIdx == -1 {
+			src.rxIdx = 1
+		}
+	} else {
+		src.ev = ev
+	}
+	return
+}
+
+func (src *ipSource) extract(ent *entry.Entry) net.IP {
+	var s string
+	if src.keys != nil {
+		v, _, _, err := jsonparser.Get(ent.Data, src.keys...)
+		if err != nil {
+			return nil
+		}
+		s = string(v)
+	} else if src.rx != nil {
+		m := src.rx.FindSubmatch(ent.Data)
+		if len(m) <= src.rxIdx {
+			return nil
+		}
+		s = string(m[src.rxIdx])
+	} else {
+		v, ok := ent.GetEnumeratedValue(src.ev)
+		if !ok {
+			return nil
+		}
+		switch t := v.(type) {
+		case net.IP:
+			return t
+		case string:
+			s = t
+		case []byte:
+			s = string(t)
+		default:
+			return nil
+		}
+	}
+	return net.ParseIP(strings.TrimSpace(s))
+}
@@ -0,0 +1,286 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package processors
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gravwell/gravwell/v4/ingest/config"
+	"github.com/gravwell/gravwell/v4/ingest/entry"
+)
+
+const (
+	KVExtractProcessor string = `kvextract`
+
+	defaultPairDelimiter   = ` `
+	defaultKVDelimiter     = `=`
+	defaultQuoteCharacters = `"`
+)
+
+var (
+	ErrKVDelimiterConflict = errors.New("Pair-Delimiter and KV-Delimiter cannot be the same")
+	ErrInvalidQuoteChars   = errors.New("Quote-Characters cannot contain delimiter characters")
+)
+
+// KVExtractConfig controls how key/value (logfmt style) payloads are parsed.
+// Keys may be renamed by specifying them as original:new.
+type KVExtractConfig struct {
+	Pair_Delimiter    string   // separates pairs, default is a single space
+	KV_Delimiter      string   // separates a key from its value, default is =
+	Quote_Characters  string   // characters that may quote a value, default is "
+	Keys              []string // keys to extract, every key is extracted if unset
+	Enumerated_Values bool     // attach extracted pairs as enumerated values instead of rewriting the entry
+	Drop_Misses       bool     // drop entries that do not contain any of the requested keys
+}
+
+func KVExtractLoadConfig(vc *config.VariableConfig) (c KVExtractConfig, err error) {
+	if err = vc.MapTo(&c); err == nil {
+		_, err = c.validate()
+	}
+	return
+}
+
+// validate applies the default delimiters and builds the map of extracted keys to their output names
+func (c *KVExtractConfig) validate() (keys map[string]string, err error) {
+	if c.Pair_Delimiter == `` {
+		c.Pair_Delimiter = defaultPairDelimiter
+	}
+	if c.KV_Delimiter == `` {
+		c.KV_Delimiter = defaultKVDelimiter
+	}
+	if c.Quote_Characters == `` {
+		c.Quote_Characters = defaultQuoteCharacters
+	}
+	if c.Pair_Delimiter == c.KV_Delimiter {
+		err = ErrKVDelimiterConflict
+		return
+	} else if strings.ContainsAny(c.Quote_Characters, c.Pair_Delimiter+c.KV_Delimiter+`\`) {
+		err = ErrInvalidQuoteChars
+		return
+	}
+	if len(c.Keys) == 0 {
+		return
+	}
+	keys = make(map[string]string, len(c.Keys))
+	names := make(map[string]bool, len(c.Keys))
+	for _, k := range c.Keys {
+		if k = strings.TrimSpace(k); k == `` {
+			continue
+		}
+		name := k
+		if idx := strings.LastIndex(k, `:`); idx != -1 {
+			k, name = strings.TrimSpace(k[:idx]), strings.TrimSpace(k[idx+1:])
+		}
+		if k == `` || name == `` {
+			err = fmt.Errorf("Invalid key %q: %w", k, ErrInvalidKeyname)
+			return
+		} else if _, ok := keys[k]; ok {
+			err = fmt.Errorf("%s: %w", k, ErrDuplicateKey)
+			return
+		} else if names[name] {
+			err = fmt.Errorf("%s: %w", name, ErrDuplicateKeyname)
+			return
+		}
+		keys[k] = name
+		names[name] = true
+	}
+	if len(keys) == 0 {
+		err = ErrInvalidExtractions
+	}
+	return
+}
+
+type KVExtractor struct {
+	nocloser
+	KVExtractConfig
+	keys   map[string]string
+	pd, kd []byte
+	bb     bytes.Buffer
+	seen   map[string]bool
+}
+
+func NewKVExtractor(cfg KVExtractConfig) (*KVExtractor, error) {
+	keys, err := cfg.validate()
+	if err != nil {
+		return nil, err
+	}
+	return &KVExtractor{
+		KVExtractConfig: cfg,
+		keys:            keys,
+		pd:              []byte(cfg.Pair_Delimiter),
+		kd:              []byte(cfg.KV_Delimiter),
+		seen:            map[string]bool{},
+	}, nil
+}
+
+func (kv *KVExtractor) Config(v interface{}) (err error) {
+	if v == nil {
+		err = ErrNilConfig
+	} else if cfg, ok := v.(KVExtractConfig); ok {
+		var keys map[string]string
+		if keys, err = cfg.validate(); err == nil {
+			kv.KVExtractConfig = cfg
+			kv.keys = keys
+			kv.pd, kv.kd = []byte(cfg.Pair_Delimiter), []byte(cfg.KV_Delimiter)
+		}
+	} else {
+		err = fmt.Errorf("Invalid configuration, unknown type type %T", v)
+	}
+	return
+}
+
+func (kv *KVExtractor) Process(ents []*entry.Entry) (rset []*entry.Entry, err error) {
+	if len(ents) == 0 {
+		return
+	}
+	rset = ents[:0]
+	for _, ent := range ents {
+		if ent == nil {
+			continue
+		} else if ent = kv.processItem(ent); ent != nil {
+			rset = append(rset, ent)
+		}
+	}
+	return
+}
+
+func (kv *KVExtractor) processItem(ent *entry.Entry) *entry.Entry {
+	var cnt int
+	clear(kv.seen)
+	kv.bb.Reset()
+	kv.parse(ent.Data, func(k, v []byte) {
+		name := string(k)
+		if kv.keys != nil {
+			var ok bool
+			if name, ok = kv.keys[name]; !ok {
+				return
+			}
+		}
+		if kv.seen[name] {
+			return //first instance of a key wins
+		}
+		kv.seen[name] = true
+		if kv.Enumerated_Values {
+			if ent.AddEnumeratedValueEx(name, string(v)) == nil {
+				cnt++
+			}
+			return
+		}
+		if cnt == 0 {
+			kv.bb.WriteByte('{')
+		} else {
+			kv.bb.WriteByte(',')
+		}
+		writeJSONString(&kv.bb, name)
+		kv.bb.WriteByte(':')
+		writeJSONString(&kv.bb, string(v))
+		cnt++
+	})
+	if cnt == 0 {
+		if kv.Drop_Misses {
+			return nil
+		}
+		return ent
+	}
+	if !kv.Enumerated_Values {
+		kv.bb.WriteByte('}')
+		ent.Data = append([]byte{}, kv.bb.Bytes()...) //force allocation
+	}
+	return ent
+}
+
+// parse walks the pairs in data handing each key and value to fn.  Tokens without a
+// KV delimiter are skipped, values may be quoted and quoted values may contain
+// backslash escaped quotes and backslashes.
+func (kv *KVExtractor) parse(data []byte, fn func(k, v []byte)) {
+	for len(data) > 0 {
+		if bytes.HasPrefix(data, kv.pd) {
+			data = data[len(kv.pd):]
+			continue
+		}
+		kidx := bytes.Index(data, kv.kd)
+		pidx := bytes.Index(data, kv.pd)
+		if kidx == -1 {
+			return //no more pairs
+		} else if pidx != -1 && pidx < kidx {
+			data = data[pidx:] //token without a kv delimiter
+			continue
+		}
+		k := kv.unquoteKey(bytes.TrimSpace(data[:kidx]))
+		data = data[kidx+len(kv.kd):]
+		if kv.pd[0] != ' ' && kv.pd[0] != '\t' {
+			//whitespace is not significant, allow it ahead of a quoted value
+			data = bytes.TrimLeft(data, " \t")
+		}
+
+		var v []byte
+		if len(data) > 0 && strings.IndexByte(kv.Quote_Characters, data[0]) != -1 {
+			v, data = unquoteValue(data)
+		} else {
+			if pidx = bytes.Index(data, kv.pd); pidx == -1 {
+				pidx = len(data)
+			}
+			v, data = bytes.TrimSpace(data[:pidx]), data[pidx:]
+		}
+		if len(k) > 0 {
+			fn(k, v)
+		}
+	}
+}
+
+func (kv *KVExtractor) unquoteKey(k []byte) []byte {
+	if len(k) >= 2 && k[0] == k[len(k)-1] && strings.IndexByte(kv.Quote_Characters, k[0]) != -1 {
+		k = k[1 : len(k)-1]
+	}
+	return k
+}
+
+// unquoteValue consumes a quoted value from the start of data, returning the
+// unescaped value and the remaining data.  An unterminated quote consumes everything.
+func unquoteValue(data []byte) (v, r []byte) {
+	q := data[0]
+	data = data[1:]
+	var escaped bool
+	for i, c := range data {
+		if escaped {
+			escaped = false
+			continue
+		}
+		switch c {
+		case '\\':
+			escaped = true
+		case q:
+			return unescapeValue(data[:i], q), data[i+1:]
+		}
+	}
+	return unescapeValue(data, q), nil
+}
+
+func unescapeValue(v []byte, q byte) []byte {
+	if bytes.IndexByte(v, '\\') == -1 {
+		return v
+	}
+	r := make([]byte, 0, len(v))
+	for i := 0; i < len(v); i++ {
+		if v[i] == '\\' && i+1 < len(v) && (v[i+1] == q || v[i+1] == '\\') {
+			i++
+		}
+		r = append(r, v[i])
+	}
+	return r
+}
+
+func writeJSONString(bb *bytes.Buffer, s string) {
+	b, _ := json.Marshal(s) //marshalling a string cannot fail
+	bb.Write(b)
+}
@@ -0,0 +1,164 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package processors
+
+import (
+	"testing"
+
+	"github.com/gravwell/gravwell/v4/ingest/entry"
+)
+
+func TestKVExtractConfig(t *testing.T) {
+	b := `
+	[preprocessor "kv"]
+		type = kvextract
+		Pair-Delimiter=","
+		KV-Delimiter=":"
+		Quote-Characters="'"
+		Keys=src
+		Keys="dst : destination"
+		Drop-Misses=true
+	`
+	p, err := testLoadPreprocessor(b, `kv`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kv, ok := p.(*KVExtractor)
+	if !ok {
+		t.Fatalf("preprocessor is the wrong type: %T != *KVExtractor", p)
+	}
+	if kv.Pair_Delimiter != `,` || kv.KV_Delimiter != `:` || kv.Quote_Characters != `'` || !kv.Drop_Misses {
+		t.Fatalf("bad config: %+v", kv.KVExtractConfig)
+	} else if len(kv.keys) != 2 || kv.keys[`src`] != `src` || kv.keys[`dst`] != `destination` {
+		t.Fatalf("bad keys: %v", kv.keys)
+	}
+
+	//check defaults
+	b = `
+	[preprocessor "kv"]
+		type = kvextract
+	`
+	if p, err = testLoadPreprocessor(b, `kv`); err != nil {
+		t.Fatal(err)
+	}
+	kv = p.(*KVExtractor)
+	if kv.Pair_Delimiter != ` ` || kv.KV_Delimiter != `=` || kv.Quote_Characters != `"` || kv.keys != nil {
+		t.Fatalf("bad defaults: %+v %v", kv.KVExtractConfig, kv.keys)
+	}
+}
+
+func TestKVExtractBadConfig(t *testing.T) {
+	bad := []KVExtractConfig{
+		{Pair_Delimiter: `=`},
+		{Quote_Characters: `=`},
+		{Quote_Characters: `\`},
+		{Keys: []string{`a`, `a`}},
+		{Keys: []string{`a:c`, `b:c`}},
+		{Keys: []string{`:c`}},
+		{Keys: []string{`a:`}},
+	}
+	for _, cfg := range bad {
+		if _, err := NewKVExtractor(cfg); err == nil {
+			t.Fatalf("bad config did not fail: %+v", cfg)
+		}
+	}
+}
+
+func TestKVExtractRewrite(t *testing.T) {
+	tests := []struct {
+		cfg  KVExtractConfig
+		in   string
+		out  string
+		drop bool
+	}{
+		{
+			in:  `<134>fw01: action=allow src=10.0.0.1 dst=10.0.0.2 msg="hello \"there\" world" empty= trailing`,
+			out: `{"action":"allow","src":"10.0.0.1","dst":"10.0.0.2","msg":"hello \"there\" world","empty":""}`,
+		},
+		{
+			cfg: KVExtractConfig{Keys: []string{`src:source`, `msg`}},
+			in:  `action=allow src=10.0.0.1 src=10.0.0.9 msg="a b"`,
+			out: `{"source":"10.0.0.1","msg":"a b"}`,
+		},
+		{
+			cfg: KVExtractConfig{Pair_Delimiter: `|`, KV_Delimiter: `:`, Quote_Characters: `"'`},
+			in:  `user: 'bob smith' | "group":admins|path:'c:\\temp\\'`,
+			out: `{"user":"bob smith","group":"admins","path":"c:\\temp\\"}`,
+		},
+		{
+			cfg: KVExtractConfig{Pair_Delimiter: `, `, KV_Delimiter: `=>`},
+			in:  `a=>1, b=>"x, y", c=>3`,
+			out: `{"a":"1","b":"x, y","c":"3"}`,
+		},
+		{
+			in:  `no pairs at all`,
+			out: `no pairs at all`,
+		},
+		{
+			cfg:  KVExtractConfig{Drop_Misses: true},
+			in:   `no pairs at all`,
+			drop: true,
+		},
+		{
+			cfg:  KVExtractConfig{Keys: []string{`missing`}, Drop_Misses: true},
+			in:   `a=1 b=2`,
+			drop: true,
+		},
+	}
+	for i, tt := range tests {
+		kv, err := NewKVExtractor(tt.cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ents, err := kv.Process([]*entry.Entry{{Data: []byte(tt.in)}})
+		if err != nil {
+			t.Fatal(err)
+		} else if tt.drop {
+			if len(ents) != 0 {
+				t.Fatalf("%d: entry not dropped: %s", i, ents[0].Data)
+			}
+			continue
+		} else if len(ents) != 1 {
+			t.Fatalf("%d: bad entry count %d", i, len(ents))
+		} else if string(ents[0].Data) != tt.out {
+			t.Fatalf("%d: bad output:\n%s\n%s", i, ents[0].Data, tt.out)
+		}
+	}
+}
+
+func TestKVExtractEnumeratedValues(t *testing.T) {
+	kv, err := NewKVExtractor(KVExtractConfig{
+		Keys:              []string{`src:source`, `dst`, `msg`},
+		Enumerated_Values: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := `action=allow src=10.0.0.1 dst=10.0.0.2 msg="a b"`
+	ents, err := kv.Process([]*entry.Entry{{Data: []byte(data)}})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(ents) != 1 {
+		t.Fatalf("bad entry count %d", len(ents))
+	}
+	ent := ents[0]
+	if string(ent.Data) != data {
+		t.Fatalf("entry data was modified: %s", ent.Data)
+	}
+	for k, v := range map[string]string{`source`: `10.0.0.1`, `dst`: `10.0.0.2`, `msg`: `a b`} {
+		if val, ok := ent.GetEnumeratedValue(k); !ok {
+			t.Fatalf("missing EV %s", k)
+		} else if s, ok := val.(string); !ok || s != v {
+			t.Fatalf("bad EV %s: %v", k, val)
+		}
+	}
+	if _, ok := ent.GetEnumeratedValue(`action`); ok {
+		t.Fatal("unselected key was attached")
+	}
+}
@@ -0,0 +1,569 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package processors
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/asergeyev/nradix"
+	"github.com/gravwell/gravwell/v4/ingest/config"
+	"github.com/gravwell/gravwell/v4/ingest/entry"
+	"github.com/gravwell/gravwell/v4/ingest/log"
+	"github.com/gravwell/jsonparser"
+)
+
+const (
+	LookupProcessor string = `lookup`
+
+	lookupFormatCSV  = `csv`
+	lookupFormatJSON = `json`
+
+	lookupKeySep = "\x00"
+)
+
+var (
+	ErrLookupNoTable       = errors.New("Table is required")
+	ErrLookupNoKey         = errors.New("at least one Key-Column is required")
+	ErrLookupFormat        = errors.New("Format must be csv or json")
+	ErrLookupSource        = errors.New("exactly one of Source-EV, JSON-Path, or Regex is required")
+	ErrLookupSourceCount   = errors.New("a Source-EV or JSON-Path is required for each Key-Column")
+	ErrLookupRegexCaptures = errors.New("Regex must have a capture group for each Key-Column")
+	ErrLookupCIDRKeys      = errors.New("Match-CIDR requires exactly one Key-Column")
+	ErrLookupMissingColumn = errors.New("Column not found in table")
+	ErrLookupEmptyTable    = errors.New("Table has no header")
+)
+
+// LookupConfig enriches entries from a CSV or JSON table.  Key values are pulled from each
+// entry in Key-Column order using enumerated values, JSON paths, or the capture groups of
+// a regular expression, where groups named after a key column are used before the other
+// groups.  Columns selects the table columns to attach as column or column:evname, all
+// non-key columns are attached by default and empty cells are skipped.  With Match-CIDR
+// the single key column holds networks or addresses and the most specific network
+// containing the key wins.  The table is reloaded when the file changes.
+type LookupConfig struct {
+	Table           string
+	Format          string // csv or json, default is based on the file extension
+	Key_Column      []string
+	Source_EV       []string
+	JSON_Path       []string
+	Regex           string
+	Columns         []string
+	EV_Prefix       string
+	Match_CIDR      bool
+	Ignore_Case     bool   // keys are matched without regard to case
+	Reload_Interval string // how often the table file is checked for changes
+}
+
+type lookupColumn struct {
+	col  string
+	name string
+}
+
+func LookupLoadConfig(vc *config.VariableConfig) (c LookupConfig, err error) {
+	if err = vc.MapTo(&c); err == nil {
+		_, err = c.validate()
+	}
+	return
+}
+
+func (c *LookupConfig) validate() (cols []lookupColumn, err error) {
+	if c.Table = strings.TrimSpace(c.Table); c.Table == `` {
+		err = ErrLookupNoTable
+		return
+	}
+	c.Table = filepath.Clean(c.Table)
+	switch c.Format = strings.ToLower(strings.TrimSpace(c.Format)); c.Format {
+	case ``:
+		c.Format = lookupFormatCSV
+		if strings.EqualFold(filepath.Ext(c.Table), `.json`) {
+			c.Format = lookupFormatJSON
+		}
+	case lookupFormatCSV, lookupFormatJSON:
+	default:
+		err = fmt.Errorf("%q: %w", c.Format, ErrLookupFormat)
+		return
+	}
+	c.Key_Column = trimStrings(c.Key_Column)
+	c.Source_EV = trimStrings(c.Source_EV)
+	c.JSON_Path = trimStrings(c.JSON_Path)
+	if len(c.Key_Column) == 0 {
+		err = ErrLookupNoKey
+		return
+	} else if c.Match_CIDR && len(c.Key_Column) != 1 {
+		err = ErrLookupCIDRKeys
+		return
+	} else if _, err = newLookupSource(c.Key_Column, c.Source_EV, c.JSON_Path, c.Regex); err != nil {
+		return
+	} else if _, err = parseReloadInterval(c.Reload_Interval); err != nil {
+		return
+	}
+	var names []string
+	for _, v := range c.Columns {
+		if v = strings.TrimSpace(v); v == `` {
+			continue
+		}
+		lc := lookupColumn{col: v, name: v}
+		if idx := strings.Index(v, `:`); idx != -1 {
+			lc.col, lc.name = strings.TrimSpace(v[:idx]), strings.TrimSpace(v[idx+1:])
+		}
+		if lc.name = c.EV_Prefix + lc.name; lc.col == `` || len(lc.name) == 0 || len(lc.name) > entry.MaxEvNameLength {
+			err = fmt.Errorf("%q: %w", v, ErrInvalidKeyname)
+			return
+		} else if inStringSet(names, lc.name) {
+			err = fmt.Errorf("%s: %w", lc.name, ErrDuplicateKeyname)
+			return
+		}
+		names = append(names, lc.name)
+		cols = append(cols, lc)
+	}
+	return
+}
+
+// spec identifies how a table file is indexed, processors with the same spec share a table
+func (c *LookupConfig) spec() string {
+	return fmt.Sprintf("%s|%t|%t|%q", c.Format, c.Match_CIDR, c.Ignore_Case, c.Key_Column)
+}
+
+func trimStrings(vals []string) (r []string) {
+	for _, v := range vals {
+		if v = strings.TrimSpace(v); v != `` {
+			r = append(r, v)
+		}
+	}
+	return
+}
+
+// lookupSource pulls one value for each key column out of an entry
+type lookupSource struct {
+	evs   []string
+	paths [][]string
+	rx    *regexp.Regexp
+	rxIdx []int
+}
+
+func newLookupSource(keys, evs, paths []string, rx string) (src lookupSource, err error) {
+	var cnt int
+	for _, v := range []bool{len(evs) > 0, len(paths) > 0, rx != ``} {
+		if v {
+			cnt++
+		}
+	}
+	if cnt != 1 {
+		err = ErrLookupSource
+		return
+	}
+	switch {
+	case len(evs) > 0:
+		if len(evs) != len(keys) {
+			err = ErrLookupSourceCount
+			return
+		}
+		src.evs = evs
+	case len(paths) > 0:
+		if len(paths) != len(keys) {
+			err = ErrLookupSourceCount
+			return
+		}
+		for _, p := range paths {
+			src.paths = append(src.paths, unquoteFields(splitRespectQuotes(p, dotSplitter)))
+		}
+	default:
+		if src.rx, err = regexp.Compile(rx); err != nil {
+			return
+		} else if src.rx.NumSubexp() < len(keys) {
+			err = ErrLookupRegexCaptures
+			return
+		}
+		//groups named after a key column first, the rest take the remaining groups in order
+		var used []int
+		for _, k := range keys {
+			idx := src.rx.SubexpIndex(k)
+			src.rxIdx = append(src.rxIdx, idx)
+			if idx != -1 {
+				used = append(used, idx)
+			}
+		}
+		next := 1
+		for i := range src.rxIdx {
+			if src.rxIdx[i] != -1 {
+				continue
+			}
+			for next <= src.rx.NumSubexp() && containsInt(used, next) {
+				next++
+			}
+			if next > src.rx.NumSubexp() {
+				err = ErrLookupRegexCaptures
+				return
+			}
+			src.rxIdx[i] = next
+			next++
+		}
+	}
+	return
+}
+
+func containsInt(set []int, v int) bool {
+	for _, x := range set {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+// extract appends the key values for an entry to vals
+func (src *lookupSource) extract(ent *entry.Entry, vals []string) ([]string, bool) {
+	switch {
+	case src.evs != nil:
+		for _, name := range src.evs {
+			ev, ok := ent.EVB.Get(name)
+			if !ok {
+				return vals, false
+			}
+			vals = append(vals, ev.Value.String())
+		}
+	case src.paths != nil:
+		for _, p := range src.paths {
+			v, _, _, err := jsonparser.Get(ent.Data, p...)
+			if err != nil {
+				return vals, false
+			}
+			vals = append(vals, string(v))
+		}
+	default:
+		m := src.rx.FindSubmatch(ent.Data)
+		if m == nil {
+			return vals, false
+		}
+		for _, idx := range src.rxIdx {
+			vals = append(vals, string(m[idx]))
+		}
+	}
+	return vals, true
+}
+
+// lookupTable is a loaded table, rows are keyed by their joined key columns or held in
+// a radix tree for CIDR tables
+type lookupTable struct {
+	columns []string
+	rows    map[string][]string
+	tree    *nradix.Tree
+}
+
+func (lt *lookupTable) index(col string) int {
+	for i, v := range lt.columns {
+		if v == col {
+			return i
+		}
+	}
+	return -1
+}
+
+func (lt *lookupTable) find(keys []string, ignoreCase bool) (row []string, ok bool) {
+	if lt.tree != nil {
+		ip := net.ParseIP(strings.TrimSpace(keys[0]))
+		if ip == nil {
+			return
+		}
+		v, err := lt.tree.FindCIDR(ip.String())
+		if err != nil || v == nil {
+			return
+		}
+		row, ok = v.([]string)
+		return
+	}
+	key := strings.Join(keys, lookupKeySep)
+	if ignoreCase {
+		key = strings.ToLower(key)
+	}
+	row, ok = lt.rows[key]
+	return
+}
+
+// loadLookupTable returns a loader for tables using the key columns, the first row for a
+// key wins
+func loadLookupTable(format string, keys []string, cidr, ignoreCase bool) func(string) (*lookupTable, error) {
+	return func(pth string) (lt *lookupTable, err error) {
+		var fin *os.File
+		if fin, err = os.Open(pth); err != nil {
+			return
+		}
+		defer fin.Close()
+		var cols []string
+		var rows [][]string
+		if format == lookupFormatJSON {
+			cols, rows, err = readLookupJSON(fin)
+		} else {
+			cols, rows, err = readLookupCSV(fin)
+		}
+		if err != nil {
+			return
+		}
+		lt = &lookupTable{columns: cols}
+		keyIdx := make([]int, 0, len(keys))
+		for _, k := range keys {
+			idx := lt.index(k)
+			if idx == -1 {
+				return nil, fmt.Errorf("%s: %w", k, ErrLookupMissingColumn)
+			}
+			keyIdx = append(keyIdx, idx)
+		}
+		if cidr {
+			lt.tree = nradix.NewTree(len(rows))
+			for _, row := range rows {
+				if err = lt.tree.AddCIDR(strings.TrimSpace(row[keyIdx[0]]), row); err != nil && err != nradix.ErrNodeBusy {
+					return nil, fmt.Errorf("invalid network %q: %w", row[keyIdx[0]], err)
+				}
+			}
+			err = nil
+			return
+		}
+		lt.rows = make(map[string][]string, len(rows))
+		kvals := make([]string, len(keyIdx))
+		for _, row := range rows {
+			for i, idx := range keyIdx {
+				kvals[i] = row[idx]
+			}
+			key := strings.Join(kvals, lookupKeySep)
+			if ignoreCase {
+				key = strings.ToLower(key)
+			}
+			if _, ok := lt.rows[key]; !ok {
+				lt.rows[key] = row
+			}
+		}
+		return
+	}
+}
+
+// readLookupCSV reads a CSV table with a header row, short rows are padded
+func readLookupCSV(rdr io.Reader) (cols []string, rows [][]string, err error) {
+	cr := csv.NewReader(rdr)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	if cols, err = cr.Read(); err == io.EOF {
+		err = ErrLookupEmptyTable
+		return
+	} else if err != nil {
+		return
+	}
+	for i := range cols {
+		cols[i] = strings.TrimSpace(cols[i])
+	}
+	for {
+		var row []string
+		if row, err = cr.Read(); err == io.EOF {
+			err = nil
+			return
+		} else if err != nil {
+			return
+		}
+		for len(row) < len(cols) {
+			row = append(row, ``)
+		}
+		rows = append(rows, row[:len(cols)])
+	}
+}
+
+// readLookupJSON reads a table from a JSON array of objects, columns are the sorted union
+// of object keys and values which are not strings are kept in their JSON form
+func readLookupJSON(rdr io.Reader) (cols []string, rows [][]string, err error) {
+	var objs []map[string]json.RawMessage
+	if err = json.NewDecoder(rdr).Decode(&objs); err != nil {
+		return
+	}
+	idx := map[string]int{}
+	for _, obj := range objs {
+		for k := range obj {
+			if _, ok := idx[k]; !ok {
+				idx[k] = 0
+				cols = append(cols, k)
+			}
+		}
+	}
+	if len(cols) == 0 {
+		err = ErrLookupEmptyTable
+		return
+	}
+	sort.Strings(cols)
+	for i, c := range cols {
+		idx[c] = i
+	}
+	for _, obj := range objs {
+		row := make([]string, len(cols))
+		for k, v := range obj {
+			var s string
+			if json.Unmarshal(v, &s) != nil {
+				s = string(v)
+			}
+			row[idx[k]] = s
+		}
+		rows = append(rows, row)
+	}
+	return
+}
+
+type Lookup struct {
+	LookupConfig
+	cols     []lookupColumn
+	src      lookupSource
+	interval time.Duration
+	table    *sharedFile[lookupTable]
+	tables   *sharedFileSet[lookupTable]
+	last     *lookupTable // table the column indexes were resolved against
+	colIdx   []int
+	keys     []string
+	lg       log.IngestLogger
+}
+
+func NewLookup(cfg LookupConfig, tagger Tagger) (l *Lookup, err error) {
+	l = &Lookup{}
+	if lg, ok := tagger.(log.IngestLogger); ok {
+		l.lg = lg
+	}
+	if err = l.init(cfg); err != nil {
+		l = nil
+	}
+	return
+}
+
+func (l *Lookup) Config(v interface{}) (err error) {
+	if v == nil {
+		err = ErrNilConfig
+	} else if cfg, ok := v.(LookupConfig); ok {
+		err = l.init(cfg)
+	} else {
+		err = fmt.Errorf("Invalid configuration, unknown type type %T", v)
+	}
+	return
+}
+
+func (l *Lookup) init(cfg LookupConfig) (err error) {
+	var cols []lookupColumn
+	if cols, err = cfg.validate(); err != nil {
+		return
+	}
+	tables := lookupTableSet(&cfg)
+	var tbl *sharedFile[lookupTable]
+	if tbl, err = tables.acquire(cfg.Table); err != nil {
+		return
+	}
+	lt := tbl.get()
+	if len(cols) == 0 {
+		for _, c := range lt.columns {
+			if !inStringSet(cfg.Key_Column, c) {
+				cols = append(cols, lookupColumn{col: c, name: cfg.EV_Prefix + c})
+			}
+		}
+	}
+	for _, c := range cols {
+		if lt.index(c.col) == -1 {
+			err = fmt.Errorf("%s: %w", c.col, ErrLookupMissingColumn)
+		} else if len(c.name) > entry.MaxEvNameLength {
+			err = fmt.Errorf("%q: %w", c.name, ErrInvalidKeyname)
+		}
+		if err != nil {
+			tables.release([]*sharedFile[lookupTable]{tbl})
+			return
+		}
+	}
+	l.release()
+	l.LookupConfig = cfg
+	l.cols = cols
+	l.table, l.tables, l.last = tbl, tables, nil
+	l.interval, _ = parseReloadInterval(cfg.Reload_Interval)
+	l.src, _ = newLookupSource(cfg.Key_Column, cfg.Source_EV, cfg.JSON_Path, cfg.Regex)
+	return
+}
+
+func (l *Lookup) release() {
+	if l.table != nil {
+		l.tables.release([]*sharedFile[lookupTable]{l.table})
+		l.table = nil
+	}
+}
+
+func (l *Lookup) Process(ents []*entry.Entry) (rset []*entry.Entry, err error) {
+	if len(ents) == 0 {
+		return
+	}
+	if lerr := l.table.checkReload(time.Now(), l.interval); lerr != nil && l.lg != nil {
+		l.lg.Warn("failed to reload lookup table", log.KV("table", l.table.path), log.KVErr(lerr))
+	}
+	lt := l.table.get()
+	if lt != l.last {
+		//columns may have moved when the table was reloaded
+		l.colIdx = l.colIdx[:0]
+		for _, c := range l.cols {
+			l.colIdx = append(l.colIdx, lt.index(c.col))
+		}
+		l.last = lt
+	}
+	for _, ent := range ents {
+		if ent != nil {
+			l.enrich(ent, lt)
+		}
+	}
+	rset = ents
+	return
+}
+
+func (l *Lookup) enrich(ent *entry.Entry, lt *lookupTable) {
+	var ok bool
+	if l.keys, ok = l.src.extract(ent, l.keys[:0]); !ok {
+		return
+	}
+	row, ok := lt.find(l.keys, l.Ignore_Case)
+	if !ok {
+		return
+	}
+	for i, idx := range l.colIdx {
+		if idx != -1 && row[idx] != `` {
+			ent.AddEnumeratedValueEx(l.cols[i].name, row[idx])
+		}
+	}
+}
+
+func (l *Lookup) Flush() []*entry.Entry {
+	return nil
+}
+
+func (l *Lookup) Close() error {
+	l.release()
+	return nil
+}
+
+var (
+	lookupTablesMtx sync.Mutex
+	lookupTables    = map[string]*sharedFileSet[lookupTable]{}
+)
+
+// lookupTableSet returns the shared tables indexed the way the config requires
+func lookupTableSet(c *LookupConfig) (s *sharedFileSet[lookupTable]) {
+	spec := c.spec()
+	lookupTablesMtx.Lock()
+	if s = lookupTables[spec]; s == nil {
+		s = newSharedFileSet(loadLookupTable(c.Format, c.Key_Column, c.Match_CIDR, c.Ignore_Case))
+		lookupTables[spec] = s
+	}
+	lookupTablesMtx.Unlock()
+	return
+}
@@ -0,0 +1,262 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package processors
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gravwell/gravwell/v4/ingest/entry"
+)
+
+const (
+	testLookupUsers = `user,domain,department,manager
+alice,corp,engineering,bob
+Bob,corp,sales,carol
+alice,lab,research,dave
+`
+	testLookupNets = `network,site,owner
+10.0.0.0/8,datacenter,infra
+10.1.0.0/16,office,it
+2001:db8::/32,cloud,platform
+192.168.1.1,printer,it
+`
+)
+
+func writeTestTable(t *testing.T, name, data string) string {
+	t.Helper()
+	pth := filepath.Join(t.TempDir(), name)
+	writeTestFile(t, pth, []byte(data))
+	return pth
+}
+
+func testLookup(t *testing.T, cfg LookupConfig) *Lookup {
+	t.Helper()
+	l, err := NewLookup(cfg, &testTagger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	return l
+}
+
+func checkLookupEV(t *testing.T, ent *entry.Entry, name, val string) {
+	t.Helper()
+	if v, ok := ent.GetEnumeratedValue(name); !ok {
+		if val != `` {
+			t.Fatalf("missing %s", name)
+		}
+	} else if val == `` {
+		t.Fatalf("unexpected %s: %v", name, v)
+	} else if v != val {
+		t.Fatalf("bad %s: %v != %s", name, v, val)
+	}
+}
+
+func TestLookupConfig(t *testing.T) {
+	pth := writeTestTable(t, `users.csv`, testLookupUsers)
+	b := `
+	[preprocessor "lu"]
+		type = lookup
+		Table="` + pth + `"
+		Key-Column=user
+		Key-Column=domain
+		Regex="user=(\\S+)@(\\S+)"
+		Columns=department
+		Columns="manager:boss"
+		EV-Prefix=user_
+	`
+	p, err := testLoadPreprocessor(b, `lu`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, ok := p.(*Lookup)
+	if !ok {
+		t.Fatalf("preprocessor is the wrong type: %T != *Lookup", p)
+	} else if l.Format != lookupFormatCSV || len(l.cols) != 2 || l.cols[1].name != `user_boss` {
+		t.Fatalf("bad config: %+v %+v", l.LookupConfig, l.cols)
+	}
+	if err = p.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	bad := []LookupConfig{
+		{Key_Column: []string{`user`}, Source_EV: []string{`user`}},
+		{Table: pth, Source_EV: []string{`user`}},
+		{Table: pth, Key_Column: []string{`user`}},
+		{Table: pth, Key_Column: []string{`user`}, Source_EV: []string{`user`}, Regex: `(\S+)`},
+		{Table: pth, Key_Column: []string{`user`, `domain`}, Source_EV: []string{`user`}},
+		{Table: pth, Key_Column: []string{`user`, `domain`}, Regex: `(\S+)`},
+		{Table: pth, Key_Column: []string{`user`, `domain`}, Source_EV: []string{`a`, `b`}, Match_CIDR: true},
+		{Table: pth, Key_Column: []string{`user`}, Source_EV: []string{`user`}, Format: `xml`},
+		{Table: pth, Key_Column: []string{`user`}, Source_EV: []string{`user`}, Reload_Interval: `-1s`},
+		{Table: pth, Key_Column: []string{`missing`}, Source_EV: []string{`user`}},
+		{Table: pth, Key_Column: []string{`user`}, Source_EV: []string{`user`}, Columns: []string{`missing`}},
+		{Table: pth, Key_Column: []string{`user`}, Source_EV: []string{`user`}, Columns: []string{`domain:x`, `manager:x`}},
+		{Table: filepath.Join(t.TempDir(), `missing.csv`), Key_Column: []string{`user`}, Source_EV: []string{`user`}},
+	}
+	for _, c := range bad {
+		if l, err = NewLookup(c, &testTagger{}); err == nil {
+			l.Close()
+			t.Fatalf("bad config %+v did not fail", c)
+		}
+	}
+}
+
+func TestLookupKeys(t *testing.T) {
+	pth := writeTestTable(t, `users.csv`, testLookupUsers)
+	l := testLookup(t, LookupConfig{
+		Table:      pth,
+		Key_Column: []string{`user`, `domain`},
+		Regex:      `user=(?P<domain>\w+)\\(\w+)`,
+	})
+	ents := []*entry.Entry{
+		{Data: []byte(`login user=corp\alice`)},
+		{Data: []byte(`login user=lab\alice`)},
+		{Data: []byte(`login user=corp\bob`)},
+		{Data: []byte(`nothing here`)},
+	}
+	if _, err := l.Process(ents); err != nil {
+		t.Fatal(err)
+	}
+	checkLookupEV(t, ents[0], `department`, `engineering`)
+	checkLookupEV(t, ents[0], `manager`, `bob`)
+	checkLookupEV(t, ents[0], `user`, ``)
+	checkLookupEV(t, ents[1], `department`, `research`)
+	checkLookupEV(t, ents[2], `department`, ``)
+	checkLookupEV(t, ents[3], `department`, ``)
+
+	//case insensitive keys from enumerated values
+	l = testLookup(t, LookupConfig{
+		Table:       pth,
+		Key_Column:  []string{`user`, `domain`},
+		Source_EV:   []string{`u`, `d`},
+		Columns:     []string{`department:dept`},
+		Ignore_Case: true,
+	})
+	ent := &entry.Entry{}
+	ent.AddEnumeratedValueEx(`u`, `BOB`)
+	ent.AddEnumeratedValueEx(`d`, `Corp`)
+	if _, err := l.Process([]*entry.Entry{ent}); err != nil {
+		t.Fatal(err)
+	}
+	checkLookupEV(t, ent, `dept`, `sales`)
+	checkLookupEV(t, ent, `manager`, ``)
+}
+
+func TestLookupJSON(t *testing.T) {
+	pth := writeTestTable(t, `hosts.json`, `[
+		{"host": "web1", "owner": "web team", "tier": 1},
+		{"host": "db1", "owner": "dba", "tier": 0, "pci": true}
+	]`)
+	l := testLookup(t, LookupConfig{
+		Table:      pth,
+		Key_Column: []string{`host`},
+		JSON_Path:  []string{`src.host`},
+		EV_Prefix:  `asset_`,
+	})
+	if l.Format != lookupFormatJSON {
+		t.Fatalf("format not detected: %s", l.Format)
+	}
+	ents := []*entry.Entry{
+		{Data: []byte(`{"src": {"host": "db1"}}`)},
+		{Data: []byte(`{"src": {"host": "web1"}}`)},
+	}
+	if _, err := l.Process(ents); err != nil {
+		t.Fatal(err)
+	}
+	checkLookupEV(t, ents[0], `asset_owner`, `dba`)
+	checkLookupEV(t, ents[0], `asset_tier`, `0`)
+	checkLookupEV(t, ents[0], `asset_pci`, `true`)
+	checkLookupEV(t, ents[1], `asset_owner`, `web team`)
+	checkLookupEV(t, ents[1], `asset_pci`, ``)
+}
+
+func TestLookupCIDR(t *testing.T) {
+	pth := writeTestTable(t, `nets.csv`, testLookupNets)
+	l := testLookup(t, LookupConfig{
+		Table:      pth,
+		Key_Column: []string{`network`},
+		Source_EV:  []string{`ip`},
+		Match_CIDR: true,
+	})
+	tests := []struct {
+		ip   string
+		site string
+	}{
+		{`10.2.3.4`, `datacenter`},
+		{`10.1.3.4`, `office`},
+		{`2001:db8::1`, `cloud`},
+		{`192.168.1.1`, `printer`},
+		{`192.168.1.2`, ``},
+		{`not an ip`, ``},
+	}
+	for _, tt := range tests {
+		ent := &entry.Entry{}
+		if ip := net.ParseIP(tt.ip); ip != nil {
+			ent.AddEnumeratedValueEx(`ip`, ip)
+		} else {
+			ent.AddEnumeratedValueEx(`ip`, tt.ip)
+		}
+		if _, err := l.Process([]*entry.Entry{ent}); err != nil {
+			t.Fatal(err)
+		}
+		checkLookupEV(t, ent, `site`, tt.site)
+	}
+}
+
+func TestLookupReload(t *testing.T) {
+	pth := writeTestTable(t, `users.csv`, testLookupUsers)
+	l := testLookup(t, LookupConfig{
+		Table:      pth,
+		Key_Column: []string{`user`},
+		Source_EV:  []string{`user`},
+		Columns:    []string{`department`},
+	})
+	//a second processor on the same table shares it
+	l2 := testLookup(t, LookupConfig{
+		Table:      pth,
+		Key_Column: []string{`user`},
+		Source_EV:  []string{`user`},
+	})
+	if l.table != l2.table {
+		t.Fatal("table is not shared")
+	}
+	lookup := func() *entry.Entry {
+		ent := &entry.Entry{}
+		ent.AddEnumeratedValueEx(`user`, `Bob`)
+		if _, err := l.Process([]*entry.Entry{ent}); err != nil {
+			t.Fatal(err)
+		}
+		return ent
+	}
+	checkLookupEV(t, lookup(), `department`, `sales`)
+
+	//columns are reordered in the new file
+	writeTestFile(t, pth, []byte("department,user\nmarketing,Bob\n"))
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(pth, future, future); err != nil {
+		t.Fatal(err)
+	}
+	checkLookupEV(t, lookup(), `department`, `sales`) //not checked yet
+	l.table.lastCheck = time.Time{}
+	checkLookupEV(t, lookup(), `department`, `marketing`)
+
+	//a broken file keeps the old table
+	writeTestFile(t, pth, []byte("department,owner\n"))
+	future = future.Add(time.Hour)
+	if err := os.Chtimes(pth, future, future); err != nil {
+		t.Fatal(err)
+	}
+	l.table.lastCheck = time.Time{}
+	checkLookupEV(t, lookup(), `department`, `marketing`)
+}
@@ -0,0 +1,274 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package processors
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/gravwell/gravwell/v4/ingest/config"
+	"github.com/gravwell/gravwell/v4/ingest/entry"
+)
+
+const (
+	MultilineProcessor string = `multiline`
+
+	defaultMultilineSeparator  = "\n"
+	defaultMultilineMaxLines   = 500
+	defaultMultilineMaxBytes   = 1024 * 1024
+	defaultMultilineTimeout    = 2 * time.Second
+	defaultMultilineMaxPending = 1024
+)
+
+var (
+	ErrMultilineNoRules    = errors.New("one of Start-Regex, Continuation-Regex, or Indented-Continuation is required")
+	ErrInvalidMaxLines     = errors.New("Max-Lines cannot be negative")
+	ErrInvalidMaxBytes     = errors.New("Max-Bytes cannot be negative")
+	ErrInvalidMaxPending   = errors.New("Max-Pending cannot be negative")
+	ErrInvalidMultiTimeout = errors.New("Timeout must be a positive duration")
+)
+
+// MultilineConfig merges consecutive entries with the same tag and source into a single
+// entry.  An entry continues the current entry when it matches Continuation-Regex or, with
+// Indented-Continuation, begins with a space or tab.  Otherwise it continues the current
+// entry unless it matches Start-Regex; when no Start-Regex is given every other entry
+// starts a new one.  Merged entries keep the timestamp and enumerated values of the first
+// entry and are emitted when the next one starts, a limit is reached, Timeout passes with
+// no new lines, or the preprocessor is flushed.
+type MultilineConfig struct {
+	Start_Regex           string
+	Continuation_Regex    string
+	Indented_Continuation bool
+	Separator             string // inserted between merged entries, default is a newline
+	Max_Lines             int    // maximum number of entries merged together
+	Max_Bytes             int    // maximum size of a merged entry
+	Timeout               string // how long a partial entry is held waiting for more lines
+	Max_Pending           int    // maximum number of tag and source pairs with partial entries
+}
+
+func MultilineLoadConfig(vc *config.VariableConfig) (c MultilineConfig, err error) {
+	if err = vc.MapTo(&c); err == nil {
+		_, _, _, err = c.validate()
+	}
+	return
+}
+
+func (c *MultilineConfig) validate() (start, cont *regexp.Regexp, timeout time.Duration, err error) {
+	if c.Start_Regex != `` {
+		if start, err = regexp.Compile(c.Start_Regex); err != nil {
+			err = fmt.Errorf("invalid Start-Regex: %w", err)
+			return
+		}
+	}
+	if c.Continuation_Regex != `` {
+		if cont, err = regexp.Compile(c.Continuation_Regex); err != nil {
+			err = fmt.Errorf("invalid Continuation-Regex: %w", err)
+			return
+		}
+	}
+	if start == nil && cont == nil && !c.Indented_Continuation {
+		err = ErrMultilineNoRules
+		return
+	}
+	if c.Separator == `` {
+		c.Separator = defaultMultilineSeparator
+	}
+	if c.Max_Lines < 0 {
+		err = ErrInvalidMaxLines
+		return
+	} else if c.Max_Lines == 0 {
+		c.Max_Lines = defaultMultilineMaxLines
+	}
+	if c.Max_Bytes < 0 {
+		err = ErrInvalidMaxBytes
+		return
+	} else if c.Max_Bytes == 0 {
+		c.Max_Bytes = defaultMultilineMaxBytes
+	}
+	if c.Max_Pending < 0 {
+		err = ErrInvalidMaxPending
+		return
+	} else if c.Max_Pending == 0 {
+		c.Max_Pending = defaultMultilineMaxPending
+	}
+	timeout = defaultMultilineTimeout
+	if c.Timeout != `` {
+		if timeout, err = time.ParseDuration(c.Timeout); err != nil {
+			return
+		} else if timeout <= 0 {
+			err = ErrInvalidMultiTimeout
+			return
+		}
+	}
+	return
+}
+
+type multilineKey struct {
+	tag entry.EntryTag
+	src string
+}
+
+type multilineGroup struct {
+	ent   *entry.Entry
+	buff  []byte
+	lines int
+	seq   uint64
+	last  time.Time
+}
+
+// Multiline holds partial entries until they are complete, it implements Expirer so that
+// a ProcessorSet releases partial entries from idle sources once Timeout passes
+type Multiline struct {
+	MultilineConfig
+	start   *regexp.Regexp
+	cont    *regexp.Regexp
+	timeout time.Duration
+	pending map[multilineKey]*multilineGroup
+	seq     uint64
+	lastExp time.Time
+	now     func() time.Time
+}
+
+func NewMultiline(cfg MultilineConfig) (m *Multiline, err error) {
+	m = &Multiline{
+		pending: map[multilineKey]*multilineGroup{},
+		now:     time.Now,
+	}
+	if err = m.init(cfg); err != nil {
+		m = nil
+	}
+	return
+}
+
+func (m *Multiline) Config(v interface{}) (err error) {
+	if v == nil {
+		err = ErrNilConfig
+	} else if cfg, ok := v.(MultilineConfig); ok {
+		err = m.init(cfg)
+	} else {
+		err = fmt.Errorf("Invalid configuration, unknown type type %T", v)
+	}
+	return
+}
+
+func (m *Multiline) init(cfg MultilineConfig) (err error) {
+	var start, cont *regexp.Regexp
+	var timeout time.Duration
+	if start, cont, timeout, err = cfg.validate(); err != nil {
+		return
+	}
+	m.MultilineConfig = cfg
+	m.start, m.cont = start, cont
+	m.timeout = timeout
+	return
+}
+
+func (m *Multiline) Process(ents []*entry.Entry) (rset []*entry.Entry, err error) {
+	if len(ents) == 0 {
+		return
+	}
+	now := m.now()
+	for _, ent := range ents {
+		if ent == nil {
+			continue
+		}
+		key := multilineKey{tag: ent.Tag, src: string(ent.SRC)}
+		g, ok := m.pending[key]
+		if ok && m.continues(ent.Data) && g.lines < m.Max_Lines && m.size(g)+len(m.Separator)+len(ent.Data) <= m.Max_Bytes {
+			if g.buff == nil {
+				g.buff = append(make([]byte, 0, len(g.ent.Data)+len(m.Separator)+len(ent.Data)), g.ent.Data...)
+			}
+			g.buff = append(append(g.buff, m.Separator...), ent.Data...)
+			g.lines++
+			g.last = now
+			continue
+		} else if ok {
+			rset = append(rset, m.complete(g))
+			delete(m.pending, key)
+		} else if len(m.pending) >= m.Max_Pending {
+			rset = append(rset, m.evictOldest())
+		}
+		m.seq++
+		m.pending[key] = &multilineGroup{ent: ent, lines: 1, seq: m.seq, last: now}
+	}
+	if now.Sub(m.lastExp) >= expireInterval {
+		rset = append(rset, m.Expire(now)...)
+	}
+	return
+}
+
+// Flush emits every partial entry
+func (m *Multiline) Flush() []*entry.Entry {
+	return m.release(func(*multilineGroup) bool { return true })
+}
+
+// Expire emits partial entries which have not seen a new line within Timeout
+func (m *Multiline) Expire(now time.Time) []*entry.Entry {
+	m.lastExp = now
+	return m.release(func(g *multilineGroup) bool { return now.Sub(g.last) >= m.timeout })
+}
+
+func (m *Multiline) Close() error {
+	return nil
+}
+
+// continues returns true if the data continues the current entry
+func (m *Multiline) continues(data []byte) bool {
+	if m.cont != nil && m.cont.Match(data) {
+		return true
+	} else if m.Indented_Continuation && len(data) > 0 && (data[0] == ' ' || data[0] == '\t') {
+		return true
+	}
+	return m.start != nil && !m.start.Match(data)
+}
+
+func (m *Multiline) size(g *multilineGroup) int {
+	if g.buff != nil {
+		return len(g.buff)
+	}
+	return len(g.ent.Data)
+}
+
+func (m *Multiline) complete(g *multilineGroup) *entry.Entry {
+	if g.buff != nil {
+		g.ent.Data = g.buff
+	}
+	return g.ent
+}
+
+// release emits the groups selected by fn in the order they were started
+func (m *Multiline) release(fn func(*multilineGroup) bool) (rset []*entry.Entry) {
+	var groups []*multilineGroup
+	for k, g := range m.pending {
+		if fn(g) {
+			groups = append(groups, g)
+			delete(m.pending, k)
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].seq < groups[j].seq })
+	for _, g := range groups {
+		rset = append(rset, m.complete(g))
+	}
+	return
+}
+
+func (m *Multiline) evictOldest() *entry.Entry {
+	var oldest *multilineGroup
+	var okey multilineKey
+	for k, g := range m.pending {
+		if oldest == nil || g.last.Before(oldest.last) {
+			oldest, okey = g, k
+		}
+	}
+	delete(m.pending, okey)
+	return m.complete(oldest)
+}
@@ -0,0 +1,253 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package processors
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gravwell/gravwell/v4/ingest/entry"
+)
+
+const testJavaTrace = `2024-01-02 03:04:05 ERROR request failed
+java.lang.NullPointerException: oops
+	at com.example.Foo.bar(Foo.java:10)
+	at com.example.Main.main(Main.java:5)
+Caused by: java.io.IOException: closed
+	... 2 more
+2024-01-02 03:04:06 INFO recovered`
+
+func newTestMultiline(t *testing.T, cfg MultilineConfig) (*Multiline, *testClock) {
+	m, err := NewMultiline(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tc := &testClock{t: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}
+	m.now = tc.now
+	return m, tc
+}
+
+func testLines(s string, src net.IP) (ents []*entry.Entry) {
+	for _, l := range strings.Split(s, "\n") {
+		ents = append(ents, &entry.Entry{Tag: 1, SRC: src, Data: []byte(l)})
+	}
+	return
+}
+
+func multilineData(ents []*entry.Entry) (r []string) {
+	for _, ent := range ents {
+		r = append(r, string(ent.Data))
+	}
+	return
+}
+
+func TestMultilineConfig(t *testing.T) {
+	b := `
+	[preprocessor "ml"]
+		type = multiline
+		Start-Regex="^\\d{4}-\\d{2}-\\d{2}"
+		Indented-Continuation=true
+		Max-Lines=100
+		Timeout=5s
+	`
+	p, err := testLoadPreprocessor(b, `ml`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, ok := p.(*Multiline)
+	if !ok {
+		t.Fatalf("preprocessor is the wrong type: %T != *Multiline", p)
+	} else if m.start == nil || m.cont != nil || m.timeout != 5*time.Second || m.Max_Lines != 100 ||
+		m.Max_Bytes != defaultMultilineMaxBytes || m.Separator != "\n" {
+		t.Fatalf("bad config: %+v", m.MultilineConfig)
+	}
+	if _, ok := p.(Expirer); !ok {
+		t.Fatal("multiline is not an Expirer")
+	}
+
+	bad := []MultilineConfig{
+		{},
+		{Start_Regex: `(`},
+		{Continuation_Regex: `(`},
+		{Indented_Continuation: true, Max_Lines: -1},
+		{Indented_Continuation: true, Max_Bytes: -1},
+		{Indented_Continuation: true, Max_Pending: -1},
+		{Indented_Continuation: true, Timeout: `-1s`},
+		{Indented_Continuation: true, Timeout: `soon`},
+	}
+	for _, cfg := range bad {
+		if _, err := NewMultiline(cfg); err == nil {
+			t.Fatalf("bad config did not fail: %+v", cfg)
+		}
+	}
+}
+
+func TestMultilineRules(t *testing.T) {
+	lines := strings.Split(testJavaTrace, "\n")
+	exp := strings.Join(lines[:6], "\n")
+	cfgs := []MultilineConfig{
+		{Start_Regex: `^\d{4}-\d{2}-\d{2}`},
+		{Continuation_Regex: `^(\s|Caused by:|java\.)`},
+		{Indented_Continuation: true, Continuation_Regex: `^(Caused by:|java\.)`},
+	}
+	for _, cfg := range cfgs {
+		m, _ := newTestMultiline(t, cfg)
+		ents, err := m.Process(testLines(testJavaTrace, nil))
+		if err != nil {
+			t.Fatal(err)
+		} else if len(ents) != 1 || string(ents[0].Data) != exp {
+			t.Fatalf("%+v: bad merge %d\n%q", cfg, len(ents), multilineData(ents))
+		}
+		if ents = m.Flush(); len(ents) != 1 || string(ents[0].Data) != lines[6] {
+			t.Fatalf("%+v: flush did not emit the partial entry: %q", cfg, multilineData(ents))
+		} else if ents = m.Flush(); len(ents) != 0 {
+			t.Fatalf("second flush emitted %d entries", len(ents))
+		}
+	}
+}
+
+func TestMultilineSources(t *testing.T) {
+	m, _ := newTestMultiline(t, MultilineConfig{Indented_Continuation: true, Separator: ` | `})
+	a, b := net.ParseIP(`10.0.0.1`), net.ParseIP(`10.0.0.2`)
+	in := []*entry.Entry{
+		{Tag: 1, SRC: a, Data: []byte(`a1`)},
+		{Tag: 1, SRC: b, Data: []byte(`b1`)},
+		{Tag: 2, SRC: a, Data: []byte(`c1`)},
+		{Tag: 1, SRC: a, Data: []byte(` a2`)},
+		{Tag: 1, SRC: b, Data: []byte(` b2`)},
+		{Tag: 2, SRC: a, Data: []byte(`c2`)},
+	}
+	in[0].AddEnumeratedValueEx(`first`, true)
+	ents, err := m.Process(in)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(ents) != 1 || string(ents[0].Data) != `c1` {
+		t.Fatalf("bad entries: %q", multilineData(ents))
+	}
+	ents = m.Flush()
+	if len(ents) != 3 || string(ents[0].Data) != `a1 |  a2` || string(ents[1].Data) != `b1 |  b2` || string(ents[2].Data) != `c2` {
+		t.Fatalf("bad flushed entries: %q", multilineData(ents))
+	} else if v, ok := ents[0].GetEnumeratedValue(`first`); !ok || v != true {
+		t.Fatal("merged entry lost the enumerated values of the first entry")
+	}
+}
+
+func TestMultilineLimits(t *testing.T) {
+	m, _ := newTestMultiline(t, MultilineConfig{Indented_Continuation: true, Max_Lines: 3})
+	ents, err := m.Process(testLines("a\n 1\n 2\n 3\n 4", nil))
+	if err != nil {
+		t.Fatal(err)
+	} else if len(ents) != 1 || string(ents[0].Data) != "a\n 1\n 2" {
+		t.Fatalf("bad Max-Lines split: %q", multilineData(ents))
+	} else if ents = m.Flush(); len(ents) != 1 || string(ents[0].Data) != " 3\n 4" {
+		t.Fatalf("bad Max-Lines remainder: %q", multilineData(ents))
+	}
+
+	m, _ = newTestMultiline(t, MultilineConfig{Indented_Continuation: true, Max_Bytes: 8})
+	if ents, err = m.Process(testLines("abc\n de\n fg", nil)); err != nil {
+		t.Fatal(err)
+	} else if len(ents) != 1 || string(ents[0].Data) != "abc\n de" {
+		t.Fatalf("bad Max-Bytes split: %q", multilineData(ents))
+	}
+
+	m, _ = newTestMultiline(t, MultilineConfig{Indented_Continuation: true, Max_Pending: 2})
+	in := []*entry.Entry{
+		{Tag: 1, Data: []byte(`a`)},
+		{Tag: 2, Data: []byte(`b`)},
+		{Tag: 3, Data: []byte(`c`)},
+	}
+	if ents, err = m.Process(in); err != nil {
+		t.Fatal(err)
+	} else if len(ents) != 1 || string(ents[0].Data) != `a` || len(m.pending) != 2 {
+		t.Fatalf("bad Max-Pending eviction: %q %d", multilineData(ents), len(m.pending))
+	}
+}
+
+func TestMultilineTimeout(t *testing.T) {
+	m, tc := newTestMultiline(t, MultilineConfig{Indented_Continuation: true, Timeout: `5s`})
+	ents, err := m.Process(testLines("a\n 1", nil))
+	if err != nil {
+		t.Fatal(err)
+	} else if len(ents) != 0 {
+		t.Fatalf("entries emitted early: %q", multilineData(ents))
+	}
+	tc.t = tc.t.Add(4 * time.Second)
+	if ents = m.Expire(tc.t); len(ents) != 0 {
+		t.Fatalf("entries expired early: %q", multilineData(ents))
+	} else if ents, _ = m.Process(testLines(" 2", nil)); len(ents) != 0 {
+		t.Fatalf("continuation emitted early: %q", multilineData(ents))
+	}
+	tc.t = tc.t.Add(5 * time.Second)
+	if ents = m.Expire(tc.t); len(ents) != 1 || string(ents[0].Data) != "a\n 1\n 2" {
+		t.Fatalf("partial entry not expired: %q", multilineData(ents))
+	}
+
+	//timeouts are also checked as entries arrive from other sources
+	if ents, _ = m.Process([]*entry.Entry{{Tag: 1, Data: []byte(`x`)}}); len(ents) != 0 {
+		t.Fatalf("bad entries %q", multilineData(ents))
+	}
+	tc.t = tc.t.Add(10 * time.Second)
+	if ents, _ = m.Process([]*entry.Entry{{Tag: 2, Data: []byte(`y`)}}); len(ents) != 1 || string(ents[0].Data) != `x` {
+		t.Fatalf("partial entry not expired on process: %q", multilineData(ents))
+	}
+}
+
+func TestMultilineExpireRoutine(t *testing.T) {
+	var tw testWriter
+	m, _ := newTestMultiline(t, MultilineConfig{Indented_Continuation: true, Timeout: `5s`})
+	ps := NewProcessorSet(&tw)
+	ps.AddProcessor(m)
+	if ps.expireDone != nil {
+		t.Fatal("expire routine started before any entries were processed")
+	}
+	if err := ps.Process(&entry.Entry{Tag: 1, Data: []byte(`a`)}); err != nil {
+		t.Fatal(err)
+	} else if ps.expireDone == nil {
+		t.Fatal("expire routine not started by process")
+	}
+	if err := ps.Close(); err != nil {
+		t.Fatal(err)
+	} else if ps.expireDone != nil {
+		t.Fatal("expire routine not stopped by close")
+	}
+
+	// a set that is never used never starts the routine, so dropping it without a close is safe
+	ps = NewProcessorSet(&tw)
+	ps.AddProcessor(m)
+	if ps.expireDone != nil {
+		t.Fatal("expire routine started on an idle set")
+	}
+}
+
+func TestMultilineProcessorSet(t *testing.T) {
+	var tw testWriter
+	m, tc := newTestMultiline(t, MultilineConfig{Indented_Continuation: true, Timeout: `5s`})
+	ps := NewProcessorSet(&tw)
+	ps.AddProcessor(m)
+	if err := ps.ProcessBatch(testLines("a\n 1\nb", nil)); err != nil {
+		t.Fatal(err)
+	} else if len(tw.ents) != 1 {
+		t.Fatalf("bad entry count %d", len(tw.ents))
+	}
+	ps.Lock()
+	ps.expire(tc.t.Add(10 * time.Second))
+	ps.Unlock()
+	if len(tw.ents) != 2 || string(tw.ents[1].Data) != `b` {
+		t.Fatalf("expired entry not written: %q", multilineData(tw.ents))
+	}
+	if err := ps.Process(&entry.Entry{Tag: 1, Data: []byte(`c`)}); err != nil {
+		t.Fatal(err)
+	} else if err = ps.Close(); err != nil {
+		t.Fatal(err)
+	} else if len(tw.ents) != 3 || string(tw.ents[2].Data) != `c` {
+		t.Fatalf("partial entry not written on close: %q", multilineData(tw.ents))
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/gravwell/gravwell/v4/ingest/attach"
 	"github.com/gravwell/gravwell/v4/ingest/config"
@@ -29,6 +30,8 @@ import (
 const (
 	preProcSectName string = `preprocessor`
 	preProcTypeName string = `type`
+
+	expireInterval = time.Second
 )
 
 var (
@@ -37,14 +40,21 @@ var (
 	ErrNotFound         = errors.New("Processor not found")
 	ErrNotReady         = errors.New("ProcessorSet not ready")
 	ErrInvalidEntry     = errors.New("ErrInvalidEntry")
+	ErrAckUnsupported   = errors.New("Writer does not support acknowledged writes")
+	ErrAckHeldEntries   = errors.New("Preprocessors which hold entries cannot be used with acknowledged writes")
 
 	emptyStruct = []byte(`{}`)
 )
 
 type ProcessorSet struct {
 	sync.Mutex
-	wtr entWriter
-	set []Processor
+	wtr        entWriter
+	set        []Processor
+	counters   []*procCounter
+	expirer    bool // the set holds an Expirer, the expire routine starts on the first write
+	holder     bool // a processor may hold entries back and emit them on a later call
+	expireDone chan struct{}
+	expireWg   sync.WaitGroup
 }
 
 type ProcessorConfig map[string]*config.VariableConfig
@@ -56,6 +66,14 @@ type Processor interface {
 	Close() error //give the processor a chance to tidy up
 }
 
+// Expirer is implemented by processors that hold entries and must release them after a
+// period of inactivity.  A ProcessorSet containing an Expirer calls Expire periodically and
+// sends the returned entries through the remaining processors.  The routine driving Expire
+// starts when the set first processes entries and runs until the set is closed.
+type Expirer interface {
+	Expire(now time.Time) []*entry.Entry
+}
+
 func CheckProcessor(id string) error {
 	id = strings.TrimSpace(strings.ToLower(id))
 	switch id {
@@ -81,6 +99,22 @@ func CheckProcessor(id string) error {
 	case RegexReplaceProcessor:
 	case RegexDropProcessor:
 	case AttachProcessor:
+	case KVExtractProcessor:
+	case CEFProcessor:
+	case LEEFProcessor:
+	case DedupProcessor:
+	case SampleProcessor:
+	case XMLExtractProcessor:
+	case GeoIPProcessor:
+	case IPExistProcessor:
+	case RedactProcessor:
+	case MultilineProcessor:
+	case DecompressProcessor:
+	case ProtoDecodeProcessor:
+	case AvroDecodeProcessor:
+	case SwitchProcessor:
+	case LookupProcessor:
+	case RewriteProcessor:
 	default:
 		return checkProcessorOS(id)
 	}
@@ -100,6 +134,11 @@ type entWriter interface {
 	WriteBatchContext(context.Context, []*entry.Entry) error
 }
 
+// ackWriter is implemented by writers which can report when a batch is safely stored
+type ackWriter interface {
+	WriteBatchAcked(context.Context, []*entry.Entry, func(error)) error
+}
+
 type preprocessorBase struct {
 	Type string
 }
@@ -154,6 +193,38 @@ func ProcessorLoadConfig(vc *config.VariableConfig) (cfg interface{}, err error)
 		cfg, err = RegexDropLoadConfig(vc)
 	case AttachProcessor:
 		cfg, err = AttachLoadConfig(vc)
+	case KVExtractProcessor:
+		cfg, err = KVExtractLoadConfig(vc)
+	case CEFProcessor:
+		cfg, err = CEFLoadConfig(vc)
+	case LEEFProcessor:
+		cfg, err = LEEFLoadConfig(vc)
+	case DedupProcessor:
+		cfg, err = DedupLoadConfig(vc)
+	case SampleProcessor:
+		cfg, err = SampleLoadConfig(vc)
+	case XMLExtractProcessor:
+		cfg, err = XMLExtractLoadConfig(vc)
+	case GeoIPProcessor:
+		cfg, err = GeoIPLoadConfig(vc)
+	case IPExistProcessor:
+		cfg, err = IPExistLoadConfig(vc)
+	case RedactProcessor:
+		cfg, err = RedactLoadConfig(vc)
+	case MultilineProcessor:
+		cfg, err = MultilineLoadConfig(vc)
+	case DecompressProcessor:
+		cfg, err = DecompressLoadConfig(vc)
+	case ProtoDecodeProcessor:
+		cfg, err = ProtoDecodeLoadConfig(vc)
+	case AvroDecodeProcessor:
+		cfg, err = AvroDecodeLoadConfig(vc)
+	case SwitchProcessor:
+		cfg, err = SwitchLoadConfig(vc)
+	case LookupProcessor:
+		cfg, err = LookupLoadConfig(vc)
+	case RewriteProcessor:
+		cfg, err = RewriteLoadConfig(vc)
 	default:
 		cfg, err = processorLoadConfigOS(vc)
 	}
@@ -187,8 +258,16 @@ func (pc ProcessorConfig) MarshalJSON() ([]byte, error) {
 }
 
 func (pc ProcessorConfig) getProcessor(name string, tgr Tagger) (p Processor, err error) {
+	return pc.getNestedProcessor(name, tgr, nil)
+}
+
+// getNestedProcessor builds a named processor, parents holds the switch preprocessors
+// whose sub-chains contain it
+func (pc ProcessorConfig) getNestedProcessor(name string, tgr Tagger, parents []string) (p Processor, err error) {
 	if vc, ok := pc[name]; !ok || vc == nil {
 		err = ErrNotFound
+	} else if pc.processorType(name) == SwitchProcessor {
+		p, err = pc.newSwitch(name, vc, tgr, parents)
 	} else {
 		p, err = newProcessor(vc, tgr)
 	}
@@ -335,6 +414,99 @@ func newProcessor(vc *config.VariableConfig, tgr Tagger) (p Processor, err error
 			return
 		}
 		p, err = NewAttachProcessor(cfg)
+	case KVExtractProcessor:
+		var cfg KVExtractConfig
+		if cfg, err = KVExtractLoadConfig(vc); err != nil {
+			return
+		}
+		p, err = NewKVExtractor(cfg)
+	case CEFProcessor:
+		var cfg CEFConfig
+		if cfg, err = CEFLoadConfig(vc); err != nil {
+			return
+		}
+		p, err = NewCEF(cfg)
+	case LEEFProcessor:
+		var cfg CEFConfig
+		if cfg, err = LEEFLoadConfig(vc); err != nil {
+			return
+		}
+		p, err = NewLEEF(cfg)
+	case DedupProcessor:
+		var cfg DedupConfig
+		if cfg, err = DedupLoadConfig(vc); err != nil {
+			return
+		}
+		p, err = NewDedup(cfg)
+	case SampleProcessor:
+		var cfg SampleConfig
+		if cfg, err = SampleLoadConfig(vc); err != nil {
+			return
+		}
+		p, err = NewSample(cfg, tgr)
+	case XMLExtractProcessor:
+		var cfg XMLExtractConfig
+		if cfg, err = XMLExtractLoadConfig(vc); err != nil {
+			return
+		}
+		p, err = NewXMLExtractor(cfg)
+	case GeoIPProcessor:
+		var cfg GeoIPConfig
+		if cfg, err = GeoIPLoadConfig(vc); err != nil {
+			return
+		}
+		p, err = NewGeoIP(cfg, tgr)
+	case IPExistProcessor:
+		var cfg IPExistConfig
+		if cfg, err = IPExistLoadConfig(vc); err != nil {
+			return
+		}
+		p, err = NewIPExist(cfg, tgr)
+	case RedactProcessor:
+		var cfg RedactConfig
+		if cfg, err = RedactLoadConfig(vc); err != nil {
+			return
+		}
+		p, err = NewRedact(cfg)
+	case MultilineProcessor:
+		var cfg MultilineConfig
+		if cfg, err = MultilineLoadConfig(vc); err != nil {
+			return
+		}
+		p, err = NewMultiline(cfg)
+	case DecompressProcessor:
+		var cfg DecompressConfig
+		if cfg, err = DecompressLoadConfig(vc); err != nil {
+			return
+		}
+		p, err = NewDecompressor(cfg)
+	case ProtoDecodeProcessor:
+		var cfg ProtoDecodeConfig
+		if cfg, err = ProtoDecodeLoadConfig(vc); err != nil {
+			return
+		}
+		p, err = NewProtoDecoder(cfg)
+	case AvroDecodeProcessor:
+		var cfg AvroDecodeConfig
+		if cfg, err = AvroDecodeLoadConfig(vc); err != nil {
+			return
+		}
+		p, err = NewAvroDecoder(cfg)
+	case SwitchProcessor:
+		//switches need the rest of the configuration to build their sub-chains
+		err = ErrSwitchNeedsConfigs
+	case LookupProcessor:
+		var cfg LookupConfig
+		if cfg, err = LookupLoadConfig(vc); err != nil {
+			return
+		}
+		p, err = NewLookup(cfg, tgr)
+	case RewriteProcessor:
+		var cfg RewriteConfig
+		if cfg, err = RewriteLoadConfig(vc); err != nil {
+			return
+		}
+		p, err = NewRewrite(cfg, tgr)
 	default:
 		p, err = newProcessorOS(vc, tgr)
 	}
@@ -360,9 +532,87 @@ func (pr *ProcessorSet) Count() int {
 }
 
 func (pr *ProcessorSet) AddProcessor(p Processor) {
+	name := processorTypeName(p)
+	pr.AddNamedProcessor(name, name, p)
+}
+
+// AddNamedProcessor adds a processor to the set, counters for the processor are
+// reported under the given configuration name and preprocessor type.
+func (pr *ProcessorSet) AddNamedProcessor(name, typ string, p Processor) {
 	pr.Lock()
 	defer pr.Unlock()
 	pr.set = append(pr.set, p)
+	pr.counters = append(pr.counters, getProcCounter(name, typ))
+	if _, ok := p.(Expirer); ok {
+		pr.expirer = true
+	}
+	if holdsEntries(p) {
+		pr.holder = true
+	}
+}
+
+// holdsEntries returns true if a processor may hold entries back and emit them on a later
+// call, entries emitted that way cannot be tied back to the batch they arrived in.
+func holdsEntries(p Processor) bool {
+	switch v := p.(type) {
+	case Expirer, *CiscoISE, *Plugin:
+		return true
+	case *Switch:
+		for _, c := range v.chains {
+			for _, cp := range c.procs {
+				if holdsEntries(cp) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// startExpire starts the expire routine if the set holds an Expirer and it is not already
+// running, the caller must hold the lock.  Sets that never see an entry never start it.
+func (pr *ProcessorSet) startExpire() {
+	if pr.expirer && pr.expireDone == nil {
+		pr.expireDone = make(chan struct{})
+		pr.expireWg.Add(1)
+		go pr.expireRoutine(pr.expireDone)
+	}
+}
+
+func (pr *ProcessorSet) expireRoutine(done chan struct{}) {
+	defer pr.expireWg.Done()
+	tckr := time.NewTicker(expireInterval)
+	defer tckr.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case now := <-tckr.C:
+			pr.Lock()
+			if pr.wtr != nil {
+				pr.expire(now)
+			}
+			pr.Unlock()
+		}
+	}
+}
+
+// expire collects entries released by expiring processors and pushes them through the
+// rest of the set, the caller must hold the lock.  Write errors are dropped as there is
+// no caller to hand them to, the writer is responsible for reporting them.
+func (pr *ProcessorSet) expire(now time.Time) {
+	for i, v := range pr.set {
+		e, ok := v.(Expirer)
+		if !ok {
+			continue
+		}
+		if ents := e.Expire(now); len(ents) > 0 {
+			pr.counters[i].update(0, len(ents), nil)
+			if ents, err := pr.processItemsOnFlush(i+1, ents); err == nil && len(ents) > 0 {
+				pr.writeSet(ents)
+			}
+		}
+	}
 }
 
 func (pr *ProcessorSet) Process(ent *entry.Entry) (err error) {
@@ -375,6 +625,7 @@ func (pr *ProcessorSet) Process(ent *entry.Entry) (err error) {
 	} else if len(pr.set) == 0 {
 		err = pr.wtr.WriteEntry(ent)
 	} else {
+		pr.startExpire()
 		//we have processors, start recursing into them
 		var set []*entry.Entry
 		if set, err = pr.processItems([]*entry.Entry{ent}); err == nil {
@@ -395,6 +646,7 @@ func (pr *ProcessorSet) ProcessBatch(ents []*entry.Entry) (err error) {
 	} else if len(pr.set) == 0 {
 		err = pr.wtr.WriteBatch(ents)
 	} else {
+		pr.startExpire()
 		//we have processors, start recursing into them
 		var set []*entry.Entry
 		if set, err = pr.processItems(ents); err == nil {
@@ -415,6 +667,7 @@ func (pr *ProcessorSet) ProcessContext(ent *entry.Entry, ctx context.Context) (e
 	} else if len(pr.set) == 0 {
 		err = pr.wtr.WriteEntryContext(ctx, ent)
 	} else {
+		pr.startExpire()
 		//we have processors, start recursing into them
 		var set []*entry.Entry
 		if set, err = pr.processItems([]*entry.Entry{ent}); err == nil {
@@ -435,6 +688,7 @@ func (pr *ProcessorSet) ProcessBatchContext(ents []*entry.Entry, ctx context.Con
 	} else if len(pr.set) == 0 {
 		err = pr.writeSetContext(ents, ctx)
 	} else {
+		pr.startExpire()
 		//we have processors, start recursing into them
 		var set []*entry.Entry
 		if set, err = pr.processItems(ents); err == nil {
@@ -445,15 +699,60 @@ func (pr *ProcessorSet) ProcessBatchContext(ents []*entry.Entry, ctx context.Con
 	return
 }
 
+// AckSupported returns nil if the set can be used with ProcessBatchAcked.  The writer must
+// support acknowledged writes and no preprocessor may hold entries back, as the entries it
+// emits later are not covered by the acknowledgement of the batch they arrived in.
+func (pr *ProcessorSet) AckSupported() error {
+	pr.Lock()
+	defer pr.Unlock()
+	return pr.ackSupported()
+}
+
+func (pr *ProcessorSet) ackSupported() error {
+	if _, ok := pr.wtr.(ackWriter); !ok {
+		return ErrAckUnsupported
+	} else if pr.holder {
+		return ErrAckHeldEntries
+	}
+	return nil
+}
+
+// ProcessBatchAcked runs a batch through the preprocessors and writes the results with
+// WriteBatchAcked, cb fires once the writer has acknowledged everything the preprocessors
+// emitted.  Entries dropped by a preprocessor count as done.  cb is only called if
+// ProcessBatchAcked returns nil, the errors returned by AckSupported are returned if the
+// set cannot acknowledge writes.
+func (pr *ProcessorSet) ProcessBatchAcked(ents []*entry.Entry, ctx context.Context, cb func(error)) (err error) {
+	var set []*entry.Entry
+	pr.Lock()
+	if pr.wtr == nil {
+		err = ErrNotReady
+	} else if err = pr.ackSupported(); err == nil {
+		//expiring processors hold entries, so there is never an expire routine to start here
+		if set, err = pr.processItems(ents); err == nil && len(set) > 0 {
+			err = pr.wtr.(ackWriter).WriteBatchAcked(ctx, set, cb)
+		}
+	}
+	pr.Unlock()
+	if err == nil && len(set) == 0 {
+		cb(nil) //nothing survived the preprocessors, so there is nothing to wait on
+	}
+	return
+}
+
 func (pr *ProcessorSet) writeSet(ents []*entry.Entry) error {
-	if len(ents) == 1 {
+	if len(ents) == 0 {
+		return nil //everything was dropped or is being held
+	} else if len(ents) == 1 {
 		return pr.wtr.WriteEntry(ents[0])
 	}
 	return pr.wtr.WriteBatch(ents)
 }
 
 func (pr *ProcessorSet) writeSetContext(ents []*entry.Entry, ctx context.Context) error {
-	if len(ents) == 1 {
+	if len(ents) == 0 {
+		return nil //everything was dropped or is being held
+	} else if len(ents) == 1 {
 		return pr.wtr.WriteEntryContext(ctx, ents[0])
 	}
 	return pr.wtr.WriteBatchContext(ctx, ents)
@@ -467,7 +766,9 @@ func (pr *ProcessorSet) processItems(ents []*entry.Entry) (set []*entry.Entry, e
 	}
 	for i := 0; i < len(pr.set) && len(set) > 0; i++ {
 		orig := set
-		if set, err = pr.set[i].Process(orig); err != nil {
+		set, err = pr.set[i].Process(orig)
+		pr.counters[i].update(len(orig), len(set), err)
+		if err != nil {
 			//TODO FIXME Issue #1225 - https://github.com/gravwell/gravwell/issues/1225
 			if _, ok := err.(*plugin.FaultError); ok {
 				// LOG THIS for issue #1225 and put in some logic
@@ -482,15 +783,18 @@ func (pr *ProcessorSet) processItems(ents []*entry.Entry) (set []*entry.Entry, e
 	return
 }
 
-// processItemsOnFlush is just a processors that allows us to hand in the set of Processors as a parameter
+// processItemsOnFlush is just a processors that allows us to hand in the index of the first Processor to use
 // we need to be able to do this as we force a flush and process on preprocessors
-func (pr *ProcessorSet) processItemsOnFlush(prs []Processor, ents []*entry.Entry) (set []*entry.Entry, err error) {
+func (pr *ProcessorSet) processItemsOnFlush(start int, ents []*entry.Entry) (set []*entry.Entry, err error) {
 	set = ents
-	if len(set) == 0 || len(prs) == 0 {
+	if len(set) == 0 || start >= len(pr.set) {
 		return
 	}
-	for i := 0; i < len(prs) && len(set) > 0; i++ {
-		if set, err = prs[i].Process(set); err != nil {
+	for i := start; i < len(pr.set) && len(set) > 0; i++ {
+		orig := set
+		set, err = pr.set[i].Process(orig)
+		pr.counters[i].update(len(orig), len(set), err)
+		if err != nil {
 			break
 		}
 	}
@@ -501,10 +805,20 @@ func (pr *ProcessorSet) processItemsOnFlush(prs []Processor, ents []*entry.Entry
 // This function DOES NOT close the ingest muxer handle.
 // It is ONLY for shutting down preprocessors
 func (pr *ProcessorSet) Close() (err error) {
+	//the expire routine takes the lock, so stop it without holding the lock
+	pr.Lock()
+	done := pr.expireDone
+	pr.expirer, pr.expireDone = false, nil
+	pr.Unlock()
+	if done != nil {
+		close(done)
+		pr.expireWg.Wait()
+	}
 	for i, v := range pr.set {
 		if v != nil {
 			if ents := v.Flush(); len(ents) > 0 {
-				if ents, lerr := pr.processItemsOnFlush(pr.set[i+1:], ents); lerr != nil {
+				pr.counters[i].update(0, len(ents), nil)
+				if ents, lerr := pr.processItemsOnFlush(i+1, ents); lerr != nil {
 					err = addError(lerr, err)
 				} else if len(ents) > 0 {
 					if lerr := pr.writeSet(ents); lerr != nil {
@@ -546,16 +860,32 @@ func (pc ProcessorConfig) ProcessorSet(t tagWriter, names []string) (pr *Process
 			err = fmt.Errorf("%s %v", n, err)
 			return
 		}
-		pr.AddProcessor(p)
+		pr.AddNamedProcessor(n, pc.processorType(n), p)
 	}
 	return
 }
 
+// processorType returns the normalized preprocessor type for a named preprocessor
+func (pc ProcessorConfig) processorType(name string) string {
+	var pb preprocessorBase
+	if vc, ok := pc[name]; ok && vc != nil {
+		if err := vc.MapTo(&pb); err == nil {
+			return strings.TrimSpace(strings.ToLower(pb.Type))
+		}
+	}
+	return ``
+}
+
 func (pc ProcessorConfig) Validate() (err error) {
 	for k, v := range pc {
 		if _, err = ProcessorLoadConfig(v); err != nil {
 			err = fmt.Errorf("Preprocessor %s config invalid: %v", k, err)
 			return
+		} else if pc.processorType(k) == SwitchProcessor {
+			if err = pc.checkSwitch(k, nil); err != nil {
+				err = fmt.Errorf("Preprocessor %s config invalid: %v", k, err)
+				return
+			}
 		}
 	}
 	return
@@ -276,6 +276,85 @@ func entryEqual(a, b *entry.Entry) bool {
 	return bytes.Equal(a.Data, b.Data)
 }
 
+// ackTestWriter holds acknowledgements until release is called
+type ackTestWriter struct {
+	testWriter
+	cbs []func(error)
+}
+
+func (aw *ackTestWriter) WriteBatchAcked(ctx context.Context, ents []*entry.Entry, cb func(error)) error {
+	if err := aw.WriteBatchContext(ctx, ents); err != nil {
+		return err
+	}
+	aw.cbs = append(aw.cbs, cb)
+	return nil
+}
+
+func (aw *ackTestWriter) release() {
+	for _, cb := range aw.cbs {
+		cb(nil)
+	}
+	aw.cbs = nil
+}
+
+func TestProcessBatchAcked(t *testing.T) {
+	ents := []*entry.Entry{
+		{TS: entry.Now(), Data: []byte(`a`)},
+		{TS: entry.Now(), Data: []byte(`b`)},
+	}
+	var acked int
+	cb := func(err error) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		acked++
+	}
+
+	//writers which cannot acknowledge are refused
+	if err := NewProcessorSet(&testWriter{}).ProcessBatchAcked(ents, context.Background(), cb); err != ErrAckUnsupported {
+		t.Fatalf("non-acking writer was not refused: %v", err)
+	}
+
+	var aw ackTestWriter
+	ps := NewProcessorSet(&aw)
+	if err := ps.ProcessBatchAcked(ents, context.Background(), cb); err != nil {
+		t.Fatal(err)
+	} else if len(aw.ents) != 2 || acked != 0 {
+		t.Fatalf("bad write state: %d %d", len(aw.ents), acked)
+	}
+	aw.release()
+	if acked != 1 {
+		t.Fatalf("callback did not fire on release: %d", acked)
+	}
+
+	//nothing survives a drop, so the batch is done right away
+	d, err := NewDrop(DropConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ps.AddProcessor(d)
+	if err = ps.ProcessBatchAcked(ents, context.Background(), cb); err != nil {
+		t.Fatal(err)
+	} else if acked != 2 || len(aw.cbs) != 0 || len(aw.ents) != 2 {
+		t.Fatalf("dropped batch was not acknowledged: %d %d %d", acked, len(aw.cbs), len(aw.ents))
+	}
+
+	//entries held by a preprocessor would be acknowledged before they are written
+	m, err := NewMultiline(MultilineConfig{Indented_Continuation: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ps = NewProcessorSet(&aw)
+	ps.AddProcessor(m)
+	if err = ps.AckSupported(); err != ErrAckHeldEntries {
+		t.Fatalf("holding preprocessor was not refused: %v", err)
+	} else if err = ps.ProcessBatchAcked(ents, context.Background(), cb); err != ErrAckHeldEntries {
+		t.Fatalf("holding preprocessor was not refused: %v", err)
+	} else if acked != 2 || len(aw.ents) != 2 {
+		t.Fatalf("refused batch was processed: %d %d", acked, len(aw.ents))
+	}
+}
+
 func TestParallel(t *testing.T) {
 	var err error
 
@@ -474,3 +553,30 @@ func checkEntryEVs(ents []*entry.Entry) error {
 	}
 	return nil
 }
+
+func TestProcessorStats(t *testing.T) {
+	var tw testWriter
+	ps := NewProcessorSet(&tw)
+	ps.AddNamedProcessor(`statstest`, `dummy`, &dummyProcessor{})
+	for i := 0; i < 5; i++ {
+		if err := ps.Process(&entry.Entry{Data: []byte("hello")}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ps.ProcessBatch([]*entry.Entry{{Data: []byte("a")}, {Data: []byte("b")}}); err != nil {
+		t.Fatal(err)
+	}
+	var found bool
+	for _, st := range GetProcessorStats() {
+		if st.Name != `statstest` {
+			continue
+		}
+		found = true
+		if st.Type != `dummy` || st.In != 7 || st.Out != 7 || st.Errors != 0 {
+			t.Fatalf("bad stats: %+v", st)
+		}
+	}
+	if !found {
+		t.Fatal("stats for named processor not found")
+	}
+}
@@ -0,0 +1,343 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package processors
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gravwell/gravwell/v4/ingest/config"
+	"github.com/gravwell/gravwell/v4/ingest/entry"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const (
+	ProtoDecodeProcessor string = `protodecode`
+
+	protoSchemaExt = `.desc`
+)
+
+var (
+	ErrProtoNoSchema        = errors.New("Message with Descriptor-Set, or Schema-Directory is required")
+	ErrProtoNoDescriptorSet = errors.New("Message requires at least one Descriptor-Set")
+	ErrProtoUnknownMessage  = errors.New("Message not found in descriptor sets")
+	ErrProtoEmptySet        = errors.New("Descriptor set contains no files")
+	ErrProtoMessageIndex    = errors.New("Invalid Confluent message index")
+)
+
+// ProtoDecodeConfig decodes binary protobuf payloads into JSON or enumerated values.
+// Descriptor sets are produced with protoc --include_imports --descriptor_set_out.
+// Payloads are decoded as Message, or when Schema-Directory is set, payloads in the
+// Confluent wire format are decoded using the descriptor set named by their schema ID,
+// such as 42.desc, where the last file in the set is the registered schema.  Entries that
+// cannot be decoded are passed through untouched unless Drop-Misses is set.
+type ProtoDecodeConfig struct {
+	Descriptor_Set   []string
+	Message          string // fully qualified name, such as com.example.Event
+	Schema_Directory string
+	Output_Format    string // json or ev, default is json
+	EV_Prefix        string
+	Drop_Misses      bool
+}
+
+func ProtoDecodeLoadConfig(vc *config.VariableConfig) (c ProtoDecodeConfig, err error) {
+	if err = vc.MapTo(&c); err == nil {
+		_, err = c.validate()
+	}
+	return
+}
+
+func (c *ProtoDecodeConfig) validate() (ps *protoSchema, err error) {
+	c.Message, c.Schema_Directory = strings.TrimSpace(c.Message), strings.TrimSpace(c.Schema_Directory)
+	var sets []string
+	for _, v := range c.Descriptor_Set {
+		if v = strings.TrimSpace(v); v != `` {
+			sets = append(sets, v)
+		}
+	}
+	c.Descriptor_Set = sets
+	if c.Message == `` && c.Schema_Directory == `` {
+		err = ErrProtoNoSchema
+		return
+	} else if c.Message != `` && len(sets) == 0 {
+		err = ErrProtoNoDescriptorSet
+		return
+	} else if c.Output_Format, err = checkSchemaOutput(c.Output_Format, c.EV_Prefix); err != nil {
+		return
+	}
+	if c.Schema_Directory != `` {
+		if err = checkSchemaDirectory(c.Schema_Directory); err != nil {
+			return
+		}
+	}
+	if c.Message != `` {
+		if ps, err = loadProtoSchema(sets...); err != nil {
+			return
+		}
+		var d protoreflect.Descriptor
+		if d, err = ps.files.FindDescriptorByName(protoreflect.FullName(c.Message)); err != nil {
+			err = fmt.Errorf("%s: %w", c.Message, ErrProtoUnknownMessage)
+			return
+		}
+		var ok bool
+		if ps.msg, ok = d.(protoreflect.MessageDescriptor); !ok {
+			err = fmt.Errorf("%s: %w", c.Message, ErrProtoUnknownMessage)
+		}
+	}
+	return
+}
+
+// protoSchema holds a set of resolved descriptors, msg is the message used for payloads
+// which do not carry Confluent message indexes
+type protoSchema struct {
+	files *protoregistry.Files
+	types *dynamicpb.Types
+	last  protoreflect.FileDescriptor
+	msg   protoreflect.MessageDescriptor
+}
+
+// loadProtoSchema merges one or more descriptor set files, later files may depend on
+// files in earlier sets
+func loadProtoSchema(pths ...string) (ps *protoSchema, err error) {
+	var merged descriptorpb.FileDescriptorSet
+	seen := map[string]bool{}
+	for _, pth := range pths {
+		var b []byte
+		if b, err = os.ReadFile(pth); err != nil {
+			return
+		}
+		var fds descriptorpb.FileDescriptorSet
+		if err = proto.Unmarshal(b, &fds); err != nil {
+			err = fmt.Errorf("invalid descriptor set %s: %w", pth, err)
+			return
+		}
+		for _, f := range fds.File {
+			if !seen[f.GetName()] {
+				seen[f.GetName()] = true
+				merged.File = append(merged.File, f)
+			}
+		}
+	}
+	if len(merged.File) == 0 {
+		err = ErrProtoEmptySet
+		return
+	}
+	files, err := protodesc.NewFiles(&merged)
+	if err != nil {
+		return
+	}
+	ps = &protoSchema{
+		files: files,
+		types: dynamicpb.NewTypes(files),
+	}
+	if ps.last, err = files.FindFileByPath(merged.File[len(merged.File)-1].GetName()); err != nil {
+		ps = nil
+	}
+	return
+}
+
+// message resolves Confluent message indexes within the registered schema file
+func (ps *protoSchema) message(idx []int) (md protoreflect.MessageDescriptor, err error) {
+	msgs := ps.last.Messages()
+	for _, i := range idx {
+		if i < 0 || i >= msgs.Len() {
+			err = fmt.Errorf("%w: %v", ErrProtoMessageIndex, idx)
+			return
+		}
+		md = msgs.Get(i)
+		msgs = md.Messages()
+	}
+	return
+}
+
+// confluentMessageIndexes reads the message index path that follows the schema ID in
+// Confluent protobuf framing, a single zero byte is shorthand for the first message
+func confluentMessageIndexes(b []byte) (idx []int, rest []byte, err error) {
+	cnt, n := binary.Varint(b)
+	if n <= 0 || cnt < 0 || cnt > int64(len(b)) {
+		err = ErrProtoMessageIndex
+		return
+	}
+	b = b[n:]
+	if cnt == 0 {
+		return []int{0}, b, nil
+	}
+	for ; cnt > 0; cnt-- {
+		var v int64
+		if v, n = binary.Varint(b); n <= 0 {
+			err = ErrProtoMessageIndex
+			return
+		}
+		idx, b = append(idx, int(v)), b[n:]
+	}
+	rest = b
+	return
+}
+
+func loadProtoSchemaFile(pth string) (*protoSchema, error) {
+	return loadProtoSchema(pth)
+}
+
+type ProtoDecoder struct {
+	nocloser
+	ProtoDecodeConfig
+	schema *protoSchema
+	dir    *schemaDirectory[protoSchema]
+	now    func() time.Time
+}
+
+func NewProtoDecoder(cfg ProtoDecodeConfig) (pd *ProtoDecoder, err error) {
+	pd = &ProtoDecoder{
+		now: time.Now,
+	}
+	if err = pd.init(cfg); err != nil {
+		pd = nil
+	}
+	return
+}
+
+func (pd *ProtoDecoder) Config(v interface{}) (err error) {
+	if v == nil {
+		err = ErrNilConfig
+	} else if cfg, ok := v.(ProtoDecodeConfig); ok {
+		err = pd.init(cfg)
+	} else {
+		err = fmt.Errorf("Invalid configuration, unknown type type %T", v)
+	}
+	return
+}
+
+func (pd *ProtoDecoder) init(cfg ProtoDecodeConfig) (err error) {
+	var ps *protoSchema
+	if ps, err = cfg.validate(); err != nil {
+		return
+	}
+	pd.ProtoDecodeConfig = cfg
+	pd.schema = ps
+	pd.dir = nil
+	if cfg.Schema_Directory != `` {
+		pd.dir = newSchemaDirectory(cfg.Schema_Directory, protoSchemaExt, loadProtoSchemaFile)
+	}
+	return
+}
+
+func (pd *ProtoDecoder) Process(ents []*entry.Entry) (rset []*entry.Entry, err error) {
+	if len(ents) == 0 {
+		return
+	}
+	now := pd.now()
+	rset = ents[:0]
+	for _, ent := range ents {
+		if ent == nil {
+			continue
+		} else if derr := pd.decode(ent, now); derr != nil && pd.Drop_Misses {
+			continue
+		}
+		rset = append(rset, ent)
+	}
+	return
+}
+
+func (pd *ProtoDecoder) decode(ent *entry.Entry, now time.Time) (err error) {
+	ps, payload := pd.schema, ent.Data
+	var md protoreflect.MessageDescriptor
+	if ps != nil {
+		md = ps.msg
+	}
+	if pd.dir != nil {
+		if id, p, ok := confluentFrame(ent.Data); ok {
+			var idx []int
+			if ps, err = pd.dir.get(id, now); err != nil {
+				return
+			} else if idx, payload, err = confluentMessageIndexes(p); err != nil {
+				return
+			} else if md, err = ps.message(idx); err != nil {
+				return
+			}
+		}
+	}
+	if md == nil {
+		return ErrSchemaNotFound
+	}
+	msg := dynamicpb.NewMessage(md)
+	if err = (proto.UnmarshalOptions{Resolver: ps.types}).Unmarshal(payload, msg); err != nil {
+		return
+	}
+	if pd.Output_Format == schemaOutputEV {
+		attachSchemaEVs(ent, pd.EV_Prefix, protoFields(msg))
+		return
+	}
+	var b []byte
+	if b, err = (protojson.MarshalOptions{UseProtoNames: true, Resolver: ps.types}).Marshal(msg); err != nil {
+		return
+	}
+	//protojson randomizes whitespace, compact it so output is stable
+	bb := bytes.NewBuffer(make([]byte, 0, len(b)))
+	if err = json.Compact(bb, b); err == nil {
+		ent.Data = bb.Bytes()
+	}
+	return
+}
+
+// protoFields returns the populated top level fields of a message
+func protoFields(msg protoreflect.Message) (flds []schemaField) {
+	msg.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		flds = append(flds, schemaField{name: string(fd.Name()), val: protoValue(fd, v)})
+		return true
+	})
+	return
+}
+
+func protoValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) interface{} {
+	if fd.IsList() {
+		l := v.List()
+		r := make([]interface{}, 0, l.Len())
+		for i := 0; i < l.Len(); i++ {
+			r = append(r, protoScalar(fd, l.Get(i)))
+		}
+		return r
+	} else if fd.IsMap() {
+		r := map[string]interface{}{}
+		v.Map().Range(func(k protoreflect.MapKey, mv protoreflect.Value) bool {
+			r[k.String()] = protoScalar(fd.MapValue(), mv)
+			return true
+		})
+		return r
+	}
+	return protoScalar(fd, v)
+}
+
+func protoScalar(fd protoreflect.FieldDescriptor, v protoreflect.Value) interface{} {
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		r := map[string]interface{}{}
+		for _, f := range protoFields(v.Message()) {
+			r[f.name] = f.val
+		}
+		return r
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByNumber(v.Enum()); ev != nil {
+			return string(ev.Name())
+		}
+		return int32(v.Enum())
+	}
+	return v.Interface()
+}
@@ -0,0 +1,274 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package processors
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gravwell/gravwell/v4/ingest/entry"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const testProtoJSON = `{"host":"foo","count":"99","tags":["a","b"],"level":"HIGH","inner":{"x":7}}`
+
+// testProtoFile describes:
+//
+//	enum Level { LOW = 0; HIGH = 1; }
+//	message Event { message Inner { int32 x = 1; } string host = 1; int64 count = 2;
+//		repeated string tags = 3; Level level = 4; Inner inner = 5; }
+//	message Other { string name = 1; }
+func testProtoFile() *descriptorpb.FileDescriptorProto {
+	fld := func(name string, num int32, typ descriptorpb.FieldDescriptorProto_Type, lbl descriptorpb.FieldDescriptorProto_Label, tn string) *descriptorpb.FieldDescriptorProto {
+		f := &descriptorpb.FieldDescriptorProto{
+			Name:   proto.String(name),
+			Number: proto.Int32(num),
+			Type:   typ.Enum(),
+			Label:  lbl.Enum(),
+		}
+		if tn != `` {
+			f.TypeName = proto.String(tn)
+		}
+		return f
+	}
+	opt := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+	return &descriptorpb.FileDescriptorProto{
+		Name:    proto.String(`test.proto`),
+		Package: proto.String(`test`),
+		Syntax:  proto.String(`proto3`),
+		EnumType: []*descriptorpb.EnumDescriptorProto{{
+			Name: proto.String(`Level`),
+			Value: []*descriptorpb.EnumValueDescriptorProto{
+				{Name: proto.String(`LOW`), Number: proto.Int32(0)},
+				{Name: proto.String(`HIGH`), Number: proto.Int32(1)},
+			},
+		}},
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String(`Event`),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					fld(`host`, 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, opt, ``),
+					fld(`count`, 2, descriptorpb.FieldDescriptorProto_TYPE_INT64, opt, ``),
+					fld(`tags`, 3, descriptorpb.FieldDescriptorProto_TYPE_STRING, descriptorpb.FieldDescriptorProto_LABEL_REPEATED, ``),
+					fld(`level`, 4, descriptorpb.FieldDescriptorProto_TYPE_ENUM, opt, `.test.Level`),
+					fld(`inner`, 5, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, opt, `.test.Event.Inner`),
+				},
+				NestedType: []*descriptorpb.DescriptorProto{{
+					Name:  proto.String(`Inner`),
+					Field: []*descriptorpb.FieldDescriptorProto{fld(`x`, 1, descriptorpb.FieldDescriptorProto_TYPE_INT32, opt, ``)},
+				}},
+			},
+			{
+				Name:  proto.String(`Other`),
+				Field: []*descriptorpb.FieldDescriptorProto{fld(`name`, 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, opt, ``)},
+			},
+		},
+	}
+}
+
+func writeTestDescriptorSet(t *testing.T, pth string) {
+	t.Helper()
+	b, err := proto.Marshal(&descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{testProtoFile()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, pth, b)
+}
+
+func testProtoMessages(t *testing.T) (event, other []byte) {
+	fd, err := protodesc.NewFile(testProtoFile(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	md := fd.Messages().ByName(`Event`)
+	msg := dynamicpb.NewMessage(md)
+	msg.Set(md.Fields().ByName(`host`), protoreflect.ValueOfString(`foo`))
+	msg.Set(md.Fields().ByName(`count`), protoreflect.ValueOfInt64(99))
+	tags := msg.Mutable(md.Fields().ByName(`tags`)).List()
+	tags.Append(protoreflect.ValueOfString(`a`))
+	tags.Append(protoreflect.ValueOfString(`b`))
+	msg.Set(md.Fields().ByName(`level`), protoreflect.ValueOfEnum(1))
+	inner := msg.Mutable(md.Fields().ByName(`inner`)).Message()
+	inner.Set(inner.Descriptor().Fields().ByName(`x`), protoreflect.ValueOfInt32(7))
+	if event, err = proto.Marshal(msg); err != nil {
+		t.Fatal(err)
+	}
+
+	md = fd.Messages().ByName(`Other`)
+	msg = dynamicpb.NewMessage(md)
+	msg.Set(md.Fields().ByName(`name`), protoreflect.ValueOfString(`bar`))
+	if other, err = proto.Marshal(msg); err != nil {
+		t.Fatal(err)
+	}
+	return
+}
+
+func TestProtoDecodeConfig(t *testing.T) {
+	dir := t.TempDir()
+	pth := filepath.Join(dir, `test.desc`)
+	writeTestDescriptorSet(t, pth)
+	b := fmt.Sprintf(`
+	[preprocessor "pb"]
+		type = protodecode
+		Descriptor-Set=%q
+		Message=test.Event
+		Schema-Directory=%q
+	`, pth, dir)
+	p, err := testLoadPreprocessor(b, `pb`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pd, ok := p.(*ProtoDecoder)
+	if !ok {
+		t.Fatalf("preprocessor is the wrong type: %T != *ProtoDecoder", p)
+	} else if pd.Output_Format != schemaOutputJSON || pd.schema == nil || pd.dir == nil {
+		t.Fatalf("bad config: %+v", pd.ProtoDecodeConfig)
+	} else if pd.schema.msg.FullName() != `test.Event` {
+		t.Fatalf("bad message: %v", pd.schema.msg.FullName())
+	}
+
+	bad := []ProtoDecodeConfig{
+		{},
+		{Message: `test.Event`},
+		{Descriptor_Set: []string{pth}, Message: `test.Missing`},
+		{Descriptor_Set: []string{pth}, Message: `test.Level`},
+		{Descriptor_Set: []string{filepath.Join(dir, `missing.desc`)}, Message: `test.Event`},
+		{Descriptor_Set: []string{pth}, Message: `test.Event`, Output_Format: `xml`},
+	}
+	for _, c := range bad {
+		if _, err = NewProtoDecoder(c); err == nil {
+			t.Fatalf("bad config %+v did not fail", c)
+		}
+	}
+}
+
+func TestProtoDecodeJSON(t *testing.T) {
+	pth := filepath.Join(t.TempDir(), `test.desc`)
+	writeTestDescriptorSet(t, pth)
+	pd, err := NewProtoDecoder(ProtoDecodeConfig{Descriptor_Set: []string{pth}, Message: `test.Event`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	event, _ := testProtoMessages(t)
+	out, err := pd.Process([]*entry.Entry{
+		{Data: event},
+		{Data: []byte{0xff, 0xff}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(out) != 2 {
+		t.Fatalf("invalid output count: %d", len(out))
+	} else if s := string(out[0].Data); s != testProtoJSON {
+		t.Fatalf("bad JSON: %s", s)
+	}
+
+	pd.Drop_Misses = true
+	if out, err = pd.Process([]*entry.Entry{{Data: []byte{0xff, 0xff}}}); err != nil {
+		t.Fatal(err)
+	} else if len(out) != 0 {
+		t.Fatalf("miss was not dropped")
+	}
+}
+
+func TestProtoDecodeEV(t *testing.T) {
+	pth := filepath.Join(t.TempDir(), `test.desc`)
+	writeTestDescriptorSet(t, pth)
+	pd, err := NewProtoDecoder(ProtoDecodeConfig{Descriptor_Set: []string{pth}, Message: `test.Event`, Output_Format: `ev`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	event, _ := testProtoMessages(t)
+	out, err := pd.Process([]*entry.Entry{{Data: event}})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(out) != 1 {
+		t.Fatalf("invalid output count: %d", len(out))
+	}
+	ent := out[0]
+	if ev, ok := ent.GetEnumeratedValue(`host`); !ok || ev != `foo` {
+		t.Fatalf("bad host: %v", ev)
+	} else if ev, ok = ent.GetEnumeratedValue(`count`); !ok || ev != int64(99) {
+		t.Fatalf("bad count: %v %T", ev, ev)
+	} else if ev, ok = ent.GetEnumeratedValue(`tags`); !ok || ev != `["a","b"]` {
+		t.Fatalf("bad tags: %v", ev)
+	} else if ev, ok = ent.GetEnumeratedValue(`level`); !ok || ev != `HIGH` {
+		t.Fatalf("bad level: %v", ev)
+	} else if ev, ok = ent.GetEnumeratedValue(`inner`); !ok || ev != `{"x":7}` {
+		t.Fatalf("bad inner: %v", ev)
+	}
+}
+
+func TestProtoDecodeDirectory(t *testing.T) {
+	dir := t.TempDir()
+	pd, err := NewProtoDecoder(ProtoDecodeConfig{Schema_Directory: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	pd.now = func() time.Time { return now }
+	event, other := testProtoMessages(t)
+	eventFrame := confluentTestFrame(7, append([]byte{0}, event...))
+	otherFrame := confluentTestFrame(7, append([]byte{2, 2}, other...))
+
+	out, err := pd.Process([]*entry.Entry{{Data: eventFrame}})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(out) != 1 || string(out[0].Data) != string(eventFrame) {
+		t.Fatalf("miss was modified")
+	}
+
+	writeTestDescriptorSet(t, filepath.Join(dir, `7.desc`))
+	now = now.Add(schemaRetryInterval)
+	out, err = pd.Process([]*entry.Entry{{Data: eventFrame}, {Data: otherFrame}})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(out) != 2 {
+		t.Fatalf("invalid output count: %d", len(out))
+	} else if s := string(out[0].Data); s != testProtoJSON {
+		t.Fatalf("bad event JSON: %s", s)
+	} else if s = string(out[1].Data); s != `{"name":"bar"}` {
+		t.Fatalf("bad other JSON: %s", s)
+	}
+
+	//message index out of range
+	if err = pd.decode(&entry.Entry{Data: confluentTestFrame(7, []byte{2, 8, 0})}, now); err == nil {
+		t.Fatal("bad message index did not fail")
+	}
+}
+
+func TestConfluentMessageIndexes(t *testing.T) {
+	tests := []struct {
+		in   []byte
+		idx  []int
+		rest int
+	}{
+		{[]byte{0, 1}, []int{0}, 1},
+		{[]byte{2, 4}, []int{2}, 0},
+		{[]byte{4, 2, 0, 9}, []int{1, 0}, 1},
+	}
+	for _, tt := range tests {
+		idx, rest, err := confluentMessageIndexes(tt.in)
+		if err != nil {
+			t.Fatal(err)
+		} else if fmt.Sprint(idx) != fmt.Sprint(tt.idx) || len(rest) != tt.rest {
+			t.Fatalf("bad indexes for %v: %v %v", tt.in, idx, rest)
+		}
+	}
+	for _, b := range [][]byte{nil, {1}, {4, 2}, {0x80}} {
+		if _, _, err := confluentMessageIndexes(b); err == nil {
+			t.Fatalf("bad indexes %v did not fail", b)
+		}
+	}
+}
@@ -0,0 +1,383 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package processors
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gravwell/gravwell/v4/ingest/config"
+	"github.com/gravwell/gravwell/v4/ingest/entry"
+)
+
+const (
+	RedactProcessor string = `redact`
+
+	redactMask     = `mask`
+	redactTruncate = `truncate`
+	redactHMAC     = `hmac`
+
+	defaultRedactMask         = `[REDACTED]`
+	defaultRedactTruncateKeep = 4
+	defaultRedactTokenLength  = 16
+	redactMaskChar            = '*'
+)
+
+var (
+	ErrRedactNoDetectors     = errors.New("at least one Detector or Regex is required")
+	ErrRedactUnknownDetector = errors.New("Unknown redact detector")
+	ErrRedactInvalidMode     = errors.New("Mode must be mask, truncate, or hmac")
+	ErrRedactInvalidRegex    = errors.New("Regex must be specified as name=pattern")
+	ErrRedactMissingKey      = errors.New("hmac mode requires a Key, Key-File, or Key-Env")
+	ErrRedactTokenLength     = errors.New("Token-Length must be between 1 and 64")
+	ErrRedactTruncateKeep    = errors.New("Truncate-Keep cannot be negative")
+)
+
+// redactDetectors are the built in detectors, candidates matched by the regular expression
+// must also pass the validator when one is present.  Validators return the length of the
+// valid prefix of the candidate, or zero if no prefix is valid.
+var redactDetectors = map[string]redactDetector{
+	`email`: {
+		rx:       regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9\-]+(?:\.[A-Za-z0-9\-]+)*\.[A-Za-z]{2,}`),
+		truncate: truncateEmail,
+	},
+	`pan`: {
+		rx:       regexp.MustCompile(`\b\d(?:[ \-]?\d){12,18}\b`),
+		validate: validPAN,
+	},
+	`ipv4`: {
+		rx:       regexp.MustCompile(`\b(?:(?:25[0-5]|2[0-4]\d|1?\d?\d)\.){3}(?:25[0-5]|2[0-4]\d|1?\d?\d)\b`),
+		truncate: truncateIP,
+	},
+	`ipv6`: {
+		rx:       regexp.MustCompile(`(?i)(?:\b[0-9a-f]{1,4}:|\B::)(?:[0-9a-f:.]*[0-9a-f]|:)?`),
+		validate: validIPv6,
+		truncate: truncateIP,
+	},
+}
+
+// RedactConfig replaces sensitive values in entry data.  Detectors may be any of the
+// built in email, pan (Luhn validated card numbers), ipv4, and ipv6 detectors, custom
+// detectors are given as Regex=name=pattern and replace the capture group named value,
+// the first capture group, or the whole match.  Every detector uses the global Mode unless
+// it is specified as name:mode.
+//
+// The hmac mode replaces values with a keyed token so that a value always maps to the same
+// pseudonym, the key is read from Key, the file at Key-File, or the environment variable
+// named by Key-Env (which may also be given as a file via the _FILE suffix).
+type RedactConfig struct {
+	Detector      []string
+	Regex         []string
+	Mode          string // mask, truncate, or hmac, default is mask
+	Mask          string // replacement used by mask mode
+	Truncate_Keep int    // trailing characters kept by truncate mode for card numbers and custom detectors
+	Key           string
+	Key_File      string
+	Key_Env       string
+	Token_Length  int    // number of hex characters in hmac tokens
+	Token_Prefix  string // prepended to every hmac token
+}
+
+type redactDetector struct {
+	name     string
+	mode     string
+	rx       *regexp.Regexp
+	group    int
+	validate func([]byte) int
+	truncate func([]byte) []byte
+}
+
+func RedactLoadConfig(vc *config.VariableConfig) (c RedactConfig, err error) {
+	if err = vc.MapTo(&c); err == nil {
+		_, err = c.validate()
+	}
+	return
+}
+
+func (c *RedactConfig) validate() (dets []redactDetector, err error) {
+	if c.Mode = strings.ToLower(strings.TrimSpace(c.Mode)); c.Mode == `` {
+		c.Mode = redactMask
+	} else if !validRedactMode(c.Mode) {
+		err = fmt.Errorf("%q: %w", c.Mode, ErrRedactInvalidMode)
+		return
+	}
+	if c.Mask == `` {
+		c.Mask = defaultRedactMask
+	}
+	if c.Truncate_Keep < 0 {
+		err = ErrRedactTruncateKeep
+		return
+	} else if c.Truncate_Keep == 0 {
+		c.Truncate_Keep = defaultRedactTruncateKeep
+	}
+	if c.Token_Length == 0 {
+		c.Token_Length = defaultRedactTokenLength
+	} else if c.Token_Length < 0 || c.Token_Length > sha256.Size*2 {
+		err = ErrRedactTokenLength
+		return
+	}
+	var names []string
+	for _, v := range c.Detector {
+		if v = strings.TrimSpace(v); v == `` {
+			continue
+		}
+		name, mode := c.splitMode(v)
+		d, ok := redactDetectors[strings.ToLower(name)]
+		if !ok {
+			err = fmt.Errorf("%s: %w", name, ErrRedactUnknownDetector)
+			return
+		} else if d.name, d.mode = strings.ToLower(name), mode; !validRedactMode(mode) {
+			err = fmt.Errorf("%s %q: %w", name, mode, ErrRedactInvalidMode)
+			return
+		}
+		dets = append(dets, d)
+	}
+	for _, v := range c.Regex {
+		if v = strings.TrimSpace(v); v == `` {
+			continue
+		}
+		idx := strings.Index(v, `=`)
+		if idx <= 0 || idx == len(v)-1 {
+			err = fmt.Errorf("%q: %w", v, ErrRedactInvalidRegex)
+			return
+		}
+		d := redactDetector{}
+		d.name, d.mode = c.splitMode(strings.TrimSpace(v[:idx]))
+		if !validRedactMode(d.mode) {
+			err = fmt.Errorf("%s %q: %w", d.name, d.mode, ErrRedactInvalidMode)
+			return
+		} else if d.rx, err = regexp.Compile(v[idx+1:]); err != nil {
+			err = fmt.Errorf("invalid regex for %s: %w", d.name, err)
+			return
+		}
+		if d.group = d.rx.SubexpIndex(`value`); d.group == -1 {
+			d.group = min(d.rx.NumSubexp(), 1)
+		}
+		dets = append(dets, d)
+	}
+	if len(dets) == 0 {
+		err = ErrRedactNoDetectors
+		return
+	}
+	for _, d := range dets {
+		if inStringSet(names, d.name) {
+			err = fmt.Errorf("%s: %w", d.name, ErrDuplicateKeyname)
+			return
+		}
+		names = append(names, d.name)
+		if d.mode == redactHMAC && c.Key == `` {
+			if err = config.LoadSecret(&c.Key, c.Key_File, c.Key_Env); err != nil {
+				if errors.Is(err, config.ErrMissingSecret) {
+					err = ErrRedactMissingKey
+				}
+				return
+			}
+		}
+	}
+	return
+}
+
+func (c *RedactConfig) splitMode(v string) (name, mode string) {
+	name, mode = v, c.Mode
+	if idx := strings.LastIndex(v, `:`); idx != -1 {
+		name, mode = strings.TrimSpace(v[:idx]), strings.ToLower(strings.TrimSpace(v[idx+1:]))
+	}
+	return
+}
+
+func validRedactMode(m string) bool {
+	return m == redactMask || m == redactTruncate || m == redactHMAC
+}
+
+// Redact rewrites entry data, replacing every detected value
+type Redact struct {
+	nocloser
+	RedactConfig
+	dets  []redactDetector
+	spans []redactSpan
+}
+
+type redactSpan struct {
+	start, end int
+	det        *redactDetector
+}
+
+func NewRedact(cfg RedactConfig) (r *Redact, err error) {
+	r = &Redact{}
+	if err = r.init(cfg); err != nil {
+		r = nil
+	}
+	return
+}
+
+func (r *Redact) Config(v interface{}) (err error) {
+	if v == nil {
+		err = ErrNilConfig
+	} else if cfg, ok := v.(RedactConfig); ok {
+		err = r.init(cfg)
+	} else {
+		err = fmt.Errorf("Invalid configuration, unknown type type %T", v)
+	}
+	return
+}
+
+func (r *Redact) init(cfg RedactConfig) (err error) {
+	var dets []redactDetector
+	if dets, err = cfg.validate(); err != nil {
+		return
+	}
+	r.RedactConfig = cfg
+	r.dets = dets
+	return
+}
+
+func (r *Redact) Process(ents []*entry.Entry) (rset []*entry.Entry, err error) {
+	for _, ent := range ents {
+		if ent != nil && len(ent.Data) > 0 {
+			ent.Data = r.redact(ent.Data)
+		}
+	}
+	rset = ents
+	return
+}
+
+// redact finds every match across all detectors in the original data, so replacements are
+// never matched by a later detector, and rebuilds the buffer.  Where matches overlap the
+// one which starts first wins.
+func (r *Redact) redact(data []byte) []byte {
+	r.spans = r.spans[:0]
+	for i := range r.dets {
+		d := &r.dets[i]
+		for _, m := range d.rx.FindAllSubmatchIndex(data, -1) {
+			start, end := m[2*d.group], m[2*d.group+1]
+			if start < 0 || start == end {
+				continue
+			} else if d.validate != nil {
+				if end = start + d.validate(data[start:end]); end == start {
+					continue
+				}
+			}
+			r.spans = append(r.spans, redactSpan{start: start, end: end, det: d})
+		}
+	}
+	if len(r.spans) == 0 {
+		return data
+	}
+	sort.SliceStable(r.spans, func(i, j int) bool {
+		return r.spans[i].start < r.spans[j].start
+	})
+	bb := bytes.NewBuffer(make([]byte, 0, len(data)))
+	var off int
+	for _, s := range r.spans {
+		if s.start < off {
+			continue
+		}
+		bb.Write(data[off:s.start])
+		bb.Write(r.replace(s.det, data[s.start:s.end]))
+		off = s.end
+	}
+	bb.Write(data[off:])
+	return bb.Bytes()
+}
+
+func (r *Redact) replace(d *redactDetector, v []byte) []byte {
+	switch d.mode {
+	case redactTruncate:
+		if d.truncate != nil {
+			return d.truncate(v)
+		}
+		return truncateTail(v, r.Truncate_Keep)
+	case redactHMAC:
+		mac := hmac.New(sha256.New, []byte(r.Key))
+		mac.Write(v)
+		return []byte(r.Token_Prefix + hex.EncodeToString(mac.Sum(nil))[:r.Token_Length])
+	}
+	return []byte(r.Mask)
+}
+
+// truncateTail masks everything but the trailing keep characters, separators in card
+// numbers are left in place
+func truncateTail(v []byte, keep int) (r []byte) {
+	r = bytes.Clone(v)
+	for i := len(r) - 1; i >= 0; i-- {
+		if r[i] == ' ' || r[i] == '-' {
+			continue
+		} else if keep > 0 {
+			keep--
+			continue
+		}
+		r[i] = redactMaskChar
+	}
+	return
+}
+
+// truncateEmail masks the local part and keeps the domain
+func truncateEmail(v []byte) []byte {
+	idx := bytes.LastIndexByte(v, '@')
+	return append(bytes.Repeat([]byte{redactMaskChar}, idx), v[idx:]...)
+}
+
+// truncateIP keeps the /24 of IPv4 addresses and the /48 of IPv6 addresses
+func truncateIP(v []byte) []byte {
+	ip := net.ParseIP(string(v))
+	if ip == nil {
+		return bytes.Repeat([]byte{redactMaskChar}, len(v))
+	} else if ip4 := ip.To4(); ip4 != nil && bytes.IndexByte(v, ':') == -1 {
+		return []byte(ip4.Mask(net.CIDRMask(24, 32)).String())
+	}
+	return []byte(ip.Mask(net.CIDRMask(48, 128)).String())
+}
+
+// validIPv6 rejects bare :: so scoped names like std::string are left alone
+func validIPv6(v []byte) int {
+	if len(v) > 2 && net.ParseIP(string(v)) != nil {
+		return len(v)
+	}
+	return 0
+}
+
+// validPAN finds the longest run of digit groups which passes the Luhn check, candidates
+// may run on into a following number when separated by a single space
+func validPAN(v []byte) int {
+	for end := len(v); end > 0; end-- {
+		if end < len(v) && v[end] != ' ' && v[end] != '-' {
+			continue
+		} else if luhnValid(v[:end]) {
+			return end
+		}
+	}
+	return 0
+}
+
+func luhnValid(v []byte) bool {
+	var sum, n int
+	for i := len(v) - 1; i >= 0; i-- {
+		c := v[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if n%2 == 1 {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n >= 13 && n <= 19 && sum%10 == 0
+}
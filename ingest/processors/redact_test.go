@@ -0,0 +1,154 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package processors
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gravwell/gravwell/v4/ingest/entry"
+)
+
+func TestRedactConfig(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), `key`)
+	if err := os.WriteFile(keyFile, []byte("filekey\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	b := `
+	[preprocessor "pii"]
+		type = redact
+		Detector=email:hmac
+		Detector=PAN:truncate
+		Detector=ipv4
+		Regex="ssn=\\b\\d{3}-\\d{2}-\\d{4}\\b"
+		Key-File="` + keyFile + `"
+	`
+	p, err := testLoadPreprocessor(b, `pii`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, ok := p.(*Redact)
+	if !ok {
+		t.Fatalf("preprocessor is the wrong type: %T != *Redact", p)
+	} else if r.Key != `filekey` || r.Mode != redactMask || r.Mask != defaultRedactMask || len(r.dets) != 4 {
+		t.Fatalf("bad config: %+v", r.RedactConfig)
+	}
+	for i, mode := range []string{redactHMAC, redactTruncate, redactMask, redactMask} {
+		if r.dets[i].mode != mode {
+			t.Fatalf("detector %s has mode %s != %s", r.dets[i].name, r.dets[i].mode, mode)
+		}
+	}
+
+	t.Setenv(`REDACT_TEST_KEY`, `envkey`)
+	if r, err = NewRedact(RedactConfig{Detector: []string{`email`}, Mode: `hmac`, Key_Env: `REDACT_TEST_KEY`}); err != nil {
+		t.Fatal(err)
+	} else if r.Key != `envkey` {
+		t.Fatalf("did not load key from environment: %q", r.Key)
+	}
+
+	bad := []RedactConfig{
+		{},
+		{Detector: []string{`phone`}},
+		{Detector: []string{`email`}, Mode: `scramble`},
+		{Detector: []string{`email:scramble`}},
+		{Detector: []string{`email`, `email:hmac`}, Key: `x`},
+		{Detector: []string{`email`}, Mode: `hmac`},
+		{Detector: []string{`email`}, Mode: `hmac`, Key_File: filepath.Join(t.TempDir(), `missing`)},
+		{Detector: []string{`email`}, Token_Length: 65},
+		{Detector: []string{`email`}, Truncate_Keep: -1},
+		{Regex: []string{`noname`}},
+		{Regex: []string{`bad=(`}},
+	}
+	for _, cfg := range bad {
+		if _, err := NewRedact(cfg); err == nil {
+			t.Fatalf("bad config did not fail: %+v", cfg)
+		}
+	}
+}
+
+func TestRedactDetectors(t *testing.T) {
+	r, err := NewRedact(RedactConfig{
+		Detector: []string{`email`, `pan`, `ipv4`, `ipv6`},
+		Regex:    []string{`user=user=(\w+)`, `ssn=\b(?P<value>\d{3}-\d{2}-\d{4})\b`},
+		Mask:     `XXX`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := [][2]string{
+		{`login from bob@example.com ok`, `login from XXX ok`},
+		{`card 4111 1111 1111 1111 and 4111-1111-1111-1112`, `card XXX and 4111-1111-1111-1112`},
+		{`pan=378282246310005;`, `pan=XXX;`},
+		{`4111 1111 1111 1111 12 times`, `XXX 12 times`},
+		{`src=10.1.2.3 dst=300.1.2.3`, `src=XXX dst=300.1.2.3`},
+		{`addr:fe80::1 and ::ffff:1.2.3.4 at 12:34:56`, `addr:XXX and XXX at 12:34:56`},
+		{`std::string 00:11:22:33:44:55`, `std::string 00:11:22:33:44:55`},
+		{`user=alice ssn 123-45-6789`, `user=XXX ssn XXX`},
+		{`nothing to see`, `nothing to see`},
+	}
+	for _, tt := range tests {
+		ents, err := r.Process([]*entry.Entry{{Data: []byte(tt[0])}})
+		if err != nil {
+			t.Fatal(err)
+		} else if len(ents) != 1 {
+			t.Fatalf("bad entry count %d", len(ents))
+		} else if string(ents[0].Data) != tt[1] {
+			t.Fatalf("bad redaction of %q\n%q != %q", tt[0], ents[0].Data, tt[1])
+		}
+	}
+}
+
+func TestRedactModes(t *testing.T) {
+	r, err := NewRedact(RedactConfig{
+		Detector:     []string{`email`, `pan`, `ipv4`, `ipv6`},
+		Regex:        []string{`user:hmac=user=(\w+)`},
+		Mode:         `truncate`,
+		Key:          `secret`,
+		Token_Prefix: `tok_`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ents, err := r.Process([]*entry.Entry{
+		{Data: []byte(`bob@example.com 4111 1111 1111 1111 10.1.2.3 2001:db8:1:2::5 user=alice`)},
+		{Data: []byte(`user=alice user=bob`)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	flds := strings.Fields(string(ents[0].Data))
+	exp := []string{`***@example.com`, `****`, `****`, `****`, `1111`, `10.1.2.0`, `2001:db8:1::`}
+	for i, v := range exp {
+		if flds[i] != v {
+			t.Fatalf("bad truncation %d: %q != %q", i, flds[i], v)
+		}
+	}
+	//the same value always maps to the same token, different values do not
+	tok := strings.TrimPrefix(flds[7], `user=`)
+	if !strings.HasPrefix(tok, `tok_`) || len(tok) != len(`tok_`)+defaultRedactTokenLength {
+		t.Fatalf("bad token %q", tok)
+	}
+	toks := strings.Fields(string(ents[1].Data))
+	if toks[0] != `user=`+tok || toks[1] == toks[0] {
+		t.Fatalf("tokens are not stable: %v %q", toks, tok)
+	}
+
+	//a different key produces different tokens
+	r2, err := NewRedact(RedactConfig{Regex: []string{`user=user=(\w+)`}, Mode: `hmac`, Key: `other`, Token_Prefix: `tok_`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ents, err = r2.Process([]*entry.Entry{{Data: []byte(`user=alice`)}}); err != nil {
+		t.Fatal(err)
+	} else if string(ents[0].Data) == `user=`+tok {
+		t.Fatal("key did not change the token")
+	}
+}
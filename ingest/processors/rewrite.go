@@ -0,0 +1,245 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package processors
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/gravwell/gravwell/v4/ingest"
+	"github.com/gravwell/gravwell/v4/ingest/config"
+	"github.com/gravwell/gravwell/v4/ingest/entry"
+)
+
+const (
+	RewriteProcessor string = `rewrite`
+)
+
+var (
+	ErrRewriteTemplate = errors.New("exactly one of Template or Template-File is required")
+
+	rewriteFuncs = template.FuncMap{
+		`json`: func(v interface{}) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+		`lower`:    strings.ToLower,
+		`upper`:    strings.ToUpper,
+		`trim`:     strings.TrimSpace,
+		`replace`:  strings.ReplaceAll,
+		`split`:    strings.Split,
+		`join`:     strings.Join,
+		`contains`: strings.Contains,
+		`default`: func(def, v interface{}) interface{} {
+			if v == nil || v == `` {
+				return def
+			}
+			return v
+		},
+	}
+)
+
+// RewriteConfig rebuilds entries from a Go text/template.  Templates are executed against
+// the entry with .Data, .Tag, .SRC, .TS, and .EV, which maps enumerated value names to
+// their values, and may use the json, lower, upper, trim, replace, split, join, contains,
+// and default functions.  The output replaces the entry data and entries which render to
+// nothing are dropped.  When Entry-Separator is set the output is split and each piece
+// becomes an entry carrying the original timestamp, source, and enumerated values.
+// Tag-Template renders the name of a new tag, an empty result keeps the current tag.
+// Entries which fail to render are passed through untouched.
+type RewriteConfig struct {
+	Template        string
+	Template_File   string
+	Tag_Template    string
+	Entry_Separator string
+}
+
+func RewriteLoadConfig(vc *config.VariableConfig) (c RewriteConfig, err error) {
+	if err = vc.MapTo(&c); err == nil {
+		_, _, err = c.validate()
+	}
+	return
+}
+
+func (c *RewriteConfig) validate() (tmpl, tagTmpl *template.Template, err error) {
+	c.Template_File = strings.TrimSpace(c.Template_File)
+	if (c.Template == ``) == (c.Template_File == ``) {
+		err = ErrRewriteTemplate
+		return
+	}
+	src := c.Template
+	if c.Template_File != `` {
+		var b []byte
+		if b, err = os.ReadFile(c.Template_File); err != nil {
+			return
+		}
+		src = string(b)
+	}
+	if tmpl, err = template.New(`template`).Funcs(rewriteFuncs).Parse(src); err != nil {
+		return
+	}
+	if c.Tag_Template != `` {
+		tagTmpl, err = template.New(`tag-template`).Funcs(rewriteFuncs).Parse(c.Tag_Template)
+	}
+	return
+}
+
+// rewriteData is the value templates are executed against
+type rewriteData struct {
+	Data string
+	Tag  string
+	SRC  string
+	TS   time.Time
+	EV   map[string]interface{}
+}
+
+type Rewrite struct {
+	nocloser
+	RewriteConfig
+	tmpl    *template.Template
+	tagTmpl *template.Template
+	tgr     Tagger
+	tags    map[string]entry.EntryTag
+	bb      bytes.Buffer
+	tagBB   bytes.Buffer
+}
+
+func NewRewrite(cfg RewriteConfig, tagger Tagger) (rw *Rewrite, err error) {
+	rw = &Rewrite{}
+	if err = rw.init(cfg, tagger); err != nil {
+		rw = nil
+	}
+	return
+}
+
+func (rw *Rewrite) Config(v interface{}, tagger Tagger) (err error) {
+	if v == nil {
+		err = ErrNilConfig
+	} else if cfg, ok := v.(RewriteConfig); ok {
+		err = rw.init(cfg, tagger)
+	} else {
+		err = fmt.Errorf("Invalid configuration, unknown type type %T", v)
+	}
+	return
+}
+
+func (rw *Rewrite) init(cfg RewriteConfig, tagger Tagger) (err error) {
+	var tmpl, tagTmpl *template.Template
+	if tmpl, tagTmpl, err = cfg.validate(); err != nil {
+		return
+	}
+	rw.RewriteConfig = cfg
+	rw.tmpl, rw.tagTmpl = tmpl, tagTmpl
+	rw.tgr = tagger
+	rw.tags = map[string]entry.EntryTag{}
+	return
+}
+
+func (rw *Rewrite) Process(ents []*entry.Entry) (rset []*entry.Entry, err error) {
+	if len(ents) == 0 {
+		return
+	}
+	if rw.Entry_Separator == `` {
+		//at most one entry out for each entry in, so the input can be reused
+		rset = ents[:0]
+	} else {
+		rset = make([]*entry.Entry, 0, len(ents))
+	}
+	for _, ent := range ents {
+		if ent != nil {
+			rset = rw.rewrite(ent, rset)
+		}
+	}
+	return
+}
+
+func (rw *Rewrite) rewrite(ent *entry.Entry, rset []*entry.Entry) []*entry.Entry {
+	d := rw.data(ent)
+	tag := ent.Tag
+	if rw.tagTmpl != nil {
+		rw.tagBB.Reset()
+		if err := rw.tagTmpl.Execute(&rw.tagBB, d); err != nil {
+			return append(rset, ent)
+		}
+		if name := strings.TrimSpace(rw.tagBB.String()); name != `` {
+			var err error
+			if tag, err = rw.negotiateTag(name); err != nil {
+				return append(rset, ent)
+			}
+		}
+	}
+	rw.bb.Reset()
+	if err := rw.tmpl.Execute(&rw.bb, d); err != nil {
+		return append(rset, ent)
+	}
+	pieces := [][]byte{rw.bb.Bytes()}
+	if rw.Entry_Separator != `` {
+		pieces = bytes.Split(rw.bb.Bytes(), []byte(rw.Entry_Separator))
+	}
+	var emitted bool
+	for _, p := range pieces {
+		if len(p) == 0 {
+			continue
+		}
+		if !emitted {
+			emitted = true
+			ent.Data = append(nb, p...)
+			ent.Tag = tag
+			rset = append(rset, ent)
+			continue
+		}
+		nent := &entry.Entry{
+			TS:   ent.TS,
+			SRC:  ent.SRC,
+			Tag:  tag,
+			Data: append(nb, p...),
+		}
+		nent.EVB = ent.EVB.DeepCopy()
+		rset = append(rset, nent)
+	}
+	return rset
+}
+
+func (rw *Rewrite) data(ent *entry.Entry) (d rewriteData) {
+	d = rewriteData{
+		Data: string(ent.Data),
+		TS:   ent.TS.StandardTime(),
+	}
+	if rw.tgr != nil {
+		d.Tag, _ = rw.tgr.LookupTag(ent.Tag)
+	}
+	if ent.SRC != nil {
+		d.SRC = ent.SRC.String()
+	}
+	if evs := ent.EVB.Values(); len(evs) > 0 {
+		d.EV = make(map[string]interface{}, len(evs))
+		for _, ev := range evs {
+			d.EV[ev.Name] = ev.Value.Interface()
+		}
+	}
+	return
+}
+
+func (rw *Rewrite) negotiateTag(name string) (tag entry.EntryTag, err error) {
+	var ok bool
+	if tag, ok = rw.tags[name]; ok {
+		return
+	} else if err = ingest.CheckTag(name); err != nil {
+		return
+	} else if tag, err = rw.tgr.NegotiateTag(name); err == nil {
+		rw.tags[name] = tag
+	}
+	return
+}
@@ -0,0 +1,134 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package processors
+
+import (
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gravwell/gravwell/v4/ingest/entry"
+)
+
+func TestRewriteConfig(t *testing.T) {
+	b := `
+	[preprocessor "rw"]
+		type = rewrite
+		Template="{{.Tag}}:{{.Data}}"
+		Tag-Template="{{.EV.app}}"
+	`
+	p, err := testLoadPreprocessor(b, `rw`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rw, ok := p.(*Rewrite)
+	if !ok {
+		t.Fatalf("preprocessor is the wrong type: %T != *Rewrite", p)
+	} else if rw.tmpl == nil || rw.tagTmpl == nil {
+		t.Fatalf("templates not loaded: %+v", rw.RewriteConfig)
+	}
+
+	pth := filepath.Join(t.TempDir(), `tmpl`)
+	writeTestFile(t, pth, []byte(`{{.Data}}`))
+	if _, err = NewRewrite(RewriteConfig{Template_File: pth}, &testTagger{}); err != nil {
+		t.Fatal(err)
+	}
+	bad := []RewriteConfig{
+		{},
+		{Template: `{{.Data}}`, Template_File: pth},
+		{Template: `{{.Data`},
+		{Template: `{{nope .Data}}`},
+		{Template: `{{.Data}}`, Tag_Template: `{{`},
+		{Template_File: filepath.Join(t.TempDir(), `missing`)},
+	}
+	for _, c := range bad {
+		if _, err = NewRewrite(c, &testTagger{}); err == nil {
+			t.Fatalf("bad config %+v did not fail", c)
+		}
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	tt := &testTagger{}
+	syslog, err := tt.NegotiateTag(`syslog`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rw, err := NewRewrite(RewriteConfig{
+		Template:     `{"host":{{json .EV.host}},"user":{{json (lower .EV.user)}},"tag":"{{.Tag}}","src":"{{.SRC}}","ts":"{{.TS.Format "2006-01-02"}}","msg":{{json .Data}}}`,
+		Tag_Template: `{{with .EV.app}}app-{{.}}{{end}}`,
+	}, tt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := entry.FromStandard(time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC))
+	ent := &entry.Entry{TS: ts, Tag: syslog, SRC: net.ParseIP(`10.0.0.1`), Data: []byte(`login ok`)}
+	ent.AddEnumeratedValueEx(`host`, `web1`)
+	ent.AddEnumeratedValueEx(`user`, `Alice`)
+	ent.AddEnumeratedValueEx(`app`, `sshd`)
+	other := &entry.Entry{TS: ts, Tag: syslog, Data: []byte(`no evs`)}
+	other.AddEnumeratedValueEx(`user`, `Bob`)
+	//lower of a missing value fails, so the entry is passed through
+	broken := &entry.Entry{TS: ts, Tag: syslog, Data: []byte(`broken`)}
+
+	out, err := rw.Process([]*entry.Entry{ent, other, broken})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(out) != 3 {
+		t.Fatalf("invalid output count: %d", len(out))
+	}
+	if s := string(out[0].Data); s != `{"host":"web1","user":"alice","tag":"syslog","src":"10.0.0.1","ts":"2026-03-04","msg":"login ok"}` {
+		t.Fatalf("bad rewrite: %s", s)
+	} else if name, ok := tt.LookupTag(out[0].Tag); !ok || name != `app-sshd` {
+		t.Fatalf("bad tag: %v %v", name, ok)
+	}
+	if s := string(out[1].Data); s != `{"host":null,"user":"bob","tag":"syslog","src":"","ts":"2026-03-04","msg":"no evs"}` {
+		t.Fatalf("bad rewrite: %s", s)
+	} else if out[1].Tag != syslog {
+		t.Fatalf("tag changed without a tag name")
+	}
+	if string(out[2].Data) != `broken` || out[2].Tag != syslog {
+		t.Fatalf("failed entry was modified: %s", out[2].Data)
+	}
+}
+
+func TestRewriteSplit(t *testing.T) {
+	rw, err := NewRewrite(RewriteConfig{
+		Template:        `{{range split .Data ","}}{{trim .}};{{end}}`,
+		Entry_Separator: `;`,
+	}, &testTagger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ent := &entry.Entry{TS: entry.Now(), SRC: testSrc, Tag: 3, Data: []byte(`a, b,c`)}
+	ent.AddEnumeratedValueEx(`keep`, `me`)
+	empty := &entry.Entry{Data: []byte(` , `)}
+	out, err := rw.Process([]*entry.Entry{ent, empty})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(out) != 3 {
+		t.Fatalf("invalid output count: %d", len(out))
+	}
+	for i, v := range []string{`a`, `b`, `c`} {
+		o := out[i]
+		if string(o.Data) != v {
+			t.Fatalf("bad entry %d: %s", i, o.Data)
+		} else if o.TS != ent.TS || o.Tag != 3 || !o.SRC.Equal(testSrc) {
+			t.Fatalf("bad entry %d metadata: %+v", i, o)
+		} else if val, ok := o.GetEnumeratedValue(`keep`); !ok || val != `me` {
+			t.Fatalf("entry %d lost its enumerated values", i)
+		}
+	}
+	//the copies do not share enumerated values
+	out[1].AddEnumeratedValueEx(`extra`, 1)
+	if _, ok := out[0].GetEnumeratedValue(`extra`); ok {
+		t.Fatal("enumerated values are shared")
+	}
+}
@@ -0,0 +1,315 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package processors
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gravwell/gravwell/v4/ingest"
+	"github.com/gravwell/gravwell/v4/ingest/config"
+	"github.com/gravwell/gravwell/v4/ingest/entry"
+	"github.com/gravwell/gravwell/v4/ingest/log"
+)
+
+const (
+	SampleProcessor string = `sample`
+
+	sampleTagKey = `_TAG_`
+	sampleSrcKey = `_SRC_`
+
+	defaultSampleReportInterval = time.Minute
+	defaultSampleMaxKeys        = 10000
+)
+
+var (
+	ErrSampleRateConflict    = errors.New("Rate and Probability are mutually exclusive")
+	ErrInvalidSampleRate     = errors.New("Rate must be positive")
+	ErrInvalidProbability    = errors.New("Probability must be greater than 0 and no greater than 1")
+	ErrInvalidLimitRate      = errors.New("Limit-Rate must be positive")
+	ErrInvalidLimitBurst     = errors.New("Limit-Burst must be positive")
+	ErrLimitKeyWithoutRate   = errors.New("Limit-Key requires Limit-Rate")
+	ErrInvalidReportInterval = errors.New("Report-Interval must be a positive duration")
+	ErrInvalidMaxKeys        = errors.New("Max-Keys must be positive")
+	ErrNoSampling            = errors.New("one of Rate, Probability, or Limit-Rate is required")
+)
+
+// SampleConfig controls sampling and rate limiting.  Sampling keeps every Nth entry (Rate)
+// or keeps entries at random (Probability), entries which survive sampling are then
+// run through a token bucket limiter keyed by Limit-Key, which may be _TAG_, _SRC_, or the
+// name of an enumerated value extracted by an earlier preprocessor.  Without a Limit-Key a
+// single bucket is shared by every entry.
+type SampleConfig struct {
+	Rate            int     // keep 1 in every Rate entries
+	Probability     float64 // keep each entry with this probability
+	Limit_Key       string
+	Limit_Rate      float64 // entries per second allowed for each key
+	Limit_Burst     int     // bucket size, defaults to Limit-Rate rounded up
+	Max_Keys        int     // maximum number of limiter keys tracked at once
+	Overflow_Tag    string  // retag excess entries to this tag rather than dropping them
+	Report_Interval string  // how often drop counts are logged
+}
+
+func SampleLoadConfig(vc *config.VariableConfig) (c SampleConfig, err error) {
+	if err = vc.MapTo(&c); err == nil {
+		_, err = c.validate()
+	}
+	return
+}
+
+func (c *SampleConfig) validate() (interval time.Duration, err error) {
+	if c.Rate < 0 {
+		err = ErrInvalidSampleRate
+		return
+	} else if c.Probability < 0 || c.Probability > 1 || math.IsNaN(c.Probability) {
+		err = ErrInvalidProbability
+		return
+	} else if c.Rate > 0 && c.Probability > 0 {
+		err = ErrSampleRateConflict
+		return
+	}
+	if c.Limit_Rate < 0 || math.IsNaN(c.Limit_Rate) || math.IsInf(c.Limit_Rate, 0) {
+		err = ErrInvalidLimitRate
+		return
+	} else if c.Limit_Burst < 0 {
+		err = ErrInvalidLimitBurst
+		return
+	} else if c.Limit_Key = strings.TrimSpace(c.Limit_Key); c.Limit_Key != `` && c.Limit_Rate == 0 {
+		err = ErrLimitKeyWithoutRate
+		return
+	}
+	if c.Rate == 0 && c.Probability == 0 && c.Limit_Rate == 0 {
+		err = ErrNoSampling
+		return
+	}
+	if c.Limit_Rate > 0 && c.Limit_Burst == 0 {
+		c.Limit_Burst = int(math.Ceil(c.Limit_Rate))
+	}
+	if c.Max_Keys < 0 {
+		err = ErrInvalidMaxKeys
+		return
+	} else if c.Max_Keys == 0 {
+		c.Max_Keys = defaultSampleMaxKeys
+	}
+	if c.Overflow_Tag = strings.TrimSpace(c.Overflow_Tag); c.Overflow_Tag != `` {
+		if err = ingest.CheckTag(c.Overflow_Tag); err != nil {
+			err = fmt.Errorf("invalid Overflow-Tag %q: %w", c.Overflow_Tag, err)
+			return
+		}
+	}
+	interval = defaultSampleReportInterval
+	if c.Report_Interval != `` {
+		if interval, err = time.ParseDuration(c.Report_Interval); err != nil {
+			return
+		} else if interval <= 0 {
+			err = ErrInvalidReportInterval
+			return
+		}
+	}
+	return
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Sample drops or retags entries which are sampled out or exceed the rate limit.
+// Drop counts are logged every Report-Interval when the tagger is also a logger,
+// which is the case for every ingester that hands its muxer to a ProcessorSet.
+type Sample struct {
+	SampleConfig
+	interval time.Duration
+	tagger   Tagger
+	lg       log.IngestLogger
+	overflow entry.EntryTag
+	cnt      uint64
+	buckets  map[string]*tokenBucket
+	sampled  uint64 // entries removed by sampling since the last report
+	limited  uint64 // entries removed by the limiter since the last report
+	lastRpt  time.Time
+	now      func() time.Time
+	rnd      func() float64
+}
+
+func NewSample(cfg SampleConfig, tagger Tagger) (s *Sample, err error) {
+	s = &Sample{
+		tagger: tagger,
+		now:    time.Now,
+		rnd:    rand.Float64,
+	}
+	if lg, ok := tagger.(log.IngestLogger); ok {
+		s.lg = lg
+	}
+	if err = s.init(cfg); err != nil {
+		s = nil
+		return
+	}
+	s.lastRpt = s.now()
+	return
+}
+
+func (s *Sample) Config(v interface{}) (err error) {
+	if v == nil {
+		err = ErrNilConfig
+	} else if cfg, ok := v.(SampleConfig); ok {
+		err = s.init(cfg)
+	} else {
+		err = fmt.Errorf("Invalid configuration, unknown type type %T", v)
+	}
+	return
+}
+
+func (s *Sample) init(cfg SampleConfig) (err error) {
+	var interval time.Duration
+	if interval, err = cfg.validate(); err != nil {
+		return
+	}
+	if cfg.Overflow_Tag != `` {
+		if s.tagger == nil {
+			err = errors.New("Overflow-Tag requires a tagger")
+			return
+		} else if s.overflow, err = s.tagger.NegotiateTag(cfg.Overflow_Tag); err != nil {
+			err = fmt.Errorf("Failed to get tag %s: %v", cfg.Overflow_Tag, err)
+			return
+		}
+	}
+	s.SampleConfig = cfg
+	s.interval = interval
+	s.buckets = map[string]*tokenBucket{}
+	return
+}
+
+func (s *Sample) Process(ents []*entry.Entry) (rset []*entry.Entry, err error) {
+	if len(ents) == 0 {
+		return
+	}
+	now := s.now()
+	rset = ents[:0]
+	for _, ent := range ents {
+		if ent == nil {
+			continue
+		}
+		if !s.sample() {
+			s.sampled++
+		} else if !s.allow(ent, now) {
+			s.limited++
+		} else {
+			rset = append(rset, ent)
+			continue
+		}
+		if s.Overflow_Tag != `` {
+			ent.Tag = s.overflow
+			rset = append(rset, ent)
+		}
+	}
+	if now.Sub(s.lastRpt) >= s.interval {
+		s.report(now)
+	}
+	return
+}
+
+func (s *Sample) Flush() []*entry.Entry {
+	return nil
+}
+
+// Close logs any outstanding drop counts
+func (s *Sample) Close() error {
+	s.report(s.now())
+	return nil
+}
+
+// sample returns true if the entry survives sampling
+func (s *Sample) sample() (keep bool) {
+	if s.Rate > 1 {
+		keep = s.cnt%uint64(s.Rate) == 0
+		s.cnt++
+	} else if s.Probability > 0 {
+		keep = s.rnd() < s.Probability
+	} else {
+		keep = true
+	}
+	return
+}
+
+// allow returns true if the entry's bucket has a token available
+func (s *Sample) allow(ent *entry.Entry, now time.Time) bool {
+	if s.Limit_Rate <= 0 {
+		return true
+	}
+	key := s.limitKey(ent)
+	b, ok := s.buckets[key]
+	if !ok {
+		if len(s.buckets) >= s.Max_Keys {
+			s.prune(now)
+		}
+		b = &tokenBucket{tokens: float64(s.Limit_Burst), last: now}
+		s.buckets[key] = b
+	} else if d := now.Sub(b.last); d > 0 {
+		b.tokens = min(b.tokens+d.Seconds()*s.Limit_Rate, float64(s.Limit_Burst))
+		b.last = now
+	}
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+func (s *Sample) limitKey(ent *entry.Entry) string {
+	switch s.Limit_Key {
+	case ``:
+		return ``
+	case sampleTagKey:
+		return strconv.FormatUint(uint64(ent.Tag), 10)
+	case sampleSrcKey:
+		return string(ent.SRC)
+	}
+	if ev, ok := ent.EVB.Get(s.Limit_Key); ok {
+		return string(ev.ValueBuff())
+	}
+	return ``
+}
+
+// prune forgets buckets which have refilled, a refilled bucket behaves exactly like a
+// new one.  If every bucket is still draining they are all forgotten.
+func (s *Sample) prune(now time.Time) {
+	for k, b := range s.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*s.Limit_Rate >= float64(s.Limit_Burst) {
+			delete(s.buckets, k)
+		}
+	}
+	if len(s.buckets) >= s.Max_Keys {
+		clear(s.buckets)
+	}
+}
+
+func (s *Sample) report(now time.Time) {
+	s.lastRpt = now
+	if s.sampled == 0 && s.limited == 0 {
+		return
+	}
+	if s.lg != nil {
+		action := `dropped`
+		if s.Overflow_Tag != `` {
+			action = `retagged`
+		}
+		s.lg.Info("sample preprocessor removed entries",
+			log.KV("action", action),
+			log.KV("sampled", s.sampled),
+			log.KV("limited", s.limited),
+			log.KV("overflow-tag", s.Overflow_Tag))
+	}
+	s.sampled, s.limited = 0, 0
+}
@@ -0,0 +1,171 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package processors
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/gravwell/gravwell/v4/ingest/entry"
+)
+
+func TestSampleConfig(t *testing.T) {
+	b := `
+	[preprocessor "s"]
+		type = sample
+		Rate=10
+		Limit-Key=_TAG_
+		Limit-Rate=2.5
+		Overflow-Tag=overflow
+	`
+	p, err := testLoadPreprocessor(b, `s`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, ok := p.(*Sample)
+	if !ok {
+		t.Fatalf("preprocessor is the wrong type: %T != *Sample", p)
+	} else if s.Rate != 10 || s.Limit_Burst != 3 || s.Max_Keys != defaultSampleMaxKeys || s.interval != defaultSampleReportInterval {
+		t.Fatalf("bad config: %+v", s.SampleConfig)
+	}
+
+	var tg testTagger
+	bad := []SampleConfig{
+		{},
+		{Rate: -1},
+		{Rate: 2, Probability: 0.5},
+		{Probability: 1.5},
+		{Limit_Rate: -1},
+		{Limit_Key: `_SRC_`},
+		{Rate: 2, Overflow_Tag: `bad tag`},
+		{Rate: 2, Report_Interval: `-1s`},
+	}
+	for _, cfg := range bad {
+		if _, err := NewSample(cfg, &tg); err == nil {
+			t.Fatalf("bad config did not fail: %+v", cfg)
+		}
+	}
+}
+
+func TestSampleRate(t *testing.T) {
+	var tg testTagger
+	s, err := NewSample(SampleConfig{Rate: 4}, &tg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var kept int
+	for i := 0; i < 10; i++ {
+		ents := make([]*entry.Entry, 10)
+		for j := range ents {
+			ents[j] = &entry.Entry{}
+		}
+		if ents, err = s.Process(ents); err != nil {
+			t.Fatal(err)
+		}
+		kept += len(ents)
+	}
+	if kept != 25 || s.sampled != 75 {
+		t.Fatalf("bad 1 in 4 sampling: kept %d sampled %d", kept, s.sampled)
+	}
+	s.Close()
+	if s.sampled != 0 {
+		t.Fatal("counts not reset on report")
+	}
+}
+
+func TestSampleProbability(t *testing.T) {
+	var tg testTagger
+	s, err := NewSample(SampleConfig{Probability: 0.5}, &tg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vals := []float64{0.1, 0.6, 0.4, 0.9}
+	s.rnd = func() (r float64) {
+		r, vals = vals[0], vals[1:]
+		return
+	}
+	ents := []*entry.Entry{{Data: []byte(`a`)}, {Data: []byte(`b`)}, {Data: []byte(`c`)}, {Data: []byte(`d`)}}
+	if ents, err = s.Process(ents); err != nil {
+		t.Fatal(err)
+	} else if len(ents) != 2 || string(ents[0].Data) != `a` || string(ents[1].Data) != `c` {
+		t.Fatalf("bad probabilistic sampling: %v", ents)
+	}
+}
+
+func TestSampleLimit(t *testing.T) {
+	var tg testTagger
+	s, err := NewSample(SampleConfig{Limit_Key: `_SRC_`, Limit_Rate: 2, Overflow_Tag: `overflow`}, &tg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	overflow, _ := tg.NegotiateTag(`overflow`)
+	clk := &testClock{t: time.Now()}
+	s.now = clk.now
+	a, b := net.ParseIP(`10.0.0.1`), net.ParseIP(`10.0.0.2`)
+	count := func(ents []*entry.Entry) (kept, over int) {
+		for _, ent := range ents {
+			if ent.Tag == overflow {
+				over++
+			} else {
+				kept++
+			}
+		}
+		return
+	}
+	mk := func(src net.IP, n int) (r []*entry.Entry) {
+		for i := 0; i < n; i++ {
+			r = append(r, &entry.Entry{Tag: 100, SRC: src})
+		}
+		return
+	}
+
+	ents, err := s.Process(append(mk(a, 5), mk(b, 1)...))
+	if err != nil {
+		t.Fatal(err)
+	} else if kept, over := count(ents); kept != 3 || over != 3 {
+		t.Fatalf("bad limit: kept %d overflow %d", kept, over)
+	}
+	//half a second refills a single token
+	clk.t = clk.t.Add(500 * time.Millisecond)
+	if ents, err = s.Process(mk(a, 3)); err != nil {
+		t.Fatal(err)
+	} else if kept, over := count(ents); kept != 1 || over != 2 {
+		t.Fatalf("bad refill: kept %d overflow %d", kept, over)
+	}
+	//the bucket never holds more than the burst
+	clk.t = clk.t.Add(time.Hour)
+	if ents, err = s.Process(mk(a, 5)); err != nil {
+		t.Fatal(err)
+	} else if kept, over := count(ents); kept != 2 || over != 3 {
+		t.Fatalf("bad burst: kept %d overflow %d", kept, over)
+	}
+}
+
+func TestSampleLimitDrop(t *testing.T) {
+	var tg testTagger
+	s, err := NewSample(SampleConfig{Limit_Key: `user`, Limit_Rate: 1, Max_Keys: 2}, &tg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.now = (&testClock{t: time.Now()}).now
+	var ents []*entry.Entry
+	for _, u := range []string{`a`, `a`, `b`, `b`, `c`, `c`} {
+		ent := &entry.Entry{}
+		ent.AddEnumeratedValueEx(`user`, u)
+		ents = append(ents, ent)
+	}
+	if ents, err = s.Process(ents); err != nil {
+		t.Fatal(err)
+	} else if len(ents) != 3 || s.limited != 3 {
+		t.Fatalf("bad keyed limit: kept %d limited %d", len(ents), s.limited)
+	} else if len(s.buckets) > s.Max_Keys {
+		t.Fatalf("limiter keys not bounded: %d", len(s.buckets))
+	}
+}
@@ -0,0 +1,137 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package processors
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gravwell/gravwell/v4/ingest/entry"
+)
+
+const (
+	schemaOutputJSON = `json`
+	schemaOutputEV   = `ev`
+
+	confluentMagic      byte = 0
+	confluentHeaderSize      = 5
+	schemaRetryInterval      = 30 * time.Second
+)
+
+var (
+	ErrInvalidSchemaOutput = errors.New("Output-Format must be json or ev")
+	ErrSchemaNotFound      = errors.New("No schema found for entry")
+	ErrNotSchemaDirectory  = errors.New("Schema-Directory is not a directory")
+)
+
+// confluentFrame splits the Confluent wire format header from a payload, the header is
+// a zero magic byte followed by a big endian schema ID
+func confluentFrame(b []byte) (id uint32, payload []byte, ok bool) {
+	if len(b) <= confluentHeaderSize || b[0] != confluentMagic {
+		return
+	}
+	id, payload, ok = binary.BigEndian.Uint32(b[1:confluentHeaderSize]), b[confluentHeaderSize:], true
+	return
+}
+
+func checkSchemaOutput(format, prefix string) (string, error) {
+	switch format = strings.ToLower(strings.TrimSpace(format)); format {
+	case ``:
+		format = schemaOutputJSON
+	case schemaOutputJSON, schemaOutputEV:
+	default:
+		return ``, fmt.Errorf("%q: %w", format, ErrInvalidSchemaOutput)
+	}
+	if format == schemaOutputEV && len(prefix) > entry.MaxEvNameLength {
+		return ``, fmt.Errorf("%q: %w", prefix, ErrInvalidKeyname)
+	}
+	return format, nil
+}
+
+func checkSchemaDirectory(dir string) (err error) {
+	var fi os.FileInfo
+	if fi, err = os.Stat(dir); err == nil && !fi.IsDir() {
+		err = fmt.Errorf("%s: %w", dir, ErrNotSchemaDirectory)
+	}
+	return
+}
+
+// schemaDirectory lazily loads schemas named by their registry ID, such as 42.avsc, from
+// a local mirror of a schema registry.  IDs which fail to load are retried periodically
+// so a schema may be dropped into the directory while the ingester is running.
+type schemaDirectory[T any] struct {
+	dir    string
+	ext    string
+	load   func(string) (*T, error)
+	cache  map[uint32]*T
+	missed map[uint32]time.Time
+}
+
+func newSchemaDirectory[T any](dir, ext string, load func(string) (*T, error)) *schemaDirectory[T] {
+	return &schemaDirectory[T]{
+		dir:    dir,
+		ext:    ext,
+		load:   load,
+		cache:  map[uint32]*T{},
+		missed: map[uint32]time.Time{},
+	}
+}
+
+func (sd *schemaDirectory[T]) get(id uint32, now time.Time) (v *T, err error) {
+	var ok bool
+	if v, ok = sd.cache[id]; ok {
+		return
+	}
+	if last, ok := sd.missed[id]; ok && now.Sub(last) < schemaRetryInterval {
+		err = fmt.Errorf("%d: %w", id, ErrSchemaNotFound)
+		return
+	}
+	if v, err = sd.load(filepath.Join(sd.dir, strconv.FormatUint(uint64(id), 10)+sd.ext)); err != nil {
+		sd.missed[id] = now
+		return
+	}
+	delete(sd.missed, id)
+	sd.cache[id] = v
+	return
+}
+
+// schemaField is a decoded top level field
+type schemaField struct {
+	name string
+	val  interface{}
+}
+
+// attachSchemaEVs attaches top level fields as enumerated values, nested values are
+// attached as JSON
+func attachSchemaEVs(ent *entry.Entry, prefix string, flds []schemaField) {
+	for _, f := range flds {
+		if f.val == nil {
+			continue
+		}
+		name := prefix + f.name
+		if len(name) > entry.MaxEvNameLength {
+			continue
+		}
+		switch v := f.val.(type) {
+		case string, bool, int32, int64, uint32, uint64, float32, float64, []byte:
+			ent.AddEnumeratedValueEx(name, v)
+		default:
+			if b, err := json.Marshal(v); err == nil {
+				ent.AddEnumeratedValueEx(name, string(b))
+			}
+		}
+	}
+}
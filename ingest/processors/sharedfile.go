@@ -0,0 +1,130 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package processors
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultReloadInterval = 30 * time.Second
+)
+
+var (
+	ErrInvalidReloadPeriod = errors.New("Reload-Interval must be a positive duration")
+)
+
+func parseReloadInterval(v string) (d time.Duration, err error) {
+	d = defaultReloadInterval
+	if v != `` {
+		if d, err = time.ParseDuration(v); err == nil && d <= 0 {
+			err = ErrInvalidReloadPeriod
+		}
+	}
+	return
+}
+
+// sharedFileSet holds files loaded by preprocessors, such as lookup databases, so that
+// every preprocessor using the same file shares a single copy.  Ingesters often build
+// many ProcessorSets and the files can be large.
+type sharedFileSet[T any] struct {
+	mtx   sync.Mutex
+	files map[string]*sharedFile[T]
+	load  func(string) (*T, error)
+}
+
+func newSharedFileSet[T any](load func(string) (*T, error)) *sharedFileSet[T] {
+	return &sharedFileSet[T]{
+		files: map[string]*sharedFile[T]{},
+		load:  load,
+	}
+}
+
+// sharedFile is a loaded file which is reloaded when the file on disk changes
+type sharedFile[T any] struct {
+	path      string
+	refs      int
+	v         atomic.Pointer[T]
+	load      func(string) (*T, error)
+	mtx       sync.Mutex
+	mod       time.Time
+	size      int64
+	lastCheck time.Time
+}
+
+func (s *sharedFileSet[T]) acquire(pth string) (f *sharedFile[T], err error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if f = s.files[pth]; f != nil {
+		f.refs++
+		return
+	}
+	f = &sharedFile[T]{path: pth, refs: 1, load: s.load}
+	if err = f.reload(); err != nil {
+		f = nil
+		return
+	}
+	f.lastCheck = time.Now()
+	s.files[pth] = f
+	return
+}
+
+// release drops references to the files, a file is forgotten once nothing references it
+func (s *sharedFileSet[T]) release(fs []*sharedFile[T]) {
+	s.mtx.Lock()
+	for _, f := range fs {
+		if f.refs--; f.refs <= 0 {
+			delete(s.files, f.path)
+		}
+	}
+	s.mtx.Unlock()
+}
+
+func (f *sharedFile[T]) get() *T {
+	return f.v.Load()
+}
+
+func (f *sharedFile[T]) reload() (err error) {
+	var fi os.FileInfo
+	var v *T
+	if fi, err = os.Stat(f.path); err != nil {
+		return
+	} else if v, err = f.load(f.path); err != nil {
+		return fmt.Errorf("%s: %w", f.path, err)
+	}
+	f.v.Store(v)
+	f.mod, f.size = fi.ModTime(), fi.Size()
+	return
+}
+
+// checkReload reloads the file if it changed on disk, the existing copy stays
+// in use if the new file cannot be loaded
+func (f *sharedFile[T]) checkReload(now time.Time, interval time.Duration) (err error) {
+	if !f.mtx.TryLock() {
+		return //someone else is checking
+	}
+	defer f.mtx.Unlock()
+	if now.Sub(f.lastCheck) < interval {
+		return
+	}
+	f.lastCheck = now
+	var fi os.FileInfo
+	if fi, err = os.Stat(f.path); err != nil {
+		return
+	} else if fi.ModTime().Equal(f.mod) && fi.Size() == f.size {
+		return
+	}
+	err = f.reload()
+	return
+}
@@ -0,0 +1,86 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package processors
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// ProcessorStats holds cumulative counters for a preprocessor.  Ingesters often build
+// many ProcessorSets from the same configuration, so counters are shared by name and
+// cover every set that contains the preprocessor.
+type ProcessorStats struct {
+	Name   string
+	Type   string
+	In     uint64 // entries handed to the preprocessor
+	Out    uint64 // entries the preprocessor emitted
+	Errors uint64 // calls that returned an error
+}
+
+type procCounter struct {
+	name string
+	typ  string
+	in   atomic.Uint64
+	out  atomic.Uint64
+	errs atomic.Uint64
+}
+
+var (
+	procCountersMtx sync.Mutex
+	procCounters    = map[string]*procCounter{}
+)
+
+func getProcCounter(name, typ string) (pc *procCounter) {
+	procCountersMtx.Lock()
+	if pc = procCounters[name]; pc == nil {
+		pc = &procCounter{name: name, typ: typ}
+		procCounters[name] = pc
+	}
+	procCountersMtx.Unlock()
+	return
+}
+
+// processorTypeName is used to name counters for preprocessors added without a configuration name
+func processorTypeName(p Processor) string {
+	return strings.TrimPrefix(fmt.Sprintf("%T", p), "*processors.")
+}
+
+func (pc *procCounter) update(in, out int, err error) {
+	if pc == nil {
+		return
+	}
+	pc.in.Add(uint64(in))
+	pc.out.Add(uint64(out))
+	if err != nil {
+		pc.errs.Add(1)
+	}
+}
+
+// GetProcessorStats returns the counters for every preprocessor that has been
+// added to a ProcessorSet, sorted by name.
+func GetProcessorStats() (r []ProcessorStats) {
+	procCountersMtx.Lock()
+	r = make([]ProcessorStats, 0, len(procCounters))
+	for _, pc := range procCounters {
+		r = append(r, ProcessorStats{
+			Name:   pc.name,
+			Type:   pc.typ,
+			In:     pc.in.Load(),
+			Out:    pc.out.Load(),
+			Errors: pc.errs.Load(),
+		})
+	}
+	procCountersMtx.Unlock()
+	sort.Slice(r, func(i, j int) bool { return r[i].Name < r[j].Name })
+	return
+}
@@ -0,0 +1,439 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package processors
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gravwell/gravwell/v4/ingest"
+	"github.com/gravwell/gravwell/v4/ingest/config"
+	"github.com/gravwell/gravwell/v4/ingest/entry"
+	"github.com/gravwell/gravwell/v4/ingest/processors/plugin"
+)
+
+const (
+	SwitchProcessor string = `switch`
+
+	switchCondTag   = `tag`
+	switchCondSrc   = `src`
+	switchCondRegex = `regex`
+	switchCondEV    = `ev`
+)
+
+var (
+	ErrSwitchNoCases      = errors.New("At least one Case is required")
+	ErrInvalidSwitchCase  = errors.New("Case must be condition:preprocessor[,preprocessor...]")
+	ErrInvalidSwitchCond  = errors.New("Case condition must be tag=, src=, regex=, or ev=")
+	ErrSwitchCycle        = errors.New("Switch sub-chains refer back to themselves")
+	ErrSwitchNeedsConfigs = errors.New("Switch preprocessors must be built from a preprocessor configuration")
+)
+
+// SwitchConfig sends each entry through the sub-chain of the first Case whose condition
+// matches, entries matching no case go through the Default chain.  Sub-chains are comma
+// separated lists of preprocessor names from the same configuration, an empty chain passes
+// entries through untouched.  Output of every sub-chain continues down the parent chain.
+// A switch with a single case acts as an if.  Conditions are:
+//
+//	tag=<tag name>
+//	src=<CIDR or IP>
+//	regex=<pattern matched against the entry data>
+//	ev=<name> or ev=<name>=<value>, matching on presence or on the string form of the value
+//
+// For example:
+//
+//	Case=tag=syslog:syslog-parse,syslog-route
+//	Case=src=10.0.0.0/8:internal
+//	Default=generic
+type SwitchConfig struct {
+	Case    []string
+	Default string
+}
+
+type switchCond struct {
+	kind   string
+	tag    string
+	tg     entry.EntryTag
+	cidr   *net.IPNet
+	re     *regexp.Regexp
+	ev     string
+	val    string
+	hasVal bool
+}
+
+type switchCase struct {
+	cond  switchCond
+	chain []string
+}
+
+func SwitchLoadConfig(vc *config.VariableConfig) (c SwitchConfig, err error) {
+	if err = vc.MapTo(&c); err == nil {
+		_, _, err = c.validate()
+	}
+	return
+}
+
+func (c *SwitchConfig) validate() (cases []switchCase, def []string, err error) {
+	if len(c.Case) == 0 {
+		err = ErrSwitchNoCases
+		return
+	}
+	for _, v := range c.Case {
+		var sc switchCase
+		if sc, err = parseSwitchCase(v); err != nil {
+			return
+		}
+		cases = append(cases, sc)
+	}
+	def = splitSwitchChain(c.Default)
+	return
+}
+
+// chains returns every preprocessor name referenced by the switch
+func (c *SwitchConfig) chains() (names []string, err error) {
+	var cases []switchCase
+	var def []string
+	if cases, def, err = c.validate(); err != nil {
+		return
+	}
+	for _, sc := range cases {
+		names = append(names, sc.chain...)
+	}
+	names = append(names, def...)
+	return
+}
+
+// parseSwitchCase splits a case on the last colon, chain names cannot contain colons
+// but conditions such as IPv6 networks and regular expressions may
+func parseSwitchCase(v string) (sc switchCase, err error) {
+	idx := strings.LastIndex(v, `:`)
+	if idx == -1 {
+		err = fmt.Errorf("%q: %w", v, ErrInvalidSwitchCase)
+		return
+	}
+	sc.chain = splitSwitchChain(v[idx+1:])
+	kind, arg, ok := strings.Cut(strings.TrimSpace(v[:idx]), `=`)
+	if !ok || arg == `` {
+		err = fmt.Errorf("%q: %w", v, ErrInvalidSwitchCond)
+		return
+	}
+	sc.cond.kind = strings.ToLower(strings.TrimSpace(kind))
+	switch sc.cond.kind {
+	case switchCondTag:
+		if sc.cond.tag = strings.TrimSpace(arg); ingest.CheckTag(sc.cond.tag) != nil {
+			err = fmt.Errorf("%q: %w", v, ErrInvalidSwitchCond)
+		}
+	case switchCondSrc:
+		if sc.cond.cidr, err = parseSwitchCIDR(strings.TrimSpace(arg)); err != nil {
+			err = fmt.Errorf("%q: %w", v, err)
+		}
+	case switchCondRegex:
+		if sc.cond.re, err = regexp.Compile(arg); err != nil {
+			err = fmt.Errorf("%q: %w", v, err)
+		}
+	case switchCondEV:
+		sc.cond.ev, sc.cond.val, sc.cond.hasVal = strings.Cut(arg, `=`)
+		if sc.cond.ev = strings.TrimSpace(sc.cond.ev); sc.cond.ev == `` || len(sc.cond.ev) > entry.MaxEvNameLength {
+			err = fmt.Errorf("%q: %w", v, ErrInvalidKeyname)
+		}
+	default:
+		err = fmt.Errorf("%q: %w", v, ErrInvalidSwitchCond)
+	}
+	return
+}
+
+func parseSwitchCIDR(v string) (n *net.IPNet, err error) {
+	if _, n, err = net.ParseCIDR(v); err == nil {
+		return
+	}
+	ip := net.ParseIP(v)
+	if ip == nil {
+		return
+	}
+	err = nil
+	if ip4 := ip.To4(); ip4 != nil {
+		n = &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}
+	} else {
+		n = &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}
+	}
+	return
+}
+
+func splitSwitchChain(v string) (r []string) {
+	for _, n := range strings.Split(v, `,`) {
+		if n = strings.TrimSpace(n); n != `` {
+			r = append(r, n)
+		}
+	}
+	return
+}
+
+func (sc *switchCond) match(ent *entry.Entry) bool {
+	switch sc.kind {
+	case switchCondTag:
+		return ent.Tag == sc.tg
+	case switchCondSrc:
+		return ent.SRC != nil && sc.cidr.Contains(ent.SRC)
+	case switchCondRegex:
+		return sc.re.Match(ent.Data)
+	case switchCondEV:
+		ev, ok := ent.EVB.Get(sc.ev)
+		return ok && (!sc.hasVal || ev.Value.String() == sc.val)
+	}
+	return false
+}
+
+// switchChain is a linear run of preprocessors inside a switch, it mirrors the way a
+// ProcessorSet drives its own preprocessors
+type switchChain struct {
+	procs    []Processor
+	counters []*procCounter
+}
+
+func (sc *switchChain) process(start int, ents []*entry.Entry) (set []*entry.Entry, err error) {
+	set = ents
+	for i := start; i < len(sc.procs) && len(set) > 0; i++ {
+		orig := set
+		set, err = sc.procs[i].Process(orig)
+		sc.counters[i].update(len(orig), len(set), err)
+		if err != nil {
+			if _, ok := err.(*plugin.FaultError); ok {
+				set, err = orig, nil
+				continue
+			}
+			break
+		}
+	}
+	return
+}
+
+func (sc *switchChain) flush() (set []*entry.Entry, err error) {
+	for i, p := range sc.procs {
+		if ents := p.Flush(); len(ents) > 0 {
+			sc.counters[i].update(0, len(ents), nil)
+			if ents, lerr := sc.process(i+1, ents); lerr != nil {
+				err = addError(lerr, err)
+			} else {
+				set = append(set, ents...)
+			}
+		}
+	}
+	return
+}
+
+func (sc *switchChain) expire(now time.Time) (set []*entry.Entry) {
+	for i, p := range sc.procs {
+		e, ok := p.(Expirer)
+		if !ok {
+			continue
+		}
+		if ents := e.Expire(now); len(ents) > 0 {
+			sc.counters[i].update(0, len(ents), nil)
+			if ents, err := sc.process(i+1, ents); err == nil {
+				set = append(set, ents...)
+			}
+		}
+	}
+	return
+}
+
+func (sc *switchChain) expires() bool {
+	for _, p := range sc.procs {
+		if _, ok := p.(Expirer); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (sc *switchChain) close() (err error) {
+	for _, p := range sc.procs {
+		err = addError(p.Close(), err)
+	}
+	return
+}
+
+// Switch routes entries through sub-chains, the last chain is the default
+type Switch struct {
+	SwitchConfig
+	cases    []switchCase
+	chains   []*switchChain
+	flushErr error
+}
+
+// expiringSwitch is handed to a ProcessorSet when a sub-chain holds an Expirer so that
+// switches without one do not cost the set an expiry routine
+type expiringSwitch struct {
+	*Switch
+}
+
+func (es expiringSwitch) Expire(now time.Time) (set []*entry.Entry) {
+	for _, c := range es.chains {
+		set = append(set, c.expire(now)...)
+	}
+	return
+}
+
+// newSwitch builds a switch and its sub-chains, parents holds the switches already
+// being built above this one so that a switch cannot contain itself
+func (pc ProcessorConfig) newSwitch(name string, vc *config.VariableConfig, tgr Tagger, parents []string) (p Processor, err error) {
+	if inStringSet(parents, name) {
+		err = fmt.Errorf("%s: %w", name, ErrSwitchCycle)
+		return
+	}
+	var cfg SwitchConfig
+	if err = vc.MapTo(&cfg); err != nil {
+		return
+	}
+	cases, def, err := cfg.validate()
+	if err != nil {
+		return
+	}
+	s := &Switch{
+		SwitchConfig: cfg,
+		cases:        cases,
+	}
+	defer func() {
+		if err != nil {
+			s.Close()
+		}
+	}()
+	parents = append(parents, name)
+	for i := range s.cases {
+		if s.cases[i].cond.kind == switchCondTag {
+			if s.cases[i].cond.tg, err = tgr.NegotiateTag(s.cases[i].cond.tag); err != nil {
+				return
+			}
+		}
+		var c *switchChain
+		if c, err = pc.newSwitchChain(s.cases[i].chain, tgr, parents); err != nil {
+			return
+		}
+		s.chains = append(s.chains, c)
+	}
+	var c *switchChain
+	if c, err = pc.newSwitchChain(def, tgr, parents); err != nil {
+		return
+	}
+	s.chains = append(s.chains, c)
+	p = s
+	for _, c := range s.chains {
+		if c.expires() {
+			p = expiringSwitch{s}
+			break
+		}
+	}
+	return
+}
+
+func (pc ProcessorConfig) newSwitchChain(names []string, tgr Tagger, parents []string) (c *switchChain, err error) {
+	c = &switchChain{}
+	for _, n := range names {
+		var p Processor
+		if p, err = pc.getNestedProcessor(n, tgr, parents); err != nil {
+			c.close()
+			c, err = nil, fmt.Errorf("%s %w", n, err)
+			return
+		}
+		c.procs = append(c.procs, p)
+		c.counters = append(c.counters, getProcCounter(n, pc.processorType(n)))
+	}
+	return
+}
+
+// checkSwitch ensures every preprocessor a switch refers to exists and that no switch
+// contains itself
+func (pc ProcessorConfig) checkSwitch(name string, parents []string) (err error) {
+	if inStringSet(parents, name) {
+		return fmt.Errorf("%s: %w", name, ErrSwitchCycle)
+	}
+	vc, ok := pc[name]
+	if !ok || vc == nil {
+		return fmt.Errorf("%s %w", name, ErrNotFound)
+	}
+	var cfg SwitchConfig
+	if err = vc.MapTo(&cfg); err != nil {
+		return
+	}
+	var names []string
+	if names, err = cfg.chains(); err != nil {
+		return
+	}
+	parents = append(parents, name)
+	for _, n := range names {
+		if vc, ok := pc[n]; !ok || vc == nil {
+			return fmt.Errorf("%s %w", n, ErrNotFound)
+		} else if pc.processorType(n) == SwitchProcessor {
+			if err = pc.checkSwitch(n, parents); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+func (s *Switch) Process(ents []*entry.Entry) (rset []*entry.Entry, err error) {
+	if len(ents) == 0 {
+		return
+	}
+	groups := make([][]*entry.Entry, len(s.chains))
+	for _, ent := range ents {
+		if ent != nil {
+			idx := s.route(ent)
+			groups[idx] = append(groups[idx], ent)
+		}
+	}
+	//every entry has been copied into a group, so the input slice can be reused
+	rset = ents[:0]
+	for i, g := range groups {
+		if len(g) == 0 {
+			continue
+		}
+		var set []*entry.Entry
+		if set, err = s.chains[i].process(0, g); err != nil {
+			rset = nil
+			return
+		}
+		rset = append(rset, set...)
+	}
+	return
+}
+
+// route returns the index of the chain for an entry
+func (s *Switch) route(ent *entry.Entry) int {
+	for i := range s.cases {
+		if s.cases[i].cond.match(ent) {
+			return i
+		}
+	}
+	return len(s.cases)
+}
+
+func (s *Switch) Flush() (set []*entry.Entry) {
+	for _, c := range s.chains {
+		ents, err := c.flush()
+		s.flushErr = addError(err, s.flushErr)
+		set = append(set, ents...)
+	}
+	return
+}
+
+// Close closes every sub-chain, errors hit while flushing sub-chains are reported here
+// as Flush has no way to return them
+func (s *Switch) Close() (err error) {
+	err, s.flushErr = s.flushErr, nil
+	for _, c := range s.chains {
+		err = addError(c.close(), err)
+	}
+	return
+}
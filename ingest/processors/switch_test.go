@@ -0,0 +1,235 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package processors
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/gravwell/gravwell/v4/ingest/config"
+	"github.com/gravwell/gravwell/v4/ingest/entry"
+)
+
+const testSwitchConfig = `
+[preprocessor "sw"]
+	type = switch
+	Case=tag=syslog:pa
+	Case=src=10.0.0.0/8:pb
+	Case="regex=^\\{:"
+	Case=ev=app=nginx:pc, pa
+	Default=pd
+
+[preprocessor "pa"]
+	type = regexreplace
+	Regex="^"
+	Replacement="a:"
+
+[preprocessor "pb"]
+	type = regexreplace
+	Regex="^"
+	Replacement="b:"
+
+[preprocessor "pc"]
+	type = regexreplace
+	Regex="^"
+	Replacement="c:"
+
+[preprocessor "pd"]
+	type = regexreplace
+	Regex="^"
+	Replacement="d:"
+`
+
+type testTagWriter struct {
+	testTagger
+	testWriter
+}
+
+func loadTestProcessorConfig(t *testing.T, b string) ProcessorConfig {
+	t.Helper()
+	var tc testConfigStruct
+	if err := config.LoadConfigBytes(&tc, []byte(b)); err != nil {
+		t.Fatal(err)
+	}
+	return tc.Preprocessor
+}
+
+func TestSwitchConfig(t *testing.T) {
+	pc := loadTestProcessorConfig(t, testSwitchConfig)
+	if err := pc.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckProcessor(SwitchProcessor); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newProcessor(pc[`sw`], &testTagger{}); err != ErrSwitchNeedsConfigs {
+		t.Fatalf("switch built without its configuration: %v", err)
+	}
+
+	bad := []string{
+		``,
+		`tag=syslog`,
+		`tag:pa`,
+		`foo=bar:pa`,
+		`src=10.0.0.0/33:pa`,
+		`regex=[:pa`,
+		`ev=:pa`,
+	}
+	for _, v := range bad {
+		c := SwitchConfig{Case: []string{v}}
+		if v == `` {
+			c.Case = nil
+		}
+		if _, _, err := c.validate(); err == nil {
+			t.Fatalf("bad case %q did not fail", v)
+		}
+	}
+	c := SwitchConfig{Case: []string{`src=fe80::1:pa`, `src=fe80::/10:`}}
+	if cases, _, err := c.validate(); err != nil {
+		t.Fatal(err)
+	} else if !cases[0].cond.cidr.Contains(net.ParseIP(`fe80::1`)) || len(cases[1].chain) != 0 {
+		t.Fatalf("bad IPv6 cases: %+v", cases)
+	}
+}
+
+func TestSwitchReferences(t *testing.T) {
+	pc := loadTestProcessorConfig(t, `
+	[preprocessor "sw1"]
+		type = switch
+		Case=tag=foo:sw2
+	[preprocessor "sw2"]
+		type = switch
+		Case=tag=bar:sw1
+	[preprocessor "sw3"]
+		type = switch
+		Case=tag=bar:missing
+	`)
+	if err := pc.checkSwitch(`sw1`, nil); !errors.Is(err, ErrSwitchCycle) {
+		t.Fatalf("cycle not detected: %v", err)
+	} else if _, err = pc.getProcessor(`sw1`, &testTagger{}); !errors.Is(err, ErrSwitchCycle) {
+		t.Fatalf("cycle not detected on build: %v", err)
+	} else if err = pc.checkSwitch(`sw3`, nil); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("missing preprocessor not detected: %v", err)
+	} else if err = pc.Validate(); err == nil {
+		t.Fatal("invalid switches passed validation")
+	}
+}
+
+func TestSwitchProcess(t *testing.T) {
+	pc := loadTestProcessorConfig(t, testSwitchConfig)
+	var tw testTagWriter
+	ps, err := pc.ProcessorSet(&tw, []string{`sw`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	syslog, err := tw.NegotiateTag(`syslog`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := tw.NegotiateTag(`other`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outside, inside := net.ParseIP(`192.168.1.1`), net.ParseIP(`10.1.2.3`)
+	nginx := &entry.Entry{Tag: other, SRC: outside, Data: []byte(`4`)}
+	nginx.AddEnumeratedValueEx(`app`, `nginx`)
+	apache := &entry.Entry{Tag: other, SRC: outside, Data: []byte(`5`)}
+	apache.AddEnumeratedValueEx(`app`, `apache`)
+	ents := []*entry.Entry{
+		apache,
+		{Tag: syslog, SRC: inside, Data: []byte(`1`)},
+		{Tag: other, SRC: inside, Data: []byte(`2`)},
+		{Tag: other, SRC: outside, Data: []byte(`{3`)},
+		nginx,
+	}
+	if err = ps.ProcessBatch(ents); err != nil {
+		t.Fatal(err)
+	}
+	//entries come out grouped by case, default last
+	exp := []string{`a:1`, `b:2`, `{3`, `a:c:4`, `d:5`}
+	if len(tw.ents) != len(exp) {
+		t.Fatalf("invalid output count: %d != %d", len(tw.ents), len(exp))
+	}
+	for i, v := range exp {
+		if s := string(tw.ents[i].Data); s != v {
+			t.Fatalf("bad entry %d: %q != %q", i, s, v)
+		}
+	}
+	if err = ps.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSwitchFlushExpire(t *testing.T) {
+	pc := loadTestProcessorConfig(t, `
+	[preprocessor "sw"]
+		type = switch
+		Case=tag=ml:ml, pa
+		Default=pa
+	[preprocessor "ml"]
+		type = multiline
+		Start-Regex="^start"
+		Timeout=1s
+	[preprocessor "pa"]
+		type = regexreplace
+		Regex="^"
+		Replacement="a:"
+	`)
+	tt := &testTagger{}
+	ml, err := tt.NegotiateTag(`ml`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := pc.getProcessor(`sw`, tt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	es, ok := p.(expiringSwitch)
+	if !ok {
+		t.Fatalf("switch with a multiline sub-chain is the wrong type: %T", p)
+	}
+
+	ents := []*entry.Entry{
+		{Tag: ml, Data: []byte(`start a`)},
+		{Tag: ml, Data: []byte(`more`)},
+		{Tag: ml + 1, Data: []byte(`other`)},
+	}
+	out, err := es.Process(ents)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(out) != 1 || string(out[0].Data) != `a:other` {
+		t.Fatalf("bad output: %v", multilineData(out))
+	}
+
+	//the held entry is released on expiry and finishes its sub-chain
+	if out = es.Expire(time.Now().Add(time.Hour)); len(out) != 1 || string(out[0].Data) != "a:start a\nmore" {
+		t.Fatalf("bad expired entries: %v", multilineData(out))
+	}
+
+	if out, err = es.Process([]*entry.Entry{{Tag: ml, Data: []byte(`start b`)}}); err != nil {
+		t.Fatal(err)
+	} else if len(out) != 0 {
+		t.Fatalf("entry was not held: %v", multilineData(out))
+	}
+	if out = es.Flush(); len(out) != 1 || string(out[0].Data) != `a:start b` {
+		t.Fatalf("bad flushed entries: %v", multilineData(out))
+	}
+	if err = es.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	//no expiring sub-chain, no expiry routine
+	if p, err = loadTestProcessorConfig(t, testSwitchConfig).getProcessor(`sw`, tt); err != nil {
+		t.Fatal(err)
+	} else if _, ok = p.(*Switch); !ok {
+		t.Fatalf("switch is the wrong type: %T", p)
+	}
+}
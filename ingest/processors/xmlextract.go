@@ -0,0 +1,702 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package processors
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/gravwell/gravwell/v4/ingest/config"
+	"github.com/gravwell/gravwell/v4/ingest/entry"
+)
+
+const (
+	XMLExtractProcessor string = `xmlextract`
+
+	xmlOutputJSON = `json`
+	xmlOutputXML  = `xml`
+
+	defaultXMLRootElement   = `extract`
+	defaultXMLJoinDelimiter = `,`
+)
+
+var (
+	ErrInvalidSelector     = errors.New("Invalid XML selector")
+	ErrUnknownNamespace    = errors.New("Unknown namespace prefix")
+	ErrInvalidNamespace    = errors.New("Invalid namespace, must be prefix=URI")
+	ErrInvalidOutputFormat = errors.New("Output-Format must be json or xml")
+	ErrInvalidRootElement  = errors.New("Invalid Root-Element")
+)
+
+// XMLExtractConfig selects values out of XML payloads using XPath style selectors such as
+// /Event/System/EventID, //Data[@Name='TargetUserName'], or /event/@time.  Selectors may be
+// named by specifying them as name=selector.  Unprefixed element names match any namespace,
+// prefixed names must have their prefix declared in Namespaces as prefix=URI.
+type XMLExtractConfig struct {
+	Drop_Misses       bool
+	Strict_Extraction bool
+	Extractions       []string
+	Namespaces        []string
+	Output_Format     string // json (default) or xml
+	Root_Element      string // root element used when Output-Format is xml
+	Enumerated_Values bool   // attach selected values as enumerated values instead of rewriting the entry
+	Join_Delimiter    string // joins repeated values attached as enumerated values, default is a comma
+}
+
+func XMLExtractLoadConfig(vc *config.VariableConfig) (c XMLExtractConfig, err error) {
+	if err = vc.MapTo(&c); err == nil {
+		_, err = c.validate()
+	}
+	return
+}
+
+func (c *XMLExtractConfig) validate() (sels []xmlSelector, err error) {
+	if !c.Drop_Misses && c.Strict_Extraction {
+		err = ErrMissStrictConflict
+		return
+	}
+	if c.Output_Format = strings.ToLower(strings.TrimSpace(c.Output_Format)); c.Output_Format == `` {
+		c.Output_Format = xmlOutputJSON
+	} else if c.Output_Format != xmlOutputJSON && c.Output_Format != xmlOutputXML {
+		err = ErrInvalidOutputFormat
+		return
+	}
+	if c.Root_Element = strings.TrimSpace(c.Root_Element); c.Root_Element == `` {
+		c.Root_Element = defaultXMLRootElement
+	} else if !validXMLName(c.Root_Element) {
+		err = fmt.Errorf("%q: %w", c.Root_Element, ErrInvalidRootElement)
+		return
+	}
+	if c.Join_Delimiter == `` {
+		c.Join_Delimiter = defaultXMLJoinDelimiter
+	}
+	ns := map[string]string{}
+	for _, v := range c.Namespaces {
+		prefix, uri, ok := strings.Cut(v, `=`)
+		if prefix, uri = strings.TrimSpace(prefix), strings.TrimSpace(uri); !ok || prefix == `` || uri == `` {
+			err = fmt.Errorf("%q: %w", v, ErrInvalidNamespace)
+			return
+		}
+		ns[prefix] = uri
+	}
+	var names, paths []string
+	for _, v := range c.Extractions {
+		if v = strings.TrimSpace(v); v == `` {
+			continue
+		}
+		var sel xmlSelector
+		if sel, err = parseXMLSelector(v, ns); err != nil {
+			return
+		} else if inStringSet(paths, sel.path) {
+			err = fmt.Errorf("%s: %w", sel.path, ErrDuplicateKey)
+			return
+		} else if inStringSet(names, sel.name) {
+			err = fmt.Errorf("%s: %w", sel.name, ErrDuplicateKeyname)
+			return
+		}
+		paths = append(paths, sel.path)
+		names = append(names, sel.name)
+		sels = append(sels, sel)
+	}
+	if len(sels) == 0 {
+		err = ErrMissingExtractions
+	}
+	return
+}
+
+type XMLExtractor struct {
+	nocloser
+	XMLExtractConfig
+	sels []xmlSelector
+	bb   bytes.Buffer
+}
+
+func NewXMLExtractor(cfg XMLExtractConfig) (*XMLExtractor, error) {
+	sels, err := cfg.validate()
+	if err != nil {
+		return nil, err
+	}
+	return &XMLExtractor{
+		XMLExtractConfig: cfg,
+		sels:             sels,
+	}, nil
+}
+
+func (xe *XMLExtractor) Config(v interface{}) (err error) {
+	if v == nil {
+		err = ErrNilConfig
+	} else if cfg, ok := v.(XMLExtractConfig); ok {
+		var sels []xmlSelector
+		if sels, err = cfg.validate(); err == nil {
+			xe.XMLExtractConfig = cfg
+			xe.sels = sels
+		}
+	} else {
+		err = fmt.Errorf("Invalid configuration, unknown type type %T", v)
+	}
+	return
+}
+
+func (xe *XMLExtractor) Process(ents []*entry.Entry) (rset []*entry.Entry, err error) {
+	if len(ents) == 0 {
+		return
+	}
+	rset = ents[:0]
+	for _, ent := range ents {
+		if ent == nil {
+			continue
+		} else if ent = xe.processItem(ent); ent != nil {
+			rset = append(rset, ent)
+		}
+	}
+	return
+}
+
+func (xe *XMLExtractor) processItem(ent *entry.Entry) *entry.Entry {
+	doc, err := parseXMLDocument(ent.Data)
+	if err != nil {
+		if xe.Drop_Misses {
+			return nil
+		}
+		return ent
+	}
+	var cnt int
+	xe.bb.Reset()
+	if !xe.Enumerated_Values {
+		xe.openOutput()
+	}
+	for i := range xe.sels {
+		sel := &xe.sels[i]
+		nodes, vals := sel.eval(doc)
+		if len(vals) == 0 {
+			continue
+		}
+		cnt++
+		if xe.Enumerated_Values {
+			ent.AddEnumeratedValueEx(sel.name, strings.Join(vals, xe.Join_Delimiter))
+		} else if xe.Output_Format == xmlOutputXML {
+			xe.writeXML(sel, nodes, vals)
+		} else {
+			xe.writeJSON(sel, vals, cnt == 1)
+		}
+	}
+	if xe.Strict_Extraction && cnt != len(xe.sels) {
+		return nil
+	} else if cnt == 0 {
+		if xe.Drop_Misses {
+			return nil
+		}
+		return ent
+	}
+	if !xe.Enumerated_Values {
+		xe.closeOutput()
+		ent.Data = append([]byte{}, xe.bb.Bytes()...) //force allocation
+	}
+	return ent
+}
+
+func (xe *XMLExtractor) openOutput() {
+	if xe.Output_Format == xmlOutputXML {
+		xe.bb.WriteString(`<` + xe.Root_Element + `>`)
+	} else {
+		xe.bb.WriteByte('{')
+	}
+}
+
+func (xe *XMLExtractor) closeOutput() {
+	if xe.Output_Format == xmlOutputXML {
+		xe.bb.WriteString(`</` + xe.Root_Element + `>`)
+	} else {
+		xe.bb.WriteByte('}')
+	}
+}
+
+// writeJSON adds a key to the output object, repeated values become an array
+func (xe *XMLExtractor) writeJSON(sel *xmlSelector, vals []string, first bool) {
+	if !first {
+		xe.bb.WriteByte(',')
+	}
+	writeJSONString(&xe.bb, sel.name)
+	xe.bb.WriteByte(':')
+	if len(vals) == 1 {
+		writeJSONString(&xe.bb, vals[0])
+		return
+	}
+	xe.bb.WriteByte('[')
+	for i, v := range vals {
+		if i > 0 {
+			xe.bb.WriteByte(',')
+		}
+		writeJSONString(&xe.bb, v)
+	}
+	xe.bb.WriteByte(']')
+}
+
+// writeXML adds each match to the output, matched elements are written as compact
+// subtrees renamed to the selector name and matched attributes as simple elements
+func (xe *XMLExtractor) writeXML(sel *xmlSelector, nodes []*xmlNode, vals []string) {
+	if sel.attr != nil {
+		for _, v := range vals {
+			xe.bb.WriteString(`<` + sel.name + `>`)
+			xml.EscapeText(&xe.bb, []byte(v))
+			xe.bb.WriteString(`</` + sel.name + `>`)
+		}
+		return
+	}
+	for _, n := range nodes {
+		n.writeCompact(&xe.bb, sel.name)
+	}
+}
+
+// xmlNode is a minimal DOM node, text nodes have an empty name and only carry text
+type xmlNode struct {
+	name  xml.Name
+	attrs []xml.Attr
+	kids  []*xmlNode
+	text  string
+}
+
+func (n *xmlNode) isText() bool {
+	return n.name.Local == ``
+}
+
+// parseXMLDocument builds a document node holding the first root element found in data.
+// Leading garbage such as a syslog header is skipped and trailing data is ignored.
+func parseXMLDocument(data []byte) (doc *xmlNode, err error) {
+	idx := xmlStart(data)
+	if idx == -1 {
+		err = io.ErrUnexpectedEOF
+		return
+	}
+	dec := xml.NewDecoder(bytes.NewReader(data[idx:]))
+	dec.CharsetReader = func(_ string, r io.Reader) (io.Reader, error) {
+		return r, nil //entries are already decoded, ignore the declared encoding
+	}
+	doc = &xmlNode{}
+	stack := []*xmlNode{doc}
+	for {
+		var tok xml.Token
+		if tok, err = dec.Token(); err != nil {
+			doc = nil
+			return
+		}
+		top := stack[len(stack)-1]
+		switch t := tok.(type) {
+		case xml.StartElement:
+			n := &xmlNode{name: t.Name, attrs: t.Attr}
+			top.kids = append(top.kids, n)
+			stack = append(stack, n)
+		case xml.EndElement:
+			if stack = stack[:len(stack)-1]; len(stack) == 1 {
+				return //root element is complete
+			}
+		case xml.CharData:
+			if len(stack) > 1 {
+				top.kids = append(top.kids, &xmlNode{text: string(t)})
+			}
+		}
+	}
+}
+
+// xmlStart finds the first < that can open a declaration or element, a syslog
+// priority such as <14> cannot
+func xmlStart(data []byte) int {
+	for off := 0; off < len(data); {
+		idx := bytes.IndexByte(data[off:], '<')
+		if idx == -1 {
+			break
+		}
+		off += idx + 1
+		if off < len(data) {
+			if c := data[off]; c == '?' || c == '!' || c == '_' || c == ':' || (c|0x20) >= 'a' && (c|0x20) <= 'z' || c > 0x7f {
+				return off - 1
+			}
+		}
+	}
+	return -1
+}
+
+// textContent returns the concatenated text of the node and its descendants
+func (n *xmlNode) textContent() string {
+	if n.isText() {
+		return strings.TrimSpace(n.text)
+	}
+	var sb strings.Builder
+	n.appendText(&sb)
+	return strings.TrimSpace(sb.String())
+}
+
+func (n *xmlNode) appendText(sb *strings.Builder) {
+	for _, k := range n.kids {
+		if k.isText() {
+			sb.WriteString(k.text)
+		} else {
+			k.appendText(sb)
+		}
+	}
+}
+
+// writeCompact writes the node without insignificant whitespace or namespace declarations
+func (n *xmlNode) writeCompact(bb *bytes.Buffer, name string) {
+	bb.WriteString(`<` + name)
+	for _, a := range n.attrs {
+		if a.Name.Space == `xmlns` || (a.Name.Space == `` && a.Name.Local == `xmlns`) {
+			continue
+		}
+		bb.WriteString(` ` + a.Name.Local + `="`)
+		xml.EscapeText(bb, []byte(a.Value))
+		bb.WriteByte('"')
+	}
+	if len(n.kids) == 0 {
+		bb.WriteString(`/>`)
+		return
+	}
+	bb.WriteByte('>')
+	for _, k := range n.kids {
+		if !k.isText() {
+			k.writeCompact(bb, k.name.Local)
+		} else if t := strings.TrimSpace(k.text); t != `` {
+			xml.EscapeText(bb, []byte(t))
+		}
+	}
+	bb.WriteString(`</` + name + `>`)
+}
+
+// xmlName matches element and attribute names, an empty space matches any namespace
+// and a local name of * matches any name
+type xmlName struct {
+	space string
+	local string
+}
+
+func (xn xmlName) match(n xml.Name) bool {
+	return (xn.local == `*` || xn.local == n.Local) && (xn.space == `` || xn.space == n.Space)
+}
+
+type xmlPred struct {
+	pos    int // 1 based position, 0 if the predicate is a comparison
+	attr   bool
+	name   xmlName
+	val    string
+	hasVal bool
+}
+
+type xmlStep struct {
+	desc  bool // step follows a //
+	name  xmlName
+	preds []xmlPred
+}
+
+type xmlSelector struct {
+	name  string
+	path  string
+	steps []xmlStep
+	attr  *xmlName // set when the selector ends in an attribute
+}
+
+// parseXMLSelector parses a selector of the form [name=]/step/step, where a step is an
+// element name optionally followed by predicates such as [2], [@attr], [@attr='v'], or
+// [child='v'].  A step preceded by // matches at any depth and the final step may be @attr.
+func parseXMLSelector(v string, ns map[string]string) (sel xmlSelector, err error) {
+	if !strings.HasPrefix(v, `/`) {
+		name, path, ok := strings.Cut(v, `=`)
+		if sel.name, sel.path = strings.TrimSpace(name), strings.TrimSpace(path); !ok || !validXMLName(sel.name) {
+			err = fmt.Errorf("%q: %w", v, ErrInvalidKeyname)
+			return
+		}
+	} else {
+		sel.path = v
+	}
+	p := sel.path
+	if !strings.HasPrefix(p, `/`) {
+		err = fmt.Errorf("%q must start with /: %w", p, ErrInvalidSelector)
+		return
+	}
+	for len(p) > 0 {
+		var st xmlStep
+		if strings.HasPrefix(p, `//`) {
+			st.desc, p = true, p[2:]
+		} else if strings.HasPrefix(p, `/`) {
+			p = p[1:]
+		} else {
+			err = fmt.Errorf("%q: %w", sel.path, ErrInvalidSelector)
+			return
+		}
+		var tok string
+		if tok, p, err = nextXMLStep(p); err != nil {
+			err = fmt.Errorf("%q: %w", sel.path, err)
+			return
+		} else if tok == `` || sel.attr != nil {
+			err = fmt.Errorf("%q: %w", sel.path, ErrInvalidSelector)
+			return
+		}
+		if strings.HasPrefix(tok, `@`) {
+			if st.desc || len(sel.steps) == 0 {
+				err = fmt.Errorf("%q: attributes must follow an element: %w", sel.path, ErrInvalidSelector)
+				return
+			}
+			var an xmlName
+			if an, err = parseXMLName(tok[1:], ns); err != nil {
+				return
+			}
+			sel.attr = &an
+			continue
+		}
+		if st, err = parseXMLStep(tok, st.desc, ns); err != nil {
+			err = fmt.Errorf("%q: %w", sel.path, err)
+			return
+		}
+		sel.steps = append(sel.steps, st)
+	}
+	if sel.name == `` {
+		if sel.attr != nil {
+			sel.name = sel.attr.local
+		} else {
+			sel.name = sel.steps[len(sel.steps)-1].name.local
+		}
+		if sel.name == `*` {
+			err = fmt.Errorf("%q: wildcard selectors must be named: %w", sel.path, ErrInvalidKeyname)
+		}
+	}
+	return
+}
+
+// nextXMLStep splits off the next step, respecting brackets and quotes
+func nextXMLStep(p string) (tok, rest string, err error) {
+	var depth int
+	var quote byte
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '[':
+			depth++
+		case c == ']':
+			if depth--; depth < 0 {
+				err = ErrInvalidSelector
+				return
+			}
+		case c == '/' && depth == 0:
+			tok, rest = p[:i], p[i:]
+			return
+		}
+	}
+	if depth != 0 || quote != 0 {
+		err = ErrInvalidSelector
+		return
+	}
+	tok = p
+	return
+}
+
+func parseXMLStep(tok string, desc bool, ns map[string]string) (st xmlStep, err error) {
+	st.desc = desc
+	name, preds, _ := strings.Cut(tok, `[`)
+	if st.name, err = parseXMLName(name, ns); err != nil {
+		return
+	}
+	if preds == `` {
+		return
+	}
+	preds = `[` + preds
+	for len(preds) > 0 {
+		if preds[0] != '[' {
+			err = ErrInvalidSelector
+			return
+		}
+		var end int
+		var quote byte
+		for end = 1; end < len(preds); end++ {
+			if c := preds[end]; quote != 0 {
+				if c == quote {
+					quote = 0
+				}
+			} else if c == '\'' || c == '"' {
+				quote = c
+			} else if c == ']' {
+				break
+			}
+		}
+		if end >= len(preds) {
+			err = ErrInvalidSelector
+			return
+		}
+		var pr xmlPred
+		if pr, err = parseXMLPred(strings.TrimSpace(preds[1:end]), ns); err != nil {
+			return
+		}
+		st.preds = append(st.preds, pr)
+		preds = preds[end+1:]
+	}
+	return
+}
+
+func parseXMLPred(s string, ns map[string]string) (pr xmlPred, err error) {
+	if pos, perr := strconv.Atoi(s); perr == nil {
+		if pos < 1 {
+			err = ErrInvalidSelector
+		}
+		pr.pos = pos
+		return
+	}
+	name, val, ok := strings.Cut(s, `=`)
+	if name = strings.TrimSpace(name); strings.HasPrefix(name, `@`) {
+		pr.attr, name = true, name[1:]
+	}
+	if pr.name, err = parseXMLName(name, ns); err != nil {
+		return
+	}
+	if ok {
+		val = strings.TrimSpace(val)
+		if len(val) < 2 || (val[0] != '\'' && val[0] != '"') || val[len(val)-1] != val[0] {
+			err = ErrInvalidSelector
+			return
+		}
+		pr.val, pr.hasVal = val[1:len(val)-1], true
+	}
+	return
+}
+
+func parseXMLName(s string, ns map[string]string) (n xmlName, err error) {
+	s = strings.TrimSpace(s)
+	if prefix, local, ok := strings.Cut(s, `:`); ok {
+		var uri string
+		if uri, ok = ns[prefix]; !ok {
+			err = fmt.Errorf("%s: %w", prefix, ErrUnknownNamespace)
+			return
+		}
+		n.space, s = uri, local
+	}
+	if s != `*` && !validXMLName(s) {
+		err = fmt.Errorf("%q: %w", s, ErrInvalidSelector)
+		return
+	}
+	n.local = s
+	return
+}
+
+func validXMLName(s string) bool {
+	if s == `` {
+		return false
+	}
+	for i, r := range s {
+		switch {
+		case r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || r > 0x7f:
+		case i > 0 && (r == '-' || r == '.' || (r >= '0' && r <= '9')):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// eval returns the matching element nodes and their values in document order
+func (sel *xmlSelector) eval(doc *xmlNode) (nodes []*xmlNode, vals []string) {
+	ctx := []*xmlNode{doc}
+	for _, st := range sel.steps {
+		var next []*xmlNode
+		seen := map[*xmlNode]bool{}
+		add := func(n *xmlNode) {
+			for _, m := range st.matchChildren(n) {
+				if !seen[m] {
+					seen[m] = true
+					next = append(next, m)
+				}
+			}
+		}
+		for _, n := range ctx {
+			if st.desc {
+				n.walk(add)
+			} else {
+				add(n)
+			}
+		}
+		if ctx = next; len(ctx) == 0 {
+			return
+		}
+	}
+	for _, n := range ctx {
+		if sel.attr == nil {
+			nodes = append(nodes, n)
+			vals = append(vals, n.textContent())
+		} else if v, ok := n.attr(*sel.attr); ok {
+			vals = append(vals, v)
+		}
+	}
+	return
+}
+
+// walk calls fn on the node and every descendant element
+func (n *xmlNode) walk(fn func(*xmlNode)) {
+	fn(n)
+	for _, k := range n.kids {
+		if !k.isText() {
+			k.walk(fn)
+		}
+	}
+}
+
+func (n *xmlNode) attr(xn xmlName) (string, bool) {
+	for _, a := range n.attrs {
+		if xn.match(a.Name) {
+			return a.Value, true
+		}
+	}
+	return ``, false
+}
+
+// matchChildren applies the step to the children of n, positional predicates
+// are relative to the candidates remaining after earlier predicates
+func (st *xmlStep) matchChildren(n *xmlNode) (r []*xmlNode) {
+	for _, k := range n.kids {
+		if !k.isText() && st.name.match(k.name) {
+			r = append(r, k)
+		}
+	}
+	for _, pr := range st.preds {
+		if pr.pos > 0 {
+			if pr.pos > len(r) {
+				return nil
+			}
+			r = r[pr.pos-1 : pr.pos]
+			continue
+		}
+		filtered := r[:0:0]
+		for _, k := range r {
+			if pr.test(k) {
+				filtered = append(filtered, k)
+			}
+		}
+		r = filtered
+	}
+	return
+}
+
+func (pr *xmlPred) test(n *xmlNode) bool {
+	if pr.attr {
+		v, ok := n.attr(pr.name)
+		return ok && (!pr.hasVal || v == pr.val)
+	}
+	for _, k := range n.kids {
+		if !k.isText() && pr.name.match(k.name) && (!pr.hasVal || k.textContent() == pr.val) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,214 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package processors
+
+import (
+	"testing"
+
+	"github.com/gravwell/gravwell/v4/ingest/entry"
+)
+
+const (
+	testWinEventXML = `<Event xmlns='http://schemas.microsoft.com/win/2004/08/events/event'>` +
+		`<System><Provider Name='Microsoft-Windows-Security-Auditing'/><EventID>4624</EventID>` +
+		`<TimeCreated SystemTime='2024-01-02T03:04:05.0Z'/><Computer>dc01.example.com</Computer></System>` +
+		`<EventData><Data Name='SubjectUserName'>-</Data><Data Name='TargetUserName'>bob</Data>` +
+		`<Data Name='IpAddress'>10.0.0.1</Data></EventData></Event>`
+	testGenXML = `<event time="2024-01-02T03:04:05Z"><account><user>alice</user><email>a@example.com</email></account>` +
+		`<class>7</class><groups>admin</groups><groups>users</groups><ip>192.168.1.1</ip><data>hello &amp; goodbye</data></event>`
+	testSOAPXML = `<?xml version="1.0" encoding="ISO-8859-1"?>
+<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope" xmlns:m="urn:appliance">
+  <soap:Body>
+    <m:Alert severity="high">
+      <m:Name>port scan</m:Name>
+      <Name>not namespaced</Name>
+    </m:Alert>
+  </soap:Body>
+</soap:Envelope>`
+)
+
+func TestXMLExtractConfig(t *testing.T) {
+	b := `
+	[preprocessor "xml"]
+		type = xmlextract
+		Drop-Misses=true
+		Extractions=/event/account/user
+		Extractions="group=//groups"
+		Extractions=/event/@time
+		Namespaces="m=urn:appliance"
+		Extractions="alert=/soap:Envelope/soap:Body/m:Alert/m:Name"
+		Namespaces="soap=http://www.w3.org/2003/05/soap-envelope"
+	`
+	p, err := testLoadPreprocessor(b, `xml`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	xe, ok := p.(*XMLExtractor)
+	if !ok {
+		t.Fatalf("preprocessor is the wrong type: %T != *XMLExtractor", p)
+	} else if len(xe.sels) != 4 || xe.Output_Format != xmlOutputJSON || !xe.Drop_Misses {
+		t.Fatalf("bad config: %+v", xe.XMLExtractConfig)
+	}
+	for i, name := range []string{`user`, `group`, `time`, `alert`} {
+		if xe.sels[i].name != name {
+			t.Fatalf("bad selector name %d: %s != %s", i, xe.sels[i].name, name)
+		}
+	}
+}
+
+func TestXMLExtractBadConfig(t *testing.T) {
+	bad := []XMLExtractConfig{
+		{},
+		{Extractions: []string{`/a`}, Strict_Extraction: true},
+		{Extractions: []string{`/a`}, Output_Format: `yaml`},
+		{Extractions: []string{`/a`, `/a`}},
+		{Extractions: []string{`/a/b`, `/c/b`}},
+		{Extractions: []string{`a`}},
+		{Extractions: []string{`/a/*`}},
+		{Extractions: []string{`/a[0]`}},
+		{Extractions: []string{`/a[@b='c]`}},
+		{Extractions: []string{`/a/@b/c`}},
+		{Extractions: []string{`//@b`}},
+		{Extractions: []string{`/x:a`}},
+		{Extractions: []string{`/a`}, Namespaces: []string{`x`}},
+		{Extractions: []string{`bad name=/a`}},
+		{Extractions: []string{`/a`}, Output_Format: `xml`, Root_Element: `1bad`},
+	}
+	for _, cfg := range bad {
+		if _, err := NewXMLExtractor(cfg); err == nil {
+			t.Fatalf("bad config did not fail: %+v", cfg)
+		}
+	}
+}
+
+func TestXMLExtractJSON(t *testing.T) {
+	tests := []struct {
+		cfg  XMLExtractConfig
+		in   string
+		out  string
+		drop bool
+	}{
+		{
+			cfg: XMLExtractConfig{Extractions: []string{
+				`/Event/System/EventID`,
+				`user=//Data[@Name='TargetUserName']`,
+				`provider=/Event/System/Provider/@Name`,
+				`/Event/System/TimeCreated/@SystemTime`,
+			}},
+			in:  `<14>Jan 2 03:04:05 dc01 ` + testWinEventXML,
+			out: `{"EventID":"4624","user":"bob","provider":"Microsoft-Windows-Security-Auditing","SystemTime":"2024-01-02T03:04:05.0Z"}`,
+		},
+		{
+			cfg: XMLExtractConfig{Extractions: []string{`/event/account/user`, `//groups`, `/event/data`, `second=/event/groups[2]`, `/event[class='7']/ip`, `missing=/event/nope`}},
+			in:  testGenXML,
+			out: `{"user":"alice","groups":["admin","users"],"data":"hello \u0026 goodbye","second":"users","ip":"192.168.1.1"}`,
+		},
+		{
+			cfg: XMLExtractConfig{
+				Extractions: []string{`ns=//m:Alert/m:Name`, `any=//Alert/Name`, `/soap:Envelope/soap:Body/m:Alert/@severity`},
+				Namespaces:  []string{`m=urn:appliance`, `soap=http://www.w3.org/2003/05/soap-envelope`},
+			},
+			in:  testSOAPXML,
+			out: `{"ns":"port scan","any":["port scan","not namespaced"],"severity":"high"}`,
+		},
+		{
+			cfg: XMLExtractConfig{Extractions: []string{`/a/b`}},
+			in:  `not xml`,
+			out: `not xml`,
+		},
+		{
+			cfg:  XMLExtractConfig{Extractions: []string{`/a/b`}, Drop_Misses: true},
+			in:   `<a><c>1</c></a>`,
+			drop: true,
+		},
+		{
+			cfg:  XMLExtractConfig{Extractions: []string{`/a/b`, `/a/c`}, Drop_Misses: true, Strict_Extraction: true},
+			in:   `<a><c>1</c></a>`,
+			drop: true,
+		},
+		{
+			cfg:  XMLExtractConfig{Extractions: []string{`/a/b`}, Drop_Misses: true},
+			in:   `<a><b>1</b>`,
+			drop: true,
+		},
+	}
+	for i, tt := range tests {
+		xe, err := NewXMLExtractor(tt.cfg)
+		if err != nil {
+			t.Fatalf("%d: %v", i, err)
+		}
+		ents, err := xe.Process([]*entry.Entry{{Data: []byte(tt.in)}})
+		if err != nil {
+			t.Fatal(err)
+		} else if tt.drop {
+			if len(ents) != 0 {
+				t.Fatalf("%d: entry not dropped: %s", i, ents[0].Data)
+			}
+			continue
+		} else if len(ents) != 1 {
+			t.Fatalf("%d: bad entry count %d", i, len(ents))
+		} else if string(ents[0].Data) != tt.out {
+			t.Fatalf("%d: bad output:\n%s\n%s", i, ents[0].Data, tt.out)
+		}
+	}
+}
+
+func TestXMLExtractXML(t *testing.T) {
+	xe, err := NewXMLExtractor(XMLExtractConfig{
+		Extractions:   []string{`/event/account`, `ts=/event/@time`, `//groups`},
+		Output_Format: `XML`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ents, err := xe.Process([]*entry.Entry{{Data: []byte(testGenXML)}, {Data: []byte(testWinEventXML)}})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(ents) != 2 {
+		t.Fatalf("bad entry count %d", len(ents))
+	}
+	want := `<extract><account><user>alice</user><email>a@example.com</email></account><ts>2024-01-02T03:04:05Z</ts><groups>admin</groups><groups>users</groups></extract>`
+	if string(ents[0].Data) != want {
+		t.Fatalf("bad output:\n%s\n%s", ents[0].Data, want)
+	} else if string(ents[1].Data) != testWinEventXML {
+		t.Fatalf("miss was modified: %s", ents[1].Data)
+	}
+
+	//namespace declarations are stripped from rebuilt subtrees
+	if xe, err = NewXMLExtractor(XMLExtractConfig{Extractions: []string{`sys=/Event/System/Provider`}, Output_Format: `xml`, Root_Element: `win`}); err != nil {
+		t.Fatal(err)
+	}
+	if ents, err = xe.Process([]*entry.Entry{{Data: []byte(testWinEventXML)}}); err != nil {
+		t.Fatal(err)
+	} else if want = `<win><sys Name="Microsoft-Windows-Security-Auditing"/></win>`; string(ents[0].Data) != want {
+		t.Fatalf("bad output:\n%s\n%s", ents[0].Data, want)
+	}
+}
+
+func TestXMLExtractEnumeratedValues(t *testing.T) {
+	xe, err := NewXMLExtractor(XMLExtractConfig{
+		Extractions:       []string{`/event/account/user`, `//groups`, `/event/@time`},
+		Enumerated_Values: true,
+		Join_Delimiter:    `|`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ents, err := xe.Process([]*entry.Entry{{Data: []byte(testGenXML)}})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(ents) != 1 || string(ents[0].Data) != testGenXML {
+		t.Fatalf("bad entries: %v", ents)
+	}
+	for k, v := range map[string]string{`user`: `alice`, `groups`: `admin|users`, `time`: `2024-01-02T03:04:05Z`} {
+		if val, ok := ents[0].GetEnumeratedValue(k); !ok || val != v {
+			t.Fatalf("bad EV %s: %v", k, val)
+		}
+	}
+}
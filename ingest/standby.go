@@ -0,0 +1,297 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package ingest
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gravwell/gravwell/v4/ingest/log"
+)
+
+const (
+	DestinationGroupPrimary = `primary`
+	DestinationGroupStandby = `standby`
+
+	defaultStandbyMinPrimary = 1
+	standbyCheckInterval     = 250 * time.Millisecond
+)
+
+var (
+	ErrNoPrimaryTargets    = errors.New("Standby targets require at least one primary target")
+	ErrInvalidStandbyDelay = errors.New("Standby failover and failback delays cannot be negative")
+)
+
+// StandbyConfig controls when a muxer with standby targets fails over to them.
+// The standby targets only receive data while fewer than MinPrimary primary targets are hot,
+// a zero MinPrimary means the muxer fails over only once every primary target is down.
+// FailoverDelay is how long the primaries must be below MinPrimary before failing over and
+// FailbackDelay is how long they must be back before the muxer returns to them.
+type StandbyConfig struct {
+	MinPrimary    int
+	FailoverDelay time.Duration
+	FailbackDelay time.Duration
+}
+
+// standbyGroups tracks which destination group is active for a muxer.  Relay routines for
+// the inactive group keep their connections up but do not pull from the feeder channels,
+// and the inactive group does not count towards the hot and dead counts.
+// A nil standbyGroups means there are no standby targets and every target is always active.
+type standbyGroups struct {
+	mtx       sync.Mutex
+	cfg       StandbyConfig
+	standby   []bool // indexed by destination
+	hot       []bool // indexed by destination
+	primaries int
+	failed    atomic.Bool   // true when the standby group is active
+	since     time.Time     // when the primaries first crossed the threshold, zero if they have not
+	changed   chan struct{} // closed and replaced every time the active group switches
+	notify    chan struct{} // kicks the standby routine when a connection changes state
+}
+
+func newStandbyGroups(dests []Target, cfg StandbyConfig) (sb *standbyGroups, err error) {
+	if cfg.FailoverDelay < 0 || cfg.FailbackDelay < 0 {
+		err = ErrInvalidStandbyDelay
+		return
+	}
+	var primaries, standbys int
+	for _, d := range dests {
+		if d.Standby {
+			standbys++
+		} else {
+			primaries++
+		}
+	}
+	if standbys == 0 {
+		return //no standby group, nothing to track
+	} else if primaries == 0 {
+		err = ErrNoPrimaryTargets
+		return
+	}
+	if cfg.MinPrimary == 0 {
+		cfg.MinPrimary = defaultStandbyMinPrimary
+	} else if cfg.MinPrimary < 0 || cfg.MinPrimary > primaries {
+		err = fmt.Errorf("Invalid standby minimum primary count %d, there are %d primary targets", cfg.MinPrimary, primaries)
+		return
+	}
+	sb = &standbyGroups{
+		cfg:       cfg,
+		standby:   make([]bool, len(dests)),
+		hot:       make([]bool, len(dests)),
+		primaries: primaries,
+		changed:   make(chan struct{}),
+		notify:    make(chan struct{}, 1),
+	}
+	for i, d := range dests {
+		sb.standby[i] = d.Standby
+	}
+	return
+}
+
+// active returns true if the destination at idx is in the active group
+func (sb *standbyGroups) active(idx int) bool {
+	return sb == nil || sb.standby[idx] == sb.failed.Load()
+}
+
+// wake returns a channel which is closed when the active group next switches.
+// A nil standbyGroups returns a nil channel, which never fires.
+func (sb *standbyGroups) wake() (r <-chan struct{}) {
+	if sb != nil {
+		sb.mtx.Lock()
+		r = sb.changed
+		sb.mtx.Unlock()
+	}
+	return
+}
+
+// group returns the name of the active group, empty if there are no standby targets
+func (sb *standbyGroups) group() string {
+	if sb == nil {
+		return ``
+	} else if sb.failed.Load() {
+		return DestinationGroupStandby
+	}
+	return DestinationGroupPrimary
+}
+
+func (sb *standbyGroups) kick() {
+	select {
+	case sb.notify <- struct{}{}:
+	default:
+	}
+}
+
+// counts returns the number of hot primary and standby destinations, the caller must hold the lock
+func (sb *standbyGroups) counts() (primary, standby int) {
+	for i, hot := range sb.hot {
+		if !hot {
+			continue
+		} else if sb.standby[i] {
+			standby++
+		} else {
+			primary++
+		}
+	}
+	return
+}
+
+// evaluate decides whether the active group should switch at time now, the caller must hold the lock.
+// The standby group is only worth using while at least one standby target is hot, if none are
+// the muxer stays on (or returns to) whatever primaries it has and only stays put when
+// nothing at all is hot.
+func (sb *standbyGroups) evaluate(now time.Time) (toggle bool) {
+	primary, standby := sb.counts()
+	failed := sb.failed.Load()
+	want := primary < sb.cfg.MinPrimary
+	if want && standby == 0 {
+		want = failed && primary == 0
+	}
+	if want == failed {
+		sb.since = time.Time{}
+		return
+	}
+	if sb.since.IsZero() {
+		sb.since = now
+	}
+	delay := sb.cfg.FailoverDelay
+	if failed {
+		delay = sb.cfg.FailbackDelay
+	}
+	if now.Sub(sb.since) >= delay {
+		sb.since = time.Time{}
+		toggle = true
+	}
+	return
+}
+
+// connUp marks the destination at idx as hot, it only counts towards the hot connections
+// and receives data from the load balancer if its group is active.
+func (im *IngestMuxer) connUp(idx int) {
+	if im.sb != nil {
+		im.sb.mtx.Lock()
+		defer im.sb.mtx.Unlock()
+		im.sb.hot[idx] = true
+		im.sb.kick()
+		if !im.sb.active(idx) {
+			return
+		}
+	}
+	im.goHot()
+	if im.lb != nil {
+		im.lb.setHot(idx, true)
+	}
+}
+
+// connDown marks the destination at idx as dead
+func (im *IngestMuxer) connDown(idx int) {
+	if im.sb != nil {
+		im.sb.mtx.Lock()
+		defer im.sb.mtx.Unlock()
+		im.sb.hot[idx] = false
+		im.sb.kick()
+		if !im.sb.active(idx) {
+			return
+		}
+	}
+	im.goDead()
+	if im.lb != nil {
+		im.lb.setHot(idx, false)
+	}
+}
+
+// standbyRoutine watches the primary destinations and switches the active group when
+// they cross the failover threshold.
+func (im *IngestMuxer) standbyRoutine() {
+	defer im.wg.Done()
+	tckr := time.NewTicker(standbyCheckInterval)
+	defer tckr.Stop()
+	for {
+		select {
+		case <-im.ctx.Done():
+			return
+		case <-im.sb.notify:
+		case <-tckr.C:
+		}
+		im.checkStandby(time.Now())
+	}
+}
+
+// checkStandby switches the active group if the primaries have been over or under the
+// threshold for long enough.
+func (im *IngestMuxer) checkStandby(now time.Time) {
+	im.sb.mtx.Lock()
+	if !im.sb.evaluate(now) {
+		im.sb.mtx.Unlock()
+		return
+	}
+	im.switchGroup(!im.sb.failed.Load())
+	primary, standby := im.sb.counts()
+	im.sb.mtx.Unlock()
+
+	im.mtx.Lock()
+	im.ingesterState.ActiveGroup = im.sb.group()
+	im.ingesterStateUpdated = true
+	im.mtx.Unlock()
+
+	if im.sb.failed.Load() {
+		im.Warn("too few primary indexers are hot, failing over to standby indexers",
+			log.KV("primary", primary), log.KV("standby", standby),
+			log.KV("ingester", im.name), log.KV("ingesteruuid", im.uuid))
+	} else {
+		im.Info("failing back to primary indexers",
+			log.KV("primary", primary), log.KV("standby", standby),
+			log.KV("ingester", im.name), log.KV("ingesteruuid", im.uuid))
+	}
+}
+
+// switchGroup activates the standby group if failed is set, otherwise the primary group.
+// The entering group is brought up before the leaving group is taken down so that the hot
+// count never drops to zero on a healthy switch and kicks the cache on.
+// The caller must hold the standby lock.
+func (im *IngestMuxer) switchGroup(failed bool) {
+	sb := im.sb
+	sb.failed.Store(failed)
+	for i := range sb.standby {
+		if sb.standby[i] != failed {
+			continue
+		} else if !sb.hot[i] {
+			atomic.AddInt32(&im.connDead, 1)
+			continue
+		}
+		im.hotUp()
+		if im.lb != nil {
+			im.lb.setHot(i, true)
+		}
+	}
+	for i := range sb.standby {
+		if sb.standby[i] == failed {
+			continue
+		} else if !sb.hot[i] {
+			atomic.AddInt32(&im.connDead, -1)
+			continue
+		}
+		im.hotDown()
+		if im.lb != nil {
+			//stop steering entries at the leaving group and hand back anything already forwarded
+			im.lb.setHot(i, false)
+			ents, blks := im.lb.drain(i)
+			for _, ent := range ents {
+				im.eq.push(ent, nil)
+			}
+			for _, blk := range blks {
+				im.eq.push(nil, blk)
+			}
+		}
+	}
+	close(sb.changed)
+	sb.changed = make(chan struct{})
+}
@@ -0,0 +1,154 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package ingest
+
+import (
+	"testing"
+	"time"
+)
+
+var standbyTestDests = []Target{
+	{Address: `tcp://p1:4023`},
+	{Address: `tcp://p2:4023`},
+	{Address: `tcp://s1:4023`, Standby: true},
+}
+
+func TestNewStandbyGroups(t *testing.T) {
+	if sb, err := newStandbyGroups(standbyTestDests[:2], StandbyConfig{}); err != nil || sb != nil {
+		t.Fatalf("no standby targets should not produce a tracker: %v", err)
+	} else if !sb.active(0) || sb.group() != `` || sb.wake() != nil {
+		t.Fatal("nil tracker does not treat everything as active")
+	}
+	if _, err := newStandbyGroups(standbyTestDests[2:], StandbyConfig{}); err != ErrNoPrimaryTargets {
+		t.Fatalf("failed to catch missing primaries: %v", err)
+	}
+	if _, err := newStandbyGroups(standbyTestDests, StandbyConfig{MinPrimary: 3}); err == nil {
+		t.Fatal("failed to catch minimum primary count larger than the primary group")
+	}
+	if _, err := newStandbyGroups(standbyTestDests, StandbyConfig{FailbackDelay: -time.Second}); err != ErrInvalidStandbyDelay {
+		t.Fatalf("failed to catch negative delay: %v", err)
+	}
+
+	sb, err := newStandbyGroups(standbyTestDests, StandbyConfig{})
+	if err != nil {
+		t.Fatal(err)
+	} else if sb.cfg.MinPrimary != defaultStandbyMinPrimary || sb.primaries != 2 {
+		t.Fatalf("bad defaults: %+v %d", sb.cfg, sb.primaries)
+	} else if !sb.active(0) || !sb.active(1) || sb.active(2) || sb.group() != DestinationGroupPrimary {
+		t.Fatal("primaries are not active at startup")
+	}
+}
+
+func TestStandbyEvaluate(t *testing.T) {
+	sb, err := newStandbyGroups(standbyTestDests, StandbyConfig{
+		MinPrimary:    2,
+		FailoverDelay: time.Second,
+		FailbackDelay: 2 * time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	sb.hot = []bool{true, true, true}
+	if sb.evaluate(now) {
+		t.Fatal("switched with healthy primaries")
+	}
+
+	//a degraded primary group has to stay degraded for the failover delay
+	sb.hot[1] = false
+	if sb.evaluate(now) || sb.evaluate(now.Add(500*time.Millisecond)) {
+		t.Fatal("failed over before the delay")
+	}
+	//a blip resets the clock
+	sb.hot[1] = true
+	if sb.evaluate(now.Add(750 * time.Millisecond)) {
+		t.Fatal("failed over with healthy primaries")
+	}
+	sb.hot[1] = false
+	if sb.evaluate(now.Add(1500*time.Millisecond)) || !sb.evaluate(now.Add(2500*time.Millisecond)) {
+		t.Fatal("did not fail over after the delay")
+	}
+	sb.failed.Store(true)
+
+	//recovered primaries wait out the failback delay
+	sb.hot[1] = true
+	if sb.evaluate(now.Add(3 * time.Second)) {
+		t.Fatal("failed back before the delay")
+	} else if !sb.evaluate(now.Add(5 * time.Second)) {
+		t.Fatal("did not fail back after the delay")
+	}
+	sb.failed.Store(false)
+
+	//never fail over to a standby group that is down too
+	sb.hot = []bool{true, false, false}
+	if sb.evaluate(now) || sb.evaluate(now.Add(time.Hour)) {
+		t.Fatal("failed over to a dead standby group")
+	}
+
+	//a dead standby group sends us back to whatever primaries are left
+	sb.failed.Store(true)
+	if sb.evaluate(now) || !sb.evaluate(now.Add(time.Hour)) {
+		t.Fatal("did not fail back from a dead standby group")
+	}
+	//but stay put if there is nothing to go back to
+	sb.hot = []bool{false, false, false}
+	if sb.evaluate(now) || sb.evaluate(now.Add(time.Hour)) {
+		t.Fatal("failed back to a dead primary group")
+	}
+}
+
+func TestStandbySwitch(t *testing.T) {
+	im, err := NewMuxer(MuxerConfig{
+		Destinations: standbyTestDests,
+		Tags:         []string{`test`},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer im.cf()
+	im.connDead = int32(im.sb.primaries)
+	counts := func(hot, dead int32) {
+		t.Helper()
+		if im.connHot != hot || im.connDead != dead {
+			t.Fatalf("bad counts: hot %d dead %d, expected %d %d", im.connHot, im.connDead, hot, dead)
+		}
+	}
+
+	//the standby connection does not count while the primaries are active
+	im.connUp(0)
+	im.connUp(2)
+	counts(1, 1)
+	wake := im.sb.wake()
+
+	im.connDown(0)
+	counts(0, 2)
+	im.checkStandby(time.Now())
+	counts(1, 0)
+	if im.sb.group() != DestinationGroupStandby || !im.sb.active(2) || im.sb.active(0) {
+		t.Fatal("did not fail over")
+	} else if im.ingesterState.ActiveGroup != DestinationGroupStandby {
+		t.Fatalf("ingester state not updated: %q", im.ingesterState.ActiveGroup)
+	}
+	select {
+	case <-wake:
+	default:
+		t.Fatal("relay routines were not woken up")
+	}
+
+	//primaries coming back do not count until we fail back
+	im.connUp(1)
+	counts(1, 0)
+	im.checkStandby(time.Now())
+	counts(1, 1)
+	if im.sb.group() != DestinationGroupPrimary || !im.sb.active(1) || im.sb.active(2) {
+		t.Fatal("did not fail back")
+	} else if im.ingesterState.ActiveGroup != DestinationGroupPrimary {
+		t.Fatalf("ingester state not updated: %q", im.ingesterState.ActiveGroup)
+	}
+}
@@ -0,0 +1,56 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package testindexer
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// GenerateCert writes a throwaway self-signed certificate and key for localhost into dir.
+// The same pair can be handed to ListenTLS and used as an ingester's client certificate.
+func GenerateCert(dir string) (certFile, keyFile string, err error) {
+	var key *ecdsa.PrivateKey
+	var der, keyDer []byte
+	if key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader); err != nil {
+		return
+	}
+	tmpl := x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{Organization: []string{`testindexer`}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		DNSNames:     []string{`localhost`},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+	}
+	if der, err = x509.CreateCertificate(rand.Reader, &tmpl, &tmpl, &key.PublicKey, key); err != nil {
+		return
+	} else if keyDer, err = x509.MarshalECPrivateKey(key); err != nil {
+		return
+	}
+	cf, kf := filepath.Join(dir, `cert.pem`), filepath.Join(dir, `key.pem`)
+	if err = os.WriteFile(cf, pem.EncodeToMemory(&pem.Block{Type: `CERTIFICATE`, Bytes: der}), 0600); err != nil {
+		return
+	} else if err = os.WriteFile(kf, pem.EncodeToMemory(&pem.Block{Type: `EC PRIVATE KEY`, Bytes: keyDer}), 0600); err != nil {
+		return
+	}
+	certFile, keyFile = cf, kf
+	return
+}
@@ -0,0 +1,544 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+// Package testindexer implements an in-process fake indexer for integration testing
+// ingesters.  The Indexer speaks the server side of the ingest protocol over TCP, TLS,
+// and unix pipes, records every entry and ingester state message it receives, and can
+// inject throttles, disconnects, and slow acknowledgements so tests can check exactly
+// what an IngestMuxer delivered.
+package testindexer
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/gravwell/gravwell/v4/ingest"
+	"github.com/gravwell/gravwell/v4/ingest/entry"
+)
+
+const (
+	authTimeout = 5 * time.Second
+)
+
+var (
+	ErrClosed  = errors.New("test indexer is closed")
+	ErrTimeout = errors.New("timed out waiting on test indexer")
+)
+
+// Ingester describes an ingester which identified itself on a connection.
+type Ingester struct {
+	Name    string
+	Version string
+	UUID    string
+	Tenant  string
+	Remote  string
+}
+
+// Indexer is a fake indexer.  Tags are shared across all connections, the default and
+// gravwell tags are pre-populated just like a real indexer.
+type Indexer struct {
+	mtx       sync.Mutex
+	wg        sync.WaitGroup
+	auth      ingest.AuthHash
+	closed    bool
+	listeners []net.Listener
+	conns     map[*conn]struct{}
+	tags      map[string]entry.EntryTag
+	tagNames  map[entry.EntryTag]string
+	nextTag   entry.EntryTag
+	ents      []*entry.Entry
+	states    []ingest.IngesterState
+	ingesters []Ingester
+	authFails int
+	ackDelay  time.Duration
+	paused    time.Time
+	refuse    bool
+	notify    chan struct{}
+}
+
+type conn struct {
+	net.Conn
+	er *ingest.EntryReader
+}
+
+// New creates an Indexer which authenticates ingesters with the given shared secret.
+// The Indexer does nothing until one of the Listen methods is called.
+func New(secret string) (ix *Indexer, err error) {
+	var auth ingest.AuthHash
+	if auth, err = ingest.GenAuthHash(secret); err != nil {
+		return
+	}
+	ix = &Indexer{
+		auth:  auth,
+		conns: map[*conn]struct{}{},
+		tags: map[string]entry.EntryTag{
+			entry.DefaultTagName:  entry.DefaultTagId,
+			entry.GravwellTagName: entry.GravwellTagId,
+		},
+		tagNames: map[entry.EntryTag]string{
+			entry.DefaultTagId:  entry.DefaultTagName,
+			entry.GravwellTagId: entry.GravwellTagName,
+		},
+		nextTag: entry.DefaultTagId + 1,
+		notify:  make(chan struct{}),
+	}
+	return
+}
+
+// ListenTCP listens for cleartext connections on addr, which may use port zero.
+// The returned target is suitable for use as a muxer destination.
+func (ix *Indexer) ListenTCP(addr string) (target string, err error) {
+	var l net.Listener
+	if l, err = net.Listen(`tcp`, addr); err != nil {
+		return
+	} else if err = ix.serve(l); err == nil {
+		target = `tcp://` + l.Addr().String()
+	}
+	return
+}
+
+// ListenTLS listens for TLS connections on addr using the certificate and key files.
+// Use GenerateCert to build a throwaway pair; ingesters must not verify the remote
+// certificate unless they trust it.
+func (ix *Indexer) ListenTLS(addr, certFile, keyFile string) (target string, err error) {
+	var cert tls.Certificate
+	var l net.Listener
+	if cert, err = tls.LoadX509KeyPair(certFile, keyFile); err != nil {
+		return
+	} else if l, err = tls.Listen(`tcp`, addr, &tls.Config{Certificates: []tls.Certificate{cert}}); err != nil {
+		return
+	} else if err = ix.serve(l); err == nil {
+		target = `tls://` + l.Addr().String()
+	}
+	return
+}
+
+// ListenPipe listens on a unix socket at pth.
+func (ix *Indexer) ListenPipe(pth string) (target string, err error) {
+	var l net.Listener
+	if l, err = net.Listen(`unix`, pth); err != nil {
+		return
+	} else if err = ix.serve(l); err == nil {
+		target = `pipe://` + pth
+	}
+	return
+}
+
+func (ix *Indexer) serve(l net.Listener) error {
+	ix.mtx.Lock()
+	defer ix.mtx.Unlock()
+	if ix.closed {
+		l.Close()
+		return ErrClosed
+	}
+	ix.listeners = append(ix.listeners, l)
+	ix.wg.Add(1)
+	go ix.acceptRoutine(l)
+	return nil
+}
+
+// Close stops all listeners, drops all connections, and waits for them to exit.
+// Received entries and states remain available.
+func (ix *Indexer) Close() (err error) {
+	ix.mtx.Lock()
+	if ix.closed {
+		ix.mtx.Unlock()
+		return ErrClosed
+	}
+	ix.closed = true
+	for _, l := range ix.listeners {
+		if lerr := l.Close(); lerr != nil && err == nil {
+			err = lerr
+		}
+	}
+	for c := range ix.conns {
+		c.Close()
+	}
+	ix.mtx.Unlock()
+	ix.wg.Wait()
+	return
+}
+
+func (ix *Indexer) acceptRoutine(l net.Listener) {
+	defer ix.wg.Done()
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			return
+		}
+		ix.wg.Add(1)
+		go ix.connRoutine(c)
+	}
+}
+
+func (ix *Indexer) connRoutine(nc net.Conn) {
+	defer ix.wg.Done()
+	c := &conn{Conn: nc}
+	if !ix.addConn(c) {
+		nc.Close()
+		return
+	}
+	defer ix.removeConn(c)
+	tenant, err := ix.authenticate(nc)
+	if err != nil {
+		return
+	}
+	er, err := ingest.NewEntryReader(nc)
+	if err != nil {
+		return
+	}
+	er.SetTagManager(ix)
+	er.AddIngesterStateCallback(ix.addState)
+	if err = er.Start(); err != nil {
+		return
+	}
+	ix.mtx.Lock()
+	c.er = er
+	ix.signal()
+	ix.mtx.Unlock()
+	if err = er.SetupConnection(); err != nil {
+		return
+	}
+	name, version, uuid := er.GetIngesterInfo()
+	ix.addIngester(Ingester{
+		Name:    name,
+		Version: version,
+		UUID:    uuid,
+		Tenant:  tenant,
+		Remote:  nc.RemoteAddr().String(),
+	})
+	ix.mtx.Lock()
+	ok := !ix.refuse
+	ix.mtx.Unlock()
+	if err = er.IngestOK(ok); err != nil || !ok {
+		return
+	} else if err = er.ConfigureStream(); err != nil {
+		return
+	}
+	for {
+		if d := ix.readDelay(); d > 0 {
+			time.Sleep(d)
+		}
+		ent, err := er.Read()
+		if err == ingest.ErrPendingDittoBlock {
+			var block []*entry.Entry
+			if block, err = er.GetPendingDittoBlock(); err != nil {
+				return
+			}
+			ix.addEntries(block...)
+			if err = er.AckDittoBlock(); err != nil {
+				return
+			}
+			continue
+		} else if err != nil {
+			return
+		}
+		ix.addEntries(ent)
+	}
+}
+
+// authenticate runs the server side of the challenge and tag negotiation
+func (ix *Indexer) authenticate(c net.Conn) (tenant string, err error) {
+	if err = c.SetDeadline(time.Now().Add(authTimeout)); err != nil {
+		return
+	}
+	if tenant, err = ingest.AuthenticateIngester(c, ix.auth, ix); err != nil {
+		if errors.Is(err, ingest.ErrFailedAuth) {
+			ix.mtx.Lock()
+			ix.authFails++
+			ix.mtx.Unlock()
+		}
+		return
+	}
+	err = c.SetDeadline(time.Time{})
+	return
+}
+
+// GetAndPopulate implements the ingest.TagManager interface, handing out sequential
+// tag IDs shared by every connection.
+func (ix *Indexer) GetAndPopulate(name string) (tg entry.EntryTag, err error) {
+	if err = ingest.CheckTag(name); err != nil {
+		return
+	}
+	ix.mtx.Lock()
+	defer ix.mtx.Unlock()
+	var ok bool
+	if tg, ok = ix.tags[name]; ok {
+		return
+	} else if ix.nextTag >= entry.MaxTagId {
+		err = ingest.ErrTooManyTags
+		return
+	}
+	tg = ix.nextTag
+	ix.nextTag++
+	ix.tags[name] = tg
+	ix.tagNames[tg] = name
+	return
+}
+
+// TagName resolves a tag ID handed out by the Indexer back to its name.
+func (ix *Indexer) TagName(tg entry.EntryTag) (name string, ok bool) {
+	ix.mtx.Lock()
+	name, ok = ix.tagNames[tg]
+	ix.mtx.Unlock()
+	return
+}
+
+// Tags returns a copy of the negotiated tag map.
+func (ix *Indexer) Tags() (tags map[string]entry.EntryTag) {
+	ix.mtx.Lock()
+	tags = make(map[string]entry.EntryTag, len(ix.tags))
+	for k, v := range ix.tags {
+		tags[k] = v
+	}
+	ix.mtx.Unlock()
+	return
+}
+
+// Entries returns every entry received so far, in the order they were read.
+func (ix *Indexer) Entries() (ents []*entry.Entry) {
+	ix.mtx.Lock()
+	ents = append(ents, ix.ents...)
+	ix.mtx.Unlock()
+	return
+}
+
+// TagEntries returns the received entries which carry the named tag.
+func (ix *Indexer) TagEntries(name string) (ents []*entry.Entry) {
+	ix.mtx.Lock()
+	defer ix.mtx.Unlock()
+	tg, ok := ix.tags[name]
+	if !ok {
+		return
+	}
+	for _, ent := range ix.ents {
+		if ent.Tag == tg {
+			ents = append(ents, ent)
+		}
+	}
+	return
+}
+
+// EntryCount returns the number of entries received so far.
+func (ix *Indexer) EntryCount() (n int) {
+	ix.mtx.Lock()
+	n = len(ix.ents)
+	ix.mtx.Unlock()
+	return
+}
+
+// Reset discards all received entries and states, tags and ingesters are kept.
+func (ix *Indexer) Reset() {
+	ix.mtx.Lock()
+	ix.ents = nil
+	ix.states = nil
+	ix.mtx.Unlock()
+}
+
+// IngesterStates returns every ingester state message received so far.
+func (ix *Indexer) IngesterStates() (states []ingest.IngesterState) {
+	ix.mtx.Lock()
+	states = make([]ingest.IngesterState, 0, len(ix.states))
+	for _, s := range ix.states {
+		states = append(states, s.Copy())
+	}
+	ix.mtx.Unlock()
+	return
+}
+
+// Ingesters returns every ingester which completed the handshake, including
+// ingesters which have since disconnected.
+func (ix *Indexer) Ingesters() (igs []Ingester) {
+	ix.mtx.Lock()
+	igs = append(igs, ix.ingesters...)
+	ix.mtx.Unlock()
+	return
+}
+
+// AuthFailures returns the number of connections which failed authentication.
+func (ix *Indexer) AuthFailures() (n int) {
+	ix.mtx.Lock()
+	n = ix.authFails
+	ix.mtx.Unlock()
+	return
+}
+
+// Connections returns the number of authenticated, live ingester connections.
+func (ix *Indexer) Connections() (n int) {
+	ix.mtx.Lock()
+	n = ix.liveConns()
+	ix.mtx.Unlock()
+	return
+}
+
+// liveConns counts authenticated connections, caller must hold the lock
+func (ix *Indexer) liveConns() (n int) {
+	for c := range ix.conns {
+		if c.er != nil {
+			n++
+		}
+	}
+	return
+}
+
+// WaitEntries waits up to the timeout for at least n entries to arrive and returns them.
+func (ix *Indexer) WaitEntries(n int, to time.Duration) (ents []*entry.Entry, err error) {
+	if err = ix.wait(to, func() bool { return len(ix.ents) >= n }); err == nil {
+		ents = ix.Entries()
+	}
+	return
+}
+
+// WaitIngesterStates waits up to the timeout for at least n ingester state messages.
+func (ix *Indexer) WaitIngesterStates(n int, to time.Duration) (states []ingest.IngesterState, err error) {
+	if err = ix.wait(to, func() bool { return len(ix.states) >= n }); err == nil {
+		states = ix.IngesterStates()
+	}
+	return
+}
+
+// WaitIngesters waits up to the timeout for at least n ingesters to complete the handshake,
+// counting reconnects.
+func (ix *Indexer) WaitIngesters(n int, to time.Duration) error {
+	return ix.wait(to, func() bool { return len(ix.ingesters) >= n })
+}
+
+// WaitConnections waits up to the timeout for at least n authenticated, live connections.
+func (ix *Indexer) WaitConnections(n int, to time.Duration) error {
+	return ix.wait(to, func() bool { return ix.liveConns() >= n })
+}
+
+// wait blocks until cond is true, cond is called with the lock held
+func (ix *Indexer) wait(to time.Duration, cond func() bool) error {
+	tmr := time.NewTimer(to)
+	defer tmr.Stop()
+	for {
+		ix.mtx.Lock()
+		if cond() {
+			ix.mtx.Unlock()
+			return nil
+		}
+		ch := ix.notify
+		ix.mtx.Unlock()
+		select {
+		case <-ch:
+		case <-tmr.C:
+			return ErrTimeout
+		}
+	}
+}
+
+// Throttle asks every live ingester to stop sending for the given duration.  Ingesters
+// resume as soon as they hear anything else from the indexer, so reads and acks are
+// paused for the duration as well.
+func (ix *Indexer) Throttle(d time.Duration) (err error) {
+	ix.mtx.Lock()
+	defer ix.mtx.Unlock()
+	ix.paused = time.Now().Add(d)
+	for c := range ix.conns {
+		if c.er == nil {
+			continue //still authenticating
+		} else if lerr := c.er.SendThrottle(d); lerr != nil && err == nil {
+			err = fmt.Errorf("%s: %w", c.RemoteAddr(), lerr)
+		}
+	}
+	return
+}
+
+// Disconnect abruptly closes every connection and returns how many were dropped.
+// Listeners keep running so ingesters may reconnect.  Entries which were read but not
+// yet acknowledged are still recorded, so muxers may deliver them again.
+func (ix *Indexer) Disconnect() (n int) {
+	ix.mtx.Lock()
+	for c := range ix.conns {
+		c.Close()
+		n++
+	}
+	ix.mtx.Unlock()
+	return
+}
+
+// SetAckDelay slows the reader down, each entry waits d before it is read and acknowledged.
+// A zero duration restores full speed.
+func (ix *Indexer) SetAckDelay(d time.Duration) {
+	ix.mtx.Lock()
+	ix.ackDelay = d
+	ix.mtx.Unlock()
+}
+
+// SetRefuseIngest controls the answer to the ingest OK query on new connections,
+// refused connections are dropped after answering.
+func (ix *Indexer) SetRefuseIngest(refuse bool) {
+	ix.mtx.Lock()
+	ix.refuse = refuse
+	ix.mtx.Unlock()
+}
+
+// readDelay returns how long to wait before the next read
+func (ix *Indexer) readDelay() (d time.Duration) {
+	ix.mtx.Lock()
+	d = ix.ackDelay
+	if p := time.Until(ix.paused); p > d {
+		d = p
+	}
+	ix.mtx.Unlock()
+	return
+}
+
+func (ix *Indexer) addConn(c *conn) bool {
+	ix.mtx.Lock()
+	defer ix.mtx.Unlock()
+	if ix.closed {
+		return false
+	}
+	ix.conns[c] = struct{}{}
+	return true
+}
+
+func (ix *Indexer) removeConn(c *conn) {
+	ix.mtx.Lock()
+	delete(ix.conns, c)
+	er := c.er
+	ix.mtx.Unlock()
+	//closing the entry reader closes its ack channel, so it must be out of the set first
+	if er != nil {
+		er.Close()
+	}
+	c.Close()
+}
+
+func (ix *Indexer) addIngester(ig Ingester) {
+	ix.mtx.Lock()
+	ix.ingesters = append(ix.ingesters, ig)
+	ix.signal()
+	ix.mtx.Unlock()
+}
+
+func (ix *Indexer) addEntries(ents ...*entry.Entry) {
+	ix.mtx.Lock()
+	ix.ents = append(ix.ents, ents...)
+	ix.signal()
+	ix.mtx.Unlock()
+}
+
+func (ix *Indexer) addState(s ingest.IngesterState) {
+	ix.mtx.Lock()
+	ix.states = append(ix.states, s)
+	ix.signal()
+	ix.mtx.Unlock()
+}
+
+// signal wakes all waiters, caller must hold the lock
+func (ix *Indexer) signal() {
+	close(ix.notify)
+	ix.notify = make(chan struct{})
+}
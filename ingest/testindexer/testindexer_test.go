@@ -0,0 +1,511 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package testindexer
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gravwell/gravwell/v4/ingest"
+	"github.com/gravwell/gravwell/v4/ingest/entry"
+)
+
+const (
+	testSecret  = `testsecret`
+	testTimeout = 10 * time.Second
+)
+
+func newTestIndexer(t *testing.T) *Indexer {
+	t.Helper()
+	ix, err := New(testSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ix.Close() })
+	return ix
+}
+
+func newTestMuxer(t *testing.T, cfg ingest.MuxerConfig) *ingest.IngestMuxer {
+	t.Helper()
+	cfg.IngesterName = `testingester`
+	cfg.IngesterVersion = `1.0`
+	cfg.IngesterUUID = `a2b3c4d5-0000-4000-8000-000000000001`
+	if len(cfg.Tags) == 0 {
+		cfg.Tags = []string{`test`}
+	}
+	im, err := ingest.NewMuxer(cfg)
+	if err != nil {
+		t.Fatal(err)
+	} else if err = im.Start(); err != nil {
+		t.Fatal(err)
+	} else if err = im.WaitForHot(testTimeout); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { im.Close() })
+	return im
+}
+
+func writeTestEntries(t *testing.T, im *ingest.IngestMuxer, tag string, start, count int) {
+	t.Helper()
+	tg, err := im.GetTag(tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := start; i < start+count; i++ {
+		ent := &entry.Entry{TS: entry.Now(), Tag: tg, Data: []byte(fmt.Sprintf("entry %d", i))}
+		if err = im.WriteEntry(ent); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = im.Sync(testTimeout); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIndexerTCP(t *testing.T) {
+	ix := newTestIndexer(t)
+	tgt, err := ix.ListenTCP(`127.0.0.1:0`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	im := newTestMuxer(t, ingest.MuxerConfig{
+		Destinations: []ingest.Target{{Address: tgt, Secret: testSecret}},
+		Tags:         []string{`test`, `other`},
+	})
+	writeTestEntries(t, im, `test`, 0, 100)
+	ents, err := ix.WaitEntries(100, testTimeout)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(ents) != 100 {
+		t.Fatalf("invalid entry count: %d", len(ents))
+	}
+	for i, ent := range ents {
+		if s := string(ent.Data); s != fmt.Sprintf("entry %d", i) {
+			t.Fatalf("bad entry %d: %s", i, s)
+		} else if name, ok := ix.TagName(ent.Tag); !ok || name != `test` {
+			t.Fatalf("bad entry tag: %v %v", name, ok)
+		}
+	}
+	if n := len(ix.TagEntries(`other`)); n != 0 {
+		t.Fatalf("unexpected entries on other tag: %d", n)
+	}
+
+	//tags negotiated after the connection came up
+	tg, err := im.NegotiateTag(`late`)
+	if err != nil {
+		t.Fatal(err)
+	} else if err = im.WriteEntry(&entry.Entry{TS: entry.Now(), Tag: tg, Data: []byte(`late`)}); err != nil {
+		t.Fatal(err)
+	} else if err = im.Sync(testTimeout); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = ix.WaitEntries(101, testTimeout); err != nil {
+		t.Fatal(err)
+	} else if ents = ix.TagEntries(`late`); len(ents) != 1 || string(ents[0].Data) != `late` {
+		t.Fatalf("bad late tag entries: %v", ents)
+	}
+
+	if igs := ix.Ingesters(); len(igs) != 1 || igs[0].Name != `testingester` || igs[0].Version != `1.0` {
+		t.Fatalf("bad ingesters: %+v", igs)
+	}
+	states, err := ix.WaitIngesterStates(1, testTimeout)
+	if err != nil {
+		t.Fatal(err)
+	} else if states[0].Name != `testingester` || states[0].UUID != `a2b3c4d5-0000-4000-8000-000000000001` {
+		t.Fatalf("bad ingester state: %+v", states[0])
+	}
+}
+
+func TestIndexerTLSPipe(t *testing.T) {
+	dir := t.TempDir()
+	cert, key, err := GenerateCert(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ix := newTestIndexer(t)
+	tlsTgt, err := ix.ListenTLS(`127.0.0.1:0`, cert, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pipeTgt, err := ix.ListenPipe(filepath.Join(dir, `pipe`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	newTestMuxer(t, ingest.MuxerConfig{
+		Destinations: []ingest.Target{{Address: tlsTgt, Secret: testSecret}},
+		PublicKey:    cert,
+		PrivateKey:   key,
+	})
+	im := newTestMuxer(t, ingest.MuxerConfig{
+		Destinations: []ingest.Target{{Address: pipeTgt, Secret: testSecret}},
+	})
+	if err = ix.WaitConnections(2, testTimeout); err != nil {
+		t.Fatal(err)
+	}
+	writeTestEntries(t, im, `test`, 0, 10)
+	if _, err = ix.WaitEntries(10, testTimeout); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIndexerAuth(t *testing.T) {
+	ix := newTestIndexer(t)
+	tgt, err := ix.ListenTCP(`127.0.0.1:0`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ic, err := ingest.InitializeConnection(tgt, `badsecret`, []string{`test`}, ``, ``, false); err == nil {
+		ic.Close()
+		t.Fatal("bad secret was accepted")
+	} else if ix.AuthFailures() != 1 {
+		t.Fatalf("auth failure not recorded: %v", err)
+	}
+	if ic, err := ingest.InitializeConnection(tgt, testSecret, []string{`bad tag`}, ``, ``, false); err == nil {
+		ic.Close()
+		t.Fatal("bad tag was accepted")
+	}
+}
+
+func TestIndexerFaults(t *testing.T) {
+	ix := newTestIndexer(t)
+	tgt, err := ix.ListenTCP(`127.0.0.1:0`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	im := newTestMuxer(t, ingest.MuxerConfig{
+		Destinations: []ingest.Target{{Address: tgt, Secret: testSecret}},
+	})
+	writeTestEntries(t, im, `test`, 0, 10)
+	if _, err = ix.WaitEntries(10, testTimeout); err != nil {
+		t.Fatal(err)
+	}
+
+	//the muxer reconnects after a drop
+	if n := ix.Disconnect(); n != 1 {
+		t.Fatalf("invalid disconnect count: %d", n)
+	} else if err = ix.WaitIngesters(2, testTimeout); err != nil {
+		t.Fatal(err)
+	} else if err = im.WaitForHot(testTimeout); err != nil {
+		t.Fatal(err)
+	}
+	writeTestEntries(t, im, `test`, 10, 10)
+	ents, err := ix.WaitEntries(20, testTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := map[string]bool{}
+	for _, ent := range ents {
+		seen[string(ent.Data)] = true
+	}
+	for i := 0; i < 20; i++ {
+		if !seen[fmt.Sprintf("entry %d", i)] {
+			t.Fatalf("entry %d was lost across the disconnect", i)
+		}
+	}
+
+	//slow acks hold up a sync
+	ix.Reset()
+	ix.SetAckDelay(50 * time.Millisecond)
+	start := time.Now()
+	writeTestEntries(t, im, `test`, 0, 5)
+	if d := time.Since(start); d < 200*time.Millisecond {
+		t.Fatalf("acks were not delayed: %v", d)
+	}
+	ix.SetAckDelay(0)
+
+	//a throttle is honored by the writer
+	if err = ix.Throttle(500 * time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	start = time.Now()
+	writeTestEntries(t, im, `test`, 5, 5)
+	if d := time.Since(start); d < 400*time.Millisecond {
+		t.Fatalf("throttle was not honored: %v", d)
+	}
+	if _, err = ix.WaitEntries(10, testTimeout); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPriorityTags(t *testing.T) {
+	ix := newTestIndexer(t)
+	tgt, err := ix.ListenTCP(`127.0.0.1:0`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	im := newTestMuxer(t, ingest.MuxerConfig{
+		Destinations: []ingest.Target{{Address: tgt, Secret: testSecret}},
+		Tags:         []string{`bulk`, `alerts`},
+		PriorityTags: []string{`alerts`},
+	})
+	bulk, err := im.GetTag(`bulk`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alerts, err := im.GetTag(`alerts`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//stall the writer and build up a backlog of bulk data, then queue up some alerts behind it
+	if err = ix.Throttle(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	const batches, batchSize = 50, 10
+	for i := 0; i < batches; i++ {
+		b := make([]*entry.Entry, 0, batchSize)
+		for j := 0; j < batchSize; j++ {
+			b = append(b, &entry.Entry{TS: entry.Now(), Tag: bulk, Data: []byte(fmt.Sprintf("bulk %d", i*batchSize+j))})
+		}
+		if err = im.WriteBatch(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	//mixed batches are split, the alerts ride the priority lane
+	if err = im.WriteBatch([]*entry.Entry{
+		{TS: entry.Now(), Tag: bulk, Data: []byte(`bulk tail`)},
+		{TS: entry.Now(), Tag: alerts, Data: []byte(`alert 0`)},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i < 5; i++ {
+		if err = im.WriteEntry(&entry.Entry{TS: entry.Now(), Tag: alerts, Data: []byte(fmt.Sprintf("alert %d", i))}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ents, err := ix.WaitEntries(batches*batchSize+6, testTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	//at most a couple of bulk batches were already in flight when the alerts showed up
+	var alertCount, lastAlert int
+	for i, ent := range ents {
+		if name, _ := ix.TagName(ent.Tag); name == `alerts` {
+			alertCount++
+			lastAlert = i
+		}
+	}
+	if alertCount != 5 {
+		t.Fatalf("expected 5 alerts, got %d", alertCount)
+	} else if lastAlert >= 3*batchSize+5 {
+		t.Fatalf("alerts waited behind the bulk data, last alert at %d of %d", lastAlert, len(ents))
+	}
+}
+
+func writeAckedBatch(t *testing.T, im *ingest.IngestMuxer, start, count int, ch chan error) {
+	t.Helper()
+	tg, err := im.GetTag(`test`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ents []*entry.Entry
+	for i := start; i < start+count; i++ {
+		ents = append(ents, &entry.Entry{TS: entry.Now(), Tag: tg, Data: []byte(fmt.Sprintf("entry %d", i))})
+	}
+	if err = im.WriteBatchAcked(context.Background(), ents, func(err error) { ch <- err }); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWriteBatchAcked(t *testing.T) {
+	ix := newTestIndexer(t)
+	tgt, err := ix.ListenTCP(`127.0.0.1:0`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	im := newTestMuxer(t, ingest.MuxerConfig{
+		Destinations: []ingest.Target{{Address: tgt, Secret: testSecret}},
+	})
+
+	//the callback waits on the indexer, not the queue
+	ix.SetAckDelay(50 * time.Millisecond)
+	ch := make(chan error, 1)
+	writeAckedBatch(t, im, 0, 10, ch)
+	select {
+	case err = <-ch:
+		t.Fatalf("callback fired before the indexer acknowledged: %v", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+	select {
+	case err = <-ch:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(testTimeout):
+		t.Fatal("callback never fired")
+	}
+	if _, err = ix.WaitEntries(10, testTimeout); err != nil {
+		t.Fatal(err)
+	}
+	ix.SetAckDelay(0)
+
+	//acknowledged batches survive a dropped connection
+	writeAckedBatch(t, im, 10, 10, ch)
+	ix.Disconnect()
+	select {
+	case err = <-ch:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(testTimeout):
+		t.Fatal("callback never fired after reconnect")
+	}
+	if _, err = ix.WaitEntries(20, testTimeout); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWriteBatchAckedOffline(t *testing.T) {
+	ix := newTestIndexer(t)
+	tgt, err := ix.ListenTCP(`127.0.0.1:0`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ix.Close() //nobody home
+
+	//without a cache the callback reports the loss on close
+	im, err := ingest.NewMuxer(ingest.MuxerConfig{
+		Destinations: []ingest.Target{{Address: tgt, Secret: testSecret}},
+		Tags:         []string{`test`},
+	})
+	if err != nil {
+		t.Fatal(err)
+	} else if err = im.Start(); err != nil {
+		t.Fatal(err)
+	}
+	ch := make(chan error, 3)
+	writeAckedBatch(t, im, 0, 10, ch)
+	if err = im.Close(); err != nil {
+		t.Fatal(err)
+	} else if err = <-ch; err != ingest.ErrNotRunning {
+		t.Fatalf("invalid error on close: %v", err)
+	}
+
+	//with a cache the callback fires once the entries are on disk
+	im, err = ingest.NewMuxer(ingest.MuxerConfig{
+		Destinations: []ingest.Target{{Address: tgt, Secret: testSecret}},
+		Tags:         []string{`test`},
+		CachePath:    t.TempDir(),
+		CacheMode:    ingest.CacheModeAlways,
+		CacheDepth:   1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	} else if err = im.Start(); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		writeAckedBatch(t, im, i*10, 10, ch)
+	}
+	select {
+	case err = <-ch:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(testTimeout):
+		t.Fatal("callback never fired for cached entries")
+	}
+	if err = im.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err = <-ch; err != nil {
+			t.Fatalf("cached batch failed: %v", err)
+		}
+	}
+}
+
+func waitActiveGroup(t *testing.T, im *ingest.IngestMuxer, group string) {
+	t.Helper()
+	deadline := time.Now().Add(testTimeout)
+	for {
+		if g, err := im.ActiveGroup(); err != nil {
+			t.Fatal(err)
+		} else if g == group {
+			return
+		} else if time.Now().After(deadline) {
+			t.Fatalf("active group is %q, expected %q", g, group)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestStandbyFailover(t *testing.T) {
+	primary := newTestIndexer(t)
+	ptgt, err := primary.ListenTCP(`127.0.0.1:0`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	standby := newTestIndexer(t)
+	stgt, err := standby.ListenTCP(`127.0.0.1:0`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	im := newTestMuxer(t, ingest.MuxerConfig{
+		Destinations: []ingest.Target{
+			{Address: ptgt, Secret: testSecret},
+			{Address: stgt, Secret: testSecret, Standby: true},
+		},
+	})
+	//the standby connects but does not get any data while the primary is up
+	if err = standby.WaitConnections(1, testTimeout); err != nil {
+		t.Fatal(err)
+	}
+	waitActiveGroup(t, im, ingest.DestinationGroupPrimary)
+	writeTestEntries(t, im, `test`, 0, 100)
+	if _, err = primary.WaitEntries(100, testTimeout); err != nil {
+		t.Fatal(err)
+	} else if n := standby.EntryCount(); n != 0 {
+		t.Fatalf("standby received %d entries while the primary was up", n)
+	} else if hot, err := im.Hot(); err != nil || hot != 1 {
+		t.Fatalf("bad hot count: %d %v", hot, err)
+	}
+
+	//take the primary down and keep it down, the standby picks up the data
+	primary.SetRefuseIngest(true)
+	primary.Disconnect()
+	waitActiveGroup(t, im, ingest.DestinationGroupStandby)
+	if hot, err := im.Hot(); err != nil || hot != 1 {
+		t.Fatalf("bad hot count after failover: %d %v", hot, err)
+	} else if dead, err := im.Dead(); err != nil || dead != 0 {
+		t.Fatalf("bad dead count after failover: %d %v", dead, err)
+	}
+	writeTestEntries(t, im, `test`, 100, 100)
+	if _, err = standby.WaitEntries(100, testTimeout); err != nil {
+		t.Fatal(err)
+	} else if n := primary.EntryCount(); n != 100 {
+		t.Fatalf("primary received %d entries while down", n)
+	}
+	err = standby.wait(testTimeout, func() bool {
+		for _, s := range standby.states {
+			if s.ActiveGroup == ingest.DestinationGroupStandby {
+				return true
+			}
+		}
+		return false
+	})
+	if err != nil {
+		t.Fatal("ingester state never reported the standby group")
+	}
+
+	//losing the standby while the primary is still down leaves data queued up until something comes back
+	standby.SetRefuseIngest(true)
+	standby.Disconnect()
+	time.Sleep(100 * time.Millisecond)
+	if g, err := im.ActiveGroup(); err != nil || g != ingest.DestinationGroupStandby {
+		t.Fatalf("bad active group with everything down: %q %v", g, err)
+	} else if hot, err := im.Hot(); err != nil || hot != 0 {
+		t.Fatalf("bad hot count with everything down: %d %v", hot, err)
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"io"
 	"math/rand"
 	"net"
 	"strings"
@@ -22,7 +23,7 @@ import (
 	"unicode"
 
 	"github.com/gravwell/gravwell/v4/ingest/entry"
-	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/zstd"
 )
 
 var (
@@ -32,6 +33,7 @@ var (
 
 const (
 	defaultKeepAliveInterval = 2 * time.Second
+	zstdWindowSize           = 1024 * 1024 // keep decoder memory bounded per connection
 )
 
 // LockedSource is actually in the Go stdlib, it's just not exported
@@ -217,25 +219,50 @@ func mergeError(ogErr, newErr error) error {
 	return newErr
 }
 
+type flushWriter interface {
+	io.Writer
+	Flush() error
+}
+
 // the klauspost snappy writer deprecated the writer that does simple writes and is now forcing a buffered writer
 // this is a little wrapper that forces a flush after every write because we need things to go to the wire when a write
 // happens. It's a hack to get around someone trying to help.
-type autoFlushSnappyWriter struct {
-	wtr *snappy.Writer
+// The zstd and lz4 stream writers behave the same way, so the wrapper is shared by every compressed stream.
+type autoFlushWriter struct {
+	wtr flushWriter
 }
 
-func newSnappyFlushWriter(wtr *snappy.Writer) *autoFlushSnappyWriter {
-	return &autoFlushSnappyWriter{
+func newAutoFlushWriter(wtr flushWriter) *autoFlushWriter {
+	return &autoFlushWriter{
 		wtr: wtr,
 	}
 }
 
-func (afsw *autoFlushSnappyWriter) Write(b []byte) (n int, err error) {
-	if afsw == nil || afsw.wtr == nil {
+func (afw *autoFlushWriter) Write(b []byte) (n int, err error) {
+	if afw == nil || afw.wtr == nil {
 		return -1, errors.New("bad writer")
 	}
-	if n, err = afsw.wtr.Write(b); err == nil {
-		err = afsw.wtr.Flush()
+	if n, err = afw.wtr.Write(b); err == nil {
+		err = afw.wtr.Flush()
 	}
 	return
 }
+
+// newZstdWriter creates a zstd stream encoder tuned for a low latency connection.
+// We use a single encoder goroutine so that a Flush always pushes complete blocks to the wire.
+func newZstdWriter(w io.Writer) (*zstd.Encoder, error) {
+	return zstd.NewWriter(w,
+		zstd.WithEncoderConcurrency(1),
+		zstd.WithEncoderLevel(zstd.SpeedDefault),
+		zstd.WithWindowSize(zstdWindowSize),
+	)
+}
+
+// newZstdReader creates a zstd stream decoder that decodes synchronously
+// so that flushed blocks are available as soon as they hit the wire.
+func newZstdReader(r io.Reader) (*zstd.Decoder, error) {
+	return zstd.NewReader(r,
+		zstd.WithDecoderConcurrency(1),
+		zstd.WithDecoderMaxWindow(zstdWindowSize),
+	)
+}
@@ -93,6 +93,8 @@ func main() {
 	// Now set up the *memory* persister which we'll actually hand in to the hub object.
 	// This saves on disk writes and keeps performance up.
 	memPersist := persist.NewMemoryPersister()
+	// The hub only sees an ack gated view of it, checkpoints move once entries are acknowledged.
+	hubPersist := ackPersister{CheckpointPersister: memPersist}
 
 	// These are the handlers listening to each individual partition
 	var listeners []*eventhubs.ListenerHandle
@@ -160,6 +162,11 @@ func main() {
 			if err != nil {
 				lg.Fatal("preprocessor construction failed", log.KVErr(err))
 			}
+			acked := true
+			if err = procset.AckSupported(); err != nil {
+				lg.Warn("preprocessors cannot acknowledge writes, checkpoints will be committed as soon as entries are written", log.KVErr(err))
+				acked = false
+			}
 			defer procset.Close()
 
 			// Set up authentication
@@ -169,7 +176,7 @@ func main() {
 			}
 
 			// Connect to the hub. We do this synchronously so we can bail out easier if one is misconfigured.
-			hub, err := eventhubs.NewHub(hubDef.Event_Hubs_Namespace, hubDef.Event_Hub, provider, eventhubs.HubWithOffsetPersistence(memPersist))
+			hub, err := eventhubs.NewHub(hubDef.Event_Hubs_Namespace, hubDef.Event_Hub, provider, eventhubs.HubWithOffsetPersistence(hubPersist))
 			if err != nil {
 				lg.Fatal("failed to connect to hub", log.KVErr(err))
 			}
@@ -235,40 +242,101 @@ func main() {
 				}
 			}
 
-			// This function gets called whenever an entry is received from an Events Hub partition.
-			// It packages the entry, extracts an appropriate timestamp, and sends it to the indexer.
-			callback := func(ctx context.Context, msg *eventhubs.Event) error {
-				ent := &entry.Entry{
-					Data: msg.Data,
-					Tag:  tagid,
-					SRC:  src,
-				}
-				size += uint64(len(msg.Data))
-				if !hubDef.Parse_Time {
-					if msg.SystemProperties != nil && msg.SystemProperties.EnqueuedTime != nil {
-						ent.TS = entry.FromStandard(*msg.SystemProperties.EnqueuedTime)
-					} else {
-						ent.TS = entry.Now()
+			// This function builds the handler for an Events Hub partition; the handler gets called
+			// whenever an entry is received.  It packages the entry, extracts an appropriate timestamp,
+			// and sends it to the indexer.  The partition checkpoint only advances once the entry
+			// and every entry before it has been acknowledged.  The first failed acknowledgement is
+			// sent on failed and every event after it is dropped, the receiver is restarted from the
+			// last committed checkpoint so those events are redelivered.
+			newHandler := func(partitionID string, failed chan<- error) eventhubs.Handler {
+				acks := utils.NewInOrderAcker()
+				return func(ctx context.Context, msg *eventhubs.Event) error {
+					if acks.Err() != nil {
+						return nil
+					}
+					ent := &entry.Entry{
+						Data: msg.Data,
+						Tag:  tagid,
+						SRC:  src,
 					}
-				} else {
-					ts, ok, err := tg.Extract(msg.Data)
-					if !ok || err != nil {
-						//  failed to extract, use the publishtime
-						hubDef.Parse_Time = false
+					size += uint64(len(msg.Data))
+					if !hubDef.Parse_Time {
 						if msg.SystemProperties != nil && msg.SystemProperties.EnqueuedTime != nil {
 							ent.TS = entry.FromStandard(*msg.SystemProperties.EnqueuedTime)
 						} else {
 							ent.TS = entry.Now()
 						}
 					} else {
-						ent.TS = entry.FromStandard(ts)
+						ts, ok, err := tg.Extract(msg.Data)
+						if !ok || err != nil {
+							//  failed to extract, use the publishtime
+							hubDef.Parse_Time = false
+							if msg.SystemProperties != nil && msg.SystemProperties.EnqueuedTime != nil {
+								ent.TS = entry.FromStandard(*msg.SystemProperties.EnqueuedTime)
+							} else {
+								ent.TS = entry.Now()
+							}
+						} else {
+							ent.TS = entry.FromStandard(ts)
+						}
 					}
+					checkpoint := msg.GetCheckpoint()
+					if !acked {
+						if err := procset.ProcessBatchContext([]*entry.Entry{ent}, exitCtx); err != nil {
+							lg.Error("failed to process entry", log.KVErr(err))
+						} else {
+							hubPersist.commit(hubDef.Event_Hubs_Namespace, hubDef.Event_Hub, hubDef.Consumer_Group, partitionID, checkpoint)
+						}
+						count++
+						return nil
+					}
+					ack := acks.Add(func() {
+						hubPersist.commit(hubDef.Event_Hubs_Namespace, hubDef.Event_Hub, hubDef.Consumer_Group, partitionID, checkpoint)
+					})
+					cb := func(err error) {
+						ack(err)
+						if err != nil {
+							select {
+							case failed <- err:
+							default:
+							}
+						}
+					}
+					if err := procset.ProcessBatchAcked([]*entry.Entry{ent}, exitCtx, cb); err != nil {
+						lg.Error("failed to process entry", log.KVErr(err))
+						cb(err)
+					}
+					count++
+					return nil
 				}
-				if err := procset.ProcessContext(ent, exitCtx); err != nil {
-					lg.Error("failed to process entry", log.KVErr(err))
+			}
+
+			// restartOnFailure replaces the receiver for a partition whenever its handler reports a
+			// failed acknowledgement.  The new receiver has no starting offset so it reads the last
+			// committed checkpoint from the persister and picks up with a fresh acker.
+			restartOnFailure := func(partitionID, cg string, failed chan error) {
+				for {
+					select {
+					case err := <-failed:
+						if exitCtx.Err() != nil {
+							return
+						}
+						lg.Warn("entry was not acknowledged, restarting partition receiver from last checkpoint",
+							log.KV("partition", partitionID), log.KVErr(err))
+						failed = make(chan error, 1)
+						if _, err = hub.Receive(
+							ctx,
+							partitionID,
+							newHandler(partitionID, failed),
+							eventhubs.ReceiveWithConsumerGroup(cg),
+						); err != nil {
+							lg.Error("failed to restart event hub partition receiver", log.KV("partition", partitionID), log.KVErr(err))
+							return
+						}
+					case <-quitSig:
+						return
+					}
 				}
-				count++
-				return nil
 			}
 
 			// get info about partitions in the hub
@@ -295,10 +363,14 @@ func main() {
 				if cg == `` {
 					cg = eventhubs.DefaultConsumerGroup
 				}
+				// seed the memory persister so a receiver which reconnects before anything is
+				// acknowledged picks up where we started
+				hubPersist.commit(hubDef.Event_Hubs_Namespace, hubDef.Event_Hub, hubDef.Consumer_Group, partitionID, checkpoint)
+				failed := make(chan error, 1)
 				handle, err := hub.Receive(
 					ctx,
 					partitionID,
-					callback,
+					newHandler(partitionID, failed),
 					eventhubs.ReceiveWithStartingOffset(checkpoint.Offset),
 					eventhubs.ReceiveWithConsumerGroup(cg),
 				)
@@ -306,6 +378,9 @@ func main() {
 					lg.Error("failed to start event hub partition receiver", log.KVErr(err))
 					return
 				}
+				if acked {
+					go restartOnFailure(partitionID, cg, failed)
+				}
 				listeners = append(listeners, handle)
 				readers = append(readers, readerInfo{hubDef.Event_Hubs_Namespace, hubDef.Event_Hub, hubDef.Consumer_Group, partitionID})
 				lg.Info("started receiver for partition", log.KV("consumer-group", cg), log.KV("partition", partitionID))
@@ -355,6 +430,24 @@ func debugout(format string, args ...interface{}) {
 	}
 }
 
+// ackPersister is handed to the hubs in place of the memory persister.  Receivers write a
+// checkpoint for every event as soon as the handler returns, which is before the indexer has
+// the entry, so those writes are dropped and checkpoints are only moved by commit.  Reads go
+// to the memory persister so a receiver which reconnects resumes from the last acknowledged event.
+type ackPersister struct {
+	persist.CheckpointPersister
+}
+
+func (ap ackPersister) Write(namespace, name, consumerGroup, partitionID string, checkpoint persist.Checkpoint) error {
+	return nil
+}
+
+func (ap ackPersister) commit(namespace, name, consumerGroup, partitionID string, checkpoint persist.Checkpoint) {
+	if err := ap.CheckpointPersister.Write(namespace, name, consumerGroup, partitionID, checkpoint); err != nil {
+		lg.Error("Failed to write checkpoint", log.KVErr(err))
+	}
+}
+
 type readerInfo struct {
 	namespace     string
 	hub           string
@@ -97,6 +97,11 @@ func main() {
 		if err != nil {
 			lg.Fatal("preprocessor construction failed", log.KVErr(err))
 		}
+		acked := true
+		if err = procset.AckSupported(); err != nil {
+			lg.Warn("preprocessors cannot acknowledge writes, messages will be acked as soon as entries are written", log.KV("subscription", psv.Subscription_Name), log.KVErr(err))
+			acked = false
+		}
 
 		// Get the subscription, creating if needed
 		subname := psv.Subscription_Name
@@ -150,11 +155,20 @@ func main() {
 		}
 
 		go func(sub *pubsub.Subscription, tagid entry.EntryTag, ps *pubsubconf) {
-			eChan := make(chan *entry.Entry, 2048)
-			go func(c chan *entry.Entry) {
-				for e := range c {
-					if err := procset.ProcessContext(e, exitCtx); err != nil {
+			eChan := make(chan pendingMsg, 2048)
+			go func(c chan pendingMsg) {
+				for pm := range c {
+					if !acked {
+						//without acknowledgement support the message is released as soon as it is written
+						err := procset.ProcessBatchContext([]*entry.Entry{pm.ent}, exitCtx)
+						if err != nil {
+							lg.Error("failed to process entry", log.KVErr(err))
+						}
+						pm.ack(err)
+					} else if err := procset.ProcessBatchAcked([]*entry.Entry{pm.ent}, exitCtx, pm.ack); err != nil {
+						//messages are only acked once the entry is confirmed by an indexer or committed to the cache
 						lg.Error("failed to process entry", log.KVErr(err))
+						pm.msg.Nack()
 					}
 					count++
 				}
@@ -218,9 +232,9 @@ func main() {
 						}
 					}
 					select {
-					case eChan <- ent:
-						msg.Ack()
+					case eChan <- pendingMsg{ent: ent, msg: msg}:
 					case <-ctx.Done():
+						msg.Nack()
 					}
 				}
 				if err := sub.Receive(cctx, callback); err != nil {
@@ -237,6 +251,21 @@ func main() {
 	exitFn()
 }
 
+type pendingMsg struct {
+	ent *entry.Entry
+	msg *pubsub.Message
+}
+
+// ack releases the message back to pubsub once the entry is acknowledged, failures are
+// nacked so the message is redelivered rather than waiting out the ack deadline
+func (pm pendingMsg) ack(err error) {
+	if err == nil {
+		pm.msg.Ack()
+	} else {
+		pm.msg.Nack()
+	}
+}
+
 func debugout(format string, args ...interface{}) {
 	if debugOn {
 		fmt.Printf(format, args...)
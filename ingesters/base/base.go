@@ -66,6 +66,7 @@ type IngesterBase struct {
 	id            uuid.UUID
 	emitUUID      bool
 	sm            *utils.StatsManager
+	metrics       *metricsServer
 	configFile    string
 	configOverlay string
 }
@@ -141,6 +142,12 @@ func Init(ibc IngesterBaseConfig) (ib IngesterBase, err error) {
 		err = fmt.Errorf("failed to get Stats Manager with interval %v - %v", cfg.StatsSampleInterval(), err)
 		return
 	}
+	if cfg.Metrics_Bind != `` {
+		if ib.metrics, err = newMetricsServer(cfg.Metrics_Bind, ibc.IngesterName, ib.sm, ib.Logger); err != nil {
+			err = fmt.Errorf("failed to start metrics server on %s - %w", cfg.Metrics_Bind, err)
+			return
+		}
+	}
 
 	return
 }
@@ -246,6 +253,31 @@ func (ib *IngesterBase) GetMuxer() (igst *ingest.IngestMuxer, err error) {
 	}
 	ib.Debug("Rate limiting connection to %d bps\n", lmt)
 
+	weights, err := cfg.TargetWeights()
+	if err != nil {
+		ib.Logger.FatalCode(0, "failed to get target weights from configuration", log.KVErr(err))
+		return
+	}
+	affinity, err := cfg.TagAffinity()
+	if err != nil {
+		ib.Logger.FatalCode(0, "failed to get tag affinity from configuration", log.KVErr(err))
+		return
+	}
+	priority, err := cfg.PriorityTags()
+	if err != nil {
+		ib.Logger.FatalCode(0, "failed to get priority tags from configuration", log.KVErr(err))
+		return
+	}
+	standby := cfg.StandbyTargets()
+	minPrimary, failover, failback, err := cfg.StandbyThresholds()
+	if err != nil {
+		ib.Logger.FatalCode(0, "failed to get standby thresholds from configuration", log.KVErr(err))
+		return
+	}
+	if len(standby) > 0 {
+		ib.Debug("Failing over to %d standby targets\n", len(standby))
+	}
+
 	//fire up the ingesters
 	ib.Debug("INSECURE skip TLS certificate verification: %v\n", cfg.InsecureSkipTLSVerification())
 	id, ok := cfg.IngesterUUID()
@@ -275,6 +307,15 @@ func (ib *IngesterBase) GetMuxer() (igst *ingest.IngestMuxer, err error) {
 		LogSourceOverride:  net.ParseIP(cfg.Log_Source_Override),
 		Attach:             ch.AttachConfig(),
 		MaxEntrySize:       cfg.Max_Entry_Size,
+		Weights:            weights,
+		TagAffinity:        affinity,
+		PriorityTags:       priority,
+		StandbyTargets:     standby,
+		Standby: ingest.StandbyConfig{
+			MinPrimary:    minPrimary,
+			FailoverDelay: failover,
+			FailbackDelay: failback,
+		},
 	}
 	if igst, err = ingest.NewUniformMuxer(igCfg); err != nil {
 		ib.Logger.Fatal("failed to build our ingest system", log.KVErr(err))
@@ -282,6 +323,7 @@ func (ib *IngesterBase) GetMuxer() (igst *ingest.IngestMuxer, err error) {
 	}
 
 	ib.Debug("Started ingester muxer\n")
+	ib.metrics.setMuxer(igst, id.String())
 	if cfg.SelfIngest() {
 		ib.Logger.AddRelay(igst)
 	}
@@ -431,6 +473,7 @@ func (ib IngesterBase) AnnounceShutdown() {
 	if ib.sm != nil {
 		ib.sm.Stop()
 	}
+	ib.metrics.close()
 }
 
 func (ib *IngesterBase) RegisterStat(name string) (*utils.StatsItem, error) {
@@ -0,0 +1,182 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package base
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gravwell/gravwell/v4/ingest"
+	"github.com/gravwell/gravwell/v4/ingest/log"
+	"github.com/gravwell/gravwell/v4/ingest/processors"
+	"github.com/gravwell/gravwell/v4/ingesters/utils"
+	"github.com/gravwell/gravwell/v4/ingesters/version"
+)
+
+const (
+	metricsPath          = `/metrics`
+	metricsPrefix        = `gravwell_ingester`
+	metricsReadTimeout   = 10 * time.Second
+	metricsShutdownDelay = 2 * time.Second
+)
+
+// metricsServer serves an OpenMetrics endpoint for the ingester.  The muxer is attached
+// once it is built, until then only the stats items and preprocessor counters are served.
+type metricsServer struct {
+	sync.Mutex
+	name string
+	uuid string
+	igst *ingest.IngestMuxer
+	sm   *utils.StatsManager
+	srv  *http.Server
+	lgr  *log.Logger
+}
+
+func newMetricsServer(bind, name string, sm *utils.StatsManager, lgr *log.Logger) (ms *metricsServer, err error) {
+	var lst net.Listener
+	if lst, err = net.Listen("tcp", bind); err != nil {
+		return
+	}
+	ms = &metricsServer{
+		name: name,
+		sm:   sm,
+		lgr:  lgr,
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(metricsPath, ms.serveMetrics)
+	ms.srv = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: metricsReadTimeout,
+	}
+	go func() {
+		if err := ms.srv.Serve(lst); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			lgr.Error("metrics server failed", log.KV("bind", bind), log.KVErr(err))
+		}
+	}()
+	return
+}
+
+func (ms *metricsServer) setMuxer(igst *ingest.IngestMuxer, uuid string) {
+	if ms != nil {
+		ms.Lock()
+		ms.igst, ms.uuid = igst, uuid
+		ms.Unlock()
+	}
+}
+
+func (ms *metricsServer) close() {
+	if ms == nil {
+		return
+	}
+	ctx, cf := context.WithTimeout(context.Background(), metricsShutdownDelay)
+	defer cf()
+	ms.srv.Shutdown(ctx)
+}
+
+func (ms *metricsServer) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	ms.Lock()
+	igst, uuid := ms.igst, ms.uuid
+	ms.Unlock()
+
+	w.Header().Set("Content-Type", utils.OpenMetricsContentType)
+	mw := utils.NewMetricsWriter(w)
+	mw.Write(metricsPrefix, utils.MetricInfo, `Ingester identity`, utils.MetricSample{
+		Labels: []utils.MetricLabel{{`name`, ms.name}, {`version`, version.GetVersion()}, {`uuid`, uuid}},
+		Value:  1,
+	})
+	if igst != nil {
+		writeMuxerMetrics(mw, igst.Metrics())
+	}
+	if ms.sm != nil {
+		tots := ms.sm.Totals()
+		names := make([]string, 0, len(tots))
+		for name := range tots {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		samples := make([]utils.MetricSample, 0, len(names))
+		for _, name := range names {
+			samples = append(samples, utils.MetricSample{Labels: []utils.MetricLabel{{`stat`, name}}, Value: float64(tots[name])})
+		}
+		mw.Write(metricsPrefix+`_stat`, utils.MetricCounter, `Ingester specific stats items`, samples...)
+	}
+	writeProcessorMetrics(mw, processors.GetProcessorStats())
+	if err := mw.Close(); err != nil {
+		ms.lgr.Info("failed to write metrics", log.KV("remote", r.RemoteAddr), log.KVErr(err))
+	}
+}
+
+func sample(v float64) utils.MetricSample {
+	return utils.MetricSample{Value: v}
+}
+
+func writeMuxerMetrics(mw *utils.MetricsWriter, m ingest.MuxerMetrics) {
+	mw.Write(metricsPrefix+`_uptime_seconds`, utils.MetricGauge, `Time since the ingest muxer started`, sample(m.Uptime.Seconds()))
+	mw.Write(metricsPrefix+`_destinations`, utils.MetricGauge, `Configured indexer destinations`, sample(float64(m.Destinations)))
+	mw.Write(metricsPrefix+`_connections`, utils.MetricGauge, `Indexer connections by state`,
+		utils.MetricSample{Labels: []utils.MetricLabel{{`state`, `hot`}}, Value: float64(m.Hot)},
+		utils.MetricSample{Labels: []utils.MetricLabel{{`state`, `dead`}}, Value: float64(m.Dead)},
+	)
+	if m.ActiveGroup != `` {
+		mw.Write(metricsPrefix+`_destination_group`, utils.MetricGauge, `Indexer destination group receiving data`, utils.MetricSample{
+			Labels: []utils.MetricLabel{{`group`, m.ActiveGroup}},
+			Value:  1,
+		})
+	}
+	mw.Write(metricsPrefix+`_children`, utils.MetricGauge, `Child ingesters reporting through this ingester`, sample(float64(m.Children)))
+	mw.Write(metricsPrefix+`_entries`, utils.MetricCounter, `Entries handed to the ingest muxer`, sample(float64(m.Entries)))
+	mw.Write(metricsPrefix+`_bytes`, utils.MetricCounter, `Bytes of entry data handed to the ingest muxer`, sample(float64(m.Bytes)))
+
+	var cacheEnabled float64
+	if m.CacheEnabled {
+		cacheEnabled = 1
+	}
+	mw.Write(metricsPrefix+`_cache_enabled`, utils.MetricGauge, `Ingest cache is enabled`, utils.MetricSample{
+		Labels: []utils.MetricLabel{{`mode`, m.CacheState}},
+		Value:  cacheEnabled,
+	})
+	if m.CacheEnabled {
+		mw.Write(metricsPrefix+`_cache_bytes`, utils.MetricGauge, `Bytes committed to the on-disk ingest cache`, sample(float64(m.CacheSize)))
+		mw.Write(metricsPrefix+`_cache_depth`, utils.MetricGauge, `Entries and blocks buffered in the in-memory ingest cache`, sample(float64(m.CacheDepth)))
+	}
+
+	entries := make([]utils.MetricSample, 0, len(m.Tags))
+	bts := make([]utils.MetricSample, 0, len(m.Tags))
+	for _, tm := range m.Tags {
+		lbls := []utils.MetricLabel{{`tag`, tm.Name}}
+		entries = append(entries, utils.MetricSample{Labels: lbls, Value: float64(tm.Entries)})
+		bts = append(bts, utils.MetricSample{Labels: lbls, Value: float64(tm.Bytes)})
+	}
+	mw.Write(metricsPrefix+`_tag_entries`, utils.MetricCounter, `Entries handed to the ingest muxer by tag`, entries...)
+	mw.Write(metricsPrefix+`_tag_bytes`, utils.MetricCounter, `Bytes of entry data handed to the ingest muxer by tag`, bts...)
+}
+
+func writeProcessorMetrics(mw *utils.MetricsWriter, stats []processors.ProcessorStats) {
+	in := make([]utils.MetricSample, 0, len(stats))
+	out := make([]utils.MetricSample, 0, len(stats))
+	errs := make([]utils.MetricSample, 0, len(stats))
+	for _, ps := range stats {
+		lbls := []utils.MetricLabel{{`preprocessor`, ps.Name}, {`type`, ps.Type}}
+		in = append(in, utils.MetricSample{Labels: lbls, Value: float64(ps.In)})
+		out = append(out, utils.MetricSample{Labels: lbls, Value: float64(ps.Out)})
+		errs = append(errs, utils.MetricSample{Labels: lbls, Value: float64(ps.Errors)})
+	}
+	mw.Write(metricsPrefix+`_preprocessor_entries_in`, utils.MetricCounter, `Entries handed to a preprocessor`, in...)
+	mw.Write(metricsPrefix+`_preprocessor_entries_out`, utils.MetricCounter, `Entries emitted by a preprocessor`, out...)
+	mw.Write(metricsPrefix+`_preprocessor_errors`, utils.MetricCounter, `Preprocessor calls that returned an error`, errs...)
+}
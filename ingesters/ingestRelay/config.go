@@ -0,0 +1,222 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/gravwell/gravwell/v4/ingest"
+	"github.com/gravwell/gravwell/v4/ingest/attach"
+	"github.com/gravwell/gravwell/v4/ingest/config"
+	"github.com/gravwell/gravwell/v4/ingest/entry"
+	"github.com/gravwell/gravwell/v4/ingest/processors"
+)
+
+const (
+	defaultCleartextPort uint16 = 4023
+	defaultTLSPort       uint16 = 4024
+
+	bindTCP  = `tcp`
+	bindTLS  = `tls`
+	bindPipe = `pipe`
+)
+
+var (
+	ErrNoListeners = errors.New("No listeners specified")
+	ErrNoSecret    = errors.New("Ingest-Secret is required")
+)
+
+// listener accepts ingesters speaking the native ingest protocol.  Bind-String is a
+// tcp://, tls://, or pipe:// address, a bare address is treated as tcp.  Every listener
+// has its own Ingest-Secret so a compromised DMZ secret does not expose the upstream
+// indexers.  Allowed-Tag restricts the tags ingesters may negotiate and accepts glob
+// patterns such as windows-*, no Allowed-Tag allows every tag.
+type listener struct {
+	Bind_String   string
+	Cert_File     string
+	Key_File      string
+	Ingest_Secret string `json:"-"` // DO NOT send this when marshalling
+	Allowed_Tag   []string
+	Preprocessor  []string
+}
+
+type cfgReadType struct {
+	Global       config.IngestConfig
+	Attach       attach.AttachConfig `gcfg:",section=raw,ident=regex"`
+	Listener     map[string]*listener
+	Preprocessor processors.ProcessorConfig
+}
+
+type cfgType struct {
+	config.IngestConfig
+	Attach       attach.AttachConfig `gcfg:",section=raw,ident=regex"`
+	Listener     map[string]*listener
+	Preprocessor processors.ProcessorConfig
+}
+
+func GetConfig(path, overlayPath string) (*cfgType, error) {
+	var cr cfgReadType
+	if err := config.LoadConfigFile(&cr, path); err != nil {
+		return nil, err
+	} else if err = config.LoadConfigOverlays(&cr, overlayPath); err != nil {
+		return nil, err
+	}
+	c := &cfgType{
+		IngestConfig: cr.Global,
+		Attach:       cr.Attach,
+		Listener:     cr.Listener,
+		Preprocessor: cr.Preprocessor,
+	}
+	if err := c.Verify(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *cfgType) Verify() error {
+	if err := c.IngestConfig.Verify(); err != nil {
+		return err
+	} else if err = c.Attach.Verify(); err != nil {
+		return err
+	} else if err = c.Preprocessor.Validate(); err != nil {
+		return err
+	}
+	if len(c.Listener) == 0 {
+		return ErrNoListeners
+	}
+	bindMp := make(map[string]string, len(c.Listener))
+	for k, v := range c.Listener {
+		if v == nil {
+			return fmt.Errorf("Listener %s is empty", k)
+		}
+		if err := v.validate(); err != nil {
+			return fmt.Errorf("Listener %s %w", k, err)
+		}
+		if n, ok := bindMp[v.Bind_String]; ok {
+			return fmt.Errorf("Listener %s Bind-String %s already in use by %s", k, v.Bind_String, n)
+		}
+		bindMp[v.Bind_String] = k
+		if err := c.Preprocessor.CheckProcessors(v.Preprocessor); err != nil {
+			return fmt.Errorf("Listener %s preprocessor invalid: %v", k, err)
+		}
+	}
+	return nil
+}
+
+// Tags returns the literal allowed tags so they are negotiated up front, everything
+// else is negotiated as relayed ingesters ask for it.
+func (c *cfgType) Tags() ([]string, error) {
+	tagMp := map[string]bool{entry.DefaultTagName: true}
+	for _, v := range c.Listener {
+		for _, t := range v.Allowed_Tag {
+			if !strings.ContainsAny(t, `*?[`) {
+				tagMp[t] = true
+			}
+		}
+	}
+	tags := make([]string, 0, len(tagMp))
+	for k := range tagMp {
+		tags = append(tags, k)
+	}
+	sort.Strings(tags)
+	return tags, nil
+}
+
+func (c *cfgType) IngestBaseConfig() config.IngestConfig {
+	return c.IngestConfig
+}
+
+func (c *cfgType) AttachConfig() attach.AttachConfig {
+	return c.Attach
+}
+
+func (l *listener) validate() (err error) {
+	var addr string
+	var bt string
+	if bt, addr, err = l.bind(); err != nil {
+		return
+	}
+	switch bt {
+	case bindTLS:
+		if l.Cert_File == `` || l.Key_File == `` {
+			return errors.New("TLS listeners require Cert-File and Key-File")
+		} else if _, err = tls.LoadX509KeyPair(l.Cert_File, l.Key_File); err != nil {
+			return
+		}
+		fallthrough
+	case bindTCP:
+		if _, err = net.ResolveTCPAddr(`tcp`, addr); err != nil {
+			return
+		}
+	}
+	if l.Ingest_Secret == `` {
+		return ErrNoSecret
+	}
+	for _, t := range l.Allowed_Tag {
+		if _, err = path.Match(t, ``); err != nil {
+			return fmt.Errorf("Allowed-Tag %q is invalid: %w", t, err)
+		} else if !strings.ContainsAny(t, `*?[`) {
+			if err = ingest.CheckTag(t); err != nil {
+				return fmt.Errorf("Allowed-Tag %q is invalid: %w", t, err)
+			}
+		}
+	}
+	return
+}
+
+// bind returns the listener type and the address, default ports are added to network addresses
+func (l *listener) bind() (bt, addr string, err error) {
+	bt, addr = bindTCP, strings.TrimSpace(l.Bind_String)
+	if bits := strings.SplitN(addr, `://`, 2); len(bits) == 2 {
+		bt, addr = strings.ToLower(bits[0]), bits[1]
+	}
+	if addr == `` {
+		err = errors.New("No Bind-String provided")
+		return
+	}
+	switch bt {
+	case bindTCP:
+		addr = config.AppendDefaultPort(addr, defaultCleartextPort)
+	case bindTLS:
+		addr = config.AppendDefaultPort(addr, defaultTLSPort)
+	case bindPipe:
+	default:
+		err = fmt.Errorf("invalid bind protocol specifier %q", bt)
+	}
+	return
+}
+
+// listen opens the network listener described by the Bind-String
+func (l *listener) listen() (lst net.Listener, err error) {
+	var bt, addr string
+	if bt, addr, err = l.bind(); err != nil {
+		return
+	}
+	switch bt {
+	case bindTCP:
+		lst, err = net.Listen(`tcp`, addr)
+	case bindTLS:
+		var cert tls.Certificate
+		if cert, err = tls.LoadX509KeyPair(l.Cert_File, l.Key_File); err == nil {
+			lst, err = tls.Listen(`tcp`, addr, &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{cert},
+			})
+		}
+	case bindPipe:
+		lst, err = net.Listen(`unix`, addr)
+	}
+	return
+}
@@ -0,0 +1,123 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+// The ingest relay terminates the native ingest protocol from downstream ingesters,
+// runs their entries through preprocessors, and forwards them upstream through a
+// cached ingest muxer.  Relayed ingesters are reported upstream as children.
+package main
+
+import (
+	"fmt"
+	"os"
+
+	// Embed tzdata so that we don't rely on potentially broken timezone DBs on the host
+	_ "time/tzdata"
+
+	"github.com/gravwell/gravwell/v4/debug"
+	"github.com/gravwell/gravwell/v4/ingest"
+	"github.com/gravwell/gravwell/v4/ingest/log"
+	"github.com/gravwell/gravwell/v4/ingest/processors"
+	"github.com/gravwell/gravwell/v4/ingesters/base"
+	"github.com/gravwell/gravwell/v4/ingesters/utils"
+)
+
+const (
+	defaultConfigLoc  = `/opt/gravwell/etc/ingest_relay.conf`
+	defaultConfigDLoc = `/opt/gravwell/etc/ingest_relay.conf.d`
+	appName           = `ingestrelay`
+)
+
+var (
+	lg *log.Logger
+)
+
+func main() {
+	go debug.HandleDebugSignals(appName)
+	var cfg *cfgType
+	ibc := base.IngesterBaseConfig{
+		IngesterName:                 appName,
+		AppName:                      appName,
+		DefaultConfigLocation:        defaultConfigLoc,
+		DefaultConfigOverlayLocation: defaultConfigDLoc,
+		GetConfigFunc:                GetConfig,
+	}
+	ib, err := base.Init(ibc)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to get configuration %v\n", err)
+		return
+	} else if err = ib.AssignConfig(&cfg); err != nil || cfg == nil {
+		fmt.Fprintf(os.Stderr, "failed to assign configuration %v %v\n", err, cfg == nil)
+		return
+	}
+	lg = ib.Logger
+	id, ok := cfg.IngesterUUID()
+	if !ok {
+		lg.FatalCode(0, "could not read ingester UUID")
+	}
+
+	igst, err := ib.GetMuxer()
+	if err != nil {
+		lg.FatalCode(0, "failed to get ingest connection", log.KVErr(err))
+		return
+	}
+	defer igst.Close()
+	ib.AnnounceStartup()
+
+	kids := newChildren(igst)
+	var listeners []*relayListener
+	var procs []*processors.ProcessorSet
+	for k, v := range cfg.Listener {
+		rc := relayConfig{
+			name:    k,
+			allowed: v.Allowed_Tag,
+			up:      igst,
+			kids:    kids,
+			lg:      lg,
+		}
+		if rc.auth, err = ingest.GenAuthHash(v.Ingest_Secret); err != nil {
+			lg.Fatal("failed to generate auth hash", log.KV("listener", k), log.KVErr(err))
+		}
+		ps, err := cfg.Preprocessor.ProcessorSet(igst, v.Preprocessor)
+		if err != nil {
+			lg.Fatal("preprocessor error", log.KV("listener", k), log.KV("preprocessor", v.Preprocessor), log.KVErr(err))
+		}
+		rc.proc = ps
+		procs = append(procs, ps)
+		lst, err := v.listen()
+		if err != nil {
+			lg.Fatal("failed to listen", log.KV("listener", k), log.KV("bindstring", v.Bind_String), log.KVErr(err))
+		}
+		rl := newRelayListener(rc, lst)
+		rl.Start()
+		listeners = append(listeners, rl)
+		ib.Debug("Listener %s accepting ingesters on %s\n", k, v.Bind_String)
+	}
+
+	//listen for the stop signal so we can die gracefully
+	utils.WaitForQuit()
+	ib.AnnounceShutdown()
+
+	for _, rl := range listeners {
+		if err := rl.Close(); err != nil {
+			lg.Error("failed to close listener", log.KV("listener", rl.name), log.KVErr(err))
+		}
+	}
+	for _, ps := range procs {
+		if err := ps.Close(); err != nil {
+			lg.Error("failed to close preprocessors", log.KVErr(err))
+		}
+	}
+
+	lg.Info("ingest relay exiting", log.KV("ingesteruuid", id))
+	if err := igst.Sync(utils.ExitSyncTimeout); err != nil {
+		lg.Error("failed to sync", log.KVErr(err))
+	}
+	if err := igst.Close(); err != nil {
+		lg.Error("failed to close", log.KVErr(err))
+	}
+}
@@ -0,0 +1,350 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package main
+
+import (
+	"errors"
+	"io"
+	"net"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/gravwell/gravwell/v4/ingest"
+	"github.com/gravwell/gravwell/v4/ingest/entry"
+	"github.com/gravwell/gravwell/v4/ingest/log"
+)
+
+const (
+	authTimeout = 5 * time.Second
+)
+
+var (
+	ErrTagNotAllowed    = errors.New("tag is not allowed on this listener")
+	ErrTagNotNegotiated = errors.New("entry tag was not negotiated on this connection")
+)
+
+// entryProcessor is the preprocessor set entries are pushed through on their way to the muxer
+type entryProcessor interface {
+	Process(*entry.Entry) error
+}
+
+// upstream is the subset of the IngestMuxer the relay forwards into
+type upstream interface {
+	NegotiateTag(string) (entry.EntryTag, error)
+	RegisterChild(string, ingest.IngesterState)
+	UnregisterChild(string)
+}
+
+type relayConfig struct {
+	name    string
+	auth    ingest.AuthHash
+	allowed []string //tag patterns, empty allows everything
+	proc    entryProcessor
+	up      upstream
+	kids    *children
+	lg      *log.Logger
+}
+
+// relayListener terminates the ingest protocol for a single listener and pushes
+// entries from every authenticated ingester into the preprocessor set.
+type relayListener struct {
+	relayConfig
+	mtx    sync.Mutex
+	wg     sync.WaitGroup
+	lst    net.Listener
+	conns  map[net.Conn]struct{}
+	closed bool
+}
+
+func newRelayListener(rc relayConfig, lst net.Listener) *relayListener {
+	return &relayListener{
+		relayConfig: rc,
+		lst:         lst,
+		conns:       map[net.Conn]struct{}{},
+	}
+}
+
+func (rl *relayListener) Start() {
+	rl.wg.Add(1)
+	go rl.acceptRoutine()
+}
+
+// Close stops accepting, drops every ingester connection, and waits for them to exit
+func (rl *relayListener) Close() (err error) {
+	rl.mtx.Lock()
+	rl.closed = true
+	err = rl.lst.Close()
+	for c := range rl.conns {
+		c.Close()
+	}
+	rl.mtx.Unlock()
+	rl.wg.Wait()
+	return
+}
+
+func (rl *relayListener) acceptRoutine() {
+	defer rl.wg.Done()
+	for {
+		c, err := rl.lst.Accept()
+		if err != nil {
+			rl.mtx.Lock()
+			closed := rl.closed
+			rl.mtx.Unlock()
+			if closed {
+				return
+			}
+			//transient accept failures should not take the listener down
+			rl.lg.Warn("failed to accept connection", log.KV("listener", rl.name), log.KVErr(err))
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+		if !rl.addConn(c) {
+			c.Close()
+			return
+		}
+		rl.wg.Add(1)
+		go rl.connRoutine(c)
+	}
+}
+
+func (rl *relayListener) addConn(c net.Conn) bool {
+	rl.mtx.Lock()
+	defer rl.mtx.Unlock()
+	if rl.closed {
+		return false
+	}
+	rl.conns[c] = struct{}{}
+	return true
+}
+
+func (rl *relayListener) delConn(c net.Conn) {
+	rl.mtx.Lock()
+	delete(rl.conns, c)
+	rl.mtx.Unlock()
+	c.Close()
+}
+
+func (rl *relayListener) connRoutine(c net.Conn) {
+	defer rl.wg.Done()
+	defer rl.delConn(c)
+	remote := c.RemoteAddr().String()
+	if err := c.SetDeadline(time.Now().Add(authTimeout)); err != nil {
+		return
+	}
+	rc := newRelayConn(rl)
+	if _, err := ingest.AuthenticateIngester(c, rl.auth, rc); err != nil {
+		rl.lg.Warn("ingester failed to authenticate", log.KV("listener", rl.name), log.KV("remote", remote), log.KVErr(err))
+		return
+	} else if err = c.SetDeadline(time.Time{}); err != nil {
+		return
+	}
+	er, err := ingest.NewEntryReader(c)
+	if err != nil {
+		rl.lg.Error("failed to create entry reader", log.KV("listener", rl.name), log.KV("remote", remote), log.KVErr(err))
+		return
+	}
+	er.SetTagManager(rc)
+	if err = er.Start(); err != nil {
+		rl.lg.Error("failed to start entry reader", log.KV("listener", rl.name), log.KV("remote", remote), log.KVErr(err))
+		return
+	}
+	defer er.Close()
+	if err = er.SetupConnection(); err != nil {
+		rl.lg.Warn("failed to set up ingester connection", log.KV("listener", rl.name), log.KV("remote", remote), log.KVErr(err))
+		return
+	}
+
+	//register the ingester as a child so it shows up upstream, state updates replace this
+	name, version, id := er.GetIngesterInfo()
+	key := id
+	if key == `` {
+		key = remote
+	}
+	st := ingest.IngesterState{
+		UUID:     id,
+		Name:     name,
+		Version:  version,
+		IP:       remoteIP(c),
+		Children: map[string]ingest.IngesterState{},
+	}
+	rl.kids.add(key, st)
+	defer rl.kids.remove(key)
+	er.AddIngesterStateCallback(func(s ingest.IngesterState) {
+		s.IP = st.IP
+		rl.kids.update(key, s)
+	})
+
+	if err = er.IngestOK(true); err != nil {
+		rl.lg.Warn("ingest OK exchange failed", log.KV("listener", rl.name), log.KV("remote", remote), log.KVErr(err))
+		return
+	} else if err = er.ConfigureStream(); err != nil {
+		rl.lg.Warn("failed to configure stream", log.KV("listener", rl.name), log.KV("remote", remote), log.KVErr(err))
+		return
+	}
+	rl.lg.Info("ingester connected", log.KV("listener", rl.name), log.KV("remote", remote),
+		log.KV("ingester", name), log.KV("version", version), log.KV("ingesteruuid", id))
+	if err = rc.relay(er); err != nil {
+		rl.lg.Warn("ingester connection closed", log.KV("listener", rl.name), log.KV("remote", remote),
+			log.KV("ingester", name), log.KV("ingesteruuid", id), log.KVErr(err))
+	}
+}
+
+// relayConn is the tag manager for a single ingester connection.  It records the tags
+// the ingester negotiated so that entries carrying any other tag ID are rejected rather
+// than landing on whatever muxer tag happens to have that ID.
+type relayConn struct {
+	rl   *relayListener
+	mtx  sync.RWMutex
+	tags map[entry.EntryTag]struct{}
+}
+
+func newRelayConn(rl *relayListener) *relayConn {
+	return &relayConn{
+		rl:   rl,
+		tags: map[entry.EntryTag]struct{}{},
+	}
+}
+
+// GetAndPopulate implements the ingest.TagManager interface for the connection
+func (rc *relayConn) GetAndPopulate(name string) (tg entry.EntryTag, err error) {
+	if tg, err = rc.rl.GetAndPopulate(name); err == nil {
+		rc.mtx.Lock()
+		rc.tags[tg] = struct{}{}
+		rc.mtx.Unlock()
+	}
+	return
+}
+
+func (rc *relayConn) negotiated(tg entry.EntryTag) (ok bool) {
+	rc.mtx.RLock()
+	_, ok = rc.tags[tg]
+	rc.mtx.RUnlock()
+	return
+}
+
+// relay reads entries until the connection closes, a clean close returns nil.
+// An entry or ditto block carrying a tag the ingester did not negotiate drops the connection.
+func (rc *relayConn) relay(er *ingest.EntryReader) (err error) {
+	var ent *entry.Entry
+	for {
+		if ent, err = er.Read(); err == nil {
+			if !rc.negotiated(ent.Tag) {
+				return ErrTagNotNegotiated
+			} else if err = rc.rl.proc.Process(ent); err != nil {
+				return
+			}
+			continue
+		} else if err != ingest.ErrPendingDittoBlock {
+			break
+		}
+		var block []*entry.Entry
+		if block, err = er.GetPendingDittoBlock(); err != nil {
+			return
+		}
+		for _, ent = range block {
+			if !rc.negotiated(ent.Tag) {
+				er.NackDittoBlock()
+				return ErrTagNotNegotiated
+			}
+		}
+		for _, ent = range block {
+			if err = rc.rl.proc.Process(ent); err != nil {
+				er.NackDittoBlock()
+				return
+			}
+		}
+		if err = er.AckDittoBlock(); err != nil {
+			return
+		}
+	}
+	if isClosed(err) {
+		err = nil
+	}
+	return
+}
+
+// GetAndPopulate implements the ingest.TagManager interface.  Tags are checked against
+// the allow list and negotiated with the muxer, so entries arrive with muxer tag IDs.
+func (rl *relayListener) GetAndPopulate(name string) (tg entry.EntryTag, err error) {
+	if !rl.tagAllowed(name) {
+		rl.lg.Warn("ingester requested a tag which is not allowed", log.KV("listener", rl.name), log.KV("tag", name))
+		err = ErrTagNotAllowed
+		return
+	}
+	tg, err = rl.up.NegotiateTag(name)
+	return
+}
+
+// tagAllowed checks a tag against the allow list, the gravwell tag carries ingester
+// logs and is always allowed.
+func (rl *relayListener) tagAllowed(name string) bool {
+	if len(rl.allowed) == 0 || name == entry.GravwellTagName {
+		return true
+	}
+	for _, p := range rl.allowed {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// children tracks relayed ingesters across every listener and registers them with the
+// muxer.  An ingester may hold several connections, so they are reference counted.
+type children struct {
+	mtx  sync.Mutex
+	up   upstream
+	refs map[string]int
+}
+
+func newChildren(up upstream) *children {
+	return &children{
+		up:   up,
+		refs: map[string]int{},
+	}
+}
+
+func (c *children) add(key string, st ingest.IngesterState) {
+	c.mtx.Lock()
+	c.refs[key]++
+	c.up.RegisterChild(key, st)
+	c.mtx.Unlock()
+}
+
+func (c *children) update(key string, st ingest.IngesterState) {
+	c.mtx.Lock()
+	if c.refs[key] > 0 {
+		c.up.RegisterChild(key, st)
+	}
+	c.mtx.Unlock()
+}
+
+func (c *children) remove(key string) {
+	c.mtx.Lock()
+	if c.refs[key]--; c.refs[key] <= 0 {
+		delete(c.refs, key)
+		c.up.UnregisterChild(key)
+	}
+	c.mtx.Unlock()
+}
+
+func remoteIP(c net.Conn) net.IP {
+	switch v := c.RemoteAddr().(type) {
+	case *net.TCPAddr:
+		return v.IP
+	case *net.UnixAddr:
+		return net.IPv4(127, 0, 0, 1)
+	}
+	return nil
+}
+
+func isClosed(err error) bool {
+	return err == nil || errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed)
+}
@@ -0,0 +1,273 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package main
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/gravwell/gravwell/v4/ingest"
+	"github.com/gravwell/gravwell/v4/ingest/entry"
+	"github.com/gravwell/gravwell/v4/ingest/log"
+	"github.com/gravwell/gravwell/v4/ingest/testindexer"
+)
+
+const (
+	testUpstreamSecret = `upstream`
+	testRelaySecret    = `dmz`
+	testChildUUID      = `0b5e2a4c-1111-4222-8333-444455556666`
+	testTimeout        = 10 * time.Second
+)
+
+// testProc writes straight to the muxer in place of a preprocessor set
+type testProc struct {
+	im *ingest.IngestMuxer
+}
+
+func (p testProc) Process(ent *entry.Entry) error {
+	return p.im.WriteEntry(ent)
+}
+
+func startTestMuxer(t *testing.T, cfg ingest.MuxerConfig) *ingest.IngestMuxer {
+	t.Helper()
+	im, err := ingest.NewMuxer(cfg)
+	if err != nil {
+		t.Fatal(err)
+	} else if err = im.Start(); err != nil {
+		t.Fatal(err)
+	} else if err = im.WaitForHot(testTimeout); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { im.Close() })
+	return im
+}
+
+// startTestRelay builds an upstream indexer, the relay muxer, and a relay listener
+func startTestRelay(t *testing.T, allowed []string) (*testindexer.Indexer, *relayListener) {
+	t.Helper()
+	ix, err := testindexer.New(testUpstreamSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ix.Close() })
+	tgt, err := ix.ListenTCP(`127.0.0.1:0`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	up := startTestMuxer(t, ingest.MuxerConfig{
+		Destinations: []ingest.Target{{Address: tgt, Secret: testUpstreamSecret}},
+		Tags:         []string{entry.DefaultTagName},
+		IngesterName: appName,
+		IngesterUUID: `9f0e1d2c-0000-4000-8000-000000000000`,
+	})
+	rc := relayConfig{
+		name:    `test`,
+		allowed: allowed,
+		proc:    testProc{im: up},
+		up:      up,
+		kids:    newChildren(up),
+		lg:      log.NewDiscardLogger(),
+	}
+	if rc.auth, err = ingest.GenAuthHash(testRelaySecret); err != nil {
+		t.Fatal(err)
+	}
+	lst, err := net.Listen(`tcp`, `127.0.0.1:0`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rl := newRelayListener(rc, lst)
+	rl.Start()
+	t.Cleanup(func() { rl.Close() })
+	return ix, rl
+}
+
+func waitChildState(t *testing.T, ix *testindexer.Indexer, present bool) {
+	t.Helper()
+	for deadline := time.Now().Add(testTimeout); time.Now().Before(deadline); time.Sleep(100 * time.Millisecond) {
+		if states := ix.IngesterStates(); len(states) > 0 {
+			if _, ok := states[len(states)-1].Children[testChildUUID]; ok == present {
+				return
+			}
+		}
+	}
+	t.Fatalf("relayed ingester presence never became %v", present)
+}
+
+func TestRelay(t *testing.T) {
+	ix, rl := startTestRelay(t, []string{`syslog`, `win-*`})
+	child, err := ingest.NewMuxer(ingest.MuxerConfig{
+		Destinations: []ingest.Target{{Address: `tcp://` + rl.lst.Addr().String(), Secret: testRelaySecret}},
+		Tags:         []string{`syslog`, `win-security`},
+		IngesterName: `child`,
+		IngesterUUID: testChildUUID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	} else if err = child.Start(); err != nil {
+		t.Fatal(err)
+	} else if err = child.WaitForHot(testTimeout); err != nil {
+		t.Fatal(err)
+	}
+	defer child.Close()
+
+	for _, tag := range []string{`syslog`, `win-security`} {
+		tg, err := child.GetTag(tag)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 5; i++ {
+			if err = child.WriteEntry(&entry.Entry{TS: entry.Now(), Tag: tg, Data: []byte(tag)}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err = child.Sync(testTimeout); err != nil {
+		t.Fatal(err)
+	} else if _, err = ix.WaitEntries(10, testTimeout); err != nil {
+		t.Fatal(err)
+	}
+	for _, tag := range []string{`syslog`, `win-security`} {
+		ents := ix.TagEntries(tag)
+		if len(ents) != 5 {
+			t.Fatalf("invalid %s entry count: %d", tag, len(ents))
+		}
+		for _, ent := range ents {
+			if string(ent.Data) != tag {
+				t.Fatalf("entry on the wrong tag: %s != %s", ent.Data, tag)
+			}
+		}
+	}
+
+	//the relayed ingester shows up upstream until it leaves
+	waitChildState(t, ix, true)
+	if err = child.Close(); err != nil {
+		t.Fatal(err)
+	}
+	waitChildState(t, ix, false)
+}
+
+func TestRelayRejects(t *testing.T) {
+	ix, rl := startTestRelay(t, []string{`syslog`, `win-*`})
+	tgt := `tcp://` + rl.lst.Addr().String()
+	if ic, err := ingest.InitializeConnection(tgt, testUpstreamSecret, []string{`syslog`}, ``, ``, false); err != ingest.ErrFailedAuth {
+		if ic != nil {
+			ic.Close()
+		}
+		t.Fatalf("wrong secret was not rejected: %v", err)
+	}
+	if ic, err := ingest.InitializeConnection(tgt, testRelaySecret, []string{`syslog`, `secret`}, ``, ``, false); err != ingest.ErrFailedTagNegotiation {
+		if ic != nil {
+			ic.Close()
+		}
+		t.Fatalf("disallowed tag was not rejected: %v", err)
+	}
+	if _, ok := ix.Tags()[`secret`]; ok {
+		t.Fatal("disallowed tag was negotiated upstream")
+	}
+}
+
+// startTestRelayConn relays a raw entry writer through a relay connection
+func startTestRelayConn(t *testing.T, rc *relayConn) (*ingest.EntryWriter, chan error) {
+	t.Helper()
+	lst, err := net.Listen(`tcp`, `127.0.0.1:0`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lst.Close()
+	cli, err := net.Dial(`tcp`, lst.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv, err := lst.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	er, err := ingest.NewEntryReader(srv)
+	if err != nil {
+		t.Fatal(err)
+	} else if err = er.Start(); err != nil {
+		t.Fatal(err)
+	}
+	ew, err := ingest.NewEntryWriter(cli)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cli.Close()
+		er.Close()
+	})
+	errc := make(chan error, 1)
+	go func() { errc <- rc.relay(er) }()
+	return ew, errc
+}
+
+func TestRelayUnnegotiatedTag(t *testing.T) {
+	ix, rl := startTestRelay(t, []string{`syslog`})
+	rc := newRelayConn(rl)
+	tg, err := rc.GetAndPopulate(`syslog`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	//the upstream muxer knows the default tag, the restricted client never negotiated it
+	raw, err := rl.up.NegotiateTag(entry.DefaultTagName)
+	if err != nil {
+		t.Fatal(err)
+	} else if raw == tg {
+		t.Fatal("test tags collide")
+	}
+
+	ew, errc := startTestRelayConn(t, rc)
+	if err = ew.WriteSync(&entry.Entry{TS: entry.Now(), Tag: tg, Data: []byte(`good`)}); err != nil {
+		t.Fatal(err)
+	} else if _, err = ix.WaitEntries(1, testTimeout); err != nil {
+		t.Fatal(err)
+	}
+	if err = ew.Write(&entry.Entry{TS: entry.Now(), Tag: raw, Data: []byte(`bad`)}); err != nil {
+		t.Fatal(err)
+	}
+	ew.ForceAck() //flushes the entry, the relay never acks it so the error is expected
+	select {
+	case err = <-errc:
+		if err != ErrTagNotNegotiated {
+			t.Fatalf("bad relay error: %v", err)
+		}
+	case <-time.After(testTimeout):
+		t.Fatal("un-negotiated tag did not drop the connection")
+	}
+
+	if err = rl.proc.(testProc).im.Sync(testTimeout); err != nil {
+		t.Fatal(err)
+	}
+	if ents := ix.TagEntries(entry.DefaultTagName); len(ents) != 0 {
+		t.Fatalf("un-negotiated tag was relayed: %d entries", len(ents))
+	} else if ents = ix.TagEntries(`syslog`); len(ents) != 1 {
+		t.Fatalf("invalid syslog entry count: %d", len(ents))
+	}
+}
+
+func TestTagAllowed(t *testing.T) {
+	rl := &relayListener{relayConfig: relayConfig{allowed: []string{`syslog`, `win-*`}}}
+	for tag, ok := range map[string]bool{
+		`syslog`:              true,
+		`win-security`:        true,
+		entry.GravwellTagName: true,
+		`syslogs`:             false,
+		`windows`:             false,
+		entry.DefaultTagName:  false,
+	} {
+		if rl.tagAllowed(tag) != ok {
+			t.Fatalf("tag %s allowed != %v", tag, ok)
+		}
+	}
+	rl.allowed = nil
+	if !rl.tagAllowed(`anything`) {
+		t.Fatal("empty allow list rejected a tag")
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/gravwell/gravwell/v4/ingest/log"
 	"github.com/gravwell/gravwell/v4/ingest/processors"
 	"github.com/gravwell/gravwell/v4/ingest/processors/tags"
+	"github.com/gravwell/gravwell/v4/ingesters/utils"
 )
 
 const (
@@ -30,6 +31,7 @@ const (
 	ipv6Len          = 16
 	currKafkaVersion = `2.1.1`
 	minTLSVersion    = tls.VersionTLS12
+	ackWaitTimeout   = 5 * time.Second
 )
 
 type closer interface {
@@ -96,6 +98,7 @@ type kafkaConsumerConfig struct {
 	igst       *ingest.IngestMuxer
 	lg         *log.Logger
 	pproc      *processors.ProcessorSet
+	acked      bool // offsets are only marked once the indexers acknowledge the entries
 	tgr        *tags.Tagger
 }
 
@@ -255,6 +258,7 @@ func (kc *kafkaConsumer) ConsumeClaim(session sarama.ConsumerGroupSession, claim
 
 	var currTS int64
 	batch := make([]*sarama.ConsumerMessage, 0, kc.batchSize)
+	acks := utils.NewInOrderAcker()
 
 	kc.lg.Info("consumer started", log.KV("consumer", kc.name), log.KV("group", kc.group))
 	var reason string
@@ -271,7 +275,7 @@ loop:
 			ts := msg.Timestamp.Unix()
 			if currTS != ts && len(batch) > 0 {
 				//flush the existing batch
-				if err = kc.flush(session, batch); err != nil {
+				if err = kc.flush(session, acks, batch); err != nil {
 					kc.lg.Error("failed to write entries", log.KV("consumer", kc.name), log.KV("count", len(batch)), log.KVErr(err))
 					reason = `consumer write failed on timestamp transition`
 					break loop
@@ -283,7 +287,7 @@ loop:
 			//check if we hit capacity
 			if len(batch) == cap(batch) {
 				//flush the existing batch
-				if err = kc.flush(session, batch); err != nil {
+				if err = kc.flush(session, acks, batch); err != nil {
 					kc.lg.Error("failed to write entries", log.KV("consumer", kc.name), log.KV("count", len(batch)), log.KVErr(err))
 					reason = `consumer write failed on max-capacity write`
 					break loop
@@ -292,9 +296,13 @@ loop:
 				batch = batch[0:0]
 			}
 		case <-tckr.C:
+			if err = acks.Err(); err != nil {
+				reason = `consumer write was not acknowledged`
+				break loop
+			}
 			if len(batch) > 0 {
 				//flush the existing batch
-				if err = kc.flush(session, batch); err != nil {
+				if err = kc.flush(session, acks, batch); err != nil {
 					kc.lg.Error("failed to write entries", log.KV("consumer", kc.name), log.KV("count", len(batch)), log.KVErr(err))
 					reason = `consumer write failed on ticker`
 					break loop
@@ -304,6 +312,19 @@ loop:
 			}
 		}
 	}
+	//give outstanding batches a chance to be acknowledged so their offsets are committed with the session
+	//anything still pending is redelivered to the next claim
+	if pending := acks.Pending(); pending > 0 {
+		ctx, cf := context.WithTimeout(context.Background(), ackWaitTimeout)
+		if werr := acks.Wait(ctx); werr != nil {
+			kc.lg.Warn("consumer exited with unacknowledged batches",
+				log.KV("consumer", kc.name),
+				log.KV("group", kc.group),
+				log.KV("pending", acks.Pending()),
+				log.KVErr(werr))
+		}
+		cf()
+	}
 	//add the reason for exiting and an error if there is one, typically its just context cancelled but... maybe its something else
 	if err != nil {
 		kc.lg.Info("consumer exited with error",
@@ -331,9 +352,12 @@ func (kc *kafkaConsumer) resolveTag(tn string) (tag entry.EntryTag, ok bool, err
 	return
 }
 
-func (kc *kafkaConsumer) flush(session sarama.ConsumerGroupSession, msgs []*sarama.ConsumerMessage) (err error) {
+// flush hands a batch to the muxer, the messages are only marked once the batch and every
+// batch before it has been acknowledged by an indexer or committed to the cache.
+// Consumers whose preprocessors cannot acknowledge writes mark them as soon as they are written.
+func (kc *kafkaConsumer) flush(session sarama.ConsumerGroupSession, acks *utils.InOrderAcker, msgs []*sarama.ConsumerMessage) (err error) {
 	var sz uint
-	var cnt uint
+	ents := make([]*entry.Entry, 0, len(msgs))
 	for _, m := range msgs {
 		// optionally override the timestamp, if no window is set, this does nothing
 		ts := kc.timeWindow.Override(m.Timestamp)
@@ -357,22 +381,35 @@ func (kc *kafkaConsumer) flush(session sarama.ConsumerGroupSession, msgs []*sara
 		if ent.Tag, ent.SRC, err = kc.resolveSourceAndTag(m); err != nil {
 			return
 		}
-		if err = kc.pproc.ProcessContext(ent, kc.ctx); err != nil {
+		sz += uint(ent.Size())
+		ents = append(ents, ent)
+	}
+	if kc.acked {
+		//the batch slice is reused by the claim routine, so the release needs its own copy
+		marks := append([]*sarama.ConsumerMessage(nil), msgs...)
+		cb := acks.Add(func() {
+			for i := range marks {
+				session.MarkMessage(marks[i], ``)
+			}
+		})
+		if err = kc.pproc.ProcessBatchAcked(ents, kc.ctx, cb); err != nil {
+			cb(err)
 			return
 		}
-		sz += uint(ent.Size())
-		cnt++
+	} else if err = kc.pproc.ProcessBatchContext(ents, kc.ctx); err != nil {
+		return
 	}
 	if kc.sync {
 		if err = kc.igst.SyncContext(kc.ctx, 0); err != nil {
 			return
 		}
 	}
-	//commit the messages
-	for i := range msgs {
-		session.MarkMessage(msgs[i], ``)
+	if !kc.acked {
+		for i := range msgs {
+			session.MarkMessage(msgs[i], ``)
+		}
 	}
-	kc.count += cnt
+	kc.count += uint(len(ents))
 	kc.size += sz
 	return
 }
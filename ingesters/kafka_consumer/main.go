@@ -99,6 +99,12 @@ func main() {
 			lg.Fatal("preprocessor construction error",
 				log.KV("consumer", k), log.KVErr(err))
 		}
+		if err = kcfg.pproc.AckSupported(); err != nil {
+			lg.Warn("preprocessors cannot acknowledge writes, offsets will be committed as soon as entries are written",
+				log.KV("consumer", k), log.KVErr(err))
+		} else {
+			kcfg.acked = true
+		}
 		procs = append(procs, kcfg.pproc)
 		kc, err := newKafkaConsumer(kcfg)
 		if err != nil {
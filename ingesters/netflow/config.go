@@ -10,8 +10,11 @@ package main
 
 import (
 	"errors"
+	"fmt"
+	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/gravwell/gravwell/v4/ingest"
 	"github.com/gravwell/gravwell/v4/ingest/attach"
@@ -23,9 +26,15 @@ const (
 	MAX_CONFIG_SIZE int64 = (1024 * 1024 * 2) //2MB, even this is crazy large
 	nfv5Type              = iota
 	ipfixType             = iota
+	sflowType             = iota
+	nfv9Type              = iota
 
 	nfv5Name  string = `netflowv5`
 	ipfixName string = `ipfix`
+	sflowName string = `sflow`
+	nfv9Name  string = `netflowv9`
+
+	defaultTemplateDir = `/opt/gravwell/cache`
 )
 
 var ()
@@ -39,6 +48,8 @@ type collector struct {
 	Ignore_Timestamps     bool
 	Flow_Type             string
 	Session_Dump_Enabled  bool
+	Template_Store        string // file used to persist Netflow V9 templates across restarts
+	Template_Timeout      string // duration after which a Netflow V9 template that has not been refreshed is dropped
 }
 
 type cfgReadType struct {
@@ -94,6 +105,9 @@ func (c *cfgType) Verify() error {
 		if ingest.CheckTag(v.Tag_Name) != nil {
 			return errors.New("Invalid characters in the Tag-Name for " + k)
 		}
+		if _, err := v.templateTimeout(); err != nil {
+			return fmt.Errorf("Invalid Template-Timeout for %s: %w", k, err)
+		}
 		if n, ok := bindMp[v.Bind_String]; ok {
 			return errors.New("Bind-String for " + k + " already in use by " + n)
 		}
@@ -135,6 +149,10 @@ func (ft flowType) String() string {
 		return "Netflow V5"
 	case ipfixType:
 		return "IPFIX"
+	case nfv9Type:
+		return "Netflow V9"
+	case sflowType:
+		return "sFlow V5"
 	}
 	return "unknown"
 }
@@ -150,6 +168,39 @@ func translateFlowType(s string) (flowType, error) {
 		return nfv5Type, nil
 	case ipfixName:
 		return ipfixType, nil
+	case `nfv9`: //nfv9Name shortcut
+		fallthrough
+	case nfv9Name:
+		return nfv9Type, nil
+	case `sflowv5`: //sflowName shortcut
+		fallthrough
+	case sflowName:
+		return sflowType, nil
 	}
 	return -1, errors.New("invalid reader type")
 }
+
+func (c *collector) templateTimeout() (time.Duration, error) {
+	if c.Template_Timeout == `` {
+		return defaultTemplateTimeout, nil
+	}
+	d, err := time.ParseDuration(c.Template_Timeout)
+	if err == nil && d <= 0 {
+		err = errors.New("timeout must be positive")
+	}
+	return d, err
+}
+
+// templateStore returns the file used to persist Netflow V9 templates for the named collector
+func (c *collector) templateStore(name string) string {
+	if c.Template_Store != `` {
+		return c.Template_Store
+	}
+	name = strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' {
+			return r
+		}
+		return '_'
+	}, name)
+	return filepath.Join(defaultTemplateDir, `netflowv9_`+name+`.templates`)
+}
@@ -211,6 +211,16 @@ func (s *sessionKey) String() string {
 	return fmt.Sprintf("%v:%d", net.IP(s.ip[:]), s.domain)
 }
 
+// addr returns the exporter address of the session
+func (s *sessionKey) addr() net.IP {
+	for _, v := range s.ip[4:] {
+		if v != 0 {
+			return net.IP(append([]byte(nil), s.ip[:]...))
+		}
+	}
+	return net.IP(append([]byte(nil), s.ip[0:4]...))
+}
+
 func (i *IpfixHandler) routine(id int) {
 	defer i.wg.Done()
 	defer delConn(id)
@@ -321,3 +331,271 @@ func (i *IpfixHandler) routine(id int) {
 		i.ch <- e
 	}
 }
+
+type NetflowV9Handler struct {
+	bindConfig
+	mtx       *sync.Mutex
+	c         *net.UDPConn
+	ready     bool
+	templates *templateCache
+}
+
+func NewNetflowV9Handler(c bindConfig) (*NetflowV9Handler, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	tc := newTemplateCache(c.templateStore, c.templateTimeout)
+	if err := tc.load(time.Now()); err != nil {
+		//a bad store just means we wait for the exporters to refresh their templates
+		lg.Warn("failed to load persisted Netflow V9 templates", log.KV("path", c.templateStore), log.KVErr(err))
+	} else if cnt := tc.count(); cnt > 0 {
+		lg.Info("loaded persisted Netflow V9 templates", log.KV("path", c.templateStore), log.KV("templates", cnt))
+	}
+
+	return &NetflowV9Handler{
+		bindConfig: c,
+		mtx:        &sync.Mutex{},
+		templates:  tc,
+	}, nil
+}
+
+func (n *NetflowV9Handler) String() string {
+	return `NetflowV9`
+}
+
+func (n *NetflowV9Handler) Listen(s string) (err error) {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	if n.c != nil {
+		err = ErrAlreadyListening
+		return
+	}
+	var a *net.UDPAddr
+	if a, err = net.ResolveUDPAddr("udp", s); err != nil {
+		return
+	}
+	if n.c, err = net.ListenUDP("udp", a); err == nil {
+		n.ready = true
+	}
+	return
+}
+
+func (n *NetflowV9Handler) Close() error {
+	if n == nil {
+		return ErrAlreadyClosed
+	}
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	n.ready = false
+	return n.c.Close()
+}
+
+func (n *NetflowV9Handler) Start(id int) error {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	if !n.ready || n.c == nil {
+		return ErrNotReady
+	}
+	if id < 0 {
+		return errors.New("invalid id")
+	}
+	go n.routine(id)
+	return nil
+}
+
+func (n *NetflowV9Handler) saveTemplates(now time.Time) {
+	n.templates.expire(now)
+	if err := n.templates.save(now); err != nil {
+		lg.Warn("failed to persist Netflow V9 templates", log.KV("path", n.templateStore), log.KVErr(err))
+	}
+}
+
+func (n *NetflowV9Handler) routine(id int) {
+	defer n.wg.Done()
+	defer delConn(id)
+	defer func() { n.saveTemplates(time.Now()) }()
+
+	var l int
+	var addr *net.UDPAddr
+	var err error
+	var ts entry.Timestamp
+	var hdr netflow.NFv9Header
+	var fss []netflow.NFv9FlowSet
+	var tmpls, opts []netflow.NFv9Template
+	defined := map[uint16]bool{}
+	tbuff := make([]byte, 65507) // just go with max UDP packet size
+	for {
+		if l, addr, err = n.c.ReadFromUDP(tbuff); err != nil {
+			debugout("Error in ReadFromUDP: %v\n", err)
+			return
+		}
+		now := time.Now()
+		if fss, err = netflow.SplitNFv9FlowSets(tbuff[:l]); err != nil {
+			debugout("Rejecting packet: %v\n", err)
+			continue //there isn't much we can do about bad packets...
+		}
+		hdr.Decode(tbuff[:l]) // cannot fail, SplitNFv9FlowSets already decoded it
+		key := getSessionKey(hdr.SourceID, addr.IP)
+
+		// refresh the cache with any templates in the packet
+		clear(defined)
+		for _, fs := range fss {
+			if !fs.IsTemplate() {
+				continue
+			}
+			ft, err := fs.Templates()
+			if err != nil {
+				debugout("Bad template flowset from %v: %v\n", key.String(), err)
+				continue
+			}
+			for _, t := range ft {
+				n.templates.update(key, t, now)
+				defined[t.ID] = true
+			}
+		}
+
+		// attach the templates needed to decode data flowsets that do not carry them
+		tmpls, opts = tmpls[:0], opts[:0]
+		for _, fs := range fss {
+			if !fs.IsData() || defined[fs.ID] {
+				continue
+			}
+			defined[fs.ID] = true
+			if t, ok := n.templates.lookup(key, fs.ID, now); ok {
+				if t.Options {
+					opts = append(opts, t)
+				} else {
+					tmpls = append(tmpls, t)
+				}
+			}
+		}
+
+		var lbuff []byte
+		if len(tmpls) == 0 && len(opts) == 0 {
+			lbuff = make([]byte, l)
+			copy(lbuff, tbuff[0:l])
+		} else {
+			debugout("Attaching %d templates\n", len(tmpls)+len(opts))
+			h := hdr
+			if cnt := int(h.Count) + len(tmpls) + len(opts); cnt <= 0xffff {
+				h.Count = uint16(cnt)
+			}
+			lbuff = h.Encode()
+			if len(tmpls) > 0 {
+				lbuff = netflow.AppendNFv9TemplateFlowSet(lbuff, false, tmpls...)
+			}
+			if len(opts) > 0 {
+				lbuff = netflow.AppendNFv9TemplateFlowSet(lbuff, true, opts...)
+			}
+			lbuff = append(lbuff, tbuff[netflow.NFv9HeaderSize:l]...)
+		}
+
+		if n.ignoreTS {
+			ts = entry.Now()
+		} else {
+			ts = entry.UnixTime(int64(hdr.Sec), 0)
+		}
+		e := &entry.Entry{
+			Tag:  n.tag,
+			SRC:  addr.IP,
+			TS:   ts,
+			Data: lbuff,
+		}
+		n.ch <- e
+
+		if n.templates.saveDue(now) {
+			n.saveTemplates(now)
+		}
+	}
+}
+
+type SFlowV5Handler struct {
+	bindConfig
+	mtx   *sync.Mutex
+	c     *net.UDPConn
+	ready bool
+}
+
+func NewSFlowV5Handler(c bindConfig) (*SFlowV5Handler, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &SFlowV5Handler{
+		bindConfig: c,
+		mtx:        &sync.Mutex{},
+	}, nil
+}
+
+func (s *SFlowV5Handler) String() string {
+	return `SFlowV5`
+}
+
+func (s *SFlowV5Handler) Listen(addr string) (err error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.c != nil {
+		err = ErrAlreadyListening
+		return
+	}
+	var a *net.UDPAddr
+	if a, err = net.ResolveUDPAddr("udp", addr); err != nil {
+		return
+	}
+	if s.c, err = net.ListenUDP("udp", a); err == nil {
+		s.ready = true
+	}
+	return
+}
+
+func (s *SFlowV5Handler) Close() error {
+	if s == nil {
+		return ErrAlreadyClosed
+	}
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.ready = false
+	return s.c.Close()
+}
+
+func (s *SFlowV5Handler) Start(id int) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if !s.ready || s.c == nil {
+		return ErrNotReady
+	}
+	if id < 0 {
+		return errors.New("invalid id")
+	}
+	go s.routine(id)
+	return nil
+}
+
+func (s *SFlowV5Handler) routine(id int) {
+	defer s.wg.Done()
+	defer delConn(id)
+	var dg netflow.SFlowDatagram
+	var l int
+	var addr *net.UDPAddr
+	var err error
+	tbuff := make([]byte, 65507) // just go with max UDP packet size
+	for {
+		if l, addr, err = s.c.ReadFromUDP(tbuff); err != nil {
+			return
+		}
+		if err = dg.Decode(tbuff[:l]); err != nil {
+			debugout("Rejecting sFlow datagram from %v: %v\n", addr.IP, err)
+			continue //there isn't much we can do about bad packets...
+		}
+		lbuff := make([]byte, l)
+		copy(lbuff, tbuff[0:l])
+		// sFlow datagrams only carry the agent uptime, so there is no timestamp to honor
+		e := &entry.Entry{
+			Tag:  s.tag,
+			SRC:  addr.IP,
+			TS:   entry.Now(),
+			Data: lbuff,
+		}
+		s.ch <- e
+	}
+}
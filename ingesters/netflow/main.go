@@ -111,6 +111,10 @@ func main() {
 		bc.localTZ = v.Assume_Local_Timezone
 		bc.sessionDumpEnabled = v.Session_Dump_Enabled
 		bc.lastInfoDump = time.Now()
+		bc.templateStore = v.templateStore(k)
+		if bc.templateTimeout, err = v.templateTimeout(); err != nil {
+			lg.FatalCode(0, "invalid template timeout", log.KV("collector", k), log.KVErr(err))
+		}
 		var bh BindHandler
 		switch ft {
 		case nfv5Type:
@@ -123,6 +127,16 @@ func main() {
 				lg.FatalCode(0, "NewIpfixHandler failed", log.KVErr(err))
 				return
 			}
+		case nfv9Type:
+			if bh, err = NewNetflowV9Handler(bc); err != nil {
+				lg.FatalCode(0, "NewNetflowV9Handler failed", log.KVErr(err))
+				return
+			}
+		case sflowType:
+			if bh, err = NewSFlowV5Handler(bc); err != nil {
+				lg.FatalCode(0, "NewSFlowV5Handler failed", log.KVErr(err))
+				return
+			}
 		default:
 			lg.FatalCode(0, "invalid flow type", log.KV("flowtype", ft))
 			return
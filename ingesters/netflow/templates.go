@@ -0,0 +1,199 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/gravwell/gravwell/v4/netflow"
+)
+
+const (
+	defaultTemplateTimeout = 30 * time.Minute
+	templateSaveInterval   = time.Minute
+)
+
+// templateKey identifies a single template, template IDs are scoped to an exporter and source ID
+type templateKey struct {
+	sessionKey
+	id uint16
+}
+
+type cachedTemplate struct {
+	options bool
+	record  []byte
+	seen    time.Time
+}
+
+// templateCache holds the most recent Netflow V9 templates seen from each exporter so that
+// data flowsets can be sent with the templates needed to decode them.  Templates that have
+// not been refreshed within the timeout are dropped.  If a path is set the cache is persisted
+// so that data arriving after a restart, but before the exporter refreshes, can be decoded.
+type templateCache struct {
+	mtx      sync.Mutex
+	path     string
+	timeout  time.Duration
+	m        map[templateKey]cachedTemplate
+	dirty    bool
+	lastSave time.Time
+}
+
+// persistedTemplate is the on-disk representation of a cached template
+type persistedTemplate struct {
+	Exporter net.IP
+	SourceID uint32
+	ID       uint16
+	Options  bool
+	Record   []byte
+	Seen     time.Time
+}
+
+func newTemplateCache(path string, timeout time.Duration) *templateCache {
+	if timeout <= 0 {
+		timeout = defaultTemplateTimeout
+	}
+	return &templateCache{
+		path:     path,
+		timeout:  timeout,
+		m:        map[templateKey]cachedTemplate{},
+		lastSave: time.Now(),
+	}
+}
+
+// update stores or refreshes a template
+func (tc *templateCache) update(sk sessionKey, t netflow.NFv9Template, now time.Time) {
+	ct := cachedTemplate{
+		options: t.Options,
+		record:  append([]byte(nil), t.Record...),
+		seen:    now,
+	}
+	tc.mtx.Lock()
+	tc.m[templateKey{sessionKey: sk, id: t.ID}] = ct
+	tc.dirty = true
+	tc.mtx.Unlock()
+}
+
+// lookup returns an unexpired template, expired templates are removed
+func (tc *templateCache) lookup(sk sessionKey, id uint16, now time.Time) (t netflow.NFv9Template, ok bool) {
+	k := templateKey{sessionKey: sk, id: id}
+	tc.mtx.Lock()
+	defer tc.mtx.Unlock()
+	var ct cachedTemplate
+	if ct, ok = tc.m[k]; !ok {
+		return
+	} else if now.Sub(ct.seen) > tc.timeout {
+		delete(tc.m, k)
+		tc.dirty = true
+		ok = false
+		return
+	}
+	t = netflow.NFv9Template{ID: id, Options: ct.options, Record: ct.record}
+	return
+}
+
+// expire drops every template that has not been refreshed within the timeout
+func (tc *templateCache) expire(now time.Time) {
+	tc.mtx.Lock()
+	for k, ct := range tc.m {
+		if now.Sub(ct.seen) > tc.timeout {
+			delete(tc.m, k)
+			tc.dirty = true
+		}
+	}
+	tc.mtx.Unlock()
+}
+
+func (tc *templateCache) count() (r int) {
+	tc.mtx.Lock()
+	r = len(tc.m)
+	tc.mtx.Unlock()
+	return
+}
+
+// load reads persisted templates, a missing store is not an error
+func (tc *templateCache) load(now time.Time) (err error) {
+	if tc.path == `` {
+		return
+	}
+	var b []byte
+	if b, err = os.ReadFile(tc.path); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			err = nil
+		}
+		return
+	}
+	var pts []persistedTemplate
+	if err = json.Unmarshal(b, &pts); err != nil {
+		return
+	}
+	tc.mtx.Lock()
+	defer tc.mtx.Unlock()
+	for _, pt := range pts {
+		if pt.ID < netflow.NFv9MinDataFlowSetID || len(pt.Record) == 0 || now.Sub(pt.Seen) > tc.timeout {
+			continue
+		}
+		k := templateKey{sessionKey: getSessionKey(pt.SourceID, pt.Exporter), id: pt.ID}
+		tc.m[k] = cachedTemplate{options: pt.Options, record: pt.Record, seen: pt.Seen}
+	}
+	return
+}
+
+// save writes the cache to disk if it has changed, the store is replaced atomically
+func (tc *templateCache) save(now time.Time) (err error) {
+	tc.mtx.Lock()
+	defer tc.mtx.Unlock()
+	if tc.path == `` || !tc.dirty {
+		return
+	}
+	pts := make([]persistedTemplate, 0, len(tc.m))
+	for k, ct := range tc.m {
+		pts = append(pts, persistedTemplate{
+			Exporter: k.addr(),
+			SourceID: k.domain,
+			ID:       k.id,
+			Options:  ct.options,
+			Record:   ct.record,
+			Seen:     ct.seen,
+		})
+	}
+	var b []byte
+	if b, err = json.Marshal(pts); err != nil {
+		return
+	}
+	var fout *os.File
+	if fout, err = os.CreateTemp(filepath.Dir(tc.path), filepath.Base(tc.path)+".tmp"); err != nil {
+		return
+	}
+	defer os.Remove(fout.Name())
+	if _, err = fout.Write(b); err != nil {
+		fout.Close()
+		return
+	} else if err = fout.Close(); err != nil {
+		return
+	} else if err = os.Rename(fout.Name(), tc.path); err != nil {
+		return
+	}
+	tc.dirty = false
+	tc.lastSave = now
+	return
+}
+
+// saveDue returns true if the cache has changed and has not been saved recently
+func (tc *templateCache) saveDue(now time.Time) (r bool) {
+	tc.mtx.Lock()
+	r = tc.path != `` && tc.dirty && now.Sub(tc.lastSave) >= templateSaveInterval
+	tc.mtx.Unlock()
+	return
+}
@@ -57,6 +57,8 @@ type bindConfig struct {
 	igst               *ingest.IngestMuxer
 	lastInfoDump       time.Time
 	sessionDumpEnabled bool
+	templateStore      string
+	templateTimeout    time.Duration
 }
 
 type BindHandler interface {
@@ -52,8 +52,10 @@ type handlerConfig struct {
 	src              net.IP
 	formatOverride   string
 	wg               *sync.WaitGroup
+	acks             *sync.WaitGroup // batches waiting on an acknowledgement before they are deleted
 	done             chan bool
 	proc             *processors.ProcessorSet
+	acked            bool // messages are only deleted once the indexers acknowledge the entries
 	ctx              context.Context
 }
 
@@ -88,7 +90,7 @@ func main() {
 
 	debugout("Started ingester muxer\n")
 
-	var wg sync.WaitGroup
+	var wg, acks sync.WaitGroup
 	done := make(chan bool)
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -124,6 +126,7 @@ func main() {
 			formatOverride:   v.Timestamp_Format_Override,
 			src:              src,
 			wg:               &wg,
+			acks:             &acks,
 			done:             done,
 			ctx:              ctx,
 		}
@@ -148,6 +151,12 @@ func main() {
 		if hcfg.proc, err = cfg.Preprocessor.ProcessorSet(igst, v.Preprocessor); err != nil {
 			lg.Fatal("preprocessor failure", log.KVErr(err))
 		}
+		if err = hcfg.proc.AckSupported(); err != nil {
+			lg.Warn("preprocessors cannot acknowledge writes, messages will be deleted as soon as entries are written",
+				log.KV("listener", k), log.KVErr(err))
+		} else {
+			hcfg.acked = true
+		}
 
 		wg.Add(1)
 		go queueRunner(hcfg)
@@ -175,6 +184,8 @@ func main() {
 	if err := igst.Close(); err != nil {
 		lg.Error("failed to close", log.KVErr(err))
 	}
+	// closing the muxer fires any outstanding acknowledgements, wait for the deletes to land
+	acks.Wait()
 }
 
 func debugout(format string, args ...interface{}) {
@@ -209,7 +220,11 @@ func queueRunner(hcfg *handlerConfig) {
 			return
 		}
 
+		if len(out) == 0 {
+			continue
+		}
 		// we may have multiple packed messages
+		ents := make([]*entry.Entry, 0, len(out))
 		for _, v := range out {
 			msg := []byte(*v.Body)
 
@@ -231,23 +246,47 @@ func queueRunner(hcfg *handlerConfig) {
 				ts = entry.Now()
 			}
 
-			ent := &entry.Entry{
+			ents = append(ents, &entry.Entry{
 				SRC:  hcfg.src,
 				TS:   ts,
 				Tag:  hcfg.tag,
 				Data: msg,
-			}
+			})
+		}
 
-			err := hcfg.proc.ProcessContext(ent, hcfg.ctx)
-			if err != nil {
-				lg.Error("failed to ingest entry", log.KVErr(err))
-			} else {
-				err = hcfg.SQS.DeleteMessages([]*sqs.Message{v}, lg)
-				if err != nil {
-					lg.Error("failed to delete message", log.KVErr(err))
-				}
+		if !hcfg.acked {
+			if err := hcfg.proc.ProcessBatchContext(ents, hcfg.ctx); err != nil {
+				lg.Error("failed to ingest entries", log.KV("count", len(ents)), log.KVErr(err))
+			} else if err = hcfg.SQS.DeleteMessages(out, lg); err != nil {
+				lg.Error("failed to delete messages", log.KVErr(err))
 			}
+			continue
 		}
+		// messages are only deleted once the batch is confirmed by an indexer or committed to the cache,
+		// anything that fails stays on the queue and is redelivered after the visibility timeout
+		hcfg.acks.Add(1)
+		if err := hcfg.proc.ProcessBatchAcked(ents, hcfg.ctx, ackDeleter(hcfg, out)); err != nil {
+			lg.Error("failed to ingest entries", log.KV("count", len(ents)), log.KVErr(err))
+			hcfg.acks.Done()
+		}
+	}
+}
+
+// ackDeleter returns an acknowledgement callback that deletes the messages from the queue,
+// the callback fires inside the muxer so the delete is pushed off to its own routine
+func ackDeleter(hcfg *handlerConfig, out []*sqs.Message) func(error) {
+	return func(err error) {
+		if err != nil {
+			lg.Error("entries were not acknowledged", log.KV("count", len(out)), log.KVErr(err))
+			hcfg.acks.Done()
+			return
+		}
+		go func() {
+			defer hcfg.acks.Done()
+			if err := hcfg.SQS.DeleteMessages(out, lg); err != nil {
+				lg.Error("failed to delete messages", log.KVErr(err))
+			}
+		}()
 	}
 }
 
@@ -0,0 +1,107 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package utils
+
+import (
+	"context"
+	"sync"
+)
+
+type pendingAck struct {
+	acked   bool
+	release func()
+}
+
+// InOrderAcker releases acknowledgements in the order batches were written.  Queues
+// which commit an offset must not move it past a batch that is still in flight, so an
+// acknowledgement which arrives early is held until every batch before it is acknowledged.
+// Once a batch fails nothing else is released and the queue is expected to redeliver
+// from the last released offset.
+type InOrderAcker struct {
+	mtx     sync.Mutex
+	pending []*pendingAck
+	err     error
+	notify  chan struct{}
+}
+
+func NewInOrderAcker() *InOrderAcker {
+	return &InOrderAcker{
+		notify: make(chan struct{}),
+	}
+}
+
+// Add registers a batch, release is called once the batch and every batch added before
+// it are acknowledged.  The returned function is the acknowledgement callback for the
+// batch and is typically handed to IngestMuxer.WriteBatchAcked; if the write itself fails
+// call it with the error.  release is called with the acker locked and must not block.
+func (a *InOrderAcker) Add(release func()) func(error) {
+	pa := &pendingAck{release: release}
+	a.mtx.Lock()
+	a.pending = append(a.pending, pa)
+	a.mtx.Unlock()
+	return func(err error) {
+		a.ack(pa, err)
+	}
+}
+
+func (a *InOrderAcker) ack(pa *pendingAck, err error) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	if err != nil {
+		if a.err == nil {
+			a.err = err
+		}
+	} else {
+		pa.acked = true
+	}
+	for a.err == nil && len(a.pending) > 0 && a.pending[0].acked {
+		if a.pending[0].release != nil {
+			a.pending[0].release()
+		}
+		a.pending[0] = nil
+		a.pending = a.pending[1:]
+	}
+	close(a.notify)
+	a.notify = make(chan struct{})
+}
+
+// Err returns the first acknowledgement failure
+func (a *InOrderAcker) Err() (err error) {
+	a.mtx.Lock()
+	err = a.err
+	a.mtx.Unlock()
+	return
+}
+
+// Pending returns the number of batches which have not been released
+func (a *InOrderAcker) Pending() (n int) {
+	a.mtx.Lock()
+	n = len(a.pending)
+	a.mtx.Unlock()
+	return
+}
+
+// Wait blocks until every batch has been released, a batch fails, or the context is done
+func (a *InOrderAcker) Wait(ctx context.Context) error {
+	for {
+		a.mtx.Lock()
+		if a.err != nil || len(a.pending) == 0 {
+			err := a.err
+			a.mtx.Unlock()
+			return err
+		}
+		ch := a.notify
+		a.mtx.Unlock()
+		select {
+		case <-ch:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
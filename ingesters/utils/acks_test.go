@@ -0,0 +1,69 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package utils
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestInOrderAcker(t *testing.T) {
+	a := NewInOrderAcker()
+	var released []int
+	var cbs []func(error)
+	for i := 0; i < 4; i++ {
+		v := i
+		cbs = append(cbs, a.Add(func() { released = append(released, v) }))
+	}
+
+	//early acknowledgements are held back
+	cbs[2](nil)
+	cbs[1](nil)
+	if len(released) != 0 || a.Pending() != 4 {
+		t.Fatalf("released out of order: %v", released)
+	}
+	cbs[0](nil)
+	if len(released) != 3 || released[0] != 0 || released[1] != 1 || released[2] != 2 {
+		t.Fatalf("bad release order: %v", released)
+	}
+
+	ctx, cf := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cf()
+	if err := a.Wait(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("wait returned with a batch pending: %v", err)
+	}
+	go cbs[3](nil)
+	if err := a.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	} else if len(released) != 4 || a.Pending() != 0 {
+		t.Fatalf("final batch not released: %v", released)
+	}
+}
+
+func TestInOrderAckerFailure(t *testing.T) {
+	a := NewInOrderAcker()
+	var released int
+	first := a.Add(func() { released++ })
+	second := a.Add(func() { released++ })
+	third := a.Add(func() { released++ })
+
+	first(nil)
+	ackErr := errors.New("indexer went away")
+	second(ackErr)
+	third(nil)
+	if released != 1 {
+		t.Fatalf("released past a failure: %d", released)
+	} else if err := a.Err(); err != ackErr {
+		t.Fatalf("bad error: %v", err)
+	} else if err = a.Wait(context.Background()); err != ackErr {
+		t.Fatalf("wait did not return the failure: %v", err)
+	}
+}
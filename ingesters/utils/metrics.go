@@ -0,0 +1,119 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package utils
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	// OpenMetricsContentType is the content type for the text exposition format produced by MetricsWriter
+	OpenMetricsContentType = `application/openmetrics-text; version=1.0.0; charset=utf-8`
+
+	MetricCounter = `counter`
+	MetricGauge   = `gauge`
+	MetricInfo    = `info`
+)
+
+type MetricLabel struct {
+	Name  string
+	Value string
+}
+
+type MetricSample struct {
+	Labels []MetricLabel
+	Value  float64
+}
+
+// MetricsWriter emits metric families in the OpenMetrics text format.
+// Errors are sticky, the first write error is returned by Close.
+type MetricsWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+func NewMetricsWriter(w io.Writer) *MetricsWriter {
+	return &MetricsWriter{
+		w: bufio.NewWriter(w),
+	}
+}
+
+// Write emits a metric family, counter sample names get a _total suffix and info samples get an _info suffix.
+func (mw *MetricsWriter) Write(name, typ, help string, samples ...MetricSample) {
+	if len(samples) == 0 {
+		return
+	}
+	mw.writeString("# TYPE " + name + " " + typ + "\n")
+	if help != `` {
+		mw.writeString("# HELP " + name + " " + escapeMetricText(help, false) + "\n")
+	}
+	sname := name
+	switch typ {
+	case MetricCounter:
+		sname += `_total`
+	case MetricInfo:
+		sname += `_info`
+	}
+	for _, s := range samples {
+		mw.writeString(sname)
+		if len(s.Labels) > 0 {
+			mw.writeString("{")
+			for i, l := range s.Labels {
+				if i > 0 {
+					mw.writeString(",")
+				}
+				mw.writeString(l.Name + `="` + escapeMetricText(l.Value, true) + `"`)
+			}
+			mw.writeString("}")
+		}
+		mw.writeString(" " + formatMetricValue(s.Value) + "\n")
+	}
+}
+
+// Close terminates the exposition and flushes it to the underlying writer
+func (mw *MetricsWriter) Close() error {
+	mw.writeString("# EOF\n")
+	if mw.err == nil {
+		mw.err = mw.w.Flush()
+	}
+	return mw.err
+}
+
+func (mw *MetricsWriter) writeString(s string) {
+	if mw.err == nil {
+		_, mw.err = mw.w.WriteString(s)
+	}
+}
+
+func escapeMetricText(s string, quote bool) string {
+	r := strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	if quote {
+		r = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+	}
+	return r.Replace(s)
+}
+
+func formatMetricValue(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return `NaN`
+	case math.IsInf(v, 1):
+		return `+Inf`
+	case math.IsInf(v, -1):
+		return `-Inf`
+	case v == math.Trunc(v) && math.Abs(v) < 1e15:
+		//counters are integers, keep them out of exponent notation
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
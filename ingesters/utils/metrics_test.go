@@ -0,0 +1,61 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package utils
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestMetricsWriter(t *testing.T) {
+	var bb bytes.Buffer
+	mw := NewMetricsWriter(&bb)
+	mw.Write(`gravwell_ingester`, MetricInfo, `ingester info`,
+		MetricSample{Labels: []MetricLabel{{`name`, `test "ingester"`}, {`version`, "a\\b\nc"}}, Value: 1})
+	mw.Write(`gravwell_ingester_tag_entries`, MetricCounter, `entries per tag`,
+		MetricSample{Labels: []MetricLabel{{`tag`, `foo`}}, Value: 10},
+		MetricSample{Labels: []MetricLabel{{`tag`, `bar`}}, Value: 1234567})
+	mw.Write(`gravwell_ingester_connections_hot`, MetricGauge, ``, MetricSample{Value: 2})
+	mw.Write(`gravwell_ingester_empty`, MetricGauge, `no samples`)
+	if err := mw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	exp := `# TYPE gravwell_ingester info
+# HELP gravwell_ingester ingester info
+gravwell_ingester_info{name="test \"ingester\"",version="a\\b\nc"} 1
+# TYPE gravwell_ingester_tag_entries counter
+# HELP gravwell_ingester_tag_entries entries per tag
+gravwell_ingester_tag_entries_total{tag="foo"} 10
+gravwell_ingester_tag_entries_total{tag="bar"} 1234567
+# TYPE gravwell_ingester_connections_hot gauge
+gravwell_ingester_connections_hot 2
+# EOF
+`
+	if bb.String() != exp {
+		t.Fatalf("bad output:\n%s\nexpected:\n%s", bb.String(), exp)
+	}
+}
+
+func TestStatsTotals(t *testing.T) {
+	sm, err := NewStatsManager(0, nil)
+	if err == nil {
+		t.Fatal("failed to catch nil logger")
+	}
+	sm = &StatsManager{}
+	a, err := sm.RegisterItem(`a`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.Add(10)
+	a.reset()
+	a.Add(5)
+	if tots := sm.Totals(); len(tots) != 1 || tots[`a`] != 15 {
+		t.Fatalf("bad totals: %v", tots)
+	}
+}
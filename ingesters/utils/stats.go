@@ -26,9 +26,10 @@ const (
 )
 
 type StatsItem struct {
-	name string
-	last uint64
-	curr uint64
+	name  string
+	last  uint64
+	curr  uint64
+	total uint64 // never reset, used by metrics exporters
 }
 
 type StatsManager struct {
@@ -153,9 +154,22 @@ func (sm *StatsManager) doTick(dur time.Duration) {
 func (si *StatsItem) Add(v uint64) {
 	if si != nil {
 		atomic.AddUint64(&si.curr, v)
+		atomic.AddUint64(&si.total, v)
 	}
 }
 
+// Totals returns the cumulative value of every registered item keyed by name.
+// Unlike the periodic stats log, totals are never reset.
+func (sm *StatsManager) Totals() (r map[string]uint64) {
+	sm.Lock()
+	r = make(map[string]uint64, len(sm.items))
+	for _, v := range sm.items {
+		r[v.name] = atomic.LoadUint64(&v.total)
+	}
+	sm.Unlock()
+	return
+}
+
 func (si *StatsItem) reset() (curr uint64) {
 	if si != nil {
 		//reset and
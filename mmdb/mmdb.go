@@ -0,0 +1,464 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+// Package mmdb is a minimal reader for MaxMind DB (MMDB) files such as the GeoLite2 and
+// GeoIP2 country, city, and ASN databases.  Records are decoded into generic maps, slices,
+// and scalar values which callers walk with Record.Path.
+package mmdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"net"
+	"os"
+)
+
+const (
+	dataSectionSeparator = 16
+	maxPointerDepth      = 32
+)
+
+var (
+	metadataMarker = []byte("\xAB\xCD\xEFMaxMind.com")
+
+	ErrNoMetadata        = errors.New("MaxMind DB metadata not found")
+	ErrInvalidMetadata   = errors.New("Invalid MaxMind DB metadata")
+	ErrUnsupportedRecord = errors.New("Unsupported MaxMind DB record size")
+	ErrCorrupt           = errors.New("MaxMind DB is corrupt")
+	ErrInvalidIP         = errors.New("Invalid IP address")
+	ErrIPv6InIPv4DB      = errors.New("IPv6 address lookup in an IPv4 only database")
+)
+
+const (
+	typeExtended = iota
+	typePointer
+	typeString
+	typeDouble
+	typeBytes
+	typeUint16
+	typeUint32
+	typeMap
+	typeInt32
+	typeUint64
+	typeUint128
+	typeArray
+	typeContainer
+	typeEndMarker
+	typeBool
+	typeFloat
+)
+
+// Metadata describes the database
+type Metadata struct {
+	DatabaseType string
+	Languages    []string
+	Description  map[string]string
+	IPVersion    uint
+	NodeCount    uint
+	RecordSize   uint
+	BuildEpoch   uint64
+	MajorVersion uint
+	MinorVersion uint
+}
+
+// Reader looks up IP addresses in an in memory MaxMind DB
+type Reader struct {
+	Metadata
+	buf      []byte
+	tree     []byte
+	data     []byte
+	nodeSize uint
+	ipv4Root uint // node reached after walking the 96 zero bits of an IPv4 mapped address
+}
+
+// Open reads an entire MaxMind DB file into memory
+func Open(pth string) (r *Reader, err error) {
+	var buf []byte
+	if buf, err = os.ReadFile(pth); err != nil {
+		return
+	}
+	return New(buf)
+}
+
+// New creates a reader from the contents of a MaxMind DB file, buf is retained
+func New(buf []byte) (r *Reader, err error) {
+	idx := bytes.LastIndex(buf, metadataMarker)
+	if idx == -1 {
+		err = ErrNoMetadata
+		return
+	}
+	r = &Reader{buf: buf}
+	var md interface{}
+	if md, _, err = r.decode(buf[idx+len(metadataMarker):], 0, 0); err != nil {
+		r = nil
+		err = fmt.Errorf("%w: %v", ErrInvalidMetadata, err)
+		return
+	}
+	if err = r.Metadata.load(md); err != nil {
+		r = nil
+		return
+	}
+	switch r.RecordSize {
+	case 24, 28, 32:
+	default:
+		err = fmt.Errorf("%w: %d", ErrUnsupportedRecord, r.RecordSize)
+		r = nil
+		return
+	}
+	r.nodeSize = r.RecordSize / 4
+	treeSize := r.NodeCount * r.nodeSize
+	if treeSize+dataSectionSeparator > uint(idx) {
+		r = nil
+		err = ErrCorrupt
+		return
+	}
+	r.tree = buf[:treeSize]
+	r.data = buf[treeSize+dataSectionSeparator : idx]
+	if r.IPVersion == 6 {
+		var node uint
+		for i := 0; i < 96 && node < r.NodeCount; i++ {
+			node = r.readNode(node, 0)
+		}
+		r.ipv4Root = node
+	}
+	return
+}
+
+func (md *Metadata) load(v interface{}) (err error) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return ErrInvalidMetadata
+	}
+	md.DatabaseType, _ = m[`database_type`].(string)
+	md.IPVersion = uintValue(m[`ip_version`])
+	md.NodeCount = uintValue(m[`node_count`])
+	md.RecordSize = uintValue(m[`record_size`])
+	md.MajorVersion = uintValue(m[`binary_format_major_version`])
+	md.MinorVersion = uintValue(m[`binary_format_minor_version`])
+	md.BuildEpoch = uint64(uintValue(m[`build_epoch`]))
+	if langs, ok := m[`languages`].([]interface{}); ok {
+		for _, l := range langs {
+			if s, ok := l.(string); ok {
+				md.Languages = append(md.Languages, s)
+			}
+		}
+	}
+	if desc, ok := m[`description`].(map[string]interface{}); ok {
+		md.Description = make(map[string]string, len(desc))
+		for k, v := range desc {
+			if s, ok := v.(string); ok {
+				md.Description[k] = s
+			}
+		}
+	}
+	if md.MajorVersion != 2 || md.NodeCount == 0 || (md.IPVersion != 4 && md.IPVersion != 6) {
+		err = ErrInvalidMetadata
+	}
+	return
+}
+
+func uintValue(v interface{}) uint {
+	switch t := v.(type) {
+	case uint64:
+		return uint(t)
+	case int64:
+		if t > 0 {
+			return uint(t)
+		}
+	}
+	return 0
+}
+
+// Lookup returns the record for an IP, ok is false if the database holds no record for it
+func (r *Reader) Lookup(ip net.IP) (rec Record, ok bool, err error) {
+	var off uint
+	if off, ok, err = r.lookupOffset(ip); err != nil || !ok {
+		return
+	}
+	var v interface{}
+	if v, _, err = r.decode(r.data, off, 0); err != nil {
+		ok = false
+		return
+	}
+	rec = Record{v: v}
+	return
+}
+
+func (r *Reader) lookupOffset(ip net.IP) (off uint, ok bool, err error) {
+	var bits int
+	var node uint
+	if ip4 := ip.To4(); ip4 != nil {
+		ip, bits = ip4, 32
+		if r.IPVersion == 6 {
+			node = r.ipv4Root
+		}
+	} else if ip = ip.To16(); ip == nil {
+		err = ErrInvalidIP
+		return
+	} else if r.IPVersion == 4 {
+		err = ErrIPv6InIPv4DB
+		return
+	} else {
+		bits = 128
+	}
+	for i := 0; i < bits && node < r.NodeCount; i++ {
+		bit := uint(ip[i>>3]>>(7-uint(i&7))) & 1
+		node = r.readNode(node, bit)
+	}
+	if node == r.NodeCount {
+		return //no record
+	} else if node < r.NodeCount {
+		err = ErrCorrupt //ran out of address bits inside the tree
+		return
+	}
+	if off = node - r.NodeCount - dataSectionSeparator; off >= uint(len(r.data)) {
+		err = ErrCorrupt
+		return
+	}
+	ok = true
+	return
+}
+
+func (r *Reader) readNode(node, bit uint) uint {
+	b := r.tree[node*r.nodeSize:]
+	switch r.RecordSize {
+	case 24:
+		b = b[bit*3:]
+		return uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+	case 28:
+		if bit == 0 {
+			return uint(b[3]&0xf0)<<20 | uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+		}
+		return uint(b[3]&0x0f)<<24 | uint(b[4])<<16 | uint(b[5])<<8 | uint(b[6])
+	default:
+		return uint(binary.BigEndian.Uint32(b[bit*4:]))
+	}
+}
+
+// decode decodes the value at off in section, returning the value and the offset following it
+func (r *Reader) decode(section []byte, off uint, depth int) (v interface{}, next uint, err error) {
+	if depth > maxPointerDepth {
+		err = ErrCorrupt
+		return
+	}
+	var typ, size uint
+	if typ, size, off, err = readControl(section, off); err != nil {
+		return
+	}
+	if typ == typePointer {
+		var ptr uint
+		if ptr, next, err = readPointer(section, off, size); err != nil {
+			return
+		} else if ptr >= uint(len(r.data)) {
+			err = ErrCorrupt
+			return
+		}
+		v, _, err = r.decode(r.data, ptr, depth+1)
+		return
+	}
+	switch typ {
+	case typeMap:
+		m := make(map[string]interface{}, size)
+		for i := uint(0); i < size; i++ {
+			var k, val interface{}
+			if k, off, err = r.decode(section, off, depth+1); err != nil {
+				return
+			}
+			ks, ok := k.(string)
+			if !ok {
+				err = ErrCorrupt
+				return
+			}
+			if val, off, err = r.decode(section, off, depth+1); err != nil {
+				return
+			}
+			m[ks] = val
+		}
+		v, next = m, off
+		return
+	case typeArray:
+		a := make([]interface{}, 0, size)
+		for i := uint(0); i < size; i++ {
+			var val interface{}
+			if val, off, err = r.decode(section, off, depth+1); err != nil {
+				return
+			}
+			a = append(a, val)
+		}
+		v, next = a, off
+		return
+	case typeBool:
+		v, next = size != 0, off
+		return
+	}
+	if off+size > uint(len(section)) {
+		err = ErrCorrupt
+		return
+	}
+	b := section[off : off+size]
+	next = off + size
+	switch typ {
+	case typeString:
+		v = string(b)
+	case typeBytes:
+		v = append([]byte{}, b...)
+	case typeDouble:
+		if size != 8 {
+			err = ErrCorrupt
+			return
+		}
+		v = math.Float64frombits(binary.BigEndian.Uint64(b))
+	case typeFloat:
+		if size != 4 {
+			err = ErrCorrupt
+			return
+		}
+		v = math.Float32frombits(binary.BigEndian.Uint32(b))
+	case typeUint16, typeUint32, typeUint64:
+		if size > 8 {
+			err = ErrCorrupt
+			return
+		}
+		var u uint64
+		for _, c := range b {
+			u = u<<8 | uint64(c)
+		}
+		v = u
+	case typeInt32:
+		if size > 4 {
+			err = ErrCorrupt
+			return
+		}
+		var u uint32
+		for _, c := range b {
+			u = u<<8 | uint32(c)
+		}
+		v = int64(int32(u))
+	case typeUint128:
+		if size > 16 {
+			err = ErrCorrupt
+			return
+		}
+		v = new(big.Int).SetBytes(b)
+	default:
+		err = fmt.Errorf("%w: unknown data type %d", ErrCorrupt, typ)
+	}
+	return
+}
+
+// readControl decodes a control byte and any extended type and size bytes
+func readControl(section []byte, off uint) (typ, size, next uint, err error) {
+	if off >= uint(len(section)) {
+		err = ErrCorrupt
+		return
+	}
+	ctrl := section[off]
+	off++
+	typ = uint(ctrl >> 5)
+	if typ == typeExtended {
+		if off >= uint(len(section)) {
+			err = ErrCorrupt
+			return
+		}
+		typ = 7 + uint(section[off])
+		off++
+		if typ <= typeMap || typ > typeFloat {
+			err = ErrCorrupt
+			return
+		}
+	}
+	size = uint(ctrl & 0x1f)
+	if typ == typePointer || size < 29 {
+		next = off
+		return
+	}
+	n := size - 28 //29, 30, and 31 are followed by 1, 2, and 3 bytes of size
+	if off+n > uint(len(section)) {
+		err = ErrCorrupt
+		return
+	}
+	var v uint
+	for _, c := range section[off : off+n] {
+		v = v<<8 | uint(c)
+	}
+	switch size {
+	case 29:
+		size = 29 + v
+	case 30:
+		size = 285 + v
+	default:
+		size = 65821 + v
+	}
+	next = off + n
+	return
+}
+
+// readPointer decodes a pointer whose control byte carried size bits ssvvv
+func readPointer(section []byte, off, size uint) (ptr, next uint, err error) {
+	ss := (size >> 3) & 0x3
+	n := ss + 1
+	if off+n > uint(len(section)) {
+		err = ErrCorrupt
+		return
+	}
+	b := section[off : off+n]
+	next = off + n
+	switch ss {
+	case 0:
+		ptr = (size&0x7)<<8 | uint(b[0])
+	case 1:
+		ptr = ((size&0x7)<<16 | uint(b[0])<<8 | uint(b[1])) + 2048
+	case 2:
+		ptr = ((size&0x7)<<24 | uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])) + 526336
+	default:
+		ptr = uint(binary.BigEndian.Uint32(b))
+	}
+	return
+}
+
+// Record is a decoded database record
+type Record struct {
+	v interface{}
+}
+
+// Path walks nested maps and arrays, string keys index maps and int keys index arrays
+func (r Record) Path(keys ...interface{}) (v interface{}, ok bool) {
+	v = r.v
+	for _, k := range keys {
+		switch kt := k.(type) {
+		case string:
+			var m map[string]interface{}
+			if m, ok = v.(map[string]interface{}); !ok {
+				return
+			} else if v, ok = m[kt]; !ok {
+				return
+			}
+		case int:
+			var a []interface{}
+			if a, ok = v.([]interface{}); !ok || kt < 0 || kt >= len(a) {
+				ok = false
+				return
+			}
+			v = a[kt]
+		default:
+			ok = false
+			return
+		}
+	}
+	ok = v != nil
+	return
+}
+
+// Value returns the raw decoded record
+func (r Record) Value() interface{} {
+	return r.v
+}
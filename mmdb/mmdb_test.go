@@ -0,0 +1,198 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package mmdb
+
+import (
+	"bytes"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func mustCIDR(t *testing.T, s string) *net.IPNet {
+	_, n, err := net.ParseCIDR(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func buildTestDB(t *testing.T, ipVersion, recordSize uint) []byte {
+	w, err := newWriter(ipVersion, `Test-City`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.RecordSize = recordSize
+	w.Languages = []string{`en`}
+	w.Description = map[string]string{`en`: `test database`}
+	recs := []struct {
+		net string
+		v   interface{}
+	}{
+		{`10.0.0.0/8`, map[string]interface{}{`country`: map[string]interface{}{`iso_code`: `US`}}},
+		{`10.1.0.0/16`, map[string]interface{}{
+			`country`:                        map[string]interface{}{`iso_code`: `DE`, `names`: map[string]string{`en`: `Germany`}},
+			`location`:                       map[string]interface{}{`latitude`: 52.5, `longitude`: 13.4},
+			`subdivisions`:                   []interface{}{map[string]interface{}{`iso_code`: `BE`}},
+			`autonomous_system_number`:       uint32(64500),
+			`autonomous_system_organization`: `Example Org`,
+			`flags`:                          []interface{}{true, false, int32(-7), float32(1.5), uint64(1 << 40), []byte{1, 2}},
+		}},
+		{`192.168.1.128/25`, map[string]interface{}{`country`: map[string]interface{}{`iso_code`: `GB`}}},
+	}
+	if ipVersion == 6 {
+		recs = append(recs, struct {
+			net string
+			v   interface{}
+		}{`2001:db8::/32`, map[string]interface{}{`country`: map[string]interface{}{`iso_code`: `JP`}}})
+	}
+	for _, r := range recs {
+		if err = w.Insert(mustCIDR(t, r.net), r.v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var bb bytes.Buffer
+	if _, err = w.WriteTo(&bb); err != nil {
+		t.Fatal(err)
+	}
+	return bb.Bytes()
+}
+
+func TestLookup(t *testing.T) {
+	for _, ipv := range []uint{4, 6} {
+		for _, rs := range []uint{24, 28, 32} {
+			r, err := New(buildTestDB(t, ipv, rs))
+			if err != nil {
+				t.Fatalf("v%d %d: %v", ipv, rs, err)
+			}
+			if r.IPVersion != ipv || r.RecordSize != rs || r.DatabaseType != `Test-City` || r.Description[`en`] != `test database` || len(r.Languages) != 1 {
+				t.Fatalf("bad metadata: %+v", r.Metadata)
+			}
+			tests := map[string]string{
+				`10.2.3.4`:      `US`,
+				`10.1.2.3`:      `DE`,
+				`192.168.1.200`: `GB`,
+				`192.168.1.100`: ``,
+				`8.8.8.8`:       ``,
+			}
+			if ipv == 6 {
+				tests[`2001:db8::1`] = `JP`
+				tests[`2001:db9::1`] = ``
+			}
+			for ip, want := range tests {
+				rec, ok, err := r.Lookup(net.ParseIP(ip))
+				if err != nil {
+					t.Fatalf("v%d %d %s: %v", ipv, rs, ip, err)
+				} else if ok != (want != ``) {
+					t.Fatalf("v%d %d %s: bad lookup %v", ipv, rs, ip, ok)
+				} else if !ok {
+					continue
+				}
+				if v, ok := rec.Path(`country`, `iso_code`); !ok || v != want {
+					t.Fatalf("v%d %d %s: bad country %v != %s", ipv, rs, ip, v, want)
+				}
+			}
+			if ipv == 4 {
+				if _, _, err = r.Lookup(net.ParseIP(`2001:db8::1`)); err != ErrIPv6InIPv4DB {
+					t.Fatalf("bad IPv6 lookup error: %v", err)
+				}
+			}
+		}
+	}
+}
+
+func TestRecordTypes(t *testing.T) {
+	pth := filepath.Join(t.TempDir(), `test.mmdb`)
+	if err := os.WriteFile(pth, buildTestDB(t, 6, 28), 0644); err != nil {
+		t.Fatal(err)
+	}
+	r, err := Open(pth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec, ok, err := r.Lookup(net.ParseIP(`10.1.0.1`))
+	if err != nil || !ok {
+		t.Fatalf("bad lookup: %v %v", ok, err)
+	}
+	checks := []struct {
+		path []interface{}
+		want interface{}
+	}{
+		{[]interface{}{`country`, `names`, `en`}, `Germany`},
+		{[]interface{}{`location`, `latitude`}, 52.5},
+		{[]interface{}{`subdivisions`, 0, `iso_code`}, `BE`},
+		{[]interface{}{`autonomous_system_number`}, uint64(64500)},
+		{[]interface{}{`autonomous_system_organization`}, `Example Org`},
+		{[]interface{}{`flags`, 0}, true},
+		{[]interface{}{`flags`, 1}, false},
+		{[]interface{}{`flags`, 2}, int64(-7)},
+		{[]interface{}{`flags`, 3}, float32(1.5)},
+		{[]interface{}{`flags`, 4}, uint64(1 << 40)},
+	}
+	for _, c := range checks {
+		if v, ok := rec.Path(c.path...); !ok || v != c.want {
+			t.Fatalf("bad value at %v: %v(%T) != %v(%T)", c.path, v, v, c.want, c.want)
+		}
+	}
+	if v, ok := rec.Path(`flags`, 5); !ok || !bytes.Equal(v.([]byte), []byte{1, 2}) {
+		t.Fatalf("bad bytes value: %v", v)
+	}
+	for _, p := range [][]interface{}{{`nope`}, {`subdivisions`, 1}, {`country`, 0}, {`flags`, `x`}} {
+		if _, ok := rec.Path(p...); ok {
+			t.Fatalf("found missing path %v", p)
+		}
+	}
+}
+
+func TestDecodeSizes(t *testing.T) {
+	r := &Reader{}
+	for _, sz := range []int{0, 28, 29, 284, 285, 65820, 65821, 70000} {
+		var bb bytes.Buffer
+		s := string(bytes.Repeat([]byte{'a'}, sz))
+		if err := encodeValue(&bb, s); err != nil {
+			t.Fatal(err)
+		}
+		v, next, err := r.decode(bb.Bytes(), 0, 0)
+		if err != nil {
+			t.Fatalf("%d: %v", sz, err)
+		} else if v != s || next != uint(bb.Len()) {
+			t.Fatalf("%d: bad decode %d %d", sz, len(v.(string)), next)
+		}
+	}
+	//uint128 and pointers are never written but must be read
+	r.data = []byte{0x43, 'a', 'b', 'c'}
+	v, next, err := r.decode([]byte{0x20, 0x00}, 0, 0)
+	if err != nil || v != `abc` || next != 2 {
+		t.Fatalf("bad pointer decode: %v %v %v", v, next, err)
+	}
+	if v, _, err = r.decode([]byte{0x02, 0x03, 0x01, 0x00}, 0, 0); err != nil || v.(*big.Int).Int64() != 256 {
+		t.Fatalf("bad uint128 decode: %v %v", v, err)
+	}
+}
+
+func TestCorrupt(t *testing.T) {
+	db := buildTestDB(t, 4, 24)
+	if _, err := New(db[:len(db)-40]); err == nil {
+		t.Fatal("failed to catch missing metadata")
+	}
+	//chop the data section out from under the tree
+	idx := bytes.LastIndex(db, metadataMarker)
+	if _, err := New(db[idx-20:]); err == nil {
+		t.Fatal("failed to catch truncated tree")
+	}
+	//truncated values must not panic
+	r := &Reader{}
+	for _, b := range [][]byte{{}, {0x45, 'a'}, {0x00}, {0x00, 0x00}, {0x5d}, {0x20}, {0x68, 1, 2}} {
+		if _, _, err := r.decode(b, 0, 0); err == nil {
+			t.Fatalf("failed to catch bad value %x", b)
+		}
+	}
+}
@@ -0,0 +1,300 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package mmdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"sort"
+	"time"
+)
+
+var (
+	errInvalidNetwork    = errors.New("Invalid network")
+	errUnsupportedType   = errors.New("Unsupported value type")
+	errTooManyNodes      = errors.New("Too many nodes for the record size")
+	errInvalidIPVersion  = errors.New("IP version must be 4 or 6")
+	errNetworkIPv6InIPv4 = errors.New("IPv6 network in an IPv4 only database")
+)
+
+const (
+	recEmpty = iota
+	recNode
+	recData
+)
+
+type wrec struct {
+	kind int
+	val  uint
+}
+
+// dbWriter builds small MaxMind DB files for exercising the reader.  Networks must
+// be inserted from least to most specific, inserting a network replaces any more
+// specific networks already held beneath it.
+type dbWriter struct {
+	IPVersion    uint
+	RecordSize   uint
+	DatabaseType string
+	Languages    []string
+	Description  map[string]string
+	nodes        [][2]wrec
+	data         bytes.Buffer
+	offsets      map[string]uint
+}
+
+// newWriter creates a writer for an IPv4 or IPv6 database, IPv4 networks inserted into
+// an IPv6 database are placed in the IPv4 mapped ::/96 subtree.
+func newWriter(ipVersion uint, dbType string) (w *dbWriter, err error) {
+	if ipVersion != 4 && ipVersion != 6 {
+		err = errInvalidIPVersion
+		return
+	}
+	w = &dbWriter{
+		IPVersion:    ipVersion,
+		RecordSize:   28,
+		DatabaseType: dbType,
+		nodes:        make([][2]wrec, 1),
+		offsets:      map[string]uint{},
+	}
+	return
+}
+
+// Insert associates a value with a network, values are typically map[string]interface{}
+func (w *dbWriter) Insert(n *net.IPNet, v interface{}) (err error) {
+	if n == nil {
+		return errInvalidNetwork
+	}
+	ones, bits := n.Mask.Size()
+	ip := n.IP
+	if bits == 32 {
+		if ip = ip.To4(); ip == nil {
+			return errInvalidNetwork
+		}
+		if w.IPVersion == 6 {
+			ip, ones = append(make(net.IP, 12), ip...), ones+96
+		}
+	} else if w.IPVersion == 4 {
+		return errNetworkIPv6InIPv4
+	} else if ip = ip.To16(); ip == nil || bits != 128 {
+		return errInvalidNetwork
+	}
+	if ones == 0 {
+		return errInvalidNetwork
+	}
+	var enc bytes.Buffer
+	if err = encodeValue(&enc, v); err != nil {
+		return
+	}
+	off, ok := w.offsets[enc.String()]
+	if !ok {
+		off = uint(w.data.Len())
+		w.offsets[enc.String()] = off
+		w.data.Write(enc.Bytes())
+	}
+	var node uint
+	for i := 0; i < ones; i++ {
+		bit := (ip[i>>3] >> (7 - uint(i&7))) & 1
+		if i == ones-1 {
+			w.nodes[node][bit] = wrec{kind: recData, val: off}
+			break
+		}
+		rec := w.nodes[node][bit]
+		if rec.kind != recNode {
+			//split the record, the new node inherits whatever the record held
+			w.nodes = append(w.nodes, [2]wrec{rec, rec})
+			rec = wrec{kind: recNode, val: uint(len(w.nodes) - 1)}
+			w.nodes[node][bit] = rec
+		}
+		node = rec.val
+	}
+	return
+}
+
+// WriteTo writes the complete database
+func (w *dbWriter) WriteTo(wtr io.Writer) (n int64, err error) {
+	var bb bytes.Buffer
+	nodeCount := uint(len(w.nodes))
+	switch w.RecordSize {
+	case 24, 28, 32:
+	default:
+		err = fmt.Errorf("%w: %d", ErrUnsupportedRecord, w.RecordSize)
+		return
+	}
+	if max := uint64(1)<<w.RecordSize - 1; uint64(nodeCount)+dataSectionSeparator+uint64(w.data.Len()) > max {
+		err = errTooManyNodes
+		return
+	}
+	resolve := func(r wrec) uint {
+		switch r.kind {
+		case recNode:
+			return r.val
+		case recData:
+			return nodeCount + dataSectionSeparator + r.val
+		}
+		return nodeCount
+	}
+	var b [8]byte
+	for _, nd := range w.nodes {
+		l, r := resolve(nd[0]), resolve(nd[1])
+		switch w.RecordSize {
+		case 24:
+			bb.Write([]byte{byte(l >> 16), byte(l >> 8), byte(l), byte(r >> 16), byte(r >> 8), byte(r)})
+		case 28:
+			bb.Write([]byte{byte(l >> 16), byte(l >> 8), byte(l), byte((l>>20)&0xf0 | (r>>24)&0x0f), byte(r >> 16), byte(r >> 8), byte(r)})
+		default:
+			binary.BigEndian.PutUint32(b[:], uint32(l))
+			binary.BigEndian.PutUint32(b[4:], uint32(r))
+			bb.Write(b[:])
+		}
+	}
+	bb.Write(make([]byte, dataSectionSeparator))
+	bb.Write(w.data.Bytes())
+	bb.Write(metadataMarker)
+	md := map[string]interface{}{
+		`binary_format_major_version`: uint16(2),
+		`binary_format_minor_version`: uint16(0),
+		`build_epoch`:                 uint64(time.Now().Unix()),
+		`database_type`:               w.DatabaseType,
+		`ip_version`:                  uint16(w.IPVersion),
+		`node_count`:                  uint32(nodeCount),
+		`record_size`:                 uint16(w.RecordSize),
+	}
+	if len(w.Languages) > 0 {
+		md[`languages`] = w.Languages
+	}
+	if len(w.Description) > 0 {
+		md[`description`] = w.Description
+	}
+	if err = encodeValue(&bb, md); err != nil {
+		return
+	}
+	return bb.WriteTo(wtr)
+}
+
+func writeControl(bb *bytes.Buffer, typ, size uint) {
+	var ext []byte
+	var ctrl byte
+	switch {
+	case size < 29:
+		ctrl = byte(size)
+	case size < 285:
+		ctrl, ext = 29, []byte{byte(size - 29)}
+	case size < 65821:
+		size -= 285
+		ctrl, ext = 30, []byte{byte(size >> 8), byte(size)}
+	default:
+		size -= 65821
+		ctrl, ext = 31, []byte{byte(size >> 16), byte(size >> 8), byte(size)}
+	}
+	if typ > typeMap {
+		bb.WriteByte(ctrl)
+		bb.WriteByte(byte(typ - 7))
+	} else {
+		bb.WriteByte(byte(typ<<5) | ctrl)
+	}
+	bb.Write(ext)
+}
+
+func writeUint(bb *bytes.Buffer, typ uint, v uint64) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], v)
+	i := 0
+	for i < 8 && b[i] == 0 {
+		i++
+	}
+	writeControl(bb, typ, uint(8-i))
+	bb.Write(b[i:])
+}
+
+func encodeValue(bb *bytes.Buffer, v interface{}) (err error) {
+	switch t := v.(type) {
+	case string:
+		writeControl(bb, typeString, uint(len(t)))
+		bb.WriteString(t)
+	case []byte:
+		writeControl(bb, typeBytes, uint(len(t)))
+		bb.Write(t)
+	case bool:
+		var sz uint
+		if t {
+			sz = 1
+		}
+		writeControl(bb, typeBool, sz)
+	case float64:
+		var b [8]byte
+		binary.BigEndian.PutUint64(b[:], math.Float64bits(t))
+		writeControl(bb, typeDouble, 8)
+		bb.Write(b[:])
+	case float32:
+		var b [4]byte
+		binary.BigEndian.PutUint32(b[:], math.Float32bits(t))
+		writeControl(bb, typeFloat, 4)
+		bb.Write(b[:])
+	case uint16:
+		writeUint(bb, typeUint16, uint64(t))
+	case uint32:
+		writeUint(bb, typeUint32, uint64(t))
+	case uint64:
+		writeUint(bb, typeUint64, t)
+	case uint:
+		writeUint(bb, typeUint64, uint64(t))
+	case int32:
+		var b [4]byte
+		binary.BigEndian.PutUint32(b[:], uint32(t))
+		writeControl(bb, typeInt32, 4)
+		bb.Write(b[:])
+	case int:
+		if t < 0 {
+			if t < math.MinInt32 {
+				return fmt.Errorf("%w: %d", errUnsupportedType, t)
+			}
+			return encodeValue(bb, int32(t))
+		}
+		writeUint(bb, typeUint64, uint64(t))
+	case []string:
+		writeControl(bb, typeArray, uint(len(t)))
+		for _, s := range t {
+			encodeValue(bb, s)
+		}
+	case []interface{}:
+		writeControl(bb, typeArray, uint(len(t)))
+		for _, x := range t {
+			if err = encodeValue(bb, x); err != nil {
+				return
+			}
+		}
+	case map[string]string:
+		m := make(map[string]interface{}, len(t))
+		for k, x := range t {
+			m[k] = x
+		}
+		return encodeValue(bb, m)
+	case map[string]interface{}:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		writeControl(bb, typeMap, uint(len(t)))
+		for _, k := range keys {
+			encodeValue(bb, k)
+			if err = encodeValue(bb, t[k]); err != nil {
+				return
+			}
+		}
+	default:
+		err = fmt.Errorf("%w: %T", errUnsupportedType, v)
+	}
+	return
+}
@@ -6,7 +6,7 @@
  * BSD 2-clause license. See the LICENSE file for details.
  **************************************************************************/
 
-// Package netflow implements a low level high speed netflowV5 encoder/decoder
+// Package netflow implements a low level high speed netflowV5 encoder/decoder, a Netflow V9 flowset splitter, and an sFlow V5 decoder
 package netflow
 
 import (
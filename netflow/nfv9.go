@@ -0,0 +1,195 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package netflow
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// Netflow V9 (RFC 3954) packets are a fixed header followed by flowsets.  Template
+// flowsets describe the layout of data flowsets, data flowsets can only be decoded
+// if the template they reference has been seen, possibly in an earlier packet.
+// This is a low level splitter, it does not decode data records.
+
+const (
+	NFv9Version    uint16 = 9
+	NFv9HeaderSize int    = 20
+
+	NFv9TemplateFlowSetID        uint16 = 0
+	NFv9OptionsTemplateFlowSetID uint16 = 1
+	NFv9MinDataFlowSetID         uint16 = 256
+
+	nfv9FlowSetHeaderSize      int = 4
+	nfv9TemplateHeaderSize     int = 4
+	nfv9OptionsTemplateHdrSize int = 6
+	nfv9FieldSize              int = 4
+)
+
+var (
+	ErrNFv9HeaderTooShort   = errors.New("Buffer too small for Netflow V9 header")
+	ErrInvalidNFv9Version   = errors.New("Not a valid Netflow V9 packet")
+	ErrInvalidNFv9FlowSet   = errors.New("Invalid Netflow V9 flowset length")
+	ErrInvalidNFv9Template  = errors.New("Invalid Netflow V9 template record")
+	ErrNotNFv9TemplateFlows = errors.New("Netflow V9 flowset is not a template flowset")
+)
+
+type NFv9Header struct {
+	Version  uint16
+	Count    uint16 // total number of template and data records in the packet
+	Uptime   uint32
+	Sec      uint32
+	Sequence uint32
+	SourceID uint32
+}
+
+// NFv9FlowSet is a single flowset, Data does not include the flowset ID and length
+// but does include any trailing padding.
+type NFv9FlowSet struct {
+	ID   uint16
+	Data []byte
+}
+
+// NFv9Template is a single template or options template record.  Record holds the
+// complete encoded record so that it can be attached to another packet unchanged.
+type NFv9Template struct {
+	ID      uint16
+	Options bool
+	Record  []byte
+}
+
+// Decode decodes a Netflow V9 header
+func (h *NFv9Header) Decode(b []byte) error {
+	if len(b) < NFv9HeaderSize {
+		return ErrNFv9HeaderTooShort
+	}
+	h.Version = binary.BigEndian.Uint16(b)
+	h.Count = binary.BigEndian.Uint16(b[2:4])
+	h.Uptime = binary.BigEndian.Uint32(b[4:8])
+	h.Sec = binary.BigEndian.Uint32(b[8:12])
+	h.Sequence = binary.BigEndian.Uint32(b[12:16])
+	h.SourceID = binary.BigEndian.Uint32(b[16:20])
+	if h.Version != NFv9Version {
+		return ErrInvalidNFv9Version
+	}
+	return nil
+}
+
+// Encode encodes a NFv9Header into a byte array
+func (h *NFv9Header) Encode() (b []byte) {
+	b = make([]byte, NFv9HeaderSize)
+	binary.BigEndian.PutUint16(b[0:2], h.Version)
+	binary.BigEndian.PutUint16(b[2:4], h.Count)
+	binary.BigEndian.PutUint32(b[4:8], h.Uptime)
+	binary.BigEndian.PutUint32(b[8:12], h.Sec)
+	binary.BigEndian.PutUint32(b[12:16], h.Sequence)
+	binary.BigEndian.PutUint32(b[16:20], h.SourceID)
+	return
+}
+
+// SplitNFv9FlowSets splits a complete Netflow V9 packet into its flowsets.
+// The flowsets reference b.
+func SplitNFv9FlowSets(b []byte) (fss []NFv9FlowSet, err error) {
+	var h NFv9Header
+	if err = h.Decode(b); err != nil {
+		return
+	}
+	for b = b[NFv9HeaderSize:]; len(b) > 0; {
+		if len(b) < nfv9FlowSetHeaderSize {
+			err = ErrInvalidNFv9FlowSet
+			return
+		}
+		l := int(binary.BigEndian.Uint16(b[2:4]))
+		if l < nfv9FlowSetHeaderSize || l > len(b) {
+			err = ErrInvalidNFv9FlowSet
+			return
+		}
+		fss = append(fss, NFv9FlowSet{
+			ID:   binary.BigEndian.Uint16(b),
+			Data: b[nfv9FlowSetHeaderSize:l],
+		})
+		b = b[l:]
+	}
+	return
+}
+
+// IsTemplate returns true if the flowset carries template or options template records.
+func (fs NFv9FlowSet) IsTemplate() bool {
+	return fs.ID == NFv9TemplateFlowSetID || fs.ID == NFv9OptionsTemplateFlowSetID
+}
+
+// IsData returns true if the flowset carries data records.
+func (fs NFv9FlowSet) IsData() bool {
+	return fs.ID >= NFv9MinDataFlowSetID
+}
+
+// Templates decodes the template records in a template or options template flowset.
+// The returned records reference the flowset data.
+func (fs NFv9FlowSet) Templates() (tmpls []NFv9Template, err error) {
+	b := fs.Data
+	switch fs.ID {
+	case NFv9TemplateFlowSetID:
+		for len(b) >= nfv9TemplateHeaderSize {
+			id := binary.BigEndian.Uint16(b)
+			if id < NFv9MinDataFlowSetID {
+				break //padding
+			}
+			l := nfv9TemplateHeaderSize + int(binary.BigEndian.Uint16(b[2:4]))*nfv9FieldSize
+			if l > len(b) {
+				err = ErrInvalidNFv9Template
+				return
+			}
+			tmpls = append(tmpls, NFv9Template{ID: id, Record: b[:l]})
+			b = b[l:]
+		}
+	case NFv9OptionsTemplateFlowSetID:
+		for len(b) >= nfv9OptionsTemplateHdrSize {
+			id := binary.BigEndian.Uint16(b)
+			if id < NFv9MinDataFlowSetID {
+				break //padding
+			}
+			scope, opts := int(binary.BigEndian.Uint16(b[2:4])), int(binary.BigEndian.Uint16(b[4:6]))
+			if scope%nfv9FieldSize != 0 || opts%nfv9FieldSize != 0 {
+				err = ErrInvalidNFv9Template
+				return
+			}
+			l := nfv9OptionsTemplateHdrSize + scope + opts
+			if l > len(b) {
+				err = ErrInvalidNFv9Template
+				return
+			}
+			tmpls = append(tmpls, NFv9Template{ID: id, Options: true, Record: b[:l]})
+			b = b[l:]
+		}
+	default:
+		err = ErrNotNFv9TemplateFlows
+	}
+	return
+}
+
+// AppendNFv9TemplateFlowSet appends a template flowset holding the provided records to b.
+// All records must be of the same kind, the flowset is an options template flowset if
+// options is set.  The flowset is padded out to a 4 byte boundary.
+func AppendNFv9TemplateFlowSet(b []byte, options bool, tmpls ...NFv9Template) []byte {
+	id := NFv9TemplateFlowSetID
+	if options {
+		id = NFv9OptionsTemplateFlowSetID
+	}
+	l := nfv9FlowSetHeaderSize
+	for _, t := range tmpls {
+		l += len(t.Record)
+	}
+	pad := (4 - l%4) % 4
+	b = binary.BigEndian.AppendUint16(b, id)
+	b = binary.BigEndian.AppendUint16(b, uint16(l+pad))
+	for _, t := range tmpls {
+		b = append(b, t.Record...)
+	}
+	return append(b, make([]byte, pad)...)
+}
@@ -0,0 +1,125 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package netflow
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func testNFv9Packet() []byte {
+	h := NFv9Header{Version: NFv9Version, Count: 4, Uptime: 1000, Sec: 1700000000, Sequence: 7, SourceID: 42}
+	b := h.Encode()
+	// template 256 with two fields, template 257 with one field
+	b = AppendNFv9TemplateFlowSet(b, false,
+		NFv9Template{Record: []byte{0x01, 0x00, 0x00, 0x02, 0x00, 0x08, 0x00, 0x04, 0x00, 0x0c, 0x00, 0x04}},
+		NFv9Template{Record: []byte{0x01, 0x01, 0x00, 0x01, 0x00, 0x04, 0x00, 0x01}},
+	)
+	// options template 258 with one scope field and one option field
+	b = AppendNFv9TemplateFlowSet(b, true,
+		NFv9Template{Record: []byte{0x01, 0x02, 0x00, 0x04, 0x00, 0x04, 0x00, 0x01, 0x00, 0x04, 0x00, 0x22, 0x00, 0x04}},
+	)
+	// data flowset for template 256, one record plus padding
+	b = append(b, 0x01, 0x00, 0x00, 0x0c, 10, 0, 0, 1, 10, 0, 0, 2)
+	return b
+}
+
+func TestNFv9Split(t *testing.T) {
+	b := testNFv9Packet()
+	var h NFv9Header
+	if err := h.Decode(b); err != nil {
+		t.Fatal(err)
+	} else if h.Count != 4 || h.Sec != 1700000000 || h.Sequence != 7 || h.SourceID != 42 {
+		t.Fatalf("bad header: %+v", h)
+	}
+	fss, err := SplitNFv9FlowSets(b)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(fss) != 3 {
+		t.Fatalf("bad flowset count %d", len(fss))
+	}
+	if !fss[0].IsTemplate() || !fss[1].IsTemplate() || !fss[2].IsData() || fss[2].ID != 256 {
+		t.Fatalf("bad flowset ids: %d %d %d", fss[0].ID, fss[1].ID, fss[2].ID)
+	}
+
+	tmpls, err := fss[0].Templates()
+	if err != nil {
+		t.Fatal(err)
+	} else if len(tmpls) != 2 || tmpls[0].ID != 256 || tmpls[1].ID != 257 || tmpls[0].Options {
+		t.Fatalf("bad templates: %+v", tmpls)
+	} else if len(tmpls[0].Record) != 12 || len(tmpls[1].Record) != 8 {
+		t.Fatalf("bad template records: %+v", tmpls)
+	}
+	if tmpls, err = fss[1].Templates(); err != nil {
+		t.Fatal(err)
+	} else if len(tmpls) != 1 || tmpls[0].ID != 258 || !tmpls[0].Options || len(tmpls[0].Record) != 14 {
+		t.Fatalf("bad options templates: %+v", tmpls)
+	}
+	if _, err = fss[2].Templates(); err != ErrNotNFv9TemplateFlows {
+		t.Fatalf("bad error on data flowset: %v", err)
+	}
+}
+
+func TestNFv9AppendTemplates(t *testing.T) {
+	tmpl := NFv9Template{ID: 300, Record: []byte{0x01, 0x2c, 0x00, 0x01, 0x00, 0x08, 0x00, 0x04}}
+	opt := NFv9Template{ID: 301, Options: true, Record: []byte{0x01, 0x2d, 0x00, 0x04, 0x00, 0x00, 0x00, 0x01, 0x00, 0x04}}
+	b := (&NFv9Header{Version: NFv9Version}).Encode()
+	b = AppendNFv9TemplateFlowSet(b, false, tmpl)
+	b = AppendNFv9TemplateFlowSet(b, true, opt)
+	if len(b)%4 != 0 {
+		t.Fatalf("flowsets not padded: %d", len(b))
+	}
+	fss, err := SplitNFv9FlowSets(b)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(fss) != 2 {
+		t.Fatalf("bad flowset count %d", len(fss))
+	}
+	for i, want := range []NFv9Template{tmpl, opt} {
+		got, err := fss[i].Templates()
+		if err != nil {
+			t.Fatal(err)
+		} else if len(got) != 1 || got[0].ID != want.ID || got[0].Options != want.Options || !bytes.Equal(got[0].Record, want.Record) {
+			t.Fatalf("bad round trip: %+v != %+v", got, want)
+		}
+	}
+}
+
+func TestNFv9Bad(t *testing.T) {
+	good := testNFv9Packet()
+	if _, err := SplitNFv9FlowSets(good[:NFv9HeaderSize-1]); err != ErrNFv9HeaderTooShort {
+		t.Fatalf("bad short header error: %v", err)
+	}
+	bad := append([]byte{}, good...)
+	binary.BigEndian.PutUint16(bad, 10)
+	if _, err := SplitNFv9FlowSets(bad); err != ErrInvalidNFv9Version {
+		t.Fatalf("bad version error: %v", err)
+	}
+	for _, l := range []uint16{0, 3, 0xffff} {
+		bad = append(bad[:0], good...)
+		binary.BigEndian.PutUint16(bad[NFv9HeaderSize+2:], l)
+		if _, err := SplitNFv9FlowSets(bad); err != ErrInvalidNFv9FlowSet {
+			t.Fatalf("bad flowset length %d error: %v", l, err)
+		}
+	}
+	if _, err := SplitNFv9FlowSets(good[:len(good)-2]); err != ErrInvalidNFv9FlowSet {
+		t.Fatalf("bad truncated flowset error: %v", err)
+	}
+
+	// template claiming more fields than the flowset holds
+	fs := NFv9FlowSet{ID: NFv9TemplateFlowSetID, Data: []byte{0x01, 0x00, 0x00, 0x09, 0x00, 0x08, 0x00, 0x04}}
+	if _, err := fs.Templates(); err != ErrInvalidNFv9Template {
+		t.Fatalf("bad template error: %v", err)
+	}
+	fs = NFv9FlowSet{ID: NFv9OptionsTemplateFlowSetID, Data: []byte{0x01, 0x00, 0x00, 0x03, 0x00, 0x00, 0x00, 0x00, 0x00}}
+	if _, err := fs.Templates(); err != ErrInvalidNFv9Template {
+		t.Fatalf("bad options template error: %v", err)
+	}
+}
@@ -0,0 +1,443 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package netflow
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// sFlow v5 datagrams are XDR encoded, every field is a big endian 32 or 64 bit
+// value and opaque data is padded out to a 4 byte boundary.  Samples and records
+// are all prefixed with a data format (enterprise << 12 | format) and a length
+// so that anything we do not understand can be skipped.
+
+const (
+	SFlowVersion uint32 = 5
+
+	SFlowAddressIPv4 uint32 = 1
+	SFlowAddressIPv6 uint32 = 2
+
+	// sample formats for the standard enterprise
+	SFlowFormatFlowSample            uint32 = 1
+	SFlowFormatCounterSample         uint32 = 2
+	SFlowFormatExpandedFlowSample    uint32 = 3
+	SFlowFormatExpandedCounterSample uint32 = 4
+
+	// flow record formats for the standard enterprise
+	SFlowRawPacketHeader uint32 = 1
+
+	// counter record formats for the standard enterprise
+	SFlowGenericInterfaceCounters uint32 = 1
+
+	// header protocols for a raw packet header record
+	SFlowHeaderEthernet uint32 = 1
+	SFlowHeaderIPv4     uint32 = 11
+	SFlowHeaderIPv6     uint32 = 12
+
+	sflowHeaderSize          int = 28 // header size with an IPv4 agent address
+	sflowFlowSampleSize      int = 32
+	sflowExpandedFlowSize    int = 44
+	sflowCounterSampleSize   int = 12
+	sflowExpandedCounterSize int = 16
+	sflowRawHeaderSize       int = 16
+	sflowIfCountersSize      int = 88
+	sflowMinRecordSize       int = 8
+)
+
+var (
+	ErrSFlowHeaderTooShort  = errors.New("Buffer too small for sFlow V5 header")
+	ErrInvalidSFlowVersion  = errors.New("Not a valid sFlow V5 datagram")
+	ErrInvalidSFlowAddress  = errors.New("Invalid sFlow agent address type")
+	ErrInvalidSFlowCount    = errors.New("sFlow sample or record count is invalid")
+	ErrSFlowSampleTooShort  = errors.New("Buffer too small for sFlow sample")
+	ErrSFlowRecordTooShort  = errors.New("Buffer too small for sFlow record")
+	ErrInvalidSFlowDataType = errors.New("sFlow record is not the requested type")
+)
+
+// SFlowDatagram is a decoded sFlow V5 datagram.  Samples are split by kind, expanded
+// samples are folded into the same types as their compact counterparts.
+type SFlowDatagram struct {
+	SFlowHeader
+	FlowSamples    []SFlowFlowSample
+	CounterSamples []SFlowCounterSample
+	Unknown        []SFlowRecord // samples with a format we do not decode
+}
+
+type SFlowHeader struct {
+	Version      uint32
+	AgentAddress net.IP
+	SubAgentID   uint32
+	Sequence     uint32
+	Uptime       uint32 // milliseconds since the agent booted
+	Count        uint32 // number of samples in the datagram
+}
+
+// SFlowFlowSample is a packet sample taken on an interface.
+// Input and Output carry the interface format in InputFormat and OutputFormat,
+// format 0 is an ifIndex, 1 is a packet discard reason, 2 is a multiple interface count.
+type SFlowFlowSample struct {
+	Expanded      bool
+	Sequence      uint32
+	SourceIDType  uint32
+	SourceIDIndex uint32
+	SamplingRate  uint32
+	SamplePool    uint32
+	Drops         uint32
+	InputFormat   uint32
+	Input         uint32
+	OutputFormat  uint32
+	Output        uint32
+	Records       []SFlowFlowRecord
+}
+
+// SFlowFlowRecord is a single flow record within a flow sample, Header is populated
+// when the record is a raw packet header.
+type SFlowFlowRecord struct {
+	SFlowRecord
+	Header *SFlowSampledHeader
+}
+
+// SFlowSampledHeader is the leading portion of a sampled packet.
+type SFlowSampledHeader struct {
+	Protocol    uint32 // SFlowHeaderEthernet, SFlowHeaderIPv4, etc.
+	FrameLength uint32 // original length of the frame
+	Stripped    uint32 // bytes removed from the frame before the header was taken
+	Header      []byte
+}
+
+// SFlowCounterSample is a set of counters from a data source.
+type SFlowCounterSample struct {
+	Expanded      bool
+	Sequence      uint32
+	SourceIDType  uint32
+	SourceIDIndex uint32
+	Records       []SFlowCounterRecord
+}
+
+// SFlowCounterRecord is a single counter record within a counter sample, Interface
+// is populated when the record holds the generic interface counters.
+type SFlowCounterRecord struct {
+	SFlowRecord
+	Interface *SFlowIfCounters
+}
+
+// SFlowIfCounters are the generic interface counters defined in RFC 2233.
+type SFlowIfCounters struct {
+	IfIndex            uint32
+	IfType             uint32
+	IfSpeed            uint64
+	IfDirection        uint32
+	IfStatus           uint32
+	IfInOctets         uint64
+	IfInUcastPkts      uint32
+	IfInMulticastPkts  uint32
+	IfInBroadcastPkts  uint32
+	IfInDiscards       uint32
+	IfInErrors         uint32
+	IfInUnknownProtos  uint32
+	IfOutOctets        uint64
+	IfOutUcastPkts     uint32
+	IfOutMulticastPkts uint32
+	IfOutBroadcastPkts uint32
+	IfOutDiscards      uint32
+	IfOutErrors        uint32
+	IfPromiscuousMode  uint32
+}
+
+// SFlowRecord is a raw sample or record, Data does not include the format and length.
+type SFlowRecord struct {
+	Format uint32
+	Data   []byte
+}
+
+// Enterprise returns the enterprise that defined the record format, 0 is the sFlow standard.
+func (r SFlowRecord) Enterprise() uint32 {
+	return r.Format >> 12
+}
+
+// FormatID returns the format of the record within its enterprise.
+func (r SFlowRecord) FormatID() uint32 {
+	return r.Format & 0xfff
+}
+
+func (r SFlowRecord) standard(format uint32) bool {
+	return r.Enterprise() == 0 && r.FormatID() == format
+}
+
+// Decode decodes the sFlow datagram header, returning the number of bytes consumed.
+// The agent address is copied so no references are held on b.
+func (h *SFlowHeader) Decode(b []byte) (n int, err error) {
+	if len(b) < sflowHeaderSize {
+		err = ErrSFlowHeaderTooShort
+		return
+	}
+	if h.Version = binary.BigEndian.Uint32(b); h.Version != SFlowVersion {
+		err = ErrInvalidSFlowVersion
+		return
+	}
+	var alen int
+	switch binary.BigEndian.Uint32(b[4:]) {
+	case SFlowAddressIPv4:
+		alen = net.IPv4len
+	case SFlowAddressIPv6:
+		alen = net.IPv6len
+	default:
+		err = ErrInvalidSFlowAddress
+		return
+	}
+	if n = 8 + alen + 16; len(b) < n {
+		err = ErrSFlowHeaderTooShort
+		return
+	}
+	h.AgentAddress = make(net.IP, alen)
+	copy(h.AgentAddress, b[8:8+alen])
+	b = b[8+alen:]
+	h.SubAgentID = binary.BigEndian.Uint32(b)
+	h.Sequence = binary.BigEndian.Uint32(b[4:])
+	h.Uptime = binary.BigEndian.Uint32(b[8:])
+	h.Count = binary.BigEndian.Uint32(b[12:])
+	return
+}
+
+// Decode decodes an entire sFlow V5 datagram.  Sample and record data is not copied,
+// the decoded datagram references b so b must not be reused while the datagram is in use.
+func (d *SFlowDatagram) Decode(b []byte) (err error) {
+	var n int
+	if n, err = d.SFlowHeader.Decode(b); err != nil {
+		return
+	}
+	var recs []SFlowRecord
+	if recs, err = splitSFlowRecords(b[n:], d.Count); err != nil {
+		return
+	}
+	d.FlowSamples = d.FlowSamples[:0]
+	d.CounterSamples = d.CounterSamples[:0]
+	d.Unknown = d.Unknown[:0]
+	for _, r := range recs {
+		switch {
+		case r.standard(SFlowFormatFlowSample) || r.standard(SFlowFormatExpandedFlowSample):
+			var fs SFlowFlowSample
+			if err = fs.decode(r); err != nil {
+				return
+			}
+			d.FlowSamples = append(d.FlowSamples, fs)
+		case r.standard(SFlowFormatCounterSample) || r.standard(SFlowFormatExpandedCounterSample):
+			var cs SFlowCounterSample
+			if err = cs.decode(r); err != nil {
+				return
+			}
+			d.CounterSamples = append(d.CounterSamples, cs)
+		default:
+			d.Unknown = append(d.Unknown, r)
+		}
+	}
+	return
+}
+
+// UptimeDuration returns the agent uptime as a duration, sFlow datagrams carry no wall clock time.
+func (h *SFlowHeader) UptimeDuration() time.Duration {
+	return time.Duration(h.Uptime) * time.Millisecond
+}
+
+func (fs *SFlowFlowSample) decode(r SFlowRecord) (err error) {
+	b := r.Data
+	if fs.Expanded = r.standard(SFlowFormatExpandedFlowSample); fs.Expanded {
+		if len(b) < sflowExpandedFlowSize {
+			err = ErrSFlowSampleTooShort
+			return
+		}
+		fs.Sequence = binary.BigEndian.Uint32(b)
+		fs.SourceIDType = binary.BigEndian.Uint32(b[4:])
+		fs.SourceIDIndex = binary.BigEndian.Uint32(b[8:])
+		fs.SamplingRate = binary.BigEndian.Uint32(b[12:])
+		fs.SamplePool = binary.BigEndian.Uint32(b[16:])
+		fs.Drops = binary.BigEndian.Uint32(b[20:])
+		fs.InputFormat = binary.BigEndian.Uint32(b[24:])
+		fs.Input = binary.BigEndian.Uint32(b[28:])
+		fs.OutputFormat = binary.BigEndian.Uint32(b[32:])
+		fs.Output = binary.BigEndian.Uint32(b[36:])
+		b = b[40:]
+	} else {
+		if len(b) < sflowFlowSampleSize {
+			err = ErrSFlowSampleTooShort
+			return
+		}
+		fs.Sequence = binary.BigEndian.Uint32(b)
+		src := binary.BigEndian.Uint32(b[4:])
+		fs.SourceIDType, fs.SourceIDIndex = src>>24, src&0xffffff
+		fs.SamplingRate = binary.BigEndian.Uint32(b[8:])
+		fs.SamplePool = binary.BigEndian.Uint32(b[12:])
+		fs.Drops = binary.BigEndian.Uint32(b[16:])
+		in := binary.BigEndian.Uint32(b[20:])
+		fs.InputFormat, fs.Input = in>>30, in&0x3fffffff
+		out := binary.BigEndian.Uint32(b[24:])
+		fs.OutputFormat, fs.Output = out>>30, out&0x3fffffff
+		b = b[28:]
+	}
+	var recs []SFlowRecord
+	if recs, err = splitSFlowRecords(b[4:], binary.BigEndian.Uint32(b)); err != nil {
+		return
+	}
+	fs.Records = make([]SFlowFlowRecord, 0, len(recs))
+	for _, r := range recs {
+		fr := SFlowFlowRecord{SFlowRecord: r}
+		if r.standard(SFlowRawPacketHeader) {
+			var sh SFlowSampledHeader
+			if sh, err = r.SampledHeader(); err != nil {
+				return
+			}
+			fr.Header = &sh
+		}
+		fs.Records = append(fs.Records, fr)
+	}
+	return
+}
+
+func (cs *SFlowCounterSample) decode(r SFlowRecord) (err error) {
+	b := r.Data
+	if cs.Expanded = r.standard(SFlowFormatExpandedCounterSample); cs.Expanded {
+		if len(b) < sflowExpandedCounterSize {
+			err = ErrSFlowSampleTooShort
+			return
+		}
+		cs.Sequence = binary.BigEndian.Uint32(b)
+		cs.SourceIDType = binary.BigEndian.Uint32(b[4:])
+		cs.SourceIDIndex = binary.BigEndian.Uint32(b[8:])
+		b = b[12:]
+	} else {
+		if len(b) < sflowCounterSampleSize {
+			err = ErrSFlowSampleTooShort
+			return
+		}
+		cs.Sequence = binary.BigEndian.Uint32(b)
+		src := binary.BigEndian.Uint32(b[4:])
+		cs.SourceIDType, cs.SourceIDIndex = src>>24, src&0xffffff
+		b = b[8:]
+	}
+	var recs []SFlowRecord
+	if recs, err = splitSFlowRecords(b[4:], binary.BigEndian.Uint32(b)); err != nil {
+		return
+	}
+	cs.Records = make([]SFlowCounterRecord, 0, len(recs))
+	for _, r := range recs {
+		cr := SFlowCounterRecord{SFlowRecord: r}
+		if r.standard(SFlowGenericInterfaceCounters) {
+			var ic SFlowIfCounters
+			if ic, err = r.IfCounters(); err != nil {
+				return
+			}
+			cr.Interface = &ic
+		}
+		cs.Records = append(cs.Records, cr)
+	}
+	return
+}
+
+// SampledHeader decodes a raw packet header flow record.
+func (r SFlowRecord) SampledHeader() (sh SFlowSampledHeader, err error) {
+	if !r.standard(SFlowRawPacketHeader) {
+		err = ErrInvalidSFlowDataType
+		return
+	}
+	b := r.Data
+	if len(b) < sflowRawHeaderSize {
+		err = ErrSFlowRecordTooShort
+		return
+	}
+	sh.Protocol = binary.BigEndian.Uint32(b)
+	sh.FrameLength = binary.BigEndian.Uint32(b[4:])
+	sh.Stripped = binary.BigEndian.Uint32(b[8:])
+	l := binary.BigEndian.Uint32(b[12:])
+	if uint64(l) > uint64(len(b)-sflowRawHeaderSize) {
+		err = ErrSFlowRecordTooShort
+		return
+	}
+	sh.Header = b[sflowRawHeaderSize : sflowRawHeaderSize+int(l)]
+	return
+}
+
+// IfCounters decodes a generic interface counters record.
+func (r SFlowRecord) IfCounters() (ic SFlowIfCounters, err error) {
+	if !r.standard(SFlowGenericInterfaceCounters) {
+		err = ErrInvalidSFlowDataType
+		return
+	}
+	b := r.Data
+	if len(b) < sflowIfCountersSize {
+		err = ErrSFlowRecordTooShort
+		return
+	}
+	ic.IfIndex = binary.BigEndian.Uint32(b)
+	ic.IfType = binary.BigEndian.Uint32(b[4:])
+	ic.IfSpeed = binary.BigEndian.Uint64(b[8:])
+	ic.IfDirection = binary.BigEndian.Uint32(b[16:])
+	ic.IfStatus = binary.BigEndian.Uint32(b[20:])
+	ic.IfInOctets = binary.BigEndian.Uint64(b[24:])
+	ic.IfInUcastPkts = binary.BigEndian.Uint32(b[32:])
+	ic.IfInMulticastPkts = binary.BigEndian.Uint32(b[36:])
+	ic.IfInBroadcastPkts = binary.BigEndian.Uint32(b[40:])
+	ic.IfInDiscards = binary.BigEndian.Uint32(b[44:])
+	ic.IfInErrors = binary.BigEndian.Uint32(b[48:])
+	ic.IfInUnknownProtos = binary.BigEndian.Uint32(b[52:])
+	ic.IfOutOctets = binary.BigEndian.Uint64(b[56:])
+	ic.IfOutUcastPkts = binary.BigEndian.Uint32(b[64:])
+	ic.IfOutMulticastPkts = binary.BigEndian.Uint32(b[68:])
+	ic.IfOutBroadcastPkts = binary.BigEndian.Uint32(b[72:])
+	ic.IfOutDiscards = binary.BigEndian.Uint32(b[76:])
+	ic.IfOutErrors = binary.BigEndian.Uint32(b[80:])
+	ic.IfPromiscuousMode = binary.BigEndian.Uint32(b[84:])
+	return
+}
+
+// splitSFlowRecords walks count format/length prefixed records in b.
+func splitSFlowRecords(b []byte, count uint32) (recs []SFlowRecord, err error) {
+	if uint64(count)*uint64(sflowMinRecordSize) > uint64(len(b)) {
+		err = ErrInvalidSFlowCount
+		return
+	}
+	recs = make([]SFlowRecord, 0, count)
+	for i := uint32(0); i < count; i++ {
+		if len(b) < sflowMinRecordSize {
+			err = ErrSFlowRecordTooShort
+			return
+		}
+		format := binary.BigEndian.Uint32(b)
+		l := binary.BigEndian.Uint32(b[4:])
+		b = b[sflowMinRecordSize:]
+		if uint64(l) > uint64(len(b)) {
+			err = ErrSFlowRecordTooShort
+			return
+		}
+		recs = append(recs, SFlowRecord{Format: format, Data: b[:l]})
+		//opaque data is padded to a 4 byte boundary
+		if pl := (int(l) + 3) &^ 3; pl <= len(b) {
+			b = b[pl:]
+		} else {
+			b = b[l:]
+		}
+	}
+	return
+}
+
+// String implements the String interface on an sFlow datagram
+func (d *SFlowDatagram) String() (s string) {
+	s = fmt.Sprintf("sFlow V%d %v %d %v %d\n", d.Version, d.AgentAddress, d.SubAgentID,
+		d.UptimeDuration(), d.Sequence)
+	for _, fs := range d.FlowSamples {
+		s += fmt.Sprintf("\tflow %d:%d 1/%d %d records\n", fs.SourceIDType, fs.SourceIDIndex, fs.SamplingRate, len(fs.Records))
+	}
+	for _, cs := range d.CounterSamples {
+		s += fmt.Sprintf("\tcounters %d:%d %d records\n", cs.SourceIDType, cs.SourceIDIndex, len(cs.Records))
+	}
+	return
+}
@@ -0,0 +1,189 @@
+/*************************************************************************
+ * Copyright 2026 Gravwell, Inc. All rights reserved.
+ * Contact: <legal@gravwell.io>
+ *
+ * This software may be modified and distributed under the terms of the
+ * BSD 2-clause license. See the LICENSE file for details.
+ **************************************************************************/
+
+package netflow
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"testing"
+)
+
+var testPktHeader = []byte{
+	0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88, 0x99, 0xaa, 0xbb, 0x08, 0x00,
+	0x45, 0x00, 0x00, 0x54, 0x12, 0x34, 0x40, 0x00, 0x40, 0x01, 0x00, 0x00,
+	0x0a, 0x00, 0x00, 0x01, 0x0a, 0x00, 0x00, 0x02, 0x08, // odd length so the record is padded
+}
+
+func xdr32(b []byte, vs ...uint32) []byte {
+	for _, v := range vs {
+		b = binary.BigEndian.AppendUint32(b, v)
+	}
+	return b
+}
+
+func xdrOpaque(b []byte, format uint32, data []byte) []byte {
+	b = xdr32(b, format, uint32(len(data)))
+	b = append(b, data...)
+	for len(b)%4 != 0 {
+		b = append(b, 0)
+	}
+	return b
+}
+
+func testRawHeaderRecord() []byte {
+	rec := xdr32(nil, SFlowHeaderEthernet, 1514, 4, uint32(len(testPktHeader)))
+	rec = append(rec, testPktHeader...)
+	return rec
+}
+
+func testIfCountersRecord() (rec []byte) {
+	rec = xdr32(nil, 7, 6)
+	rec = binary.BigEndian.AppendUint64(rec, 10000000000)
+	rec = xdr32(rec, 1, 3)
+	rec = binary.BigEndian.AppendUint64(rec, 123456789012)
+	rec = xdr32(rec, 100, 2, 3, 4, 5, 6)
+	rec = binary.BigEndian.AppendUint64(rec, 987654321098)
+	rec = xdr32(rec, 200, 7, 8, 9, 10, 0)
+	return
+}
+
+func testDatagram() (b []byte) {
+	// flow sample with a raw packet header and an unknown record
+	fs := xdr32(nil, 1000, (0<<24)|7, 512, 51200, 3, 7, (2<<30)|4, 2)
+	fs = xdrOpaque(fs, SFlowRawPacketHeader, testRawHeaderRecord())
+	fs = xdrOpaque(fs, (9<<12)|1, []byte{1, 2, 3, 4})
+
+	// expanded flow sample
+	efs := xdr32(nil, 1001, 0, 70000, 1024, 102400, 0, 0, 70000, 0, 9, 1)
+	efs = xdrOpaque(efs, SFlowRawPacketHeader, testRawHeaderRecord())
+
+	// counter sample with generic interface counters
+	cs := xdr32(nil, 55, 7, 1)
+	cs = xdrOpaque(cs, SFlowGenericInterfaceCounters, testIfCountersRecord())
+
+	b = xdr32(nil, SFlowVersion, SFlowAddressIPv4)
+	b = append(b, 192, 168, 1, 1)
+	b = xdr32(b, 3, 99, 60000, 4)
+	b = xdrOpaque(b, SFlowFormatFlowSample, fs)
+	b = xdrOpaque(b, SFlowFormatExpandedFlowSample, efs)
+	b = xdrOpaque(b, SFlowFormatCounterSample, cs)
+	b = xdrOpaque(b, (4413<<12)|5, []byte{0xde, 0xad})
+	return
+}
+
+func TestSFlowDecode(t *testing.T) {
+	var d SFlowDatagram
+	if err := d.Decode(testDatagram()); err != nil {
+		t.Fatal(err)
+	}
+	if d.Version != 5 || !d.AgentAddress.Equal(net.IPv4(192, 168, 1, 1)) || d.SubAgentID != 3 || d.Sequence != 99 || d.Uptime != 60000 {
+		t.Fatalf("bad header: %+v", d.SFlowHeader)
+	}
+	if len(d.FlowSamples) != 2 || len(d.CounterSamples) != 1 || len(d.Unknown) != 1 {
+		t.Fatalf("bad sample counts: %d %d %d", len(d.FlowSamples), len(d.CounterSamples), len(d.Unknown))
+	}
+	if d.Unknown[0].Enterprise() != 4413 || d.Unknown[0].FormatID() != 5 || len(d.Unknown[0].Data) != 2 {
+		t.Fatalf("bad unknown sample: %+v", d.Unknown[0])
+	}
+
+	fs := d.FlowSamples[0]
+	if fs.Expanded || fs.Sequence != 1000 || fs.SourceIDIndex != 7 || fs.SamplingRate != 512 || fs.Drops != 3 {
+		t.Fatalf("bad flow sample: %+v", fs)
+	} else if fs.InputFormat != 0 || fs.Input != 7 || fs.OutputFormat != 2 || fs.Output != 4 {
+		t.Fatalf("bad flow interfaces: %+v", fs)
+	} else if len(fs.Records) != 2 {
+		t.Fatalf("bad flow record count %d", len(fs.Records))
+	} else if fs.Records[1].Header != nil || fs.Records[1].Enterprise() != 9 {
+		t.Fatalf("bad unknown flow record: %+v", fs.Records[1])
+	}
+	sh := fs.Records[0].Header
+	if sh == nil {
+		t.Fatal("missing sampled header")
+	} else if sh.Protocol != SFlowHeaderEthernet || sh.FrameLength != 1514 || sh.Stripped != 4 {
+		t.Fatalf("bad sampled header: %+v", sh)
+	} else if !bytes.Equal(sh.Header, testPktHeader) {
+		t.Fatalf("bad sampled header bytes: %x", sh.Header)
+	}
+
+	efs := d.FlowSamples[1]
+	if !efs.Expanded || efs.SourceIDIndex != 70000 || efs.Input != 70000 || efs.Output != 9 || len(efs.Records) != 1 {
+		t.Fatalf("bad expanded flow sample: %+v", efs)
+	} else if efs.Records[0].Header == nil || !bytes.Equal(efs.Records[0].Header.Header, testPktHeader) {
+		t.Fatalf("bad expanded sampled header: %+v", efs.Records[0])
+	}
+
+	cs := d.CounterSamples[0]
+	if cs.Expanded || cs.Sequence != 55 || cs.SourceIDIndex != 7 || len(cs.Records) != 1 {
+		t.Fatalf("bad counter sample: %+v", cs)
+	}
+	ic := cs.Records[0].Interface
+	if ic == nil {
+		t.Fatal("missing interface counters")
+	} else if ic.IfIndex != 7 || ic.IfSpeed != 10000000000 || ic.IfInOctets != 123456789012 || ic.IfOutOctets != 987654321098 {
+		t.Fatalf("bad interface counters: %+v", ic)
+	} else if ic.IfInErrors != 5 || ic.IfOutUcastPkts != 200 || ic.IfOutErrors != 10 {
+		t.Fatalf("bad interface counters: %+v", ic)
+	}
+}
+
+func TestSFlowDecodeIPv6Agent(t *testing.T) {
+	addr := net.ParseIP("fe80::1")
+	b := xdr32(nil, SFlowVersion, SFlowAddressIPv6)
+	b = append(b, addr...)
+	b = xdr32(b, 0, 1, 2, 0)
+	var d SFlowDatagram
+	if err := d.Decode(b); err != nil {
+		t.Fatal(err)
+	} else if !d.AgentAddress.Equal(addr) || d.Sequence != 1 || d.Uptime != 2 {
+		t.Fatalf("bad header: %+v", d.SFlowHeader)
+	}
+}
+
+func TestSFlowDecodeBad(t *testing.T) {
+	good := testDatagram()
+	var d SFlowDatagram
+	//every truncation must fail cleanly, padding on the final sample is not required
+	for i := 0; i < len(good)-2; i++ {
+		if err := d.Decode(good[:i]); err == nil {
+			t.Fatalf("truncated datagram of %d bytes decoded", i)
+		}
+	}
+
+	bad := append([]byte{}, good...)
+	binary.BigEndian.PutUint32(bad, 4)
+	if err := d.Decode(bad); err != ErrInvalidSFlowVersion {
+		t.Fatalf("bad version error: %v", err)
+	}
+
+	bad = append(bad[:0], good...)
+	binary.BigEndian.PutUint32(bad[4:], 3)
+	if err := d.Decode(bad); err != ErrInvalidSFlowAddress {
+		t.Fatalf("bad address error: %v", err)
+	}
+
+	bad = append(bad[:0], good...)
+	binary.BigEndian.PutUint32(bad[24:], 0xffffffff)
+	if err := d.Decode(bad); err != ErrInvalidSFlowCount {
+		t.Fatalf("bad count error: %v", err)
+	}
+}
+
+func TestSFlowRecordType(t *testing.T) {
+	r := SFlowRecord{Format: SFlowGenericInterfaceCounters, Data: testIfCountersRecord()}
+	if _, err := r.IfCounters(); err != nil {
+		t.Fatal(err)
+	}
+	r.Format = (1 << 12) | SFlowGenericInterfaceCounters
+	if _, err := r.IfCounters(); err != ErrInvalidSFlowDataType {
+		t.Fatalf("bad error: %v", err)
+	} else if _, err = r.SampledHeader(); err != ErrInvalidSFlowDataType {
+		t.Fatalf("bad error: %v", err)
+	}
+}